@@ -340,6 +340,10 @@ func (a *ADNL) SetCustomMessageHandler(handler func(msg *MessageCustom) error) {
 	a.customMessageHandler = handler
 }
 
+func (a *ADNL) GetCustomMessageHandler() func(msg *MessageCustom) error {
+	return a.customMessageHandler
+}
+
 func (a *ADNL) SetQueryHandler(handler func(msg *MessageQuery) error) {
 	a.queryHandler = handler
 }
@@ -428,7 +432,11 @@ func (a *ADNL) query(ctx context.Context, ch *Channel, req, result tl.Serializab
 		return fmt.Errorf("request failed: %w", err)
 	}
 
-	for {
+	for attempt := 0; ; attempt++ {
+		if o := loadObserver(); attempt > 0 && o != nil {
+			o.OnQueryRetransmit(a.addr, len(packets))
+		}
+
 		for i, packet := range packets {
 			if err = a.send(ctx, packet); err != nil {
 				return fmt.Errorf("failed to send query packet %d: %w", i, err)
@@ -593,6 +601,9 @@ func (a *ADNL) send(ctx context.Context, buf []byte) error {
 		return fmt.Errorf("too big packet")
 	}
 
+	if o := loadObserver(); o != nil {
+		o.OnPacketSent(a.addr, n)
+	}
 	return nil
 }
 
@@ -104,6 +104,10 @@ func listenPacketsAsClient(a *ADNL, conn net.Conn) error {
 			return fmt.Errorf("failed to read data: %w", err)
 		}
 
+		if o := loadObserver(); o != nil {
+			o.OnPacketReceived(a.addr, n)
+		}
+
 		buf = buf[:n]
 		id := buf[:32]
 		buf = buf[32:]
@@ -41,6 +41,10 @@ type Client struct {
 
 	gateway Gateway
 
+	// reverseClients - ids for which we registered reverse connections and accept ping requests, until registration expires
+	reverseClients map[string]time.Time
+	reverseMx      sync.RWMutex
+
 	globalCtx       context.Context
 	globalCtxCancel func()
 }
@@ -69,6 +73,10 @@ func NewClientFromConfigUrl(ctx context.Context, gateway Gateway, cfgUrl string)
 }
 
 func NewClientFromConfig(gateway Gateway, cfg *liteclient.GlobalConfig) (*Client, error) {
+	return NewClient(gateway, nodesFromConfig(cfg))
+}
+
+func nodesFromConfig(cfg *liteclient.GlobalConfig) []*Node {
 	var nodes []*Node
 	for _, node := range cfg.DHT.StaticNodes.Nodes {
 		key, err := base64.StdEncoding.DecodeString(node.ID.Key)
@@ -108,7 +116,7 @@ func NewClientFromConfig(gateway Gateway, cfg *liteclient.GlobalConfig) (*Client
 		nodes = append(nodes, n)
 	}
 
-	return NewClient(gateway, nodes)
+	return nodes
 }
 
 func NewClient(gateway Gateway, nodes []*Node) (*Client, error) {
@@ -125,6 +133,7 @@ func NewClient(gateway Gateway, nodes []*Node) (*Client, error) {
 		globalCtx:       globalCtx,
 		globalCtxCancel: cancel,
 		gateway:         gateway,
+		reverseClients:  map[string]time.Time{},
 	}
 
 	for _, node := range nodes {
@@ -157,8 +166,18 @@ func (c *Client) addNode(node *Node) (_ *dhtNode, err error) {
 	}
 
 	affinity := affinity(kid, c.gateway.GetID())
+	if affinity >= uint(len(c.buckets)) {
+		return nil, fmt.Errorf("node has our own id")
+	}
 	bucket := c.buckets[affinity]
 
+	// signed record is kept to share it with other nodes when serving DHT,
+	// it should be checked before addresses are cut
+	var signed *Node
+	if len(node.AddrList.Addresses) <= 8 && node.CheckSignature() == nil {
+		signed = node
+	}
+
 	if len(node.AddrList.Addresses) == 0 {
 		return nil, fmt.Errorf("no addresses to connect to")
 	} else if len(node.AddrList.Addresses) > 8 {
@@ -170,6 +189,7 @@ func (c *Client) addNode(node *Node) (_ *dhtNode, err error) {
 	addr := node.AddrList.Addresses[0].IP.String() + ":" + fmt.Sprint(node.AddrList.Addresses[0].Port)
 
 	kNode := c.connectToNode(kid, addr, pub.Key)
+	kNode.info = signed
 	bucket.addNode(kNode)
 
 	return kNode, nil
@@ -321,11 +341,57 @@ func (c *Client) Store(
 		return 0, nil, err
 	}
 
-	checked := map[string]bool{}
-	plist := c.buildPriorityList(keyId)
+	plist := c.findNearestNodes(ctx, keyId)
 
 	const activeQueries = 6
 
+	stored := int32(0)
+
+	for {
+		noMoreNodes := false
+		var wg sync.WaitGroup
+		for i := 0; i < activeQueries; i++ {
+			node, aff := plist.getNode()
+			if node == nil {
+				noMoreNodes = true
+				break
+			}
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				storeCallCtx, cancel := context.WithTimeout(ctx, queryTimeout)
+				err := node.storeValue(storeCallCtx, keyId, &val)
+				cancel()
+				if err == nil {
+					Logger("Value stored on node", node.id(), "- affinity", aff)
+					atomic.AddInt32(&stored, 1)
+					return
+				}
+				Logger("Failed to store value on node", node.id(), "- affinity", aff, err.Error())
+			}()
+		}
+		wg.Wait()
+		if atomic.LoadInt32(&stored) >= _K || noMoreNodes {
+			break
+		}
+	}
+
+	if stored == 0 {
+		return 0, idKey, fmt.Errorf("no alive nodes found to store this key")
+	}
+
+	return int(stored), idKey, nil
+}
+
+// findNearestNodes - looks up nodes which are the closest to key id, until list of known nodes stops growing
+func (c *Client) findNearestNodes(ctx context.Context, keyId []byte) *priorityList {
+	const activeQueries = 6
+
+	checked := map[string]bool{}
+	plist := c.buildPriorityList(keyId)
+
 	for {
 		currentLen := len(checked)
 
@@ -376,44 +442,7 @@ func (c *Client) Store(
 		}
 	}
 
-	stored := int32(0)
-
-	for {
-		noMoreNodes := false
-		var wg sync.WaitGroup
-		for i := 0; i < activeQueries; i++ {
-			node, aff := plist.getNode()
-			if node == nil {
-				noMoreNodes = true
-				break
-			}
-
-			wg.Add(1)
-			go func() {
-				defer wg.Done()
-
-				storeCallCtx, cancel := context.WithTimeout(ctx, queryTimeout)
-				err := node.storeValue(storeCallCtx, keyId, &val)
-				cancel()
-				if err == nil {
-					Logger("Value stored on node", node.id(), "- affinity", aff)
-					atomic.AddInt32(&stored, 1)
-					return
-				}
-				Logger("Failed to store value on node", node.id(), "- affinity", aff, err.Error())
-			}()
-		}
-		wg.Wait()
-		if atomic.LoadInt32(&stored) >= _K || noMoreNodes {
-			break
-		}
-	}
-
-	if stored == 0 {
-		return 0, idKey, fmt.Errorf("no alive nodes found to store this key")
-	}
-
-	return int(stored), idKey, nil
+	return plist
 }
 
 func signTL(obj tl.Serializable, key ed25519.PrivateKey) ([]byte, error) {
@@ -29,6 +29,12 @@ func init() {
 	tl.Register(Stored{}, "dht.stored = dht.Stored")
 	tl.Register(Ping{}, "dht.ping random_id:long = dht.Pong")
 	tl.Register(Pong{}, "dht.pong random_id:long = dht.Pong")
+	tl.Register(ADNLNode{}, "adnl.node id:PublicKey addr_list:adnl.addressList = adnl.Node")
+	tl.Register(RegisterReverseConnection{}, "dht.registerReverseConnection node:PublicKey ttl:int signature:bytes = dht.Stored")
+	tl.Register(RequestReversePing{}, "dht.requestReversePing target:adnl.Node signature:bytes client:int256 k:int = dht.ReversePingResult")
+	tl.Register(RequestReversePingCont{}, "dht.requestReversePingCont target:adnl.Node signature:bytes client:int256 = dht.RequestReversePingCont")
+	tl.Register(ReversePingOk{}, "dht.reversePingOk = dht.ReversePingResult")
+	tl.Register(ClientNotFound{}, "dht.clientNotFound nodes:dht.nodes = dht.ReversePingResult")
 }
 
 type FindNode struct {
@@ -104,6 +110,38 @@ type Pong struct {
 	ID int64 `tl:"long"`
 }
 
+// ADNLNode - address of node which asks client behind NAT to connect to it
+type ADNLNode struct {
+	ID       any           `tl:"struct boxed [pub.ed25519]"`
+	AddrList *address.List `tl:"struct"`
+}
+
+type RegisterReverseConnection struct {
+	Node      any    `tl:"struct boxed [pub.ed25519]"`
+	TTL       int32  `tl:"int"`
+	Signature []byte `tl:"bytes"`
+}
+
+type RequestReversePing struct {
+	Target    *ADNLNode `tl:"struct boxed"`
+	Signature []byte    `tl:"bytes"`
+	Client    []byte    `tl:"int256"`
+	K         int32     `tl:"int"`
+}
+
+// RequestReversePingCont - sent by DHT node to registered client as custom message
+type RequestReversePingCont struct {
+	Target    *ADNLNode `tl:"struct boxed"`
+	Signature []byte    `tl:"bytes"`
+	Client    []byte    `tl:"int256"`
+}
+
+type ReversePingOk struct{}
+
+type ClientNotFound struct {
+	Nodes NodesList `tl:"struct"`
+}
+
 func (n *Node) CheckSignature() error {
 	pub, ok := n.ID.(adnl.PublicKeyED25519)
 	if !ok {
@@ -122,3 +160,19 @@ func (n *Node) CheckSignature() error {
 	n.Signature = signature
 	return nil
 }
+
+func (n *ADNLNode) CheckSignature(signature []byte) error {
+	pub, ok := n.ID.(adnl.PublicKeyED25519)
+	if !ok {
+		return fmt.Errorf("unsupported id type %s", reflect.TypeOf(n.ID).String())
+	}
+
+	toVerify, err := tl.Serialize(n, true)
+	if err != nil {
+		return fmt.Errorf("failed to serialize node: %w", err)
+	}
+	if !ed25519.Verify(pub.Key, toVerify, signature) {
+		return fmt.Errorf("bad signature for node: %s", hex.EncodeToString(pub.Key))
+	}
+	return nil
+}
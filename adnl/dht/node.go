@@ -26,6 +26,8 @@ type dhtNode struct {
 	ping      int64
 	addr      string
 	serverKey ed25519.PublicKey
+	// info - signed node record, nil when signature is not valid
+	info *Node
 
 	currentState int
 	badScore     int32
@@ -33,6 +35,9 @@ type dhtNode struct {
 	lastQueryAt  int64
 	inFlyQueries int32
 
+	// reversePeer - connection where reverse ping handler is installed, to not wrap it again on next registration
+	reversePeer adnl.Peer
+
 	mx sync.Mutex
 }
 
@@ -2,6 +2,7 @@ package dht
 
 import (
 	"sync"
+	"sync/atomic"
 )
 
 type nodePriority struct {
@@ -33,7 +34,7 @@ func (p *priorityList) addNode(node *dhtNode) bool {
 	item := &nodePriority{
 		id:       id,
 		node:     node,
-		priority: int(affinity(node.adnlId, p.targetId)) - int(node.badScore),
+		priority: int(affinity(node.adnlId, p.targetId)) - int(atomic.LoadInt32(&node.badScore)),
 	}
 
 	p.mx.Lock()
@@ -0,0 +1,300 @@
+package dht
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/xssnick/tonutils-go/adnl"
+	"github.com/xssnick/tonutils-go/adnl/address"
+	"github.com/xssnick/tonutils-go/tl"
+)
+
+// _MaxReverseConnectionTTL - DHT nodes keep registration not longer, clients should register again before it ends
+const _MaxReverseConnectionTTL = 5 * time.Minute
+
+var ErrReverseConnectionNotFound = errors.New("reverse connection is not found")
+
+// customHandlerPeer - optional interface of peer, implemented by adnl peers, allows to keep handler which is already set
+type customHandlerPeer interface {
+	GetCustomMessageHandler() func(msg *adnl.MessageCustom) error
+}
+
+// reverseConnectionKey - registrations are kept by nodes which are the closest to the address record of client
+func reverseConnectionKey(clientId []byte) ([]byte, error) {
+	return tl.Hash(Key{ID: clientId, Name: []byte("address"), Index: 0})
+}
+
+// reverseConnectionToSign - client signs its id, id of ADNL connection which DHT node should use to reach it and ttl
+func reverseConnectionToSign(clientId, connId []byte, ttl int32) []byte {
+	data := make([]byte, 0, 68)
+	data = append(data, clientId...)
+	data = append(data, connId...)
+	return binary.LittleEndian.AppendUint32(data, uint32(ttl))
+}
+
+// RegisterReverseConnection - registers us on DHT nodes as a client behind NAT, so other nodes can ask us
+// to connect to them with RequestReversePing. Connection is made from gateway of DHT client,
+// so key should be the key of gateway, and the same gateway should be used to serve requests (for example with StartServer).
+// Registration lives for ttl, but not longer than 5 minutes, it should be repeated before it ends,
+// which also keeps NAT mapping to DHT nodes alive.
+func (c *Client) RegisterReverseConnection(ctx context.Context, key ed25519.PrivateKey, ttl time.Duration) (registered int, err error) {
+	pub := adnl.PublicKeyED25519{Key: key.Public().(ed25519.PublicKey)}
+	clientId, err := tl.Hash(pub)
+	if err != nil {
+		return 0, err
+	}
+
+	keyId, err := reverseConnectionKey(clientId)
+	if err != nil {
+		return 0, err
+	}
+
+	if ttl > _MaxReverseConnectionTTL {
+		ttl = _MaxReverseConnectionTTL
+	}
+	until := int32(time.Now().Add(ttl).Unix())
+
+	req := RegisterReverseConnection{
+		Node:      pub,
+		TTL:       until,
+		Signature: ed25519.Sign(key, reverseConnectionToSign(clientId, c.gateway.GetID(), until)),
+	}
+
+	// ping requests can come right after registration, so we should be ready to accept them
+	now := time.Now()
+	c.reverseMx.Lock()
+	for id, expireAt := range c.reverseClients {
+		if !expireAt.After(now) {
+			delete(c.reverseClients, id)
+		}
+	}
+	c.reverseClients[string(clientId)] = time.Unix(int64(until), 0)
+	c.reverseMx.Unlock()
+
+	plist := c.findNearestNodes(ctx, keyId)
+
+	const activeQueries = 6
+
+	num := int32(0)
+	for {
+		noMoreNodes := false
+		var wg sync.WaitGroup
+		for i := 0; i < activeQueries; i++ {
+			node, _ := plist.getNode()
+			if node == nil {
+				noMoreNodes = true
+				break
+			}
+
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+
+				regCtx, cancel := context.WithTimeout(ctx, queryTimeout)
+				err := node.registerReverseConnection(regCtx, &req)
+				cancel()
+				if err != nil {
+					Logger("Failed to register reverse connection on node", node.id(), err.Error())
+					return
+				}
+				atomic.AddInt32(&num, 1)
+			}()
+		}
+		wg.Wait()
+		if atomic.LoadInt32(&num) >= _K || noMoreNodes {
+			break
+		}
+	}
+
+	if num == 0 {
+		return 0, fmt.Errorf("no alive nodes found to register reverse connection")
+	}
+	return int(num), nil
+}
+
+// RequestReversePing - asks DHT nodes to request client, which is behind NAT, to connect to us.
+// key and addresses should be the key and the public address list of gateway which accepts connections,
+// when no error is returned, connection from client will come to it soon, it can be handled with SetConnectionHandler.
+func (c *Client) RequestReversePing(ctx context.Context, key ed25519.PrivateKey, addresses address.List, clientId []byte) error {
+	if len(addresses.Addresses) == 0 {
+		return fmt.Errorf("no addresses to connect to")
+	}
+
+	keyId, err := reverseConnectionKey(clientId)
+	if err != nil {
+		return err
+	}
+
+	target := &ADNLNode{
+		ID:       adnl.PublicKeyED25519{Key: key.Public().(ed25519.PublicKey)},
+		AddrList: &addresses,
+	}
+
+	signature, err := signTL(target, key)
+	if err != nil {
+		return fmt.Errorf("failed to sign target: %w", err)
+	}
+
+	req := RequestReversePing{
+		Target:    target,
+		Signature: signature,
+		Client:    clientId,
+		K:         _K,
+	}
+
+	plist := c.buildPriorityList(keyId)
+	for {
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+
+		node, _ := plist.getNode()
+		if node == nil {
+			return ErrReverseConnectionNotFound
+		}
+
+		reqCtx, cancel := context.WithTimeout(ctx, queryTimeout)
+		sent, nodes, err := node.requestReversePing(reqCtx, &req)
+		cancel()
+		if err != nil {
+			continue
+		}
+
+		if sent {
+			return nil
+		}
+
+		for _, n := range nodes {
+			newNode, err := c.addNode(n)
+			if err != nil {
+				continue
+			}
+			plist.addNode(newNode)
+		}
+	}
+}
+
+// processReversePingRequest - handles requests to connect to other nodes, sent by DHT nodes where we are registered
+func (c *Client) processReversePingRequest(msg *adnl.MessageCustom) error {
+	req, ok := msg.Data.(RequestReversePingCont)
+	if !ok {
+		return fmt.Errorf("unexpected message type %s", reflect.TypeOf(msg.Data).String())
+	}
+
+	c.reverseMx.RLock()
+	expireAt, registered := c.reverseClients[string(req.Client)]
+	c.reverseMx.RUnlock()
+
+	if !registered || !expireAt.After(time.Now()) {
+		return fmt.Errorf("reverse connection is not registered for %s", hex.EncodeToString(req.Client))
+	}
+
+	if req.Target == nil {
+		return fmt.Errorf("no target in reverse ping request")
+	}
+
+	if err := req.Target.CheckSignature(req.Signature); err != nil {
+		return fmt.Errorf("untrusted reverse ping target: %w", err)
+	}
+
+	if req.Target.AddrList == nil || len(req.Target.AddrList.Addresses) == 0 {
+		return fmt.Errorf("no addresses of reverse ping target")
+	}
+
+	addr := req.Target.AddrList.Addresses[0].IP.String() + ":" + fmt.Sprint(req.Target.AddrList.Addresses[0].Port)
+	go c.connectBack(addr, req.Target.ID.(adnl.PublicKeyED25519).Key)
+
+	return nil
+}
+
+func (c *Client) connectBack(addr string, key ed25519.PublicKey) {
+	peer, err := c.gateway.RegisterClient(addr, key)
+	if err != nil {
+		Logger("Failed to connect to reverse ping target", addr, err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(c.globalCtx, queryTimeout)
+	defer cancel()
+
+	// any packet from us opens the way through NAT, target may not answer DHT ping, so result is not checked
+	var res any
+	_ = peer.Query(ctx, Ping{ID: rand.Int63()}, &res)
+}
+
+func (n *dhtNode) registerReverseConnection(ctx context.Context, req *RegisterReverseConnection) error {
+	val, err := tl.Serialize(*req, true)
+	if err != nil {
+		return fmt.Errorf("failed to serialize dht query: %w", err)
+	}
+
+	peer, err := n.client.gateway.RegisterClient(n.addr, n.serverKey)
+	if err != nil {
+		return err
+	}
+	// node sends ping requests over the same connection registration was made from,
+	// other messages are passed to the previous handler of peer
+	n.mx.Lock()
+	if n.reversePeer != peer {
+		var previousHandler func(msg *adnl.MessageCustom) error
+		if p, ok := peer.(customHandlerPeer); ok {
+			previousHandler = p.GetCustomMessageHandler()
+		}
+		peer.SetCustomMessageHandler(func(msg *adnl.MessageCustom) error {
+			if _, ok := msg.Data.(RequestReversePingCont); !ok && previousHandler != nil {
+				return previousHandler(msg)
+			}
+			return n.client.processReversePingRequest(msg)
+		})
+		n.reversePeer = peer
+	}
+	n.mx.Unlock()
+
+	var res any
+	err = n.query(ctx, tl.Raw(val), &res)
+	if err != nil {
+		return fmt.Errorf("failed to query dht node: %w", err)
+	}
+
+	if _, ok := res.(Stored); !ok {
+		return fmt.Errorf("failed to register reverse connection, unexpected response type %s", reflect.TypeOf(res).String())
+	}
+	return nil
+}
+
+// requestReversePing - returns true when node sent ping request to client, otherwise nodes which are closer to client
+func (n *dhtNode) requestReversePing(ctx context.Context, req *RequestReversePing) (sent bool, nodes []*Node, err error) {
+	val, err := tl.Serialize(*req, true)
+	if err != nil {
+		return false, nil, fmt.Errorf("failed to serialize dht query: %w", err)
+	}
+
+	var res any
+	err = n.query(ctx, tl.Raw(val), &res)
+	if err != nil {
+		return false, nil, fmt.Errorf("failed to query dht node: %w", err)
+	}
+
+	switch r := res.(type) {
+	case ReversePingOk:
+		return true, nil, nil
+	case ClientNotFound:
+		for _, node := range r.Nodes.List {
+			if err = node.CheckSignature(); err != nil {
+				return false, nil, fmt.Errorf("untrusted nodes list response: %w", err)
+			}
+		}
+		return false, r.Nodes.List, nil
+	}
+
+	return false, nil, fmt.Errorf("failed to request reverse ping, unexpected response type %s", reflect.TypeOf(res).String())
+}
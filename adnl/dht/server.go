@@ -0,0 +1,465 @@
+package dht
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/xssnick/tonutils-go/adnl"
+	"github.com/xssnick/tonutils-go/adnl/address"
+	"github.com/xssnick/tonutils-go/adnl/overlay"
+	"github.com/xssnick/tonutils-go/liteclient"
+	"github.com/xssnick/tonutils-go/tl"
+)
+
+const _MaxValueSize = 768
+
+// _MaxValueTTL - values which live longer are not accepted, owners should update them instead
+const _MaxValueTTL = time.Hour + time.Minute
+const _MaxNodesInAnswer = 10
+
+// _MaxValues - when storage is full, new keys are rejected until stored values expire,
+// live values are never evicted, so they cannot be flushed by flood of new keys
+const _MaxValues = 1 << 16
+
+// _MaxValuesPerSource - single peer cannot fill the whole storage
+const _MaxValuesPerSource = 256
+const _ValuesCleanupInterval = time.Minute
+
+type ServerGateway interface {
+	Gateway
+	GetAddressList() address.List
+	SetConnectionHandler(handler func(client adnl.Peer) error)
+}
+
+// Server - DHT node which answers queries of other nodes and keeps values stored to it.
+// It is also a Client, routing table is shared, so nodes announced by other nodes are used for own queries too.
+type Server struct {
+	*Client
+
+	gateway ServerGateway
+	key     ed25519.PrivateKey
+
+	values             map[string]*Value
+	valueSources       map[string]string
+	sourceValues       map[string]int
+	maxValues          int
+	maxValuesPerSource int
+	valuesMx           sync.RWMutex
+
+	reverseConnections map[string]*reverseConnection
+	reverseConnsMx     sync.RWMutex
+}
+
+// reverseConnection - registered client behind NAT, ping requests are sent to it over the connection registration came from
+type reverseConnection struct {
+	peer adnl.Peer
+	ttl  int64
+}
+
+func NewServerFromConfig(gateway ServerGateway, key ed25519.PrivateKey, cfg *liteclient.GlobalConfig) (*Server, error) {
+	return NewServer(gateway, key, nodesFromConfig(cfg))
+}
+
+// NewServer - creates DHT node on top of gateway, key should be the key of gateway, it is used to sign our node record.
+// Gateway connection handler is set by server, use HandlePeer if application needs its own handler.
+func NewServer(gateway ServerGateway, key ed25519.PrivateKey, nodes []*Node) (*Server, error) {
+	id, err := tl.Hash(adnl.PublicKeyED25519{Key: key.Public().(ed25519.PublicKey)})
+	if err != nil {
+		return nil, err
+	}
+
+	if !bytes.Equal(id, gateway.GetID()) {
+		return nil, fmt.Errorf("key is not the key of gateway")
+	}
+
+	c, err := NewClient(gateway, nodes)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{
+		Client:  c,
+		gateway: gateway,
+		key:     key,
+		values:  map[string]*Value{},
+
+		valueSources:       map[string]string{},
+		sourceValues:       map[string]int{},
+		maxValues:          _MaxValues,
+		maxValuesPerSource: _MaxValuesPerSource,
+
+		reverseConnections: map[string]*reverseConnection{},
+	}
+
+	gateway.SetConnectionHandler(func(client adnl.Peer) error {
+		s.HandlePeer(client)
+		return nil
+	})
+	go s.cleanup()
+
+	return s, nil
+}
+
+// HandlePeer - makes peer's DHT queries to be answered by server, other queries are passed to the previous handler of peer
+func (s *Server) HandlePeer(client adnl.Peer) {
+	previousHandler := client.GetQueryHandler()
+	client.SetQueryHandler(func(query *adnl.MessageQuery) error {
+		res, err := s.processQuery(client, query.Data)
+		if err != nil {
+			return err
+		}
+
+		if res == nil {
+			if previousHandler != nil {
+				return previousHandler(query)
+			}
+			return fmt.Errorf("unexpected query type %s", reflect.TypeOf(query.Data))
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), queryTimeout)
+		defer cancel()
+
+		if err = client.Answer(ctx, query.ID, res); err != nil {
+			return fmt.Errorf("failed to send dht answer: %w", err)
+		}
+		return nil
+	})
+}
+
+// processQuery - returns answer to DHT query, or nil if query is not related to DHT
+func (s *Server) processQuery(client adnl.Peer, data tl.Serializable) (tl.Serializable, error) {
+	if arr, ok := data.([]tl.Serializable); ok && len(arr) == 2 {
+		if q, isQuery := arr[0].(Query); isQuery {
+			// node which sent query announces itself, so it can be used by us and our peers
+			if q.Node != nil && q.Node.CheckSignature() == nil {
+				_, _ = s.addNode(q.Node)
+			}
+			data = arr[1]
+		}
+	}
+
+	switch q := data.(type) {
+	case Ping:
+		return Pong{ID: q.ID}, nil
+	case FindNode:
+		return s.nearestNodes(q.Key, q.K), nil
+	case FindValue:
+		if v := s.getValue(q.Key); v != nil {
+			return ValueFoundResult{Value: *v}, nil
+		}
+		return ValueNotFoundResult{Nodes: s.nearestNodes(q.Key, q.K)}, nil
+	case Store:
+		var source []byte
+		if client != nil {
+			source = client.GetID()
+		}
+		if err := s.storeValue(source, q.Value); err != nil {
+			return nil, fmt.Errorf("failed to store value: %w", err)
+		}
+		return Stored{}, nil
+	case SignedAddressListQuery:
+		return s.signedNode()
+	case RegisterReverseConnection:
+		if err := s.registerReverseConnection(client, &q); err != nil {
+			return nil, fmt.Errorf("failed to register reverse connection: %w", err)
+		}
+		return Stored{}, nil
+	case RequestReversePing:
+		return s.requestReversePing(&q)
+	}
+	return nil, nil
+}
+
+// signedNode - returns our node record with the current addresses of gateway
+func (s *Server) signedNode() (*Node, error) {
+	list := s.gateway.GetAddressList()
+	n := &Node{
+		ID:       adnl.PublicKeyED25519{Key: s.key.Public().(ed25519.PublicKey)},
+		AddrList: &list,
+		Version:  int32(time.Now().Unix()),
+	}
+
+	var err error
+	if n.Signature, err = signTL(n, s.key); err != nil {
+		return nil, fmt.Errorf("failed to sign node: %w", err)
+	}
+	return n, nil
+}
+
+// nearestNodes - returns signed records of known nodes which are the closest to key
+func (s *Server) nearestNodes(key []byte, k int32) NodesList {
+	if k <= 0 || k > _MaxNodesInAnswer {
+		k = _MaxNodesInAnswer
+	}
+
+	plist := newPriorityList(int(k), key)
+	for _, bucket := range s.buckets {
+		for _, node := range bucket.getNodes() {
+			if node != nil && node.info != nil {
+				plist.addNode(node)
+			}
+		}
+	}
+
+	var res NodesList
+	for len(res.List) < int(k) {
+		node, _ := plist.getNode()
+		if node == nil {
+			break
+		}
+		res.List = append(res.List, node.info)
+	}
+	return res
+}
+
+func (s *Server) getValue(key []byte) *Value {
+	s.valuesMx.RLock()
+	defer s.valuesMx.RUnlock()
+
+	v := s.values[string(key)]
+	if v == nil || int64(v.TTL) <= time.Now().Unix() {
+		return nil
+	}
+	return v
+}
+
+// storeValue - saves value sent by peer with source id, number of values per source and in total is limited
+func (s *Server) storeValue(source []byte, value *Value) error {
+	if value == nil {
+		return fmt.Errorf("no value")
+	}
+
+	now := time.Now()
+	if int64(value.TTL) <= now.Unix() {
+		return fmt.Errorf("value is expired")
+	}
+	if int64(value.TTL) > now.Add(_MaxValueTTL).Unix() {
+		return fmt.Errorf("too big ttl")
+	}
+	if len(value.Data) > _MaxValueSize {
+		return fmt.Errorf("too big value")
+	}
+
+	id, err := tl.Hash(value.KeyDescription.Key)
+	if err != nil {
+		return fmt.Errorf("failed to calc key id: %w", err)
+	}
+
+	if err = checkValue(id, value); err != nil {
+		return fmt.Errorf("corrupted value: %w", err)
+	}
+
+	s.valuesMx.Lock()
+	defer s.valuesMx.Unlock()
+
+	old := s.values[string(id)]
+	if old != nil && int64(old.TTL) > now.Unix() {
+		if reflect.TypeOf(old.KeyDescription.UpdateRule) != reflect.TypeOf(value.KeyDescription.UpdateRule) {
+			return fmt.Errorf("update rule of value cannot be changed")
+		}
+
+		switch value.KeyDescription.UpdateRule.(type) {
+		case UpdateRuleSignature:
+			if value.TTL <= old.TTL {
+				// we already have newer version
+				return nil
+			}
+		case UpdateRuleOverlayNodes:
+			if value, err = mergeOverlayNodes(old, value); err != nil {
+				return fmt.Errorf("failed to merge overlay nodes: %w", err)
+			}
+		}
+	}
+
+	if old == nil || s.valueSources[string(id)] != string(source) {
+		if s.sourceValues[string(source)] >= s.maxValuesPerSource {
+			return fmt.Errorf("too many values from source")
+		}
+	}
+
+	if old == nil && len(s.values) >= s.maxValues {
+		s.removeExpired(now.Unix())
+		if len(s.values) >= s.maxValues {
+			return fmt.Errorf("values storage is full")
+		}
+	}
+
+	s.deleteValue(string(id))
+	s.values[string(id)] = value
+	s.valueSources[string(id)] = string(source)
+	s.sourceValues[string(source)]++
+
+	return nil
+}
+
+// removeExpired - frees space for new values, should be called under values lock
+func (s *Server) removeExpired(now int64) {
+	for k, v := range s.values {
+		if int64(v.TTL) <= now {
+			s.deleteValue(k)
+		}
+	}
+}
+
+// deleteValue - removes value with its source accounting, should be called under values lock
+func (s *Server) deleteValue(key string) {
+	if _, ok := s.values[key]; !ok {
+		return
+	}
+
+	source := s.valueSources[key]
+	if s.sourceValues[source]--; s.sourceValues[source] <= 0 {
+		delete(s.sourceValues, source)
+	}
+	delete(s.valueSources, key)
+	delete(s.values, key)
+}
+
+// mergeOverlayNodes - joins lists of overlay nodes, the latest version of each node is kept,
+// when list is too big, the oldest nodes of the stored value are removed, value is rejected when even one node does not fit
+func mergeOverlayNodes(old, value *Value) (*Value, error) {
+	var oldNodes, newNodes overlay.NodesList
+	if _, err := tl.Parse(&oldNodes, old.Data, true); err != nil {
+		return nil, fmt.Errorf("failed to parse stored nodes: %w", err)
+	}
+	if _, err := tl.Parse(&newNodes, value.Data, true); err != nil {
+		return nil, fmt.Errorf("failed to parse new nodes: %w", err)
+	}
+
+	var list []overlay.Node
+	indexes := map[string]int{}
+	for _, node := range append(newNodes.List, oldNodes.List...) {
+		id, err := tl.Hash(node.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to calc node id: %w", err)
+		}
+
+		if i, ok := indexes[string(id)]; ok {
+			if node.Version > list[i].Version {
+				list[i] = node
+			}
+			continue
+		}
+		indexes[string(id)] = len(list)
+		list = append(list, node)
+	}
+
+	for len(list) > 0 {
+		data, err := tl.Serialize(overlay.NodesList{List: list}, true)
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize nodes: %w", err)
+		}
+
+		if len(data) <= _MaxValueSize {
+			merged := *value
+			merged.Data = data
+			if old.TTL > merged.TTL {
+				merged.TTL = old.TTL
+			}
+			return &merged, nil
+		}
+		list = list[:len(list)-1]
+	}
+	return nil, fmt.Errorf("too big value")
+}
+
+func (s *Server) registerReverseConnection(client adnl.Peer, req *RegisterReverseConnection) error {
+	pub, ok := req.Node.(adnl.PublicKeyED25519)
+	if !ok {
+		return fmt.Errorf("unsupported id type %s", reflect.TypeOf(req.Node).String())
+	}
+
+	now := time.Now()
+	if int64(req.TTL) <= now.Unix() {
+		return fmt.Errorf("ttl is in the past")
+	}
+
+	clientId, err := tl.Hash(pub)
+	if err != nil {
+		return fmt.Errorf("failed to calc client id: %w", err)
+	}
+
+	if !ed25519.Verify(pub.Key, reverseConnectionToSign(clientId, client.GetID(), req.TTL), req.Signature) {
+		return fmt.Errorf("bad signature")
+	}
+
+	ttl := int64(req.TTL)
+	if limit := now.Add(_MaxReverseConnectionTTL).Unix(); ttl > limit {
+		ttl = limit
+	}
+
+	s.reverseConnsMx.Lock()
+	s.reverseConnections[string(clientId)] = &reverseConnection{
+		peer: client,
+		ttl:  ttl,
+	}
+	s.reverseConnsMx.Unlock()
+
+	return nil
+}
+
+// requestReversePing - sends ping request to client when it is registered on our node, otherwise returns nodes which are closer to it
+func (s *Server) requestReversePing(req *RequestReversePing) (tl.Serializable, error) {
+	if req.Target == nil {
+		return nil, fmt.Errorf("no target in reverse ping request")
+	}
+
+	if err := req.Target.CheckSignature(req.Signature); err != nil {
+		return nil, fmt.Errorf("untrusted reverse ping target: %w", err)
+	}
+
+	s.reverseConnsMx.RLock()
+	conn := s.reverseConnections[string(req.Client)]
+	s.reverseConnsMx.RUnlock()
+
+	if conn != nil && conn.ttl > time.Now().Unix() {
+		ctx, cancel := context.WithTimeout(context.Background(), queryTimeout)
+		err := conn.peer.SendCustomMessage(ctx, RequestReversePingCont{
+			Target:    req.Target,
+			Signature: req.Signature,
+			Client:    req.Client,
+		})
+		cancel()
+		if err == nil {
+			return ReversePingOk{}, nil
+		}
+		Logger("Failed to send reverse ping request to client", hex.EncodeToString(req.Client), err.Error())
+	}
+
+	key, err := reverseConnectionKey(req.Client)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calc key: %w", err)
+	}
+	return ClientNotFound{Nodes: s.nearestNodes(key, req.K)}, nil
+}
+
+// cleanup - removes expired values and reverse connections
+func (s *Server) cleanup() {
+	for {
+		select {
+		case <-s.globalCtx.Done():
+			return
+		case <-time.After(_ValuesCleanupInterval):
+		}
+
+		now := time.Now().Unix()
+
+		s.valuesMx.Lock()
+		s.removeExpired(now)
+		s.valuesMx.Unlock()
+
+		s.reverseConnsMx.Lock()
+		for k, c := range s.reverseConnections {
+			if c.ttl <= now {
+				delete(s.reverseConnections, k)
+			}
+		}
+		s.reverseConnsMx.Unlock()
+	}
+}
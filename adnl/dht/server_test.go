@@ -0,0 +1,587 @@
+package dht
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xssnick/tonutils-go/adnl"
+	"github.com/xssnick/tonutils-go/adnl/address"
+	"github.com/xssnick/tonutils-go/adnl/overlay"
+	"github.com/xssnick/tonutils-go/tl"
+)
+
+func newTestServer(t *testing.T) *Server {
+	_, key, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := NewServer(adnl.NewGateway(key), key, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func newTestValue(t *testing.T, id any, key ed25519.PrivateKey, rule any, data []byte, ttl time.Duration) *Value {
+	idKey, err := tl.Hash(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	val := &Value{
+		KeyDescription: KeyDescription{
+			Key:        Key{ID: idKey, Name: []byte("address"), Index: 0},
+			ID:         id,
+			UpdateRule: rule,
+		},
+		Data: data,
+		TTL:  int32(time.Now().Add(ttl).Unix()),
+	}
+
+	if key != nil {
+		if val.KeyDescription.Signature, err = signTL(val.KeyDescription, key); err != nil {
+			t.Fatal(err)
+		}
+		if val.Signature, err = signTL(*val, key); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return val
+}
+
+func newTestOverlayNodes(t *testing.T, overlayKey []byte, keys ...ed25519.PrivateKey) []byte {
+	var list overlay.NodesList
+	for _, key := range keys {
+		node, err := overlay.NewNode(overlayKey, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		list.List = append(list.List, *node)
+	}
+
+	data, err := tl.Serialize(list, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestServer_StoreValue(t *testing.T) {
+	s := newTestServer(t)
+	defer s.Close()
+
+	_, owner, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ownerId := adnl.PublicKeyED25519{Key: owner.Public().(ed25519.PublicKey)}
+
+	v := newTestValue(t, ownerId, owner, UpdateRuleSignature{}, []byte("first"), 10*time.Minute)
+	if err = s.storeValue(nil, v); err != nil {
+		t.Fatal(err)
+	}
+
+	key, _ := tl.Hash(v.KeyDescription.Key)
+	for _, tt := range []struct {
+		name string
+		data string
+		ttl  time.Duration
+		want string
+	}{
+		{"older", "older", 5 * time.Minute, "first"},
+		{"newer", "newer", 20 * time.Minute, "newer"},
+	} {
+		if err = s.storeValue(nil, newTestValue(t, ownerId, owner, UpdateRuleSignature{}, []byte(tt.data), tt.ttl)); err != nil {
+			t.Fatal(tt.name, err)
+		}
+		if got := s.getValue(key); got == nil || string(got.Data) != tt.want {
+			t.Fatal(tt.name, "incorrect stored value")
+		}
+	}
+
+	for _, tt := range []struct {
+		name string
+		val  *Value
+	}{
+		{"expired", newTestValue(t, ownerId, owner, UpdateRuleSignature{}, []byte("x"), -time.Minute)},
+		{"too long ttl", newTestValue(t, ownerId, owner, UpdateRuleSignature{}, []byte("x"), 2*time.Hour)},
+		{"too big", newTestValue(t, ownerId, owner, UpdateRuleSignature{}, make([]byte, _MaxValueSize+1), time.Hour)},
+		{"rule changed", newTestValue(t, ownerId, nil, UpdateRuleAnybody{}, []byte("x"), time.Hour)},
+		{"not signed", newTestValue(t, ownerId, nil, UpdateRuleSignature{}, []byte("x"), time.Hour)},
+	} {
+		if err = s.storeValue(nil, tt.val); err == nil {
+			t.Fatal(tt.name, "value should not be stored")
+		}
+	}
+
+	// expired values are not returned
+	s.valuesMx.Lock()
+	s.values[string(key)].TTL = int32(time.Now().Unix() - 1)
+	s.valuesMx.Unlock()
+
+	res, err := s.processQuery(nil, FindValue{Key: key, K: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := res.(ValueNotFoundResult); !ok {
+		t.Fatal("expired value was found")
+	}
+}
+
+func TestServer_StoreOverlayNodes(t *testing.T) {
+	s := newTestServer(t)
+	defer s.Close()
+
+	overlayKey := make([]byte, 32)
+	overlayId := adnl.PublicKeyOverlay{Key: overlayKey}
+
+	var keys []ed25519.PrivateKey
+	for i := 0; i < 2; i++ {
+		_, key, err := ed25519.GenerateKey(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, key)
+	}
+
+	first := newTestValue(t, overlayId, nil, UpdateRuleOverlayNodes{}, newTestOverlayNodes(t, overlayKey, keys[0]), 20*time.Minute)
+	if err := s.storeValue(nil, first); err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(1100 * time.Millisecond) // to get new version of node
+	if err := s.storeValue(nil, newTestValue(t, overlayId, nil, UpdateRuleOverlayNodes{}, newTestOverlayNodes(t, overlayKey, keys...), 10*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	key, _ := tl.Hash(first.KeyDescription.Key)
+	v := s.getValue(key)
+	if v.TTL != first.TTL {
+		t.Fatal("ttl should be the longest of values")
+	}
+
+	var list overlay.NodesList
+	if _, err := tl.Parse(&list, v.Data, true); err != nil {
+		t.Fatal(err)
+	}
+	if len(list.List) != 2 {
+		t.Fatal("nodes were not merged", len(list.List))
+	}
+
+	var firstList overlay.NodesList
+	if _, err := tl.Parse(&firstList, first.Data, true); err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range list.List {
+		if n.ID.(adnl.PublicKeyED25519).Key.Equal(keys[0].Public()) && n.Version <= firstList.List[0].Version {
+			t.Fatal("node version was not updated")
+		}
+	}
+}
+
+func TestServer_StoreValueLimits(t *testing.T) {
+	s := newTestServer(t)
+	defer s.Close()
+	s.maxValues = 3
+	s.maxValuesPerSource = 2
+
+	newValue := func(ttl time.Duration) (*Value, ed25519.PrivateKey) {
+		_, owner, err := ed25519.GenerateKey(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return newTestValue(t, adnl.PublicKeyED25519{Key: owner.Public().(ed25519.PublicKey)}, owner, UpdateRuleSignature{}, []byte("x"), ttl), owner
+	}
+	keyOf := func(v *Value) []byte {
+		key, _ := tl.Hash(v.KeyDescription.Key)
+		return key
+	}
+
+	first, firstOwner := newValue(10 * time.Minute)
+	second, _ := newValue(20 * time.Minute)
+	for _, v := range []*Value{first, second} {
+		if err := s.storeValue([]byte("a"), v); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	extra, _ := newValue(20 * time.Minute)
+	if err := s.storeValue([]byte("a"), extra); err == nil {
+		t.Fatal("source should not store more values than limit")
+	}
+
+	// update of own value is not limited
+	firstId := adnl.PublicKeyED25519{Key: firstOwner.Public().(ed25519.PublicKey)}
+	if err := s.storeValue([]byte("a"), newTestValue(t, firstId, firstOwner, UpdateRuleSignature{}, []byte("y"), 15*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	third, _ := newValue(30 * time.Minute)
+	if err := s.storeValue([]byte("b"), third); err != nil {
+		t.Fatal(err)
+	}
+
+	// storage is full, new keys are rejected and stored values are kept
+	fourth, _ := newValue(30 * time.Minute)
+	if err := s.storeValue([]byte("c"), fourth); err == nil {
+		t.Fatal("value should be rejected when storage is full")
+	}
+	for _, v := range []*Value{first, second, third} {
+		if s.getValue(keyOf(v)) == nil {
+			t.Fatal("value was evicted")
+		}
+	}
+
+	// updates of stored keys are accepted when storage is full
+	if err := s.storeValue([]byte("a"), newTestValue(t, firstId, firstOwner, UpdateRuleSignature{}, []byte("z"), 20*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+
+	// expired value frees place in storage and of its source
+	s.values[string(keyOf(first))].TTL = int32(time.Now().Unix() - 1)
+	if err := s.storeValue([]byte("c"), fourth); err != nil {
+		t.Fatal(err)
+	}
+	if s.getValue(keyOf(first)) != nil {
+		t.Fatal("expired value was not removed")
+	}
+	if s.sourceValues["a"] != 1 {
+		t.Fatal("incorrect values accounting", s.sourceValues["a"])
+	}
+	if len(s.values) != 3 || len(s.valueSources) != 3 {
+		t.Fatal("incorrect values count", len(s.values), len(s.valueSources))
+	}
+}
+
+func TestServer_StoreValueFlood(t *testing.T) {
+	s := newTestServer(t)
+	defer s.Close()
+	s.maxValues = 8
+	s.maxValuesPerSource = 2
+
+	newValue := func(ttl time.Duration) *Value {
+		_, owner, err := ed25519.GenerateKey(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return newTestValue(t, adnl.PublicKeyED25519{Key: owner.Public().(ed25519.PublicKey)}, owner, UpdateRuleSignature{}, []byte("x"), ttl)
+	}
+
+	var stored [][]byte
+	for i := 0; i < s.maxValues; i++ {
+		v := newValue(time.Minute)
+		if err := s.storeValue([]byte(fmt.Sprint("honest", i/2)), v); err != nil {
+			t.Fatal(err)
+		}
+		key, _ := tl.Hash(v.KeyDescription.Key)
+		stored = append(stored, key)
+	}
+
+	// flood from many sources with fresh keys living longer than stored values
+	for i := 0; i < 100; i++ {
+		if err := s.storeValue([]byte(fmt.Sprint("flood", i)), newValue(time.Hour)); err == nil {
+			t.Fatal("flood value should be rejected")
+		}
+	}
+
+	for _, key := range stored {
+		if s.getValue(key) == nil {
+			t.Fatal("stored value was flushed by flood")
+		}
+	}
+	if len(s.values) != s.maxValues || len(s.sourceValues) != s.maxValues/2 {
+		t.Fatal("incorrect values accounting", len(s.values), len(s.sourceValues))
+	}
+}
+
+func TestServer_MergeOverlayNodesTooBig(t *testing.T) {
+	overlayKey := make([]byte, 32)
+	overlayId := adnl.PublicKeyOverlay{Key: overlayKey}
+
+	_, key, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	node, err := overlay.NewNode(overlayKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	node.Signature = make([]byte, _MaxValueSize)
+
+	data, err := tl.Serialize(overlay.NodesList{List: []overlay.Node{*node}}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	old := newTestValue(t, overlayId, nil, UpdateRuleOverlayNodes{}, data, 10*time.Minute)
+	value := newTestValue(t, overlayId, nil, UpdateRuleOverlayNodes{}, newTestOverlayNodes(t, overlayKey), 10*time.Minute)
+	if _, err = mergeOverlayNodes(old, value); err == nil {
+		t.Fatal("too big merged value should be rejected")
+	}
+}
+
+func TestServer_Queries(t *testing.T) {
+	_, srvKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gateway := adnl.NewGateway(srvKey)
+	s, err := NewServer(gateway, srvKey, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = gateway.StartServer("127.0.0.1:9195"); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	srvNode, err := s.signedNode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, cliKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cliGateway := adnl.NewGateway(cliKey)
+	if err = cliGateway.StartClient(); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := NewClient(cliGateway, []*Node{srvNode})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	peer, err := cliGateway.RegisterClient("127.0.0.1:9195", srvKey.Public().(ed25519.PublicKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var pong Pong
+	if err = peer.Query(ctx, Ping{ID: 777}, &pong); err != nil {
+		t.Fatal(err)
+	}
+	if pong.ID != 777 {
+		t.Fatal("incorrect pong")
+	}
+
+	var node Node
+	if err = peer.Query(ctx, SignedAddressListQuery{}, &node); err != nil {
+		t.Fatal(err)
+	}
+	if err = node.CheckSignature(); err != nil {
+		t.Fatal(err)
+	}
+	if node.AddrList.Addresses[0].Port != 9195 {
+		t.Fatal("incorrect address list")
+	}
+
+	_, owner, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	addresses := address.List{
+		Addresses: []*address.UDP{{IP: net.IPv4(1, 2, 3, 4).To4(), Port: 777}},
+		Version:   1,
+	}
+
+	stored, id, err := c.StoreAddress(ctx, addresses, 10*time.Minute, owner, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored != 1 {
+		t.Fatal("value was not stored")
+	}
+
+	found, pub, err := c.FindAddresses(ctx, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pub.Equal(owner.Public()) || !reflect.DeepEqual(found.Addresses, addresses.Addresses) {
+		t.Fatal("incorrect value found")
+	}
+
+	if _, _, err = c.FindAddresses(ctx, make([]byte, 32)); !errors.Is(err, ErrDHTValueIsNotFound) {
+		t.Fatal("not existing value should not be found", err)
+	}
+
+	// node announced in query is added to routing table and returned to others
+	cliNode := &Node{
+		ID:       adnl.PublicKeyED25519{Key: cliKey.Public().(ed25519.PublicKey)},
+		AddrList: &address.List{Addresses: []*address.UDP{{IP: net.IPv4(127, 0, 0, 1).To4(), Port: 9196}}},
+		Version:  int32(time.Now().Unix()),
+	}
+	if cliNode.Signature, err = signTL(cliNode, cliKey); err != nil {
+		t.Fatal(err)
+	}
+
+	var nodes NodesList
+	if err = peer.Query(ctx, []tl.Serializable{Query{Node: cliNode}, FindNode{Key: cliGateway.GetID(), K: 5}}, &nodes); err != nil {
+		t.Fatal(err)
+	}
+	if len(nodes.List) != 1 || !bytes.Equal(nodes.List[0].ID.(adnl.PublicKeyED25519).Key, cliNode.ID.(adnl.PublicKeyED25519).Key) {
+		t.Fatal("announced node was not returned")
+	}
+}
+
+func TestClient_ReverseRegistrationExpired(t *testing.T) {
+	c := &Client{reverseClients: map[string]time.Time{}}
+
+	clientId := make([]byte, 32)
+	msg := &adnl.MessageCustom{Data: RequestReversePingCont{Client: clientId}}
+
+	c.reverseClients[string(clientId)] = time.Now().Add(-time.Second)
+	if err := c.processReversePingRequest(msg); err == nil || !strings.Contains(err.Error(), "not registered") {
+		t.Fatal("expired registration should not be accepted", err)
+	}
+
+	c.reverseClients[string(clientId)] = time.Now().Add(time.Minute)
+	if err := c.processReversePingRequest(msg); err == nil || strings.Contains(err.Error(), "not registered") {
+		t.Fatal("active registration should be accepted and target checked", err)
+	}
+}
+
+func TestServer_ReverseConnection(t *testing.T) {
+	_, srvKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gateway := adnl.NewGateway(srvKey)
+	s, err := NewServer(gateway, srvKey, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = gateway.StartServer("127.0.0.1:9197"); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	srvNode, err := s.signedNode()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// client gateway has no public addresses, like a node behind NAT
+	_, natKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	natGateway := adnl.NewGateway(natKey)
+	if err = natGateway.StartClient(); err != nil {
+		t.Fatal(err)
+	}
+
+	natClient, err := NewClient(natGateway, []*Node{srvNode})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer natClient.Close()
+
+	// application handler of connection to dht node should keep receiving its messages
+	natPeer, err := natGateway.RegisterClient("127.0.0.1:9197", srvKey.Public().(ed25519.PublicKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var appMessages int
+	natPeer.SetCustomMessageHandler(func(msg *adnl.MessageCustom) error {
+		appMessages++
+		return nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// registration is repeated, handler should be wrapped only once
+	for i := 0; i < 2; i++ {
+		registered, err := natClient.RegisterReverseConnection(ctx, natKey, time.Minute)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if registered != 1 {
+			t.Fatal("reverse connection was not registered")
+		}
+	}
+
+	if err = natPeer.(customHandlerPeer).GetCustomMessageHandler()(&adnl.MessageCustom{Data: Ping{ID: 1}}); err != nil {
+		t.Fatal(err)
+	}
+	if appMessages != 1 {
+		t.Fatal("message was not passed to previous handler")
+	}
+
+	_, reqKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	connected := make(chan struct{})
+	reqGateway := adnl.NewGateway(reqKey)
+	reqGateway.SetConnectionHandler(func(client adnl.Peer) error {
+		// handler is also called for our own connection to dht node
+		if bytes.Equal(client.GetID(), natGateway.GetID()) {
+			close(connected)
+		}
+		return nil
+	})
+	if err = reqGateway.StartServer("127.0.0.1:9198"); err != nil {
+		t.Fatal(err)
+	}
+
+	reqClient, err := NewClient(reqGateway, []*Node{srvNode})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reqClient.Close()
+
+	if err = reqClient.RequestReversePing(ctx, reqKey, reqGateway.GetAddressList(), make([]byte, 32)); !errors.Is(err, ErrReverseConnectionNotFound) {
+		t.Fatal("not registered client should not be found", err)
+	}
+
+	if err = reqClient.RequestReversePing(ctx, reqKey, reqGateway.GetAddressList(), natGateway.GetID()); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case <-connected:
+	case <-ctx.Done():
+		t.Fatal("client has not connected")
+	}
+
+	// registration signed for another connection is rejected
+	req := RegisterReverseConnection{
+		Node: adnl.PublicKeyED25519{Key: natKey.Public().(ed25519.PublicKey)},
+		TTL:  int32(time.Now().Add(time.Minute).Unix()),
+	}
+	req.Signature = ed25519.Sign(natKey, reverseConnectionToSign(natGateway.GetID(), make([]byte, 32), req.TTL))
+
+	peer, err := reqGateway.RegisterClient("127.0.0.1:9197", srvKey.Public().(ed25519.PublicKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// server does not answer failed queries, so we only wait a bit
+	failCtx, failCancel := context.WithTimeout(ctx, time.Second)
+	defer failCancel()
+
+	var res any
+	if err = peer.Query(failCtx, req, &res); err == nil {
+		t.Fatal("registration with incorrect signature should fail")
+	}
+}
@@ -34,6 +34,7 @@ type Peer interface {
 
 type adnlClient interface {
 	Peer
+	GetCustomMessageHandler() func(msg *MessageCustom) error
 	processPacket(packet *PacketContent, ch *Channel) (err error)
 }
 
@@ -207,6 +208,10 @@ func (g *Gateway) listen(rootId []byte) {
 			continue
 		}
 
+		if o := loadObserver(); o != nil {
+			o.OnPacketReceived(addr.String(), n)
+		}
+
 		if n < 64 {
 			// too small packet
 			continue
@@ -466,6 +471,10 @@ func (p *peerConn) SetCustomMessageHandler(handler func(msg *MessageCustom) erro
 	p.client.SetCustomMessageHandler(handler)
 }
 
+func (p *peerConn) GetCustomMessageHandler() func(msg *MessageCustom) error {
+	return p.client.GetCustomMessageHandler()
+}
+
 func (p *peerConn) SetQueryHandler(handler func(msg *MessageQuery) error) {
 	p.client.SetQueryHandler(handler)
 }
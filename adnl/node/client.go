@@ -0,0 +1,453 @@
+package node
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/adnl/overlay"
+	"github.com/xssnick/tonutils-go/adnl/rldp"
+	"github.com/xssnick/tonutils-go/tl"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+// _MaxBlockSize - blocks are broadcasted as FEC and downloaded over RLDP, both limits are bigger than any real block
+const _MaxBlockSize = 16 << 20
+const _AnnounceTimeout = 10 * time.Second
+
+// _OutdatedReportInterval - broadcasts which cannot be verified may be sent by anyone, so handler is not called more often
+const _OutdatedReportInterval = time.Minute
+
+// ErrValidatorsOutdated - broadcast is signed for newer catchain than the last verified block and cannot be verified,
+// validators of the latest key block should be set with SyncKeyBlock or SetValidators, otherwise next blocks cannot be verified
+var ErrValidatorsOutdated = errors.New("validators are outdated, newer key block is required")
+
+type fullNode struct {
+	adnl *overlay.ADNLOverlayWrapper
+	rldp *overlay.RLDPOverlayWrapper
+}
+
+type shardNodes struct {
+	nodes       []*fullNode
+	broadcaster *overlay.Broadcaster
+}
+
+// Client - receives and downloads blocks directly from public overlays of full nodes, without liteservers.
+// Only broadcasts of masterchain blocks are received, they are verified with signatures of validators,
+// downloaded blocks of any workchain are verified by root and file hashes of requested id.
+type Client struct {
+	discovery
+	key ed25519.PrivateKey
+
+	overlays map[int32]*shardNodes
+	joining  map[int32]*joinCall
+	mx       sync.Mutex
+
+	catchainConfig *tlb.CatchainConfig
+	validators     *tlb.ValidatorSetAny
+	keyBlockSeqno  uint32
+	catchainSeqno  uint32
+	lastSeqno      uint32
+	outdatedAt     time.Time
+	onBlock        func(id *ton.BlockIDExt, block *tlb.Block)
+	onOutdated     func()
+	blocksMx       sync.Mutex
+}
+
+// NewClient - key is the key of gateway, it is used to announce client to overlay nodes, so they will send broadcasts to it.
+// zeroStateFileHash identifies network, it can be taken from global config: cfg.Validator.ZeroState.FileHash
+func NewClient(gateway Gateway, dhtClient DHT, key ed25519.PrivateKey, zeroStateFileHash []byte) (*Client, error) {
+	if len(zeroStateFileHash) != 32 {
+		return nil, fmt.Errorf("zero state file hash should be 32 bytes")
+	}
+
+	return &Client{
+		discovery: discovery{
+			gateway:           gateway,
+			dht:               dhtClient,
+			zeroStateFileHash: zeroStateFileHash,
+		},
+		key:      key,
+		overlays: map[int32]*shardNodes{},
+		joining:  map[int32]*joinCall{},
+	}, nil
+}
+
+// SetValidators - sets catchain config (param 28) and validators (param 34) of the latest key block with keyBlockSeqno,
+// they are used to verify signatures of masterchain block broadcasts, can be taken from ton.BlockchainConfig.
+// When verified key block is received, they are updated from its config automatically.
+func (c *Client) SetValidators(keyBlockSeqno uint32, catchainConfig *tlb.CatchainConfig, validators *tlb.ValidatorSetAny) {
+	c.blocksMx.Lock()
+	defer c.blocksMx.Unlock()
+
+	c.setValidators(keyBlockSeqno, catchainConfig, validators)
+}
+
+func (c *Client) setValidators(keyBlockSeqno uint32, catchainConfig *tlb.CatchainConfig, validators *tlb.ValidatorSetAny) {
+	c.catchainConfig = catchainConfig
+	c.validators = validators
+	c.keyBlockSeqno = keyBlockSeqno
+	// catchain of the first block signed by new validators is not known yet
+	c.catchainSeqno = 0
+}
+
+// SyncKeyBlock - downloads key block by trusted id (for example from liteserver) and takes validators from its config.
+// It should be used when key block broadcast was missed and ErrValidatorsOutdated is reported.
+func (c *Client) SyncKeyBlock(ctx context.Context, id *ton.BlockIDExt) error {
+	if id.Workchain != address.MasterchainID {
+		return fmt.Errorf("key block should be in masterchain")
+	}
+
+	block, err := c.DownloadBlock(ctx, id)
+	if err != nil {
+		return err
+	}
+	return c.applyKeyBlock(id, block)
+}
+
+func (c *Client) applyKeyBlock(id *ton.BlockIDExt, block *tlb.Block) error {
+	if !block.BlockInfo.KeyBlock {
+		return fmt.Errorf("block %d is not a key block", id.SeqNo)
+	}
+
+	catchainConfig, validators, err := ton.KeyBlockValidatorsConfig(block)
+	if err != nil {
+		return fmt.Errorf("failed to load validators of key block %d: %w", id.SeqNo, err)
+	}
+
+	c.blocksMx.Lock()
+	defer c.blocksMx.Unlock()
+
+	if id.SeqNo < c.keyBlockSeqno {
+		// newer key block is already known
+		return nil
+	}
+	c.setValidators(id.SeqNo, catchainConfig, validators)
+	return nil
+}
+
+// SetOnValidatorsOutdated - sets handler which is called when broadcasts signed for newer catchain cannot be verified,
+// it means that key block with new validators was probably missed. Handler is called not more often than once a minute,
+// it should get id of the latest key block from trusted source and pass it to SyncKeyBlock.
+func (c *Client) SetOnValidatorsOutdated(handler func()) {
+	c.blocksMx.Lock()
+	defer c.blocksMx.Unlock()
+
+	c.onOutdated = handler
+}
+
+// SetOnBlock - sets handler of new masterchain blocks received from broadcasts, only verified blocks are passed to it.
+// Shard blocks are not verified by client, their ids can be taken from shard hashes of masterchain block and downloaded.
+func (c *Client) SetOnBlock(handler func(id *ton.BlockIDExt, block *tlb.Block)) {
+	c.blocksMx.Lock()
+	defer c.blocksMx.Unlock()
+
+	c.onBlock = handler
+}
+
+// JoinOverlay - connects to nodes of masterchain public overlay and starts listening for block broadcasts.
+// Shard blocks are signed by subsets of validators which are not calculated by client, so their broadcasts
+// are not supported, shard blocks can be downloaded with DownloadBlock by ids from master blocks.
+func (c *Client) JoinOverlay(ctx context.Context, workchain int32) error {
+	if workchain != address.MasterchainID {
+		return fmt.Errorf("only masterchain overlay broadcasts are supported")
+	}
+
+	_, err := c.getNodes(ctx, workchain)
+	return err
+}
+
+// DownloadBlock - downloads block from random nodes of its workchain overlay
+func (c *Client) DownloadBlock(ctx context.Context, id *ton.BlockIDExt) (*tlb.Block, error) {
+	sn, err := c.getNodes(ctx, id.Workchain)
+	if err != nil {
+		return nil, err
+	}
+
+	nodes := append([]*fullNode{}, sn.nodes...)
+	rand.Shuffle(len(nodes), func(i, j int) {
+		nodes[i], nodes[j] = nodes[j], nodes[i]
+	})
+
+	req := DownloadBlockFull{Block: blockIDFromTon(id)}
+
+	answered := false
+	err = fmt.Errorf("no overlay nodes")
+	for _, n := range nodes {
+		var res tl.Serializable
+		if err = n.rldp.DoQuery(ctx, _MaxBlockSize, req, &res); err != nil {
+			err = fmt.Errorf("failed to query %s: %w", n.adnl.RemoteAddr(), err)
+			if ctx.Err() != nil {
+				break
+			}
+			continue
+		}
+		answered = true
+
+		switch t := res.(type) {
+		case DataFull:
+			block, parseErr := parseBlock(id, t.Block)
+			if parseErr != nil {
+				err = fmt.Errorf("incorrect block from %s: %w", n.adnl.RemoteAddr(), parseErr)
+				continue
+			}
+			return block, nil
+		case DataFullEmpty:
+			err = fmt.Errorf("block is not found")
+		default:
+			err = fmt.Errorf("unexpected response type %s", tl.Name(res))
+		}
+	}
+
+	if !answered && ctx.Err() == nil {
+		// nodes may be gone, discover them again next time
+		c.mx.Lock()
+		if c.overlays[id.Workchain] == sn {
+			delete(c.overlays, id.Workchain)
+			sn.close()
+		}
+		c.mx.Unlock()
+	}
+	return nil, fmt.Errorf("failed to download block: %w", err)
+}
+
+// joinCall - discovery of workchain nodes in progress, concurrent callers wait for its result
+type joinCall struct {
+	done chan struct{}
+	sn   *shardNodes
+	err  error
+}
+
+func (c *Client) getNodes(ctx context.Context, workchain int32) (*shardNodes, error) {
+	c.mx.Lock()
+	if sn := c.overlays[workchain]; sn != nil {
+		c.mx.Unlock()
+		return sn, nil
+	}
+
+	call := c.joining[workchain]
+	if call == nil {
+		// discovery is slow, so it is done without lock, only one per workchain
+		call = &joinCall{done: make(chan struct{})}
+		c.joining[workchain] = call
+		c.mx.Unlock()
+
+		call.sn, call.err = c.join(ctx, workchain)
+
+		c.mx.Lock()
+		delete(c.joining, workchain)
+		if call.err == nil {
+			c.overlays[workchain] = call.sn
+		}
+		c.mx.Unlock()
+		close(call.done)
+	} else {
+		c.mx.Unlock()
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-call.done:
+	}
+
+	if call.err != nil {
+		return nil, call.err
+	}
+	return call.sn, nil
+}
+
+func (c *Client) join(ctx context.Context, workchain int32) (*shardNodes, error) {
+	overlayId, peers, err := c.findPeers(ctx, workchain)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(peers) == 0 {
+		return nil, fmt.Errorf("no available nodes of workchain %d", workchain)
+	}
+
+	key, err := ShardOverlayKey(workchain, c.zeroStateFileHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calc overlay key: %w", err)
+	}
+
+	self, err := overlay.NewNode(key, c.key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign overlay node: %w", err)
+	}
+
+	sn := &shardNodes{
+		// client does not send own broadcasts, broadcaster only deduplicates and relays received ones
+		broadcaster: overlay.NewBroadcaster(overlayId, c.key, nil),
+	}
+	for _, peer := range peers {
+		a := overlay.CreateExtendedADNL(peer)
+		r := overlay.CreateExtendedRLDP(rldp.NewClientV2(a))
+
+		n := &fullNode{
+			adnl: a.CreateOverlayWithSettings(overlayId, _MaxBlockSize, true, false),
+			rldp: r.CreateOverlay(overlayId),
+		}
+		n.adnl.SetBroadcastHandler(c.broadcastHandler)
+
+		if err = sn.broadcaster.AddPeer(n.adnl); err != nil {
+			return nil, fmt.Errorf("failed to add overlay peer: %w", err)
+		}
+		sn.nodes = append(sn.nodes, n)
+
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), _AnnounceTimeout)
+			defer cancel()
+
+			var res overlay.NodesList
+			_ = n.adnl.Query(ctx, overlay.GetRandomPeers{List: overlay.NodesList{List: []overlay.Node{*self}}}, &res)
+		}()
+	}
+	return sn, nil
+}
+
+func (s *shardNodes) close() {
+	for _, n := range s.nodes {
+		s.broadcaster.RemovePeer(n.adnl)
+		n.adnl.Close()
+		n.rldp.Close()
+	}
+}
+
+func (c *Client) broadcastHandler(msg tl.Serializable, _ bool) error {
+	bc, ok := msg.(BlockBroadcast)
+	if !ok {
+		return nil
+	}
+
+	if bc.ID.Workchain != address.MasterchainID {
+		// shard blocks are signed by subsets of validators which are not calculated here
+		return nil
+	}
+	return c.processBlockBroadcast(&bc)
+}
+
+func (c *Client) processBlockBroadcast(bc *BlockBroadcast) error {
+	id := bc.ID.toTon()
+
+	c.blocksMx.Lock()
+	if c.validators == nil || c.catchainConfig == nil || id.SeqNo <= c.lastSeqno {
+		c.blocksMx.Unlock()
+		return nil
+	}
+
+	sigs := &ton.SignatureSet{
+		ValidatorSetHash: bc.ValidatorSetHash,
+		CatchainSeqno:    bc.CatchainSeqno,
+		Signatures:       make([]ton.Signature, 0, len(bc.Signatures)),
+	}
+	for _, s := range bc.Signatures {
+		sigs.Signatures = append(sigs.Signatures, ton.Signature{NodeIDShort: s.Who, Signature: s.Signature})
+	}
+
+	if err := ton.CheckMasterBlockSignatures(id, sigs, *c.catchainConfig, *c.validators); err != nil {
+		err = fmt.Errorf("failed to verify block %d: %w", id.SeqNo, err)
+		if c.catchainSeqno != 0 && uint32(sigs.CatchainSeqno) <= c.catchainSeqno {
+			c.blocksMx.Unlock()
+			return err
+		}
+		// block may be signed by validators of key block we missed, then nothing can be verified until it is synced,
+		// fields of not verified block cannot be trusted, so only catchain of signatures is taken into account
+		handler := c.onOutdated
+		notify := time.Since(c.outdatedAt) >= _OutdatedReportInterval
+		if notify {
+			c.outdatedAt = time.Now()
+		}
+		c.blocksMx.Unlock()
+
+		if notify && handler != nil {
+			handler()
+		}
+		return fmt.Errorf("%w: %w", ErrValidatorsOutdated, err)
+	}
+
+	block, err := parseBlock(id, bc.Data)
+	if err != nil {
+		c.blocksMx.Unlock()
+		return fmt.Errorf("incorrect block %d: %w", id.SeqNo, err)
+	}
+
+	if block.BlockInfo.GenCatchainSeqno != uint32(bc.CatchainSeqno) {
+		c.blocksMx.Unlock()
+		return fmt.Errorf("incorrect catchain seqno of block %d", id.SeqNo)
+	}
+
+	if block.BlockInfo.KeyBlock {
+		catchainConfig, validators, err := ton.KeyBlockValidatorsConfig(block)
+		if err != nil {
+			c.blocksMx.Unlock()
+			return fmt.Errorf("failed to load validators of key block %d: %w", id.SeqNo, err)
+		}
+		c.setValidators(id.SeqNo, catchainConfig, validators)
+	} else if block.BlockInfo.PrevKeyBlockSeqno > c.keyBlockSeqno {
+		// missed key block has not changed validators, otherwise signatures would not match
+		c.keyBlockSeqno = block.BlockInfo.PrevKeyBlockSeqno
+	}
+	c.lastSeqno = id.SeqNo
+	c.catchainSeqno = block.BlockInfo.GenCatchainSeqno
+	handler := c.onBlock
+	c.blocksMx.Unlock()
+
+	if handler != nil {
+		handler(id, block)
+	}
+	return nil
+}
+
+// parseBlock - checks that data is the block with given id and parses it
+func parseBlock(id *ton.BlockIDExt, data []byte) (*tlb.Block, error) {
+	fileHash := sha256.Sum256(data)
+	if !bytes.Equal(fileHash[:], id.FileHash) {
+		return nil, fmt.Errorf("incorrect file hash")
+	}
+
+	root, err := cell.FromBOC(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse boc: %w", err)
+	}
+
+	if !bytes.Equal(root.Hash(), id.RootHash) {
+		return nil, fmt.Errorf("incorrect root hash")
+	}
+
+	var block tlb.Block
+	if err = tlb.LoadFromCell(&block, root.BeginParse()); err != nil {
+		return nil, fmt.Errorf("failed to parse block: %w", err)
+	}
+	return &block, nil
+}
+
+func blockIDFromTon(id *ton.BlockIDExt) BlockIDExt {
+	return BlockIDExt{
+		Workchain: id.Workchain,
+		Shard:     id.Shard,
+		Seqno:     int32(id.SeqNo),
+		RootHash:  id.RootHash,
+		FileHash:  id.FileHash,
+	}
+}
+
+func (b BlockIDExt) toTon() *ton.BlockIDExt {
+	return &ton.BlockIDExt{
+		Workchain: b.Workchain,
+		Shard:     b.Shard,
+		SeqNo:     uint32(b.Seqno),
+		RootHash:  b.RootHash,
+		FileHash:  b.FileHash,
+	}
+}
@@ -0,0 +1,115 @@
+package node
+
+import (
+	"context"
+	"crypto/ed25519"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/xssnick/tonutils-go/adnl"
+	"github.com/xssnick/tonutils-go/adnl/address"
+	"github.com/xssnick/tonutils-go/adnl/dht"
+	"github.com/xssnick/tonutils-go/adnl/overlay"
+	"github.com/xssnick/tonutils-go/tl"
+)
+
+// _ShardAll - full nodes use one public overlay for the whole workchain
+const _ShardAll = int64(-0x8000000000000000)
+
+// _OverlayPeersTTL - how long discovered overlay peers are used before they are discovered again
+const _OverlayPeersTTL = 5 * time.Minute
+const _MaxOverlayPeers = 12
+
+type DHT interface {
+	FindOverlayNodes(ctx context.Context, overlayKey []byte, continuation ...*dht.Continuation) (*overlay.NodesList, *dht.Continuation, error)
+	FindAddresses(ctx context.Context, key []byte) (*address.List, ed25519.PublicKey, error)
+}
+
+type Gateway interface {
+	RegisterClient(addr string, key ed25519.PublicKey) (adnl.Peer, error)
+}
+
+// discovery - finds full nodes of workchain public overlays in DHT and connects to them
+type discovery struct {
+	gateway           Gateway
+	dht               DHT
+	zeroStateFileHash []byte
+}
+
+// ShardOverlayKey - returns full id of public overlay of workchain, used to find its nodes in DHT
+func ShardOverlayKey(workchain int32, zeroStateFileHash []byte) ([]byte, error) {
+	return tl.Hash(ShardPublicOverlayID{
+		Workchain:         workchain,
+		Shard:             _ShardAll,
+		ZeroStateFileHash: zeroStateFileHash,
+	})
+}
+
+// findPeers - returns short overlay id and connections to available nodes of workchain overlay
+func (d *discovery) findPeers(ctx context.Context, workchain int32) ([]byte, []adnl.Peer, error) {
+	key, err := ShardOverlayKey(workchain, d.zeroStateFileHash)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to calc overlay key: %w", err)
+	}
+
+	overlayId, err := tl.Hash(adnl.PublicKeyOverlay{Key: key})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to calc overlay id: %w", err)
+	}
+
+	list, _, err := d.dht.FindOverlayNodes(ctx, key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to find overlay nodes of workchain %d: %w", workchain, err)
+	}
+
+	nodes := list.List
+	if len(nodes) > _MaxOverlayPeers {
+		nodes = nodes[:_MaxOverlayPeers]
+	}
+
+	var wg sync.WaitGroup
+	connected := make([]adnl.Peer, len(nodes))
+	for i := range nodes {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			connected[i], _ = d.connect(ctx, &nodes[i])
+		}(i)
+	}
+	wg.Wait()
+
+	peers := make([]adnl.Peer, 0, len(connected))
+	for _, peer := range connected {
+		if peer != nil {
+			peers = append(peers, peer)
+		}
+	}
+	return overlayId, peers, nil
+}
+
+func (d *discovery) connect(ctx context.Context, node *overlay.Node) (adnl.Peer, error) {
+	if err := node.CheckSignature(); err != nil {
+		return nil, err
+	}
+
+	pub := node.ID.(adnl.PublicKeyED25519)
+	id, err := tl.Hash(pub)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calc node id: %w", err)
+	}
+
+	addresses, _, err := d.dht.FindAddresses(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find node address: %w", err)
+	}
+
+	for _, udp := range addresses.Addresses {
+		peer, err := d.gateway.RegisterClient(fmt.Sprintf("%s:%d", udp.IP.String(), udp.Port), pub.Key)
+		if err != nil {
+			continue
+		}
+		return peer, nil
+	}
+	return nil, fmt.Errorf("no available addresses of node")
+}
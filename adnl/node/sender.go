@@ -0,0 +1,111 @@
+package node
+
+import (
+	"context"
+	"crypto/ed25519"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/xssnick/tonutils-go/adnl/overlay"
+	"github.com/xssnick/tonutils-go/tlb"
+)
+
+type shardOverlay struct {
+	broadcaster  *overlay.Broadcaster
+	discoveredAt time.Time
+}
+
+// ExternalMessageSender - sends external messages directly to public overlays of full nodes, without liteservers.
+// Can be used as transport of ton.APIClient with SetExternalMessageSender.
+type ExternalMessageSender struct {
+	discovery
+	key ed25519.PrivateKey
+
+	overlays map[int32]*shardOverlay
+	mx       sync.Mutex
+}
+
+// NewExternalMessageSender - zeroStateFileHash identifies network, it can be taken from global config: cfg.Validator.ZeroState.FileHash
+func NewExternalMessageSender(gateway Gateway, dhtClient DHT, zeroStateFileHash []byte) (*ExternalMessageSender, error) {
+	if len(zeroStateFileHash) != 32 {
+		return nil, fmt.Errorf("zero state file hash should be 32 bytes")
+	}
+
+	// broadcasts are signed with random key, nodes do not require any specific source for external messages
+	_, key, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ExternalMessageSender{
+		discovery: discovery{
+			gateway:           gateway,
+			dht:               dhtClient,
+			zeroStateFileHash: zeroStateFileHash,
+		},
+		key:      key,
+		overlays: map[int32]*shardOverlay{},
+	}, nil
+}
+
+// SendExternalMessage - broadcasts message to random nodes of destination workchain overlay
+func (s *ExternalMessageSender) SendExternalMessage(ctx context.Context, msg *tlb.ExternalMessage) error {
+	c, err := tlb.ToCell(msg)
+	if err != nil {
+		return fmt.Errorf("failed to serialize external message: %w", err)
+	}
+
+	b, err := s.getBroadcaster(ctx, msg.DstAddr.Workchain())
+	if err != nil {
+		return err
+	}
+
+	err = b.Broadcast(ctx, NewExternalMessageBroadcast{
+		Message: ExternalMessage{Data: c.ToBOCWithFlags(false)},
+	})
+	if err != nil {
+		// peers may be gone, discover them again next time
+		s.mx.Lock()
+		delete(s.overlays, msg.DstAddr.Workchain())
+		s.mx.Unlock()
+
+		return fmt.Errorf("failed to broadcast external message: %w", err)
+	}
+	return nil
+}
+
+func (s *ExternalMessageSender) getBroadcaster(ctx context.Context, workchain int32) (*overlay.Broadcaster, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	if o := s.overlays[workchain]; o != nil && time.Since(o.discoveredAt) < _OverlayPeersTTL {
+		return o.broadcaster, nil
+	}
+
+	b, err := s.discover(ctx, workchain)
+	if err != nil {
+		return nil, err
+	}
+
+	s.overlays[workchain] = &shardOverlay{
+		broadcaster:  b,
+		discoveredAt: time.Now(),
+	}
+	return b, nil
+}
+
+func (s *ExternalMessageSender) discover(ctx context.Context, workchain int32) (*overlay.Broadcaster, error) {
+	overlayId, peers, err := s.findPeers(ctx, workchain)
+	if err != nil {
+		return nil, err
+	}
+
+	b := overlay.NewBroadcaster(overlayId, s.key, nil)
+	for _, peer := range peers {
+		if err = b.AddPeer(overlay.CreateExtendedADNL(peer).WithOverlay(overlayId)); err != nil {
+			return nil, fmt.Errorf("failed to add overlay peer: %w", err)
+		}
+	}
+	return b, nil
+}
@@ -0,0 +1,134 @@
+package node
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/adnl"
+	adnlAddress "github.com/xssnick/tonutils-go/adnl/address"
+	"github.com/xssnick/tonutils-go/adnl/dht"
+	"github.com/xssnick/tonutils-go/adnl/overlay"
+	"github.com/xssnick/tonutils-go/tl"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+type testDHT struct {
+	overlayKey []byte
+	node       *overlay.Node
+	addr       *adnlAddress.UDP
+}
+
+func (d *testDHT) FindOverlayNodes(_ context.Context, overlayKey []byte, _ ...*dht.Continuation) (*overlay.NodesList, *dht.Continuation, error) {
+	if !bytes.Equal(overlayKey, d.overlayKey) {
+		return nil, nil, fmt.Errorf("value is not found")
+	}
+	return &overlay.NodesList{List: []overlay.Node{*d.node}}, nil, nil
+}
+
+func (d *testDHT) FindAddresses(_ context.Context, key []byte) (*adnlAddress.List, ed25519.PublicKey, error) {
+	id, _ := tl.Hash(d.node.ID)
+	if !bytes.Equal(key, id) {
+		return nil, nil, fmt.Errorf("value is not found")
+	}
+	return &adnlAddress.List{Addresses: []*adnlAddress.UDP{d.addr}}, d.node.ID.(adnl.PublicKeyED25519).Key, nil
+}
+
+func TestExternalMessageSender(t *testing.T) {
+	zeroStateHash := make([]byte, 32)
+	_, _ = rand.Read(zeroStateHash)
+
+	overlayKey, err := ShardOverlayKey(0, zeroStateHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	overlayId, err := tl.Hash(adnl.PublicKeyOverlay{Key: overlayKey})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// full node which listens public overlay of basechain
+	_, nodeKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := adnl.NewGateway(nodeKey)
+	got := make(chan []byte, 1)
+	srv.SetConnectionHandler(func(client adnl.Peer) error {
+		o := overlay.CreateExtendedADNL(client).CreateOverlayWithSettings(overlayId, 1024, true, false)
+		o.SetBroadcastHandler(func(msg tl.Serializable, trusted bool) error {
+			if m, ok := msg.(NewExternalMessageBroadcast); ok {
+				got <- m.Message.Data
+			}
+			return nil
+		})
+		return nil
+	})
+	if err = srv.StartServer("127.0.0.1:9189"); err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+
+	node, err := overlay.NewNode(overlayKey, nodeKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, cliKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cli := adnl.NewGateway(cliKey)
+	if err = cli.StartClient(); err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	sender, err := NewExternalMessageSender(cli, &testDHT{
+		overlayKey: overlayKey,
+		node:       node,
+		addr:       &adnlAddress.UDP{IP: net.IPv4(127, 0, 0, 1).To4(), Port: 9189},
+	}, zeroStateHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg := &tlb.ExternalMessage{
+		DstAddr: address.MustParseAddr("EQCD39VS5jcptHL8vMjEXrzGaRcCVYto7HUn4bpAOg8xqB2N"),
+		Body:    cell.BeginCell().MustStoreUInt(777, 64).EndCell(),
+	}
+	msgCell, err := tlb.ToCell(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err = sender.SendExternalMessage(ctx, msg); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case data := <-got:
+		if !bytes.Equal(data, msgCell.ToBOCWithFlags(false)) {
+			t.Fatal("incorrect message received")
+		}
+	case <-ctx.Done():
+		t.Fatal("message was not received by node")
+	}
+
+	// masterchain overlay is not in dht
+	msg.DstAddr = address.MustParseAddr("Ef8zMzMzMzMzMzMzMzMzMzMzMzMzMzMzMzMzMzMzMzMzM0vF")
+	if err = sender.SendExternalMessage(ctx, msg); err == nil {
+		t.Fatal("should fail without overlay nodes")
+	}
+}
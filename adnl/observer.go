@@ -0,0 +1,33 @@
+package adnl
+
+import "sync/atomic"
+
+// Observer - receives instrumentation events of all ADNL connections,
+// methods are called synchronously from network routines, so they should not block
+type Observer interface {
+	// OnPacketSent - called for each packet written to network, including retransmits
+	OnPacketSent(addr string, size int)
+	// OnPacketReceived - called for each packet read from network
+	OnPacketReceived(addr string, size int)
+	// OnQueryRetransmit - called when query packets are sent again because answer was not received in time
+	OnQueryRetransmit(addr string, packets int)
+}
+
+// observerBox - interface can not be stored in atomic.Pointer directly
+type observerBox struct {
+	o Observer
+}
+
+var observer atomic.Pointer[observerBox]
+
+// SetObserver - sets instrumentation hook for all ADNL connections, nil disables it, can be called at any time
+func SetObserver(o Observer) {
+	observer.Store(&observerBox{o: o})
+}
+
+func loadObserver() Observer {
+	if b := observer.Load(); b != nil {
+		return b.o
+	}
+	return nil
+}
@@ -0,0 +1,322 @@
+package overlay
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/xssnick/tonutils-go/adnl"
+	"github.com/xssnick/tonutils-go/adnl/rldp"
+	"github.com/xssnick/tonutils-go/adnl/rldp/raptorq"
+	"github.com/xssnick/tonutils-go/tl"
+)
+
+// _MaxSimpleBroadcastSize - bigger broadcasts are sent as FEC
+const _MaxSimpleBroadcastSize = 768
+const _FECSymbolSize = 768
+const _DefaultBroadcastFanout = 5
+
+// _BroadcastSeenTTL - how long ids of processed broadcasts are kept to not process and relay them again
+const _BroadcastSeenTTL = 5 * time.Minute
+
+// _BroadcastDateWindow - broadcasts with date further from the current time are rejected,
+// it should be less than _BroadcastSeenTTL, so broadcasts can not be replayed after they are forgotten
+const _BroadcastDateWindow = 20 * time.Second
+const _RelayTimeout = 10 * time.Second
+
+// Broadcaster - sends signed broadcasts to random peers of overlay,
+// and relays broadcasts received from its peers further, each broadcast is processed only once.
+type Broadcaster struct {
+	overlayId []byte
+	key       ed25519.PrivateKey
+	cert      any
+	fanout    int
+
+	peers []*ADNLOverlayWrapper
+	seen  map[string]time.Time
+
+	mx sync.Mutex
+}
+
+// NewBroadcaster - creates broadcaster for overlay, broadcasts are signed with key,
+// cert is overlay certificate issued for key, nil for public overlays
+func NewBroadcaster(overlayId []byte, key ed25519.PrivateKey, cert any) *Broadcaster {
+	if cert == nil {
+		cert = CertificateEmpty{}
+	}
+
+	return &Broadcaster{
+		overlayId: overlayId,
+		key:       key,
+		cert:      cert,
+		fanout:    _DefaultBroadcastFanout,
+		seen:      map[string]time.Time{},
+	}
+}
+
+// SetFanout - sets number of random peers each broadcast is sent or relayed to, default is 5
+func (b *Broadcaster) SetFanout(n int) {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+	b.fanout = n
+}
+
+// AddPeer - adds overlay connection to peers, broadcasts received from it will be relayed to other peers
+func (b *Broadcaster) AddPeer(p *ADNLOverlayWrapper) error {
+	if !bytes.Equal(p.overlayId, b.overlayId) {
+		return fmt.Errorf("peer belongs to other overlay")
+	}
+
+	p.mx.Lock()
+	p.broadcaster = b
+	p.mx.Unlock()
+
+	b.mx.Lock()
+	defer b.mx.Unlock()
+
+	for _, peer := range b.peers {
+		if peer == p {
+			return nil
+		}
+	}
+	b.peers = append(b.peers, p)
+	return nil
+}
+
+func (b *Broadcaster) RemovePeer(p *ADNLOverlayWrapper) {
+	p.mx.Lock()
+	if p.broadcaster == b {
+		p.broadcaster = nil
+	}
+	p.mx.Unlock()
+
+	b.mx.Lock()
+	defer b.mx.Unlock()
+
+	for i, peer := range b.peers {
+		if peer == p {
+			b.peers = append(b.peers[:i], b.peers[i+1:]...)
+			return
+		}
+	}
+}
+
+// Broadcast - sends message to random peers, big messages are sent as FEC broadcast
+func (b *Broadcaster) Broadcast(ctx context.Context, msg tl.Serializable) error {
+	data, err := tl.Serialize(msg, true)
+	if err != nil {
+		return fmt.Errorf("failed to serialize message: %w", err)
+	}
+
+	if len(data) > _MaxSimpleBroadcastSize {
+		return b.broadcastFEC(ctx, data)
+	}
+
+	bc := Broadcast{
+		Source:      adnl.PublicKeyED25519{Key: b.key.Public().(ed25519.PublicKey)},
+		Certificate: b.cert,
+		Data:        data,
+		Date:        int32(time.Now().Unix()),
+	}
+
+	id, err := bc.CalcID()
+	if err != nil {
+		return fmt.Errorf("failed to calc broadcast id: %w", err)
+	}
+
+	bc.Signature, err = b.sign(id, bc.Date)
+	if err != nil {
+		return err
+	}
+
+	// to not process it when it will come back from peers
+	b.markSeen(id)
+
+	return b.send(ctx, nil, []tl.Serializable{bc})
+}
+
+// BroadcastFEC - sends message to random peers encoded with RaptorQ, so it can be restored even when some parts are lost
+func (b *Broadcaster) BroadcastFEC(ctx context.Context, msg tl.Serializable) error {
+	data, err := tl.Serialize(msg, true)
+	if err != nil {
+		return fmt.Errorf("failed to serialize message: %w", err)
+	}
+	return b.broadcastFEC(ctx, data)
+}
+
+func (b *Broadcaster) broadcastFEC(ctx context.Context, data []byte) error {
+	enc, err := raptorq.NewRaptorQ(_FECSymbolSize).CreateEncoder(data)
+	if err != nil {
+		return fmt.Errorf("failed to create raptorq object encoder: %w", err)
+	}
+
+	dataHash := sha256.Sum256(data)
+	bc := BroadcastFEC{
+		Source:      adnl.PublicKeyED25519{Key: b.key.Public().(ed25519.PublicKey)},
+		Certificate: b.cert,
+		DataHash:    dataHash[:],
+		DataSize:    int32(len(data)),
+		FEC: rldp.FECRaptorQ{
+			DataSize:     int32(len(data)),
+			SymbolSize:   _FECSymbolSize,
+			SymbolsCount: int32(enc.BaseSymbolsNum()),
+		},
+		Date: int32(time.Now().Unix()),
+	}
+
+	broadcastHash, err := bc.CalcID()
+	if err != nil {
+		return fmt.Errorf("failed to calc broadcast hash: %w", err)
+	}
+	b.markSeen(broadcastHash)
+
+	// 20% of additional symbols to recover lost packets
+	num := enc.BaseSymbolsNum() + enc.BaseSymbolsNum()/5 + 1
+	parts := make([]tl.Serializable, 0, num)
+	for seqno := uint32(0); seqno < num; seqno++ {
+		part := bc
+		part.Seqno = int32(seqno)
+		part.Data = enc.GenSymbol(seqno)
+
+		partDataHash := sha256.Sum256(part.Data)
+		partHash, err := tl.Hash(&BroadcastFECPartID{
+			BroadcastHash: broadcastHash,
+			DataHash:      partDataHash[:],
+			Seqno:         part.Seqno,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to compute hash id of the part: %w", err)
+		}
+
+		part.Signature, err = b.sign(partHash, part.Date)
+		if err != nil {
+			return err
+		}
+		b.markSeen(partHash)
+
+		parts = append(parts, part)
+	}
+
+	return b.send(ctx, nil, parts)
+}
+
+func (b *Broadcaster) sign(hash []byte, date int32) ([]byte, error) {
+	toSign, err := tl.Serialize(&BroadcastToSign{
+		Hash: hash,
+		Date: date,
+	}, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize broadcast to sign: %w", err)
+	}
+	return ed25519.Sign(b.key, toSign), nil
+}
+
+// send - sends messages to random peers except the source one, returns error only when no peer has received them
+func (b *Broadcaster) send(ctx context.Context, from *ADNLOverlayWrapper, msgs []tl.Serializable) error {
+	peers := b.pickPeers(from)
+	if len(peers) == 0 {
+		return fmt.Errorf("no peers to broadcast")
+	}
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(peers))
+	for i, peer := range peers {
+		wg.Add(1)
+		go func(i int, peer *ADNLOverlayWrapper) {
+			defer wg.Done()
+
+			for j, msg := range msgs {
+				if j > 0 {
+					select {
+					case <-ctx.Done():
+						errs[i] = ctx.Err()
+						return
+					case <-time.After(_PacketWaitTime):
+					}
+				}
+
+				if err := peer.ADNLWrapper.SendCustomMessage(ctx, WrapMessage(b.overlayId, msg)); err != nil {
+					errs[i] = fmt.Errorf("failed to send broadcast to %s: %w", peer.RemoteAddr(), err)
+					return
+				}
+			}
+		}(i, peer)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err == nil {
+			return nil
+		}
+	}
+	return errs[0]
+}
+
+// relay - sends received broadcast further in background
+func (b *Broadcaster) relay(from *ADNLOverlayWrapper, msg tl.Serializable) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), _RelayTimeout)
+		defer cancel()
+
+		_ = b.send(ctx, from, []tl.Serializable{msg})
+	}()
+}
+
+func (b *Broadcaster) pickPeers(exclude *ADNLOverlayWrapper) []*ADNLOverlayWrapper {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+
+	peers := make([]*ADNLOverlayWrapper, 0, len(b.peers))
+	for _, p := range b.peers {
+		if p != exclude {
+			peers = append(peers, p)
+		}
+	}
+
+	rand.Shuffle(len(peers), func(i, j int) {
+		peers[i], peers[j] = peers[j], peers[i]
+	})
+	if len(peers) > b.fanout {
+		peers = peers[:b.fanout]
+	}
+	return peers
+}
+
+// checkBroadcastDate - returns error when broadcast is too old or from the future
+func checkBroadcastDate(date int32) error {
+	now := time.Now()
+	tm := time.Unix(int64(date), 0)
+	if tm.Before(now.Add(-_BroadcastDateWindow)) {
+		return fmt.Errorf("too old broadcast")
+	}
+	if tm.After(now.Add(_BroadcastDateWindow)) {
+		return fmt.Errorf("too new broadcast")
+	}
+	return nil
+}
+
+// markSeen - returns false when id was already seen
+func (b *Broadcaster) markSeen(id []byte) bool {
+	b.mx.Lock()
+	defer b.mx.Unlock()
+
+	now := time.Now()
+	if at, ok := b.seen[string(id)]; ok && now.Sub(at) < _BroadcastSeenTTL {
+		return false
+	}
+
+	if len(b.seen) > 10000 {
+		for k, at := range b.seen {
+			if now.Sub(at) >= _BroadcastSeenTTL {
+				delete(b.seen, k)
+			}
+		}
+	}
+	b.seen[string(id)] = now
+	return true
+}
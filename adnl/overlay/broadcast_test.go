@@ -0,0 +1,250 @@
+package overlay
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/xssnick/tonutils-go/adnl"
+	"github.com/xssnick/tonutils-go/adnl/rldp"
+	"github.com/xssnick/tonutils-go/tl"
+)
+
+// testADNL - in-memory connection, messages are serialized to check them as they go through network
+type testADNL struct {
+	name   string
+	remote *testADNL
+
+	customHandler func(msg *adnl.MessageCustom) error
+	mx            sync.RWMutex
+}
+
+func newTestConnection(a, b string) (*testADNL, *testADNL) {
+	ca, cb := &testADNL{name: a}, &testADNL{name: b}
+	ca.remote, cb.remote = cb, ca
+	return ca, cb
+}
+
+func (t *testADNL) SetCustomMessageHandler(handler func(msg *adnl.MessageCustom) error) {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+	t.customHandler = handler
+}
+
+func (t *testADNL) SetQueryHandler(func(msg *adnl.MessageQuery) error)             {}
+func (t *testADNL) SetDisconnectHandler(func(addr string, key ed25519.PublicKey))  {}
+func (t *testADNL) GetDisconnectHandler() func(addr string, key ed25519.PublicKey) { return nil }
+func (t *testADNL) Query(context.Context, tl.Serializable, tl.Serializable) error  { return nil }
+func (t *testADNL) Answer(context.Context, []byte, tl.Serializable) error          { return nil }
+func (t *testADNL) RemoteAddr() string                                             { return t.remote.name }
+func (t *testADNL) GetID() []byte                                                  { return nil }
+func (t *testADNL) Close()                                                         {}
+
+func (t *testADNL) SendCustomMessage(_ context.Context, req tl.Serializable) error {
+	data, err := tl.Serialize(adnl.MessageCustom{Data: req}, true)
+	if err != nil {
+		return err
+	}
+
+	var msg adnl.MessageCustom
+	if _, err = tl.Parse(&msg, data, true); err != nil {
+		return err
+	}
+
+	t.remote.mx.RLock()
+	h := t.remote.customHandler
+	t.remote.mx.RUnlock()
+
+	go func() {
+		_ = h(&msg)
+	}()
+	return nil
+}
+
+type testReceiver struct {
+	got []any
+	mx  sync.Mutex
+}
+
+func (r *testReceiver) handler(msg tl.Serializable, _ bool) error {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+	r.got = append(r.got, msg)
+	return nil
+}
+
+func (r *testReceiver) wait(t *testing.T, n int) []any {
+	for i := 0; i < 100; i++ {
+		r.mx.Lock()
+		if len(r.got) >= n {
+			got := append([]any{}, r.got...)
+			r.mx.Unlock()
+			// wait a bit more to catch duplicates
+			time.Sleep(50 * time.Millisecond)
+			r.mx.Lock()
+			defer r.mx.Unlock()
+			if len(r.got) != n {
+				t.Fatal("broadcast was processed more than once:", len(r.got))
+			}
+			return got
+		}
+		r.mx.Unlock()
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatal("broadcast was not received")
+	return nil
+}
+
+func newTestKey(t *testing.T) ed25519.PrivateKey {
+	_, key, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestBroadcaster_Relay(t *testing.T) {
+	overlayId := make([]byte, 32)
+	_, _ = rand.Read(overlayId)
+
+	wrap := func(conn *testADNL) *ADNLOverlayWrapper {
+		return CreateExtendedADNL(conn).CreateOverlayWithSettings(overlayId, 1<<20, true, true)
+	}
+
+	ab, ba := newTestConnection("a", "b")
+	bc, cb := newTestConnection("b", "c")
+	ac, ca := newTestConnection("a", "c")
+
+	origin := NewBroadcaster(overlayId, newTestKey(t), nil)
+	for _, conn := range []*testADNL{ab, ac} {
+		if err := origin.AddPeer(wrap(conn)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// b and c get broadcast from origin and from each other
+	relayB := NewBroadcaster(overlayId, newTestKey(t), nil)
+	relayC := NewBroadcaster(overlayId, newTestKey(t), nil)
+
+	var recvB, recvC testReceiver
+	for _, p := range []struct {
+		b    *Broadcaster
+		conn *testADNL
+		recv *testReceiver
+	}{{relayB, ba, &recvB}, {relayB, bc, &recvB}, {relayC, cb, &recvC}, {relayC, ca, &recvC}} {
+		w := wrap(p.conn)
+		w.SetBroadcastHandler(p.recv.handler)
+		if err := p.b.AddPeer(w); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := origin.Broadcast(ctx, adnl.MessagePing{Value: 777}); err != nil {
+		t.Fatal(err)
+	}
+	for _, got := range [][]any{recvB.wait(t, 1), recvC.wait(t, 1)} {
+		if ping, ok := got[0].(adnl.MessagePing); !ok || ping.Value != 777 {
+			t.Fatal("incorrect broadcast", got[0])
+		}
+	}
+
+	big := rldp.Message{ID: make([]byte, 32), Data: make([]byte, 5000)}
+	_, _ = rand.Read(big.Data)
+
+	if err := origin.Broadcast(ctx, big); err != nil {
+		t.Fatal(err)
+	}
+	for _, got := range [][]any{recvB.wait(t, 2), recvC.wait(t, 2)} {
+		if msg, ok := got[1].(rldp.Message); !ok || !bytes.Equal(msg.Data, big.Data) {
+			t.Fatal("incorrect FEC broadcast")
+		}
+	}
+}
+
+func TestBroadcaster_NotAllowed(t *testing.T) {
+	overlayId := make([]byte, 32)
+	_, _ = rand.Read(overlayId)
+
+	ab, ba := newTestConnection("a", "b")
+
+	origin := NewBroadcaster(overlayId, newTestKey(t), nil)
+	if err := origin.AddPeer(CreateExtendedADNL(ab).WithOverlay(overlayId)); err != nil {
+		t.Fatal(err)
+	}
+
+	var recv testReceiver
+	// restrictive overlay, unauthorized broadcasts are not allowed
+	CreateExtendedADNL(ba).WithOverlay(overlayId).SetBroadcastHandler(recv.handler)
+
+	if err := origin.BroadcastFEC(context.Background(), adnl.MessagePing{Value: 1}); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(100 * time.Millisecond)
+
+	recv.mx.Lock()
+	defer recv.mx.Unlock()
+	if len(recv.got) != 0 {
+		t.Fatal("not allowed broadcast was processed")
+	}
+
+	if err := origin.AddPeer(CreateExtendedADNL(ab).WithOverlay(make([]byte, 32))); err == nil {
+		t.Fatal("peer of other overlay should not be added")
+	}
+}
+
+func TestADNLOverlayWrapper_BroadcastDate(t *testing.T) {
+	overlayId := make([]byte, 32)
+	_, _ = rand.Read(overlayId)
+
+	conn, _ := newTestConnection("a", "b")
+	w := CreateExtendedADNL(conn).CreateOverlayWithSettings(overlayId, 1<<20, true, true)
+
+	var recv testReceiver
+	w.SetBroadcastHandler(recv.handler)
+
+	b := NewBroadcaster(overlayId, newTestKey(t), nil)
+	data, err := tl.Serialize(adnl.MessagePing{Value: 1}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	build := func(date time.Time) *Broadcast {
+		bc := &Broadcast{
+			Source: adnl.PublicKeyED25519{Key: b.key.Public().(ed25519.PublicKey)},
+			Data:   data,
+			Date:   int32(date.Unix()),
+		}
+		id, err := bc.CalcID()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if bc.Signature, err = b.sign(id, bc.Date); err != nil {
+			t.Fatal(err)
+		}
+		return bc
+	}
+
+	// replayed broadcast, its id is already forgotten
+	if err = w.processBroadcast(build(time.Now().Add(-_BroadcastSeenTTL))); err == nil {
+		t.Fatal("old broadcast should be rejected")
+	}
+	if err = w.processBroadcast(build(time.Now().Add(time.Minute))); err == nil {
+		t.Fatal("broadcast from future should be rejected")
+	}
+	if err = w.processBroadcast(build(time.Now())); err != nil {
+		t.Fatal(err)
+	}
+
+	recv.mx.Lock()
+	defer recv.mx.Unlock()
+	if len(recv.got) != 1 {
+		t.Fatal("only actual broadcast should be processed, got", len(recv.got))
+	}
+}
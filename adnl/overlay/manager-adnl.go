@@ -7,7 +7,6 @@ import (
 	"fmt"
 	"github.com/xssnick/tonutils-go/adnl"
 	"github.com/xssnick/tonutils-go/tl"
-	"reflect"
 	"sync"
 	"time"
 )
@@ -139,11 +138,10 @@ func (a *ADNLWrapper) customHandler(msg *adnl.MessageCustom) error {
 
 		switch t := obj.(type) {
 		case Broadcast:
-			var gh any
-			_, _ = tl.Parse(&gh, t.Data, true)
-			println("BROADCAST", reflect.TypeOf(gh).String())
-		case BroadcastFECShort:
-			println("BROADCAST SHORT", t.Seqno, t.BroadcastHash)
+			if err := o.processBroadcast(&t); err != nil {
+				return fmt.Errorf("failed to process broadcast: %w", err)
+			}
+			return nil
 		case BroadcastFEC:
 			if err := o.processFECBroadcast(&t); err != nil {
 				return fmt.Errorf("failed to process FEC broadcast: %w", err)
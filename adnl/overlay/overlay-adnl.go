@@ -48,6 +48,7 @@ type ADNLOverlayWrapper struct {
 	streamsMx        sync.RWMutex
 
 	broadcastHandler func(msg tl.Serializable, trusted bool) error
+	broadcaster      *Broadcaster
 
 	*ADNLWrapper
 }
@@ -163,6 +164,126 @@ func (a *ADNLOverlayWrapper) checkRules(keyId string, dataSize int32, isFEC bool
 	return CertCheckResultNeedCheck
 }
 
+// checkBroadcastRules - checks if source is allowed to broadcast data of this size, directly or by its certificate
+func (a *ADNLOverlayWrapper) checkBroadcastRules(source, cert any, dataSize int32, isFEC bool) (CertCheckResult, error) {
+	srcId, err := tl.Hash(source)
+	if err != nil {
+		return CertCheckResultForbidden, fmt.Errorf("source key id serialize failed: %w", err)
+	}
+
+	checkRes := a.checkRules(string(srcId), dataSize, isFEC)
+	if checkRes != CertCheckResultTrusted && cert != nil {
+		var issuerId []byte
+
+		var certRes CertCheckResult
+		switch crt := cert.(type) {
+		case CheckableCert:
+			certRes, err = crt.Check(srcId, a.overlayId, dataSize, isFEC)
+			if err != nil {
+				return CertCheckResultForbidden, fmt.Errorf("cert check failed: %w", err)
+			}
+			if certRes == CertCheckResultForbidden {
+				break
+			}
+
+			var issuedBy any
+			switch c := crt.(type) {
+			case Certificate:
+				issuedBy = c.IssuedBy
+			case CertificateV2:
+				issuedBy = c.IssuedBy
+			}
+
+			issuerId, err = tl.Hash(issuedBy)
+			if err != nil {
+				return CertCheckResultForbidden, fmt.Errorf("issuer key id serialize failed: %w", err)
+			}
+		case CertificateEmpty:
+		default:
+			return CertCheckResultForbidden, fmt.Errorf("not supported cert type %s", reflect.TypeOf(cert).String())
+		}
+
+		if issuerId != nil {
+			issuerRes := a.checkRules(string(issuerId), dataSize, isFEC)
+			if issuerRes > certRes {
+				// we consider minimal of these 2
+				issuerRes = certRes
+			}
+
+			if issuerRes > checkRes {
+				// we consider maximal of these 2
+				checkRes = issuerRes
+			}
+		}
+	}
+
+	return checkRes, nil
+}
+
+func (a *ADNLOverlayWrapper) getBroadcaster() *Broadcaster {
+	a.mx.RLock()
+	defer a.mx.RUnlock()
+	return a.broadcaster
+}
+
+func (a *ADNLOverlayWrapper) processBroadcast(t *Broadcast) error {
+	broadcastHash, err := t.CalcID()
+	if err != nil {
+		return fmt.Errorf("failed to calc broadcast hash: %w", err)
+	}
+
+	toSign, err := tl.Serialize(&BroadcastToSign{
+		Hash: broadcastHash,
+		Date: t.Date,
+	}, true)
+	if err != nil {
+		return fmt.Errorf("failed to serialize broadcast for sign check: %w", err)
+	}
+
+	sourceKey, ok := t.Source.(adnl.PublicKeyED25519)
+	if !ok {
+		return fmt.Errorf("invalid signer key format")
+	}
+
+	if !ed25519.Verify(sourceKey.Key, toSign, t.Signature) {
+		return fmt.Errorf("invalid broadcast signature")
+	}
+
+	if err = checkBroadcastDate(t.Date); err != nil {
+		return err
+	}
+
+	checkRes, err := a.checkBroadcastRules(t.Source, t.Certificate, int32(len(t.Data)), false)
+	if err != nil {
+		return err
+	}
+
+	if checkRes == CertCheckResultForbidden {
+		return fmt.Errorf("not allowed")
+	}
+
+	if b := a.getBroadcaster(); b != nil {
+		if !b.markSeen(broadcastHash) {
+			// already received from other peer
+			return nil
+		}
+		b.relay(a, *t)
+	}
+
+	var res any
+	_, err = tl.Parse(&res, t.Data, true)
+	if err != nil {
+		return fmt.Errorf("failed to parse broadcast message: %w", err)
+	}
+
+	if bHandler := a.broadcastHandler; bHandler != nil {
+		if err = bHandler(res, checkRes == CertCheckResultTrusted); err != nil {
+			return fmt.Errorf("failed to process broadcast message: %w", err)
+		}
+	}
+	return nil
+}
+
 func (a *ADNLOverlayWrapper) processFECBroadcast(t *BroadcastFEC) error {
 	broadcastHash, err := t.CalcID()
 	if err != nil {
@@ -204,6 +325,10 @@ func (a *ADNLOverlayWrapper) processFECBroadcast(t *BroadcastFEC) error {
 		return fmt.Errorf("invalid broadcast signature")
 	}
 
+	if err = checkBroadcastDate(t.Date); err != nil {
+		return err
+	}
+
 	if stream == nil {
 		fec, ok := t.FEC.(rldp.FECRaptorQ)
 		if !ok {
@@ -214,55 +339,9 @@ func (a *ADNLOverlayWrapper) processFECBroadcast(t *BroadcastFEC) error {
 			return fmt.Errorf("incorrect data size")
 		}
 
-		srcId, err := tl.Hash(t.Source)
+		checkRes, err := a.checkBroadcastRules(t.Source, t.Certificate, t.DataSize, true)
 		if err != nil {
-			return fmt.Errorf("source key id serialize failed: %w", err)
-		}
-
-		checkRes := a.checkRules(string(srcId), t.DataSize, true)
-		if checkRes != CertCheckResultTrusted && t.Certificate != nil {
-			var issuerId []byte
-
-			var certRes CertCheckResult
-			switch crt := t.Certificate.(type) {
-			case CheckableCert:
-				certRes, err = crt.Check(srcId, a.overlayId, t.DataSize, true)
-				if err != nil {
-					return fmt.Errorf("cert check failed: %w", err)
-				}
-				if certRes == CertCheckResultForbidden {
-					break
-				}
-
-				var issuedBy any
-				switch cert := crt.(type) {
-				case Certificate:
-					issuedBy = cert.IssuedBy
-				case CertificateV2:
-					issuedBy = cert.IssuedBy
-				}
-
-				issuerId, err = tl.Hash(issuedBy)
-				if err != nil {
-					return fmt.Errorf("issuer key id serialize failed: %w", err)
-				}
-			case CertificateEmpty:
-			default:
-				return fmt.Errorf("not supported cert type %s", reflect.TypeOf(t.Certificate).String())
-			}
-
-			if issuerId != nil {
-				issuerRes := a.checkRules(string(issuerId), t.DataSize, true)
-				if issuerRes > certRes {
-					// we consider minimal of these 2
-					issuerRes = certRes
-				}
-
-				if issuerRes > checkRes {
-					// we consider maximal of these 2
-					checkRes = issuerRes
-				}
-			}
+			return err
 		}
 
 		if checkRes == CertCheckResultForbidden {
@@ -293,6 +372,11 @@ func (a *ADNLOverlayWrapper) processFECBroadcast(t *BroadcastFEC) error {
 		return fmt.Errorf("malformed source")
 	}
 
+	b := a.getBroadcaster()
+	if b != nil && b.markSeen(partHash) {
+		b.relay(a, *t)
+	}
+
 	stream.mx.Lock()
 	defer stream.mx.Unlock()
 
@@ -362,8 +446,8 @@ func (a *ADNLOverlayWrapper) processFECBroadcast(t *BroadcastFEC) error {
 				return fmt.Errorf("failed to send rldp complete message: %w", err)
 			}
 
-			if bHandler := a.broadcastHandler; bHandler != nil {
-				// handle result
+			if bHandler := a.broadcastHandler; bHandler != nil && (b == nil || b.markSeen(broadcastHash)) {
+				// handle result, only once when it was also received from other peers
 				err = bHandler(res, stream.trusted)
 				if err != nil {
 					return fmt.Errorf("failed to process broadcast message: %w", err)
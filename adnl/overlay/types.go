@@ -2,6 +2,7 @@ package overlay
 
 import (
 	"crypto/ed25519"
+	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
 	"github.com/xssnick/tonutils-go/adnl"
@@ -25,6 +26,7 @@ func init() {
 	tl.Register(Broadcast{}, "overlay.broadcast src:PublicKey certificate:overlay.Certificate flags:int data:bytes date:int signature:bytes = overlay.Broadcast")
 	tl.Register(BroadcastFEC{}, "overlay.broadcastFec src:PublicKey certificate:overlay.Certificate data_hash:int256 data_size:int flags:int data:bytes seqno:int fec:fec.Type date:int signature:bytes = overlay.Broadcast")
 	tl.Register(BroadcastFECShort{}, "overlay.broadcastFecShort src:PublicKey certificate:overlay.Certificate broadcast_hash:int256 part_data_hash:int256 seqno:int signature:bytes = overlay.Broadcast")
+	tl.Register(BroadcastID{}, "overlay.broadcast.id src:int256 data_hash:int256 flags:int = overlay.broadcast.Id")
 	tl.Register(BroadcastFECID{}, "overlay.broadcastFec.id src:int256 type:int256 data_hash:int256 size:int flags:int = overlay.broadcastFec.Id")
 	tl.Register(BroadcastFECPartID{}, "overlay.broadcastFec.partId broadcast_hash:int256 data_hash:int256 seqno:int = overlay.broadcastFec.PartId")
 	tl.Register(BroadcastToSign{}, "overlay.broadcast.toSign hash:int256 date:int = overlay.broadcast.ToSign")
@@ -142,6 +144,28 @@ type Broadcast struct {
 	Signature   []byte `tl:"bytes"`
 }
 
+func (t *Broadcast) CalcID() ([]byte, error) {
+	var src = make([]byte, 32)
+	if t.Flags&_BroadcastFlagAnySender == 0 {
+		var err error
+		src, err = tl.Hash(t.Source)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compute source key id: %w", err)
+		}
+	}
+
+	dataHash := sha256.Sum256(t.Data)
+	broadcastHash, err := tl.Hash(&BroadcastID{
+		Source:   src,
+		DataHash: dataHash[:],
+		Flags:    t.Flags,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to compute hash id of the broadcast: %w", err)
+	}
+	return broadcastHash, nil
+}
+
 type BroadcastFEC struct {
 	Source      any    `tl:"struct boxed [pub.ed25519]"`
 	Certificate any    `tl:"struct boxed [overlay.emptyCertificate,overlay.certificate,overlay.certificateV2]"`
@@ -191,6 +215,12 @@ type BroadcastFECShort struct {
 	Signature     []byte `tl:"bytes"`
 }
 
+type BroadcastID struct {
+	Source   []byte `tl:"int256"`
+	DataHash []byte `tl:"int256"`
+	Flags    int32  `tl:"int"`
+}
+
 type BroadcastFECID struct {
 	Source   []byte `tl:"int256"`
 	Type     []byte `tl:"int256"`
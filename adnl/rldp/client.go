@@ -43,6 +43,7 @@ type RLDP struct {
 
 type decoderStream struct {
 	decoder        *raptorq.Decoder
+	startedAt      time.Time
 	finishedAt     *time.Time
 	lastCompleteAt time.Time
 	lastMessageAt  time.Time
@@ -137,9 +138,11 @@ func (r *RLDP) handleMessage(msg *adnl.MessageCustom) error {
 			if err != nil {
 				return fmt.Errorf("failed to init raptorq decoder: %w", err)
 			}
+			now := time.Now()
 			stream = &decoderStream{
 				decoder:       dec,
-				lastMessageAt: time.Now(),
+				startedAt:     now,
+				lastMessageAt: now,
 			}
 
 			r.mx.Lock()
@@ -201,6 +204,15 @@ func (r *RLDP) handleMessage(msg *adnl.MessageCustom) error {
 				stream.finishedAt = &tm
 				stream.decoder = nil
 
+				if o := loadObserver(); o != nil {
+					o.OnTransfer(TransferEvent{
+						Addr:     r.adnl.RemoteAddr(),
+						Size:     int64(len(data)),
+						Symbols:  uint32(stream.receivedNum),
+						Duration: tm.Sub(stream.startedAt),
+					})
+				}
+
 				r.mx.Lock()
 				if len(r.recvStreams) > 100 {
 					for sID, s := range r.recvStreams {
@@ -322,7 +334,7 @@ func (r *RLDP) handleMessage(msg *adnl.MessageCustom) error {
 	return nil
 }
 
-func (r *RLDP) sendMessageParts(ctx context.Context, transferId, data []byte) error {
+func (r *RLDP) sendMessageParts(ctx context.Context, transferId, data []byte) (err error) {
 	enc, err := raptorq.NewRaptorQ(_SymbolSize).CreateEncoder(data)
 	if err != nil {
 		return fmt.Errorf("failed to create raptorq object encoder: %w", err)
@@ -342,6 +354,20 @@ func (r *RLDP) sendMessageParts(ctx context.Context, transferId, data []byte) er
 	}()
 
 	symbolsSent := uint32(0)
+	if o := loadObserver(); o != nil {
+		startedAt := time.Now()
+		defer func() {
+			o.OnTransfer(TransferEvent{
+				Addr:     r.adnl.RemoteAddr(),
+				Outgoing: true,
+				Size:     int64(len(data)),
+				Symbols:  symbolsSent,
+				Duration: time.Since(startedAt),
+				Err:      err,
+			})
+		}()
+	}
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -445,7 +471,7 @@ func (r *RLDP) DoQuery(ctx context.Context, maxAnswerSize int64, query, result t
 	}
 
 	go func() {
-		err = r.sendMessageParts(sndCtx, transferId, data)
+		err := r.sendMessageParts(sndCtx, transferId, data)
 		if err != nil {
 			res <- fmt.Errorf("failed to send query parts: %w", err)
 		}
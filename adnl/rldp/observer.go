@@ -0,0 +1,45 @@
+package rldp
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Observer - receives instrumentation events of all RLDP transfers,
+// methods are called synchronously from network routines, so they should not block
+type Observer interface {
+	OnTransfer(event TransferEvent)
+}
+
+type TransferEvent struct {
+	// Addr - address of peer
+	Addr string
+	// Outgoing - true when transfer was sent by us, false when received
+	Outgoing bool
+	// Size - size of transferred data in bytes
+	Size int64
+	// Symbols - number of FEC symbols sent or received, more than needed means packets loss
+	Symbols  uint32
+	Duration time.Duration
+	// Err - reason why outgoing transfer was not completed
+	Err error
+}
+
+// observerBox - interface can not be stored in atomic.Pointer directly
+type observerBox struct {
+	o Observer
+}
+
+var observer atomic.Pointer[observerBox]
+
+// SetObserver - sets instrumentation hook for all RLDP transfers, nil disables it, can be called at any time
+func SetObserver(o Observer) {
+	observer.Store(&observerBox{o: o})
+}
+
+func loadObserver() Observer {
+	if b := observer.Load(); b != nil {
+		return b.o
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/liteclient"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/ton/indexer"
+	"log"
+)
+
+func main() {
+	client := liteclient.NewConnectionPool()
+
+	cfg, err := liteclient.GetConfigFromUrl(context.Background(), "https://ton.org/global.config.json")
+	if err != nil {
+		log.Fatalln("get config err: ", err.Error())
+		return
+	}
+
+	// connect to mainnet lite servers
+	err = client.AddConnectionsFromConfig(context.Background(), cfg)
+	if err != nil {
+		log.Fatalln("connection err: ", err.Error())
+		return
+	}
+
+	// initialize ton api lite connection wrapper with full proof checks
+	api := ton.NewAPIClient(client, ton.ProofCheckPolicySecure).WithRetry()
+	api.SetTrustedBlockFromConfig(cfg)
+
+	log.Println("fetching and checking proofs since config init block, it may take near a minute...")
+	master, err := api.CurrentMasterchainInfo(context.Background()) // we fetch block just to trigger chain proof check
+	if err != nil {
+		log.Fatalln("get masterchain info err: ", err.Error())
+		return
+	}
+	log.Println("master proof checks are completed successfully, now communication is 100% safe!")
+
+	// address which transactions we want to process
+	treasuryAddress := address.MustParseAddr("EQCD39VS5jcptHL8vMjEXrzGaRcCVYto7HUn4bpAOg8xqB2N")
+
+	// progress is saved to file after all transactions of master block are processed,
+	// so after restart or crash we will continue from the first not fully processed block.
+	// You can implement indexer.CheckpointStore to keep progress in your db.
+	idx := indexer.NewIndexer(api, indexer.NewFileCheckpointStore("indexer.checkpoint.json"))
+	// when there is no checkpoint yet, start from the current block
+	idx.SetStartSeqno(master.SeqNo)
+
+	idx.OnAddress(treasuryAddress, func(ctx context.Context, tx *indexer.Transaction) error {
+		// process transaction here, it can be called again for the same transaction after crash,
+		// so check in your db that it was not processed before
+		log.Println(tx.String())
+		return nil
+	})
+
+	log.Println("waiting for transactions...")
+
+	// it is a blocking call, it returns only when context is done or handler returns error
+	if err = idx.Run(context.Background()); err != nil {
+		log.Fatalln("indexer stopped:", err.Error())
+	}
+}
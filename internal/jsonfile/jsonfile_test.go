@@ -0,0 +1,56 @@
+package jsonfile
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSaveLoad(t *testing.T) {
+	type value struct {
+		A int    `json:"a"`
+		B string `json:"b"`
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "value.json")
+
+	var v value
+	found, err := Load(path, &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found {
+		t.Fatal("file should not be found")
+	}
+
+	if err = Save(path, value{A: 1, B: "x"}); err != nil {
+		t.Fatal(err)
+	}
+	if err = Save(path, value{A: 2, B: "y"}); err != nil {
+		t.Fatal(err)
+	}
+
+	found, err = Load(path, &v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !found || v.A != 2 || v.B != "y" {
+		t.Fatal("incorrect loaded value", v)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatal("temp files should be removed, got", len(entries))
+	}
+
+	if err = os.WriteFile(path, []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = Load(path, &v); err == nil {
+		t.Fatal("broken file should not be loaded")
+	}
+}
@@ -0,0 +1,214 @@
+package liteclient
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// masterchainSeqnoTTL - after this time known seqno of node is considered outdated
+const masterchainSeqnoTTL = 30 * time.Second
+
+// Balancer - chooses liteserver node for each query which is not bound to a sticky node.
+// Pick is called under pool lock, so it should not call pool methods. Nodes list is never empty.
+type Balancer interface {
+	Pick(nodes []*Node) *Node
+}
+
+// MasterchainSeqnoProvider - implemented by responses which contain the last masterchain block known by node,
+// pool uses them to track how far each node is synced
+type MasterchainSeqnoProvider interface {
+	LastMasterchainSeqno() uint32
+}
+
+// Node - liteserver connection as it is seen by balancers, stats are updated by pool
+type Node struct {
+	id        uint32
+	addr      string
+	serverKey string
+
+	weight       int64
+	pending      int64
+	lastRespTime int64
+	latencyEWMA  int64
+
+	masterSeqno   uint32
+	masterSeqnoAt int64
+
+	health nodeHealth
+}
+
+func (n *Node) ID() uint32 {
+	return n.id
+}
+
+func (n *Node) Addr() string {
+	return n.addr
+}
+
+func (n *Node) ServerKey() string {
+	return n.serverKey
+}
+
+// Weight - starts from 1000, decremented on each query and incremented on each response,
+// so nodes which are not answering have lower weight
+func (n *Node) Weight() int64 {
+	return atomic.LoadInt64(&n.weight)
+}
+
+// Pending - number of queries which are sent to node and not answered or timed out yet
+func (n *Node) Pending() int64 {
+	return atomic.LoadInt64(&n.pending)
+}
+
+// LastLatency - response time of the last answered query
+func (n *Node) LastLatency() time.Duration {
+	return time.Duration(atomic.LoadInt64(&n.lastRespTime))
+}
+
+// Latency - exponentially weighted moving average of response time, zero when node has not answered yet.
+// Timed out queries are counted with the time node was waited for.
+func (n *Node) Latency() time.Duration {
+	return time.Duration(atomic.LoadInt64(&n.latencyEWMA))
+}
+
+// MasterchainSeqno - the last masterchain seqno seen in responses of node, and time when it was seen.
+// Zero when node has not answered on masterchain info queries yet.
+func (n *Node) MasterchainSeqno() (uint32, time.Time) {
+	at := atomic.LoadInt64(&n.masterSeqnoAt)
+	if at == 0 {
+		return 0, time.Time{}
+	}
+	return atomic.LoadUint32(&n.masterSeqno), time.Unix(0, at)
+}
+
+func (n *Node) observeLatency(d time.Duration) {
+	atomic.StoreInt64(&n.lastRespTime, int64(d))
+
+	for {
+		old := atomic.LoadInt64(&n.latencyEWMA)
+		val := int64(d)
+		if old != 0 {
+			// alpha = 0.2
+			val = old + (val-old)/5
+		}
+		if atomic.CompareAndSwapInt64(&n.latencyEWMA, old, val) {
+			return
+		}
+	}
+}
+
+func (n *Node) observeMasterchainSeqno(seqno uint32) {
+	atomic.StoreUint32(&n.masterSeqno, seqno)
+	atomic.StoreInt64(&n.masterSeqnoAt, time.Now().UnixNano())
+}
+
+type weightBalancer struct{}
+
+// NewWeightBalancer - default balancer, picks node with the highest weight, and with the lowest latency among equal ones
+func NewWeightBalancer() Balancer {
+	return weightBalancer{}
+}
+
+func (weightBalancer) Pick(nodes []*Node) *Node {
+	var res *Node
+	for _, node := range nodes {
+		if res == nil {
+			res = node
+			continue
+		}
+
+		nw, old := node.Weight(), res.Weight()
+		if nw > old || (nw == old && node.LastLatency() < res.LastLatency()) {
+			res = node
+		}
+	}
+	return res
+}
+
+type roundRobinBalancer struct {
+	offset uint64
+}
+
+// NewRoundRobinBalancer - picks nodes one by one
+func NewRoundRobinBalancer() Balancer {
+	return &roundRobinBalancer{}
+}
+
+func (b *roundRobinBalancer) Pick(nodes []*Node) *Node {
+	return nodes[(atomic.AddUint64(&b.offset, 1)-1)%uint64(len(nodes))]
+}
+
+type latencyBalancer struct{}
+
+// NewLatencyBalancer - picks node with the lowest average response time,
+// nodes which were not measured yet are picked first, but only by a single query at a time, until it is answered or timed out
+func NewLatencyBalancer() Balancer {
+	return latencyBalancer{}
+}
+
+func (latencyBalancer) Pick(nodes []*Node) *Node {
+	var res, probing *Node
+	for _, node := range nodes {
+		lat := node.Latency()
+		if lat == 0 {
+			if node.Pending() == 0 {
+				return node
+			}
+
+			// it is measured by another query now
+			if probing == nil || node.Pending() < probing.Pending() {
+				probing = node
+			}
+			continue
+		}
+
+		if res == nil || lat < res.Latency() {
+			res = node
+		}
+	}
+
+	if res == nil {
+		return probing
+	}
+	return res
+}
+
+type masterchainSeqnoBalancer struct {
+	maxLag uint32
+	next   Balancer
+}
+
+// NewMasterchainSeqnoBalancer - skips nodes which are behind the most synced node by more than maxLag masterchain blocks,
+// and picks one of the others using next balancer (weight balancer when nil).
+// Seqno of node is known from its responses on masterchain info queries,
+// nodes without recent info are not skipped, so they are rechecked eventually.
+func NewMasterchainSeqnoBalancer(maxLag uint32, next Balancer) Balancer {
+	if next == nil {
+		next = NewWeightBalancer()
+	}
+	return &masterchainSeqnoBalancer{maxLag: maxLag, next: next}
+}
+
+func (b *masterchainSeqnoBalancer) Pick(nodes []*Node) *Node {
+	var top uint32
+	seqnos := make([]uint32, len(nodes))
+	for i, node := range nodes {
+		seqno, at := node.MasterchainSeqno()
+		if seqno == 0 || time.Since(at) > masterchainSeqnoTTL {
+			continue
+		}
+
+		seqnos[i] = seqno
+		if seqno > top {
+			top = seqno
+		}
+	}
+
+	synced := make([]*Node, 0, len(nodes))
+	for i, node := range nodes {
+		if seqnos[i] == 0 || seqnos[i]+b.maxLag >= top {
+			synced = append(synced, node)
+		}
+	}
+	return b.next.Pick(synced)
+}
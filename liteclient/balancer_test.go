@@ -0,0 +1,168 @@
+package liteclient
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/xssnick/tonutils-go/adnl"
+	"github.com/xssnick/tonutils-go/tl"
+)
+
+func testNodes(n int) []*Node {
+	nodes := make([]*Node, n)
+	for i := range nodes {
+		nodes[i] = &Node{id: uint32(i + 1), weight: 1000}
+	}
+	return nodes
+}
+
+func TestWeightBalancer(t *testing.T) {
+	nodes := testNodes(3)
+	nodes[0].weight = 990
+	nodes[1].observeLatency(50 * time.Millisecond)
+	nodes[2].observeLatency(10 * time.Millisecond)
+
+	if n := NewWeightBalancer().Pick(nodes); n != nodes[2] {
+		t.Fatal("node with the highest weight and the lowest latency should be picked, got", n.ID())
+	}
+}
+
+func TestRoundRobinBalancer(t *testing.T) {
+	nodes := testNodes(3)
+	b := NewRoundRobinBalancer()
+
+	for i := 0; i < 6; i++ {
+		if n := b.Pick(nodes); n != nodes[i%3] {
+			t.Fatal("unexpected node", n.ID(), "at", i)
+		}
+	}
+}
+
+func TestLatencyBalancer(t *testing.T) {
+	nodes := testNodes(3)
+	nodes[0].observeLatency(30 * time.Millisecond)
+	nodes[1].observeLatency(20 * time.Millisecond)
+
+	b := NewLatencyBalancer()
+	if n := b.Pick(nodes); n != nodes[2] {
+		t.Fatal("not measured node should be picked first, got", n.ID())
+	}
+
+	// probe is in progress, so not measured node should not be picked again
+	nodes[2].pending = 1
+	if n := b.Pick(nodes); n != nodes[1] {
+		t.Fatal("the fastest node should be picked while probe is in progress, got", n.ID())
+	}
+
+	nodes[2].pending = 0
+	nodes[2].observeLatency(100 * time.Millisecond)
+	if n := b.Pick(nodes); n != nodes[1] {
+		t.Fatal("the fastest node should be picked, got", n.ID())
+	}
+
+	// one slow response should not outweigh history
+	for i := 0; i < 10; i++ {
+		nodes[0].observeLatency(10 * time.Millisecond)
+	}
+	nodes[0].observeLatency(200 * time.Millisecond)
+	if lat := nodes[0].Latency(); lat <= 10*time.Millisecond || lat >= 100*time.Millisecond {
+		t.Fatal("incorrect average latency", lat)
+	}
+}
+
+func TestMasterchainSeqnoBalancer(t *testing.T) {
+	nodes := testNodes(3)
+	nodes[0].observeMasterchainSeqno(100)
+	nodes[1].observeMasterchainSeqno(110)
+	nodes[2].observeMasterchainSeqno(108)
+	// highest weight, but lagging
+	nodes[0].weight = 2000
+
+	b := NewMasterchainSeqnoBalancer(3, nil)
+	if n := b.Pick(nodes); n == nodes[0] {
+		t.Fatal("lagging node should be skipped")
+	}
+
+	// outdated info should not exclude node
+	nodes[0].masterSeqnoAt = time.Now().Add(-2 * masterchainSeqnoTTL).UnixNano()
+	if n := b.Pick(nodes); n != nodes[0] {
+		t.Fatal("node with outdated seqno should be picked by weight, got", n.ID())
+	}
+
+	rr := NewMasterchainSeqnoBalancer(0, NewRoundRobinBalancer())
+	nodes[0].observeMasterchainSeqno(100)
+	for i := 0; i < 4; i++ {
+		if n := rr.Pick(nodes); n != nodes[1] {
+			t.Fatal("only the most synced node should be picked, got", n.ID())
+		}
+	}
+}
+
+func startTestLiteServer(t *testing.T, addr string, answer bool) string {
+	pub, key, _ := ed25519.GenerateKey(nil)
+	s := NewServer([]ed25519.PrivateKey{key})
+	s.SetMessageHandler(func(ctx context.Context, sc *ServerClient, msg tl.Serializable) error {
+		switch m := msg.(type) {
+		case adnl.MessageQuery:
+			if !answer {
+				return nil
+			}
+			return sc.Send(adnl.MessageAnswer{ID: m.ID, Data: GetMasterchainInf{}})
+		case TCPPing:
+			return sc.Send(TCPPong{RandomID: m.RandomID})
+		}
+		return nil
+	})
+	t.Cleanup(func() {
+		_ = s.Close()
+	})
+
+	go func() {
+		_ = s.Listen(addr)
+	}()
+	return base64.StdEncoding.EncodeToString(pub)
+}
+
+func TestLatencyBalancer_NodeTimeout(t *testing.T) {
+	silentKey := startTestLiteServer(t, "127.0.0.1:9199", false)
+	key := startTestLiteServer(t, "127.0.0.1:9201", true)
+	time.Sleep(100 * time.Millisecond)
+
+	pool := NewConnectionPool()
+	defer pool.Stop()
+	pool.SetBalancer(NewLatencyBalancer())
+
+	// silent node is added first, so it is probed first
+	if err := pool.AddConnection(context.Background(), "127.0.0.1:9199", silentKey); err != nil {
+		t.Fatal(err)
+	}
+	if err := pool.AddConnection(context.Background(), "127.0.0.1:9201", key); err != nil {
+		t.Fatal(err)
+	}
+
+	timeouts := 0
+	for i := 0; i < 5; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+		var resp tl.Serializable
+		err := pool.QueryLiteserver(ctx, GetMasterchainInf{}, &resp)
+		cancel()
+		if err != nil {
+			timeouts++
+		}
+	}
+
+	if timeouts != 1 {
+		t.Fatal("timed out node should be probed only once, timeouts:", timeouts)
+	}
+
+	pool.nodesMx.RLock()
+	defer pool.nodesMx.RUnlock()
+	for _, node := range pool.activeNodes {
+		if node.addr == "127.0.0.1:9199" && (node.Latency() < 300*time.Millisecond || node.Pending() != 0) {
+			t.Fatal("timeout should be counted in latency", node.Latency(), node.Pending())
+		}
+	}
+}
@@ -27,9 +27,7 @@ import (
 )
 
 type connection struct {
-	id        uint32
-	addr      string
-	serverKey string
+	*Node
 
 	connResult chan error
 
@@ -43,9 +41,6 @@ type connection struct {
 	authed  bool
 	authEvt chan bool
 
-	weight       int64
-	lastRespTime int64
-
 	pool *ConnectionPool
 }
 
@@ -135,13 +130,15 @@ func (c *ConnectionPool) AddConnection(ctx context.Context, addr, serverKey stri
 	}
 
 	conn := &connection{
-		addr:       addr,
-		serverKey:  serverKey,
+		Node: &Node{
+			id:        crc32.ChecksumIEEE([]byte(serverKey)),
+			addr:      addr,
+			serverKey: serverKey,
+			weight:    1000,
+		},
 		connResult: make(chan error, 1),
 		reqs:       make(chan *ADNLRequest),
 		pool:       c,
-		id:         crc32.ChecksumIEEE([]byte(serverKey)),
-		weight:     1000,
 	}
 
 	// get timeout if exists
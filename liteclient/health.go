@@ -0,0 +1,359 @@
+package liteclient
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/xssnick/tonutils-go/tl"
+)
+
+// ServerTimeProvider - implemented by responses which contain current time of node
+type ServerTimeProvider interface {
+	ServerTime() uint32
+}
+
+// HealthCheckConfig - zero fields are replaced with defaults
+type HealthCheckConfig struct {
+	// Interval - how often nodes are checked, default is 10 seconds
+	Interval time.Duration
+	// Timeout - timeout of each check query, default is 3 seconds
+	Timeout time.Duration
+	// MaxSeqnoLag - node is quarantined when its masterchain seqno is behind the most synced node by more blocks, default is 3
+	MaxSeqnoLag uint32
+	// MaxTimeDiff - node is quarantined when its time differs from local time by more, default is 30 seconds
+	MaxTimeDiff time.Duration
+	// MaxErrorRate - node is quarantined when part of failed queries between checks is higher, default is 0.5
+	MaxErrorRate float64
+	// MinRequests - error rate is not checked when node got less queries between checks, default is 10
+	MinRequests uint64
+}
+
+// PoolStats - snapshot of nodes state
+type PoolStats struct {
+	Nodes []NodeStats
+}
+
+type NodeStats struct {
+	ID   uint32
+	Addr string
+
+	// Latency - average response time
+	Latency     time.Duration
+	LastLatency time.Duration
+
+	// Requests and Errors - total number of queries to node and failed ones, timeouts and liteserver errors are failures
+	Requests uint64
+	Errors   uint64
+	// ErrorRate - part of failed queries between the last two health checks
+	ErrorRate float64
+
+	MasterchainSeqno   uint32
+	MasterchainSeqnoAt time.Time
+	// TimeDiff - difference between node time and local time on the last check
+	TimeDiff time.Duration
+
+	LastCheckAt time.Time
+	// Quarantined - node is not used by balancer, until the next successful check
+	Quarantined      bool
+	QuarantineReason string
+}
+
+var (
+	healthMasterchainInfoQuery = rawQuery("liteServer.getMasterchainInfo = liteServer.MasterchainInfo")
+	healthTimeQuery            = rawQuery("liteServer.getTime = liteServer.CurrentTime")
+)
+
+// rawQuery - serialized query without fields, response is parsed by types registered in ton package
+func rawQuery(schema string) tl.Raw {
+	id := make([]byte, 4)
+	binary.LittleEndian.PutUint32(id, tl.CRC(schema))
+	return id
+}
+
+// nodeHealth - health state of node, protected by its mutex
+type nodeHealth struct {
+	requests       uint64
+	errors         uint64
+	windowRequests uint64
+	windowErrors   uint64
+	errorRate      float64
+
+	timeDiff    time.Duration
+	lastCheckAt time.Time
+
+	quarantined bool
+	reason      string
+
+	mx sync.Mutex
+}
+
+// Quarantined - node was marked as unhealthy by the last health check
+func (n *Node) Quarantined() bool {
+	n.health.mx.Lock()
+	defer n.health.mx.Unlock()
+	return n.health.quarantined
+}
+
+func (n *Node) observeRequest(failed bool) {
+	n.health.mx.Lock()
+	defer n.health.mx.Unlock()
+
+	n.health.requests++
+	n.health.windowRequests++
+	if failed {
+		n.health.errors++
+		n.health.windowErrors++
+	}
+}
+
+// EnableHealthChecks - starts periodic checks of masterchain seqno and time of each node,
+// nodes which lag, have incorrect time or fail too many queries are not used by balancer until they recover.
+// Check queries are parsed by liteserver types, so ton package should be imported.
+func (c *ConnectionPool) EnableHealthChecks(cfg HealthCheckConfig) {
+	if cfg.Interval <= 0 {
+		cfg.Interval = 10 * time.Second
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 3 * time.Second
+	}
+	if cfg.MaxSeqnoLag == 0 {
+		cfg.MaxSeqnoLag = 3
+	}
+	if cfg.MaxTimeDiff <= 0 {
+		cfg.MaxTimeDiff = 30 * time.Second
+	}
+	if cfg.MaxErrorRate <= 0 {
+		cfg.MaxErrorRate = 0.5
+	}
+	if cfg.MinRequests == 0 {
+		cfg.MinRequests = 10
+	}
+
+	c.healthMx.Lock()
+	defer c.healthMx.Unlock()
+
+	if c.healthStop != nil {
+		c.healthStop()
+	}
+
+	var ctx context.Context
+	ctx, c.healthStop = context.WithCancel(c.globalCtx)
+	go c.healthCheckLoop(ctx, cfg)
+}
+
+// PoolStats - returns current state of all active nodes
+func (c *ConnectionPool) PoolStats() PoolStats {
+	c.nodesMx.RLock()
+	nodes := make([]*Node, len(c.activeNodes))
+	for i, conn := range c.activeNodes {
+		nodes[i] = conn.Node
+	}
+	c.nodesMx.RUnlock()
+
+	stats := PoolStats{Nodes: make([]NodeStats, 0, len(nodes))}
+	for _, node := range nodes {
+		seqno, seqnoAt := node.MasterchainSeqno()
+
+		node.health.mx.Lock()
+		stats.Nodes = append(stats.Nodes, NodeStats{
+			ID:                 node.ID(),
+			Addr:               node.Addr(),
+			Latency:            node.Latency(),
+			LastLatency:        node.LastLatency(),
+			Requests:           node.health.requests,
+			Errors:             node.health.errors,
+			ErrorRate:          node.health.errorRate,
+			MasterchainSeqno:   seqno,
+			MasterchainSeqnoAt: seqnoAt,
+			TimeDiff:           node.health.timeDiff,
+			LastCheckAt:        node.health.lastCheckAt,
+			Quarantined:        node.health.quarantined,
+			QuarantineReason:   node.health.reason,
+		})
+		node.health.mx.Unlock()
+	}
+	return stats
+}
+
+func (c *ConnectionPool) healthCheckLoop(ctx context.Context, cfg HealthCheckConfig) {
+	for {
+		c.checkNodes(ctx, cfg)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(cfg.Interval):
+		}
+	}
+}
+
+type healthCheckResult struct {
+	seqno    uint32
+	timeDiff time.Duration
+	err      error
+}
+
+func (c *ConnectionPool) checkNodes(ctx context.Context, cfg HealthCheckConfig) {
+	c.nodesMx.RLock()
+	nodes := append([]*connection{}, c.activeNodes...)
+	c.nodesMx.RUnlock()
+
+	results := make([]healthCheckResult, len(nodes))
+
+	var wg sync.WaitGroup
+	for i, node := range nodes {
+		wg.Add(1)
+		go func(i int, node *connection) {
+			defer wg.Done()
+			results[i] = c.checkNode(ctx, node, cfg.Timeout)
+		}(i, node)
+	}
+	wg.Wait()
+
+	var top uint32
+	for _, res := range results {
+		if res.err == nil && res.seqno > top {
+			top = res.seqno
+		}
+	}
+
+	for i, node := range nodes {
+		h := &node.health
+		h.mx.Lock()
+		h.errorRate = 0
+		if h.windowRequests > 0 {
+			h.errorRate = float64(h.windowErrors) / float64(h.windowRequests)
+		}
+
+		reason := unhealthyReason(cfg, results[i], top, h.windowRequests, h.errorRate)
+		if (reason != "") != h.quarantined {
+			if reason != "" {
+				Logger("liteserver", node.addr, "is quarantined:", reason)
+			} else {
+				Logger("liteserver", node.addr, "is healthy again")
+			}
+		}
+
+		h.windowRequests, h.windowErrors = 0, 0
+		h.timeDiff = results[i].timeDiff
+		h.lastCheckAt = time.Now()
+		h.quarantined = reason != ""
+		h.reason = reason
+		h.mx.Unlock()
+	}
+}
+
+// unhealthyReason - returns reason of quarantine, or empty string when node is healthy
+func unhealthyReason(cfg HealthCheckConfig, res healthCheckResult, topSeqno uint32, requests uint64, errorRate float64) string {
+	switch {
+	case res.err != nil:
+		return "check failed: " + res.err.Error()
+	case res.seqno+cfg.MaxSeqnoLag < topSeqno:
+		return fmt.Sprintf("lags by %d masterchain blocks", topSeqno-res.seqno)
+	case res.timeDiff > cfg.MaxTimeDiff || res.timeDiff < -cfg.MaxTimeDiff:
+		return fmt.Sprintf("time differs by %s", res.timeDiff)
+	case requests >= cfg.MinRequests && errorRate > cfg.MaxErrorRate:
+		return fmt.Sprintf("error rate is %.2f", errorRate)
+	}
+	return ""
+}
+
+func (c *ConnectionPool) checkNode(ctx context.Context, node *connection, timeout time.Duration) healthCheckResult {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	resp, err := c.queryNode(ctx, node, LiteServerQuery{Data: healthMasterchainInfoQuery})
+	if err != nil {
+		return healthCheckResult{err: fmt.Errorf("failed to get masterchain info: %w", err)}
+	}
+	info, ok := resp.(MasterchainSeqnoProvider)
+	if !ok {
+		return healthCheckResult{err: fmt.Errorf("unexpected masterchain info response %T", resp)}
+	}
+
+	resp, err = c.queryNode(ctx, node, LiteServerQuery{Data: healthTimeQuery})
+	if err != nil {
+		return healthCheckResult{err: fmt.Errorf("failed to get time: %w", err)}
+	}
+	tm, ok := resp.(ServerTimeProvider)
+	if !ok {
+		return healthCheckResult{err: fmt.Errorf("unexpected time response %T", resp)}
+	}
+
+	return healthCheckResult{
+		seqno:    info.LastMasterchainSeqno(),
+		timeDiff: time.Unix(int64(tm.ServerTime()), 0).Sub(time.Now().Truncate(time.Second)),
+	}
+}
+
+// queryNode - sends query exactly to the given node, without balancing
+func (c *ConnectionPool) queryNode(ctx context.Context, node *connection, request tl.Serializable) (tl.Serializable, error) {
+	id := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, id); err != nil {
+		return nil, err
+	}
+	strId := string(id)
+
+	ch := make(chan *ADNLResponse, 1)
+	c.reqMx.Lock()
+	c.activeReqs[strId] = &ADNLRequest{
+		QueryID:  id,
+		Data:     request,
+		RespChan: ch,
+	}
+	c.reqMx.Unlock()
+
+	defer func() {
+		c.reqMx.Lock()
+		delete(c.activeReqs, strId)
+		c.reqMx.Unlock()
+	}()
+
+	tm := time.Now()
+	if _, err := node.queryAdnl(id, request); err != nil {
+		node.observeRequest(true)
+		return nil, err
+	}
+
+	select {
+	case resp := <-ch:
+		node.observeResponse(resp.Data, time.Since(tm))
+		if err, ok := resp.Data.(error); ok {
+			return nil, err
+		}
+		return resp.Data, nil
+	case <-ctx.Done():
+		node.observeRequest(true)
+		return nil, ctx.Err()
+	}
+}
+
+// observeResponse - updates node stats, liteserver errors are counted as failures
+func (n *Node) observeResponse(resp tl.Serializable, latency time.Duration) {
+	n.observeLatency(latency)
+	if p, ok := resp.(MasterchainSeqnoProvider); ok {
+		n.observeMasterchainSeqno(p.LastMasterchainSeqno())
+	}
+
+	_, isErr := resp.(error)
+	n.observeRequest(isErr)
+}
+
+// healthyNodes - nodes which are not quarantined, or all nodes when every node is quarantined
+func healthyNodes(nodes []*connection) []*connection {
+	res := make([]*connection, 0, len(nodes))
+	for _, node := range nodes {
+		if !node.Quarantined() {
+			res = append(res, node)
+		}
+	}
+	if len(res) == 0 {
+		return nodes
+	}
+	return res
+}
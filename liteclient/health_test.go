@@ -0,0 +1,75 @@
+package liteclient
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestUnhealthyReason(t *testing.T) {
+	cfg := HealthCheckConfig{
+		MaxSeqnoLag:  3,
+		MaxTimeDiff:  30 * time.Second,
+		MaxErrorRate: 0.5,
+		MinRequests:  10,
+	}
+
+	tests := []struct {
+		name      string
+		res       healthCheckResult
+		requests  uint64
+		errorRate float64
+		healthy   bool
+	}{
+		{"synced", healthCheckResult{seqno: 100}, 0, 0, true},
+		{"small lag", healthCheckResult{seqno: 97}, 0, 0, true},
+		{"lag", healthCheckResult{seqno: 96}, 0, 0, false},
+		{"check failed", healthCheckResult{err: errors.New("timeout")}, 0, 0, false},
+		{"time ahead", healthCheckResult{seqno: 100, timeDiff: time.Minute}, 0, 0, false},
+		{"time behind", healthCheckResult{seqno: 100, timeDiff: -time.Minute}, 0, 0, false},
+		{"errors", healthCheckResult{seqno: 100}, 20, 0.6, false},
+		{"errors on few requests", healthCheckResult{seqno: 100}, 5, 1, true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			reason := unhealthyReason(cfg, test.res, 100, test.requests, test.errorRate)
+			if (reason == "") != test.healthy {
+				t.Fatal("unexpected result:", reason)
+			}
+		})
+	}
+}
+
+func TestHealthyNodes(t *testing.T) {
+	conns := make([]*connection, 3)
+	for i := range conns {
+		conns[i] = &connection{Node: &Node{id: uint32(i + 1)}}
+	}
+	conns[0].health.quarantined = true
+
+	if nodes := healthyNodes(conns); len(nodes) != 2 || nodes[0] != conns[1] {
+		t.Fatal("quarantined node should be skipped")
+	}
+
+	conns[1].health.quarantined = true
+	conns[2].health.quarantined = true
+	if nodes := healthyNodes(conns); len(nodes) != 3 {
+		t.Fatal("all nodes should be returned when all are quarantined")
+	}
+}
+
+func TestNodeErrorRate(t *testing.T) {
+	n := &Node{}
+	for i := 0; i < 4; i++ {
+		n.observeRequest(i%2 == 0)
+	}
+	n.observeResponse(nil, 10*time.Millisecond)
+
+	if n.health.requests != 5 || n.health.errors != 2 || n.health.windowErrors != 2 {
+		t.Fatal("incorrect counters", n.health.requests, n.health.errors)
+	}
+	if n.Latency() != 10*time.Millisecond {
+		t.Fatal("latency was not observed")
+	}
+}
@@ -0,0 +1,76 @@
+package liteclient
+
+import (
+	"context"
+	"encoding/binary"
+	"reflect"
+	"time"
+
+	"github.com/xssnick/tonutils-go/tl"
+)
+
+// Observer - receives instrumentation events of pool, methods are called synchronously, so they should not block
+type Observer interface {
+	OnLiteserverQuery(ctx context.Context, event QueryEvent)
+}
+
+type QueryEvent struct {
+	// Method - TL name of request, like liteServer.getMasterchainInfo
+	Method string
+	// Node - address of liteserver which processed query, empty when it was not sent
+	Node string
+
+	StartedAt time.Time
+	Duration  time.Duration
+	// Err - network error or error returned by liteserver
+	Err error
+}
+
+// observerBox - interface can not be stored in atomic.Pointer directly
+type observerBox struct {
+	o Observer
+}
+
+// SetObserver - sets instrumentation hook for liteserver queries, nil disables it, can be called at any time
+func (c *ConnectionPool) SetObserver(o Observer) {
+	c.observer.Store(&observerBox{o: o})
+}
+
+func (c *ConnectionPool) loadObserver() Observer {
+	if b := c.observer.Load(); b != nil {
+		return b.o
+	}
+	return nil
+}
+
+// _WaitMasterchainSeqnoID - prefix of queries which should be processed after the given block, it is serialized by ton package
+var _WaitMasterchainSeqnoID = tl.CRC("liteServer.waitMasterchainSeqno seqno:int timeout_ms:int = Object")
+
+// queryName - TL name of request, for raw requests it is taken by id of the query after waitMasterchainSeqno prefix
+func queryName(request tl.Serializable) string {
+	// boxed waitMasterchainSeqno has id and 2 ints
+	if raw, ok := request.(tl.Raw); ok && len(raw) >= 16 && binary.LittleEndian.Uint32(raw) == _WaitMasterchainSeqnoID {
+		request = raw[12:]
+	}
+	return tl.Name(request)
+}
+
+func (c *ConnectionPool) observeQuery(ctx context.Context, o Observer, request, result tl.Serializable, node *connection, startedAt time.Time, err error) {
+	if err == nil {
+		// liteserver errors are returned as result
+		if lsErr, ok := reflect.ValueOf(result).Elem().Interface().(error); ok {
+			err = lsErr
+		}
+	}
+
+	event := QueryEvent{
+		Method:    queryName(request),
+		StartedAt: startedAt,
+		Duration:  time.Since(startedAt),
+		Err:       err,
+	}
+	if node != nil {
+		event.Node = node.addr
+	}
+	o.OnLiteserverQuery(ctx, event)
+}
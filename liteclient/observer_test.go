@@ -0,0 +1,76 @@
+package liteclient
+
+import (
+	"context"
+	"encoding/binary"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/xssnick/tonutils-go/tl"
+)
+
+func TestQueryName(t *testing.T) {
+	if name := queryName(GetMasterchainInf{}); name != "liteServer.getMasterchainInfo" {
+		t.Fatal("incorrect name", name)
+	}
+
+	query, err := tl.Serialize(GetMasterchainInf{}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name := queryName(tl.Raw(query)); name != "liteServer.getMasterchainInfo" {
+		t.Fatal("incorrect name of raw query", name)
+	}
+
+	prefix := binary.LittleEndian.AppendUint32(nil, _WaitMasterchainSeqnoID)
+	prefix = binary.LittleEndian.AppendUint32(prefix, 100)
+	prefix = binary.LittleEndian.AppendUint32(prefix, 5000)
+	if name := queryName(tl.Raw(append(prefix, query...))); name != "liteServer.getMasterchainInfo" {
+		t.Fatal("incorrect name of query with wait prefix", name)
+	}
+}
+
+type countingObserver struct {
+	queries atomic.Int32
+}
+
+func (o *countingObserver) OnLiteserverQuery(ctx context.Context, event QueryEvent) {
+	o.queries.Add(1)
+}
+
+func TestConnectionPool_SetObserverConcurrent(t *testing.T) {
+	pool := NewConnectionPool()
+	o := &countingObserver{}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			pool.SetObserver(o)
+			pool.SetObserver(nil)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			var resp tl.Serializable
+			_ = pool.QueryLiteserver(context.Background(), GetMasterchainInf{}, &resp)
+		}
+	}()
+	wg.Wait()
+
+	pool.SetObserver(o)
+	var resp tl.Serializable
+	if err := pool.QueryLiteserver(context.Background(), GetMasterchainInf{}, &resp); err == nil {
+		t.Fatal("query without connections should fail")
+	}
+	before := o.queries.Load()
+
+	pool.SetObserver(nil)
+	_ = pool.QueryLiteserver(context.Background(), GetMasterchainInf{}, &resp)
+	if before == 0 || o.queries.Load() != before {
+		t.Fatal("observer was not set or reset", before, o.queries.Load())
+	}
+}
@@ -42,8 +42,12 @@ type ConnectionPool struct {
 	reqMx       sync.RWMutex
 	nodesMx     sync.RWMutex
 
-	onDisconnect     func(addr, key string)
-	roundRobinOffset uint64
+	onDisconnect func(addr, key string)
+	balancer     Balancer
+	observer     atomic.Pointer[observerBox]
+
+	healthStop func()
+	healthMx   sync.Mutex
 
 	authKey ed25519.PrivateKey
 
@@ -55,6 +59,7 @@ type ConnectionPool struct {
 func NewConnectionPool() *ConnectionPool {
 	c := &ConnectionPool{
 		activeReqs: map[string]*ADNLRequest{},
+		balancer:   NewWeightBalancer(),
 	}
 
 	// default reconnect policy
@@ -90,7 +95,8 @@ func (c *ConnectionPool) StickyContext(ctx context.Context) context.Context {
 	c.nodesMx.RLock()
 	if len(c.activeNodes) > 0 {
 		// pick random one
-		id = c.activeNodes[mRand.Uint32()%uint32(len(c.activeNodes))].id
+		nodes := healthyNodes(c.activeNodes)
+		id = nodes[mRand.Uint32()%uint32(len(nodes))].id
 	}
 	c.nodesMx.RUnlock()
 
@@ -143,15 +149,29 @@ func (c *ConnectionPool) Stop() {
 
 // QueryLiteserver - sends request to liteserver
 func (c *ConnectionPool) QueryLiteserver(ctx context.Context, request tl.Serializable, result tl.Serializable) error {
-	return c.QueryADNL(ctx, LiteServerQuery{Data: request}, result)
+	o := c.loadObserver()
+	if o == nil {
+		_, err := c.queryADNL(ctx, LiteServerQuery{Data: request}, result)
+		return err
+	}
+
+	tm := time.Now()
+	node, err := c.queryADNL(ctx, LiteServerQuery{Data: request}, result)
+	c.observeQuery(ctx, o, request, result, node, tm, err)
+	return err
 }
 
 // QueryADNL - sends ADNL request to peer
 func (c *ConnectionPool) QueryADNL(ctx context.Context, request tl.Serializable, result tl.Serializable) error {
+	_, err := c.queryADNL(ctx, request, result)
+	return err
+}
+
+func (c *ConnectionPool) queryADNL(ctx context.Context, request tl.Serializable, result tl.Serializable) (*connection, error) {
 	id := make([]byte, 32)
 	_, err := io.ReadFull(rand.Reader, id)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	_, hasDeadline := ctx.Deadline()
@@ -188,12 +208,12 @@ func (c *ConnectionPool) QueryADNL(ctx context.Context, request tl.Serializable,
 	if nodeID, ok := ctx.Value(_StickyCtxKey).(uint32); ok && nodeID > 0 {
 		node, err = c.querySticky(nodeID, req)
 		if err != nil {
-			return err
+			return nil, err
 		}
 	} else {
-		node, err = c.queryWithSmartBalancer(req)
+		node, err = c.queryWithBalancer(req)
 		if err != nil {
-			return err
+			return nil, err
 		}
 	}
 
@@ -201,21 +221,26 @@ func (c *ConnectionPool) QueryADNL(ctx context.Context, request tl.Serializable,
 	select {
 	case resp := <-ch:
 		atomic.AddInt64(&node.weight, 1)
-		atomic.StoreInt64(&node.lastRespTime, int64(time.Since(tm)))
+		atomic.AddInt64(&node.pending, -1)
+		node.observeResponse(resp.Data, time.Since(tm))
 
 		reflect.ValueOf(result).Elem().Set(reflect.ValueOf(resp.Data))
-		return nil
+		return node, nil
 	case <-ctx.Done():
+		atomic.AddInt64(&node.pending, -1)
+		node.observeRequest(true)
 		if time.Since(tm) < 200*time.Millisecond {
 			// consider it as too short timeout to punish node
 			atomic.AddInt64(&node.weight, 1)
+		} else {
+			node.observeLatency(time.Since(tm))
 		}
 
 		if !hasDeadline {
-			return fmt.Errorf("%w, node %s", ErrADNLReqTimeout, node.addr)
+			return node, fmt.Errorf("%w, node %s", ErrADNLReqTimeout, node.addr)
 		}
 
-		return fmt.Errorf("deadline exceeded, node %s, err: %w", node.addr, ctx.Err())
+		return node, fmt.Errorf("deadline exceeded, node %s, err: %w", node.addr, ctx.Err())
 	}
 }
 
@@ -224,33 +249,52 @@ func (c *ConnectionPool) querySticky(id uint32, req *ADNLRequest) (*connection,
 	for _, node := range c.activeNodes {
 		if node.id == id {
 			atomic.AddInt64(&node.weight, -1)
+			atomic.AddInt64(&node.pending, 1)
 			_, err := node.queryAdnl(req.QueryID, req.Data)
 			if err == nil {
 				c.nodesMx.RUnlock()
 				return node, nil
 			}
+			atomic.AddInt64(&node.pending, -1)
 			break
 		}
 	}
 	c.nodesMx.RUnlock()
 
 	// fallback if bounded node is not available
-	return c.queryWithSmartBalancer(req)
+	return c.queryWithBalancer(req)
+}
+
+// SetBalancer - sets strategy of choosing node for queries which are not bound to sticky node,
+// default is NewWeightBalancer
+func (c *ConnectionPool) SetBalancer(b Balancer) {
+	if b == nil {
+		b = NewWeightBalancer()
+	}
+
+	c.nodesMx.Lock()
+	c.balancer = b
+	c.nodesMx.Unlock()
 }
 
-func (c *ConnectionPool) queryWithSmartBalancer(req *ADNLRequest) (*connection, error) {
+func (c *ConnectionPool) queryWithBalancer(req *ADNLRequest) (*connection, error) {
 	var reqNode *connection
 
 	c.nodesMx.RLock()
-	for _, node := range c.activeNodes {
-		if reqNode == nil {
-			reqNode = node
-			continue
+	if len(c.activeNodes) > 0 {
+		// quarantined nodes are used only when there are no others
+		conns := healthyNodes(c.activeNodes)
+		nodes := make([]*Node, len(conns))
+		for i, conn := range conns {
+			nodes[i] = conn.Node
 		}
 
-		nw, old := atomic.LoadInt64(&node.weight), atomic.LoadInt64(&reqNode.weight)
-		if nw > old || (nw == old && atomic.LoadInt64(&node.lastRespTime) < atomic.LoadInt64(&reqNode.lastRespTime)) {
-			reqNode = node
+		picked := c.balancer.Pick(nodes)
+		for _, conn := range conns {
+			if conn.Node == picked {
+				reqNode = conn
+				break
+			}
 		}
 	}
 	c.nodesMx.RUnlock()
@@ -260,9 +304,11 @@ func (c *ConnectionPool) queryWithSmartBalancer(req *ADNLRequest) (*connection,
 	}
 
 	atomic.AddInt64(&reqNode.weight, -1)
+	atomic.AddInt64(&reqNode.pending, 1)
 
 	_, err := reqNode.queryAdnl(req.QueryID, req.Data)
 	if err != nil {
+		atomic.AddInt64(&reqNode.pending, -1)
 		return nil, err
 	}
 	return reqNode, nil
@@ -0,0 +1,85 @@
+package proxy
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/xssnick/tonutils-go/tl"
+)
+
+// responseCache - LRU cache of immutable liteserver responses, keyed by serialized query
+type responseCache struct {
+	limit  int
+	items  map[string]*list.Element
+	order  *list.List
+	mx     sync.Mutex
+	hits   uint64
+	misses uint64
+}
+
+type cacheEntry struct {
+	key  string
+	resp tl.Serializable
+}
+
+func newResponseCache(limit int) *responseCache {
+	return &responseCache{
+		limit: limit,
+		items: map[string]*list.Element{},
+		order: list.New(),
+	}
+}
+
+func (c *responseCache) get(key string) (tl.Serializable, bool) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	el, ok := c.items[key]
+	if !ok {
+		c.misses++
+		return nil, false
+	}
+	c.hits++
+	c.order.MoveToFront(el)
+	return el.Value.(*cacheEntry).resp, true
+}
+
+func (c *responseCache) put(key string, resp tl.Serializable) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	if c.limit <= 0 {
+		return
+	}
+
+	if el, ok := c.items[key]; ok {
+		el.Value.(*cacheEntry).resp = resp
+		c.order.MoveToFront(el)
+		return
+	}
+
+	c.items[key] = c.order.PushFront(&cacheEntry{key: key, resp: resp})
+	for c.order.Len() > c.limit {
+		last := c.order.Back()
+		c.order.Remove(last)
+		delete(c.items, last.Value.(*cacheEntry).key)
+	}
+}
+
+func (c *responseCache) setLimit(limit int) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	c.limit = limit
+	for c.order.Len() > 0 && c.order.Len() > limit {
+		last := c.order.Back()
+		c.order.Remove(last)
+		delete(c.items, last.Value.(*cacheEntry).key)
+	}
+}
+
+func (c *responseCache) stats() (hits, misses uint64, size int) {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+	return c.hits, c.misses, c.order.Len()
+}
@@ -0,0 +1,324 @@
+package proxy
+
+import (
+	"context"
+	"crypto/ed25519"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/xssnick/tonutils-go/adnl"
+	"github.com/xssnick/tonutils-go/liteclient"
+	"github.com/xssnick/tonutils-go/tl"
+	"github.com/xssnick/tonutils-go/ton"
+)
+
+// _MaxConcurrentQueries - default limit of queries processed in parallel for a single client connection
+const _MaxConcurrentQueries = 128
+
+const (
+	// ErrCodeRateLimited - returned to client when it exceeds its query rate limit or concurrent queries limit
+	ErrCodeRateLimited = -429
+	// ErrCodeUpstreamFailed - returned to client when query to upstream liteservers is failed,
+	// same code as liteserver uses for unavailable state, so clients with retrier will retry it.
+	ErrCodeUpstreamFailed = -503
+)
+
+// Proxy - liteserver gateway, it accepts lite client connections and forwards their queries to upstream,
+// usually to the connection pool with many liteservers.
+// Responses to queries which refer to the exact block (block data, transactions, proofs, states) are immutable,
+// so they are cached and served without upstream requests. Concurrent identical queries are sent upstream only once.
+type Proxy struct {
+	server   *liteclient.Server
+	upstream ton.LiteClient
+	cache    *responseCache
+
+	queryTimeout time.Duration
+
+	ratePerSec float64
+	rateBurst  float64
+	limits     map[string]*clientLimit
+	limitsMx   sync.Mutex
+
+	maxQueries int
+	active     map[*liteclient.ServerClient]int
+
+	inflight   map[string]*inflightQuery
+	inflightMx sync.Mutex
+}
+
+// Stats - cache usage counters
+type Stats struct {
+	CacheHits    uint64
+	CacheMisses  uint64
+	CacheEntries int
+}
+
+type clientLimit struct {
+	tokens      float64
+	updatedAt   time.Time
+	connections int
+}
+
+type inflightQuery struct {
+	done chan struct{}
+	resp tl.Serializable
+	err  error
+}
+
+func NewProxy(upstream ton.LiteClient, keys []ed25519.PrivateKey) *Proxy {
+	p := &Proxy{
+		server:       liteclient.NewServer(keys),
+		upstream:     upstream,
+		cache:        newResponseCache(10000),
+		queryTimeout: 15 * time.Second,
+		limits:       map[string]*clientLimit{},
+		maxQueries:   _MaxConcurrentQueries,
+		active:       map[*liteclient.ServerClient]int{},
+		inflight:     map[string]*inflightQuery{},
+	}
+	p.server.SetMessageHandler(p.handleMessage)
+	p.server.SetConnectionHook(p.onConnect)
+	p.server.SetDisconnectHook(p.onDisconnect)
+	return p
+}
+
+// SetCacheSize - max number of cached responses, default is 10000, 0 disables cache
+func (p *Proxy) SetCacheSize(entries int) {
+	p.cache.setLimit(entries)
+}
+
+// SetRateLimit - max queries per second for each client ip, burst is the number of queries
+// which can be done at once after idle period. Zero rate disables limit, it is disabled by default.
+func (p *Proxy) SetRateLimit(perSecond float64, burst int) {
+	p.limitsMx.Lock()
+	defer p.limitsMx.Unlock()
+
+	if burst < 1 {
+		burst = 1
+	}
+	p.ratePerSec = perSecond
+	p.rateBurst = float64(burst)
+}
+
+// SetMaxConcurrentQueries - max queries processed in parallel for each client connection,
+// queries over the limit are answered with ErrCodeRateLimited. Default is 128, zero disables limit.
+func (p *Proxy) SetMaxConcurrentQueries(n int) {
+	p.limitsMx.Lock()
+	defer p.limitsMx.Unlock()
+
+	p.maxQueries = n
+}
+
+// SetQueryTimeout - timeout of upstream query, default is 15 seconds
+func (p *Proxy) SetQueryTimeout(timeout time.Duration) {
+	p.queryTimeout = timeout
+}
+
+// Stats - returns cache usage counters
+func (p *Proxy) Stats() Stats {
+	hits, misses, size := p.cache.stats()
+	return Stats{
+		CacheHits:    hits,
+		CacheMisses:  misses,
+		CacheEntries: size,
+	}
+}
+
+// Listen - starts accepting clients, blocking
+func (p *Proxy) Listen(addr string) error {
+	return p.server.Listen(addr)
+}
+
+func (p *Proxy) Close() error {
+	return p.server.Close()
+}
+
+func (p *Proxy) onConnect(client *liteclient.ServerClient) error {
+	p.limitsMx.Lock()
+	defer p.limitsMx.Unlock()
+
+	l := p.limits[client.IP()]
+	if l == nil {
+		l = &clientLimit{tokens: p.rateBurst, updatedAt: time.Now()}
+		p.limits[client.IP()] = l
+	}
+	l.connections++
+	p.active[client] = 0
+	return nil
+}
+
+func (p *Proxy) onDisconnect(client *liteclient.ServerClient) {
+	p.limitsMx.Lock()
+	defer p.limitsMx.Unlock()
+
+	delete(p.active, client)
+
+	if l := p.limits[client.IP()]; l != nil {
+		l.connections--
+		if l.connections <= 0 {
+			delete(p.limits, client.IP())
+		}
+	}
+}
+
+// allow - token bucket check, limit is shared by all connections of the same ip
+func (p *Proxy) allow(ip string) bool {
+	p.limitsMx.Lock()
+	defer p.limitsMx.Unlock()
+
+	if p.ratePerSec <= 0 {
+		return true
+	}
+
+	l := p.limits[ip]
+	if l == nil {
+		return false
+	}
+
+	now := time.Now()
+	l.tokens += now.Sub(l.updatedAt).Seconds() * p.ratePerSec
+	if l.tokens > p.rateBurst {
+		l.tokens = p.rateBurst
+	}
+	l.updatedAt = now
+
+	if l.tokens < 1 {
+		return false
+	}
+	l.tokens--
+	return true
+}
+
+// acquire - reserves place for query of the client connection, returns false when limit is reached
+func (p *Proxy) acquire(client *liteclient.ServerClient) bool {
+	p.limitsMx.Lock()
+	defer p.limitsMx.Unlock()
+
+	if p.maxQueries > 0 && p.active[client] >= p.maxQueries {
+		return false
+	}
+	p.active[client]++
+	return true
+}
+
+func (p *Proxy) release(client *liteclient.ServerClient) {
+	p.limitsMx.Lock()
+	defer p.limitsMx.Unlock()
+
+	if n, ok := p.active[client]; ok && n > 0 {
+		p.active[client] = n - 1
+	}
+}
+
+func (p *Proxy) handleMessage(ctx context.Context, client *liteclient.ServerClient, msg tl.Serializable) error {
+	switch m := msg.(type) {
+	case adnl.MessageQuery:
+		q, ok := m.Data.(liteclient.LiteServerQuery)
+		if !ok {
+			return fmt.Errorf("unsupported query type %s", reflect.TypeOf(m.Data).String())
+		}
+
+		if !p.allow(client.IP()) {
+			return client.Send(adnl.MessageAnswer{ID: m.ID, Data: ton.LSError{
+				Code: ErrCodeRateLimited,
+				Text: "rate limit exceeded",
+			}})
+		}
+
+		if !p.acquire(client) {
+			return client.Send(adnl.MessageAnswer{ID: m.ID, Data: ton.LSError{
+				Code: ErrCodeRateLimited,
+				Text: "too many concurrent queries",
+			}})
+		}
+
+		// processed in parallel to not block other queries of the same client
+		go func() {
+			defer p.release(client)
+
+			resp := p.query(q.Data)
+			if err := client.Send(adnl.MessageAnswer{ID: m.ID, Data: resp}); err != nil {
+				liteclient.Logger("["+client.IP()+"]", "failed to send response:", err.Error())
+			}
+		}()
+		return nil
+	case liteclient.TCPPing:
+		return client.Send(liteclient.TCPPong{RandomID: m.RandomID})
+	}
+	return fmt.Errorf("unexpected message type %s", reflect.TypeOf(msg).String())
+}
+
+// query - returns cached response or result of upstream query, errors are converted to liteserver error
+func (p *Proxy) query(req tl.Serializable) tl.Serializable {
+	data, err := tl.Serialize(req, true)
+	if err != nil {
+		return ton.LSError{Code: ErrCodeUpstreamFailed, Text: "failed to serialize query: " + err.Error()}
+	}
+	key := string(data)
+
+	cacheable := IsImmutableQuery(req)
+	if cacheable {
+		if resp, ok := p.cache.get(key); ok {
+			return resp
+		}
+	}
+
+	resp, err := p.queryUpstream(key, req)
+	if err != nil {
+		return ton.LSError{Code: ErrCodeUpstreamFailed, Text: err.Error()}
+	}
+
+	if _, isErr := resp.(ton.LSError); cacheable && !isErr {
+		p.cache.put(key, resp)
+	}
+	return resp
+}
+
+// queryUpstream - sends query to upstream, if the same query is already in progress, waits for its result instead.
+// Query is not bound to client context, so other waiting clients are not affected by disconnect of the first one.
+func (p *Proxy) queryUpstream(key string, req tl.Serializable) (tl.Serializable, error) {
+	p.inflightMx.Lock()
+	if q, ok := p.inflight[key]; ok {
+		p.inflightMx.Unlock()
+		<-q.done
+		return q.resp, q.err
+	}
+	q := &inflightQuery{done: make(chan struct{})}
+	p.inflight[key] = q
+	p.inflightMx.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.queryTimeout)
+	defer cancel()
+
+	q.err = p.upstream.QueryLiteserver(ctx, req, &q.resp)
+
+	p.inflightMx.Lock()
+	delete(p.inflight, key)
+	p.inflightMx.Unlock()
+	close(q.done)
+
+	return q.resp, q.err
+}
+
+// IsImmutableQuery - checks that query refers to the exact block and its successful response will never change.
+// Queries prefixed with waitMasterchainSeqno are never cached.
+func IsImmutableQuery(req tl.Serializable) bool {
+	switch q := req.(type) {
+	case ton.GetBlockData, ton.GetBlockHeader, ton.GetShardBlockProof,
+		ton.GetOneTransaction, ton.GetTransactions,
+		ton.ListBlockTransactions, ton.ListBlockTransactionsExt,
+		ton.GetAllShardsInfo, ton.GetShardInfo,
+		ton.GetConfigAll, ton.GetConfigParams,
+		ton.GetAccountState, ton.GetAccountStatePruned, ton.RunSmcMethod:
+		return true
+	case ton.GetBlockProof:
+		// without target block proof is built to the last block
+		return q.Mode&1 != 0
+	case ton.LookupBlock:
+		// lookup by lt or time may return different block when it is not yet generated
+		return q.Mode == 1
+	}
+	return false
+}
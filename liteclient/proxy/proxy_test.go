@@ -0,0 +1,243 @@
+package proxy
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/xssnick/tonutils-go/liteclient"
+	"github.com/xssnick/tonutils-go/tl"
+	"github.com/xssnick/tonutils-go/ton"
+)
+
+type upstreamMock struct {
+	calls int32
+	delay time.Duration
+}
+
+func (u *upstreamMock) QueryLiteserver(ctx context.Context, payload tl.Serializable, result tl.Serializable) error {
+	atomic.AddInt32(&u.calls, 1)
+	time.Sleep(u.delay)
+
+	if list, ok := payload.([]tl.Serializable); ok {
+		// waitMasterchainSeqno prefix
+		payload = list[len(list)-1]
+	}
+
+	var resp tl.Serializable
+	switch q := payload.(type) {
+	case ton.GetBlockData:
+		resp = ton.BlockData{ID: q.ID, Payload: []byte{1, 2, 3}}
+	case ton.GetMasterchainInf:
+		resp = ton.MasterchainInfo{Last: testBlock(100), StateRootHash: make([]byte, 32), Init: &ton.ZeroStateIDExt{RootHash: make([]byte, 32), FileHash: make([]byte, 32)}}
+	default:
+		resp = ton.LSError{Code: 1, Text: "unknown"}
+	}
+	*result.(*tl.Serializable) = resp
+	return nil
+}
+
+func (u *upstreamMock) StickyContext(ctx context.Context) context.Context {
+	return ctx
+}
+
+func (u *upstreamMock) StickyContextNextNode(ctx context.Context) (context.Context, error) {
+	return ctx, nil
+}
+
+func (u *upstreamMock) StickyNodeID(ctx context.Context) uint32 {
+	return 0
+}
+
+func testBlock(seqno uint32) *ton.BlockIDExt {
+	return &ton.BlockIDExt{Workchain: -1, Shard: -0x8000000000000000, SeqNo: seqno, RootHash: make([]byte, 32), FileHash: make([]byte, 32)}
+}
+
+func startProxy(t *testing.T, up *upstreamMock) (*Proxy, *liteclient.ConnectionPool) {
+	pub, key, _ := ed25519.GenerateKey(nil)
+	p := NewProxy(up, []ed25519.PrivateKey{key})
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := lis.Addr().String()
+	_ = lis.Close()
+
+	go func() {
+		_ = p.Listen(addr)
+	}()
+	t.Cleanup(func() {
+		_ = p.Close()
+	})
+
+	client := liteclient.NewConnectionPool()
+	t.Cleanup(client.Stop)
+
+	for i := 0; ; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		err = client.AddConnection(ctx, addr, base64.StdEncoding.EncodeToString(pub))
+		cancel()
+		if err == nil {
+			break
+		}
+		if i == 20 {
+			t.Fatal("failed to connect to proxy:", err)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	return p, client
+}
+
+func TestProxy_Cache(t *testing.T) {
+	up := &upstreamMock{}
+	p, client := startProxy(t, up)
+
+	for i := 0; i < 3; i++ {
+		var resp tl.Serializable
+		if err := client.QueryLiteserver(context.Background(), ton.GetBlockData{ID: testBlock(5)}, &resp); err != nil {
+			t.Fatal(err)
+		}
+		if bd, ok := resp.(ton.BlockData); !ok || bd.ID.SeqNo != 5 || len(bd.Payload) != 3 {
+			t.Fatal("incorrect response", resp)
+		}
+	}
+
+	for i := 0; i < 2; i++ {
+		var resp tl.Serializable
+		if err := client.QueryLiteserver(context.Background(), ton.GetMasterchainInf{}, &resp); err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := resp.(ton.MasterchainInfo); !ok {
+			t.Fatal("incorrect response", resp)
+		}
+	}
+
+	if calls := atomic.LoadInt32(&up.calls); calls != 3 {
+		t.Fatal("block data should be requested once and masterchain info every time, calls:", calls)
+	}
+
+	prefix, _ := tl.Serialize(ton.WaitMasterchainSeqno{Seqno: 5, Timeout: 1000}, true)
+	query, _ := tl.Serialize(ton.GetBlockData{ID: testBlock(5)}, true)
+	var resp tl.Serializable
+	if err := client.QueryLiteserver(context.Background(), tl.Raw(append(prefix, query...)), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if bd, ok := resp.(ton.BlockData); !ok || bd.ID.SeqNo != 5 {
+		t.Fatal("incorrect response to prefixed query", resp)
+	}
+	if calls := atomic.LoadInt32(&up.calls); calls != 4 {
+		t.Fatal("prefixed query should be forwarded, calls:", calls)
+	}
+	if st := p.Stats(); st.CacheHits != 2 || st.CacheEntries != 1 {
+		t.Fatal("incorrect stats", st)
+	}
+}
+
+func TestProxy_Deduplicate(t *testing.T) {
+	up := &upstreamMock{delay: 300 * time.Millisecond}
+	_, client := startProxy(t, up)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var resp tl.Serializable
+			if err := client.QueryLiteserver(context.Background(), ton.GetMasterchainInf{}, &resp); err != nil {
+				t.Error(err)
+				return
+			}
+			if _, ok := resp.(ton.MasterchainInfo); !ok {
+				t.Error("incorrect response", resp)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if calls := atomic.LoadInt32(&up.calls); calls != 1 {
+		t.Fatal("concurrent queries should be deduplicated, calls:", calls)
+	}
+}
+
+func TestProxy_RateLimit(t *testing.T) {
+	up := &upstreamMock{}
+	p, client := startProxy(t, up)
+	p.SetRateLimit(0.01, 2)
+
+	var limited int
+	for i := 0; i < 4; i++ {
+		var resp tl.Serializable
+		if err := client.QueryLiteserver(context.Background(), ton.GetMasterchainInf{}, &resp); err != nil {
+			t.Fatal(err)
+		}
+		if lsErr, ok := resp.(ton.LSError); ok && lsErr.Code == ErrCodeRateLimited {
+			limited++
+		}
+	}
+
+	// bucket of the existing connection starts empty, so only refill can pass
+	if limited < 3 {
+		t.Fatal("queries should be rate limited, limited:", limited)
+	}
+}
+
+func TestProxy_ConcurrentQueriesLimit(t *testing.T) {
+	up := &upstreamMock{delay: 300 * time.Millisecond}
+	p, client := startProxy(t, up)
+	p.SetMaxConcurrentQueries(2)
+
+	var limited int32
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var resp tl.Serializable
+			if err := client.QueryLiteserver(context.Background(), ton.GetBlockData{ID: testBlock(uint32(i))}, &resp); err != nil {
+				t.Error(err)
+				return
+			}
+			if lsErr, ok := resp.(ton.LSError); ok && lsErr.Code == ErrCodeRateLimited {
+				atomic.AddInt32(&limited, 1)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if limited != 3 {
+		t.Fatal("queries over concurrent limit should be rejected, limited:", limited)
+	}
+
+	// places are released after processing
+	var resp tl.Serializable
+	if err := client.QueryLiteserver(context.Background(), ton.GetBlockData{ID: testBlock(10)}, &resp); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := resp.(ton.BlockData); !ok {
+		t.Fatal("incorrect response", resp)
+	}
+}
+
+func TestIsImmutableQuery(t *testing.T) {
+	if !IsImmutableQuery(ton.GetBlockProof{Mode: 1, KnownBlock: testBlock(1), TargetBlock: testBlock(2)}) {
+		t.Fatal("proof to exact block should be cached")
+	}
+	if IsImmutableQuery(ton.GetBlockProof{KnownBlock: testBlock(1)}) {
+		t.Fatal("proof to last block should not be cached")
+	}
+	if IsImmutableQuery(ton.LookupBlock{Mode: 4, ID: &ton.BlockInfoShort{Workchain: -1}}) {
+		t.Fatal("lookup by time should not be cached")
+	}
+	if IsImmutableQuery([]tl.Serializable{ton.WaitMasterchainSeqno{}, ton.GetBlockData{ID: testBlock(1)}}) {
+		t.Fatal("prefixed query should not be cached")
+	}
+	if IsImmutableQuery(ton.SendMessage{}) {
+		t.Fatal("send message should not be cached")
+	}
+}
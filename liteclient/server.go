@@ -83,6 +83,14 @@ func (s *Server) Listen(addr string) error {
 	if err != nil {
 		return err
 	}
+	return s.Serve(listener)
+}
+
+// Serve - accepts clients on already created listener, blocking
+func (s *Server) Serve(listener net.Listener) error {
+	if s.listener != nil {
+		return fmt.Errorf("already started")
+	}
 	s.listener = listener
 
 	for {
@@ -0,0 +1,515 @@
+package testserver
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+const (
+	shardAll = -0x8000000000000000
+	globalID = -217
+)
+
+// account - current state of account, cell is built from it on each block
+type account struct {
+	addr    *address.Address
+	exists  bool
+	balance tlb.Coins
+	code    *cell.Cell
+	data    *cell.Cell
+
+	lastTxLT   uint64
+	lastTxHash []byte
+}
+
+type txEntry struct {
+	addr  *address.Address
+	tx    *tlb.Transaction
+	cell  *cell.Cell
+	block *block
+}
+
+type block struct {
+	id      *ton.BlockIDExt
+	root    *cell.Cell
+	state   *cell.Cell
+	genTime uint32
+	startLT uint64
+	endLT   uint64
+
+	// txs - sorted by account and lt, as in block
+	txs []*txEntry
+	// accounts - ShardAccount cells of the state, by account id
+	accounts map[string]*cell.Cell
+
+	// shard - for master block, shard block committed by it
+	shard *block
+	// shardHashes - for master block, dictionary of shard descriptions
+	shardHashes *cell.Dictionary
+	// config - for master block, root of config params dictionary
+	config *cell.Cell
+}
+
+// chain - blocks of single workchain, it has only one shard
+type chain struct {
+	workchain int32
+	blocks    []*block
+	// zero - zero state of chain, it is a parent of the first block
+	zero *cell.Cell
+}
+
+func newChain(workchain int32) *chain {
+	return &chain{
+		workchain: workchain,
+		zero:      buildState(workchain, 0, 0, 0, cell.NewAugDict(256, tlb.DepthBalanceInfoAugExtra), nil),
+	}
+}
+
+func (ch *chain) last() *block {
+	if len(ch.blocks) == 0 {
+		return nil
+	}
+	return ch.blocks[len(ch.blocks)-1]
+}
+
+func (ch *chain) get(seqno uint32) *block {
+	if seqno == 0 || int(seqno) > len(ch.blocks) {
+		return nil
+	}
+	return ch.blocks[seqno-1]
+}
+
+func (ch *chain) prevRef() (*tlb.ExtBlkRef, *cell.Cell) {
+	if last := ch.last(); last != nil {
+		return &tlb.ExtBlkRef{
+			EndLt:    last.endLT,
+			SeqNo:    last.id.SeqNo,
+			RootHash: last.id.RootHash,
+			FileHash: last.id.FileHash,
+		}, last.state
+	}
+
+	return &tlb.ExtBlkRef{
+		SeqNo:    0,
+		RootHash: ch.zero.Hash(),
+		FileHash: fileHash(ch.zero),
+	}, ch.zero
+}
+
+func fileHash(c *cell.Cell) []byte {
+	h := sha256.Sum256(c.ToBOCWithFlags(false))
+	return h[:]
+}
+
+func accKey(addr *address.Address) string {
+	return fmt.Sprintf("%d:%x", addr.Workchain(), addr.Data())
+}
+
+func zeroCurrency() tlb.CurrencyCollection {
+	return tlb.CurrencyCollection{Coins: tlb.ZeroCoins}
+}
+
+func storeCurrency(b *cell.Builder, coins tlb.Coins) {
+	b.MustStoreBigCoins(coins.Nano()).MustStoreDict(nil)
+}
+
+func buildShardIdent(workchain int32) *cell.Builder {
+	c, err := tlb.ToCell(tlb.ShardIdent{WorkchainID: workchain})
+	if err != nil {
+		panic(err.Error())
+	}
+	return c.ToBuilder()
+}
+
+// buildAccount - serializes Account, nil when account is not exists
+func buildAccount(acc *account) (*cell.Cell, error) {
+	if !acc.exists {
+		return nil, nil
+	}
+
+	info, err := tlb.ToCell(tlb.StorageInfo{
+		StorageUsed: tlb.StorageUsed{
+			BitsUsed:        big.NewInt(0),
+			CellsUsed:       big.NewInt(0),
+			PublicCellsUsed: big.NewInt(0),
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize storage info: %w", err)
+	}
+
+	b := cell.BeginCell().MustStoreUInt(1, 1).MustStoreAddr(acc.addr).
+		MustStoreBuilder(info.ToBuilder()).
+		MustStoreUInt(acc.lastTxLT, 64)
+	storeCurrency(b, acc.balance)
+
+	if acc.code == nil {
+		// account_uninit
+		b.MustStoreUInt(0b00, 2)
+		return b.EndCell(), nil
+	}
+
+	si, err := tlb.ToCell(tlb.StateInit{Code: acc.code, Data: acc.data})
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize state init: %w", err)
+	}
+	return b.MustStoreUInt(1, 1).MustStoreBuilder(si.ToBuilder()).EndCell(), nil
+}
+
+// buildState - serializes ShardStateUnsplit, mcExtra is set only for master chain
+func buildState(workchain int32, seqno, genTime uint32, genLT uint64, accounts *cell.AugDictionary, mcExtra *cell.Cell) *cell.Cell {
+	outQueue := cell.BeginCell().
+		MustStoreUInt(0, 1).MustStoreUInt(0, 64). // empty out queue with zero extra
+		MustStoreUInt(0, 1).                      // proc info
+		MustStoreUInt(0, 1).                      // ihr pending
+		EndCell()
+
+	b := cell.BeginCell().MustStoreUInt(0x9023afe2, 32).
+		MustStoreInt(globalID, 32).
+		MustStoreBuilder(buildShardIdent(workchain)).
+		MustStoreUInt(uint64(seqno), 32).
+		MustStoreUInt(0, 32).
+		MustStoreUInt(uint64(genTime), 32).
+		MustStoreUInt(genLT, 64).
+		MustStoreUInt(0, 32).
+		MustStoreRef(outQueue).
+		MustStoreBoolBit(false).
+		MustStoreRef(cell.BeginCell().MustStoreAugDict(accounts).EndCell())
+
+	total, err := accounts.RootExtra()
+	if err != nil {
+		panic(err.Error())
+	}
+	// skip split depth, we need only balance
+	total.MustLoadUInt(5)
+
+	stats := cell.BeginCell().
+		MustStoreUInt(0, 64).MustStoreUInt(0, 64).
+		MustStoreBuilder(total.ToBuilder())
+	storeCurrency(stats, tlb.ZeroCoins)
+	stats.MustStoreUInt(0, 1). // libraries
+					MustStoreUInt(0, 1) // master ref
+
+	return b.MustStoreRef(stats.EndCell()).MustStoreMaybeRef(mcExtra).EndCell()
+}
+
+// buildHeader - serializes BlockInfo
+func buildHeader(workchain int32, seqno, genTime uint32, startLT, endLT uint64, prev, master *tlb.ExtBlkRef) (*cell.Cell, error) {
+	b := cell.BeginCell().MustStoreUInt(0x9bc7a987, 32).
+		MustStoreUInt(0, 32). // version
+		MustStoreBoolBit(workchain != address.MasterchainID).
+		MustStoreUInt(0, 7). // after merge, before split, after split, want split, want merge, key block, vert seqno incr
+		MustStoreUInt(0, 8). // flags
+		MustStoreUInt(uint64(seqno), 32).
+		MustStoreUInt(0, 32).
+		MustStoreBuilder(buildShardIdent(workchain)).
+		MustStoreUInt(uint64(genTime), 32).
+		MustStoreUInt(startLT, 64).
+		MustStoreUInt(endLT, 64).
+		MustStoreUInt(0, 32). // validator list hash
+		MustStoreUInt(0, 32). // catchain seqno
+		MustStoreUInt(0, 32). // min ref mc seqno
+		MustStoreUInt(0, 32)  // prev key block seqno
+
+	if master != nil {
+		mr, err := tlb.ToCell(master)
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize master ref: %w", err)
+		}
+		b.MustStoreRef(mr)
+	}
+
+	pr, err := tlb.ToCell(prev)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize prev ref: %w", err)
+	}
+	return b.MustStoreRef(pr).EndCell(), nil
+}
+
+// buildAccountBlocks - serializes ShardAccountBlocks of transactions, they should be sorted by account and lt
+func buildAccountBlocks(txs []*txEntry) (*cell.Cell, error) {
+	blocks := cell.NewAugDict(256, tlb.CurrencyCollectionAugExtra)
+	zero := tlb.CurrencyCollectionAugExtra.Empty
+
+	for i := 0; i < len(txs); {
+		j := i
+		list := cell.NewAugDict(64, tlb.CurrencyCollectionAugExtra)
+		for ; j < len(txs) && compareAddr(txs[j].addr, txs[i].addr) == 0; j++ {
+			if err := list.SetIntKey(new(big.Int).SetUint64(txs[j].tx.LT), cell.BeginCell().MustStoreRef(txs[j].cell).EndCell(), zero); err != nil {
+				return nil, fmt.Errorf("failed to add transaction: %w", err)
+			}
+		}
+
+		upd, err := tlb.ToCell(tlb.HashUpdate{
+			OldHash: make([]byte, 32),
+			NewHash: make([]byte, 32),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize hash update: %w", err)
+		}
+
+		accBlock := cell.BeginCell().MustStoreUInt(0x5, 4).
+			MustStoreSlice(txs[i].addr.Data(), 256).
+			MustStoreBuilder(list.AsCell().ToBuilder()).
+			MustStoreRef(upd).EndCell()
+
+		if err = blocks.Set(cell.BeginCell().MustStoreSlice(txs[i].addr.Data(), 256).EndCell(), accBlock, zero); err != nil {
+			return nil, fmt.Errorf("failed to add account block: %w", err)
+		}
+		i = j
+	}
+	return cell.BeginCell().MustStoreAugDict(blocks).EndCell(), nil
+}
+
+// buildShardDesc - serializes ShardDescr of shard block
+func buildShardDesc(shard *block) (*cell.Cell, error) {
+	desc, err := tlb.ToCell(tlb.ShardDesc{
+		SeqNo:              shard.id.SeqNo,
+		StartLT:            shard.startLT,
+		EndLT:              shard.endLT,
+		RootHash:           shard.id.RootHash,
+		FileHash:           shard.id.FileHash,
+		NextValidatorShard: shardAll,
+		GenUTime:           shard.genTime,
+		SplitMergeAt:       tlb.FutureSplitMergeNone{},
+		Currencies: struct {
+			FeesCollected tlb.CurrencyCollection `tlb:"."`
+			FundsCreated  tlb.CurrencyCollection `tlb:"."`
+		}{zeroCurrency(), zeroCurrency()},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize shard description: %w", err)
+	}
+	return desc, nil
+}
+
+// buildShardHashes - serializes ShardHashes with single shard of basechain
+func buildShardHashes(shard *block) (*cell.Dictionary, error) {
+	desc, err := buildShardDesc(shard)
+	if err != nil {
+		return nil, err
+	}
+
+	// bin tree with single leaf
+	tree := cell.BeginCell().MustStoreUInt(0, 1).MustStoreBuilder(desc.ToBuilder()).EndCell()
+
+	dict := cell.NewDict(32)
+	if err = dict.SetIntKey(big.NewInt(int64(shard.id.Workchain)), cell.BeginCell().MustStoreRef(tree).EndCell()); err != nil {
+		return nil, fmt.Errorf("failed to set shard hashes: %w", err)
+	}
+	return dict, nil
+}
+
+// buildMcStateExtra - serializes McStateExtra
+func buildMcStateExtra(shardHashes, config *cell.Dictionary) (*cell.Cell, error) {
+	if config.IsEmpty() {
+		return nil, fmt.Errorf("config should have at least one param")
+	}
+
+	cfg, err := config.ToCell()
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize config: %w", err)
+	}
+
+	info := cell.BeginCell().
+		MustStoreUInt(0, 16).
+		MustStoreUInt(0, 32).MustStoreUInt(0, 32).MustStoreBoolBit(false). // validator info
+		MustStoreUInt(0, 1).MustStoreBoolBit(false).MustStoreUInt(0, 64).  // prev blocks with empty extra
+		MustStoreBoolBit(false).                                           // after key block
+		MustStoreUInt(0, 1).                                               // last key block
+		EndCell()
+
+	b := cell.BeginCell().MustStoreUInt(0xcc26, 16).
+		MustStoreDict(shardHashes).
+		MustStoreSlice(make([]byte, 32), 256).
+		MustStoreRef(cfg).
+		MustStoreRef(info)
+	storeCurrency(b, tlb.ZeroCoins)
+	return b.EndCell(), nil
+}
+
+// buildMcBlockExtra - serializes McBlockExtra
+func buildMcBlockExtra(shardHashes *cell.Dictionary) *cell.Cell {
+	details := cell.BeginCell().
+		MustStoreDict(nil).     // prev block signatures
+		MustStoreMaybeRef(nil). // recover create msg
+		MustStoreMaybeRef(nil). // mint msg
+		EndCell()
+
+	b := cell.BeginCell().MustStoreUInt(0xcca5, 16).
+		MustStoreBoolBit(false).
+		MustStoreDict(shardHashes).
+		MustStoreUInt(0, 1) // shard fees, empty
+	storeCurrency(b, tlb.ZeroCoins)
+	storeCurrency(b, tlb.ZeroCoins)
+	return b.MustStoreRef(details).EndCell()
+}
+
+// buildBlock - creates block of chain with the given transactions, state of accounts is taken as is
+func (ch *chain) buildBlock(accounts map[string]*account, txs []*txEntry, genTime uint32, startLT, endLT uint64,
+	masterRef *tlb.ExtBlkRef, shard *block, config *cell.Dictionary) (*block, error) {
+	sort.Slice(txs, func(i, j int) bool {
+		if c := compareAddr(txs[i].addr, txs[j].addr); c != 0 {
+			return c < 0
+		}
+		return txs[i].tx.LT < txs[j].tx.LT
+	})
+
+	seqno := uint32(len(ch.blocks) + 1)
+	prev, prevState := ch.prevRef()
+
+	shardAccounts := cell.NewAugDict(256, tlb.DepthBalanceInfoAugExtra)
+	accCells := map[string]*cell.Cell{}
+	for key, acc := range accounts {
+		if acc.addr.Workchain() != ch.workchain {
+			continue
+		}
+
+		accCell, err := buildAccount(acc)
+		if err != nil {
+			return nil, err
+		}
+		if accCell == nil {
+			if acc.lastTxLT == 0 {
+				continue
+			}
+			// account_none
+			accCell = cell.BeginCell().MustStoreUInt(0, 1).EndCell()
+		}
+
+		lastHash := acc.lastTxHash
+		if lastHash == nil {
+			lastHash = make([]byte, 32)
+		}
+
+		shardAcc := cell.BeginCell().MustStoreRef(accCell).
+			MustStoreSlice(lastHash, 256).
+			MustStoreUInt(acc.lastTxLT, 64).EndCell()
+		extra := cell.BeginCell().MustStoreUInt(0, 5)
+		storeCurrency(extra, acc.balance)
+
+		if err = shardAccounts.Set(cell.BeginCell().MustStoreSlice(acc.addr.Data(), 256).EndCell(), shardAcc, extra.EndCell()); err != nil {
+			return nil, fmt.Errorf("failed to add account: %w", err)
+		}
+		accCells[key] = shardAcc
+	}
+
+	var mcStateExtra, mcBlockExtra, configRoot *cell.Cell
+	var shardHashes *cell.Dictionary
+	if shard != nil {
+		var err error
+		if shardHashes, err = buildShardHashes(shard); err != nil {
+			return nil, err
+		}
+		if mcStateExtra, err = buildMcStateExtra(shardHashes, config); err != nil {
+			return nil, err
+		}
+		mcBlockExtra = buildMcBlockExtra(shardHashes)
+		configRoot = config.AsCell()
+	}
+
+	state := buildState(ch.workchain, seqno, genTime, endLT, shardAccounts, mcStateExtra)
+
+	header, err := buildHeader(ch.workchain, seqno, genTime, startLT, endLT, prev, masterRef)
+	if err != nil {
+		return nil, err
+	}
+
+	accountBlocks, err := buildAccountBlocks(txs)
+	if err != nil {
+		return nil, err
+	}
+
+	flow, err := tlb.ValueFlow{
+		FromPrevBlk:   zeroCurrency(),
+		ToNextBlk:     zeroCurrency(),
+		Imported:      zeroCurrency(),
+		Exported:      zeroCurrency(),
+		FeesCollected: zeroCurrency(),
+		FeesImported:  zeroCurrency(),
+		Recovered:     zeroCurrency(),
+		Created:       zeroCurrency(),
+		Minted:        zeroCurrency(),
+	}.ToCell()
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize value flow: %w", err)
+	}
+
+	inMsgs := cell.BeginCell().MustStoreAugDict(cell.NewAugDict(256, tlb.ImportFeesAugExtra)).EndCell()
+	outMsgs := cell.BeginCell().MustStoreAugDict(cell.NewAugDict(256, tlb.CurrencyCollectionAugExtra)).EndCell()
+
+	extra := cell.BeginCell().MustStoreUInt(0x4a33f6fd, 32).
+		MustStoreRef(inMsgs).
+		MustStoreRef(outMsgs).
+		MustStoreRef(accountBlocks).
+		MustStoreSlice(make([]byte, 32), 256).
+		MustStoreSlice(make([]byte, 32), 256).
+		MustStoreMaybeRef(mcBlockExtra).EndCell()
+
+	// real blocks have merkle update here, but ordinary cell with the same refs is enough for clients
+	update := cell.BeginCell().MustStoreRef(prevState).MustStoreRef(state).EndCell()
+
+	root := cell.BeginCell().MustStoreUInt(0x11ef55aa, 32).
+		MustStoreInt(globalID, 32).
+		MustStoreRef(header).
+		MustStoreRef(flow).
+		MustStoreRef(update).
+		MustStoreRef(extra).EndCell()
+
+	blk := &block{
+		id: &ton.BlockIDExt{
+			Workchain: ch.workchain,
+			Shard:     shardAll,
+			SeqNo:     seqno,
+			RootHash:  root.Hash(),
+			FileHash:  fileHash(root),
+		},
+		root:        root,
+		state:       state,
+		genTime:     genTime,
+		startLT:     startLT,
+		endLT:       endLT,
+		txs:         txs,
+		accounts:    accCells,
+		shard:       shard,
+		shardHashes: shardHashes,
+		config:      configRoot,
+	}
+	for _, tx := range txs {
+		tx.block = blk
+	}
+	ch.blocks = append(ch.blocks, blk)
+	return blk, nil
+}
+
+func compareAddr(a, b *address.Address) int {
+	ad, bd := a.Data(), b.Data()
+	for i := range ad {
+		if ad[i] != bd[i] {
+			if ad[i] < bd[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// proof - merkle proof which includes the whole cell tree, it is valid for any path inside it
+func proof(c *cell.Cell) *cell.Cell {
+	sk := cell.CreateProofSkeleton()
+	sk.SetRecursive()
+
+	p, err := c.CreateProof(sk)
+	if err != nil {
+		panic(err.Error())
+	}
+	return p
+}
@@ -0,0 +1,593 @@
+package testserver
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/adnl"
+	"github.com/xssnick/tonutils-go/liteclient"
+	"github.com/xssnick/tonutils-go/tl"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/tvm"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+const (
+	// ErrCodeBlockNotFound - same code as real liteserver returns for unknown blocks
+	ErrCodeBlockNotFound = 651
+	// ErrCodeNotSupported - returned for queries which are not implemented by test server
+	ErrCodeNotSupported = -400
+	// ErrCodeTimeout - returned when waitMasterchainSeqno is timed out
+	ErrCodeTimeout = 652
+)
+
+func (s *Server) handleMessage(ctx context.Context, client *liteclient.ServerClient, msg tl.Serializable) error {
+	switch m := msg.(type) {
+	case adnl.MessageQuery:
+		q, ok := m.Data.(liteclient.LiteServerQuery)
+		if !ok {
+			return fmt.Errorf("unsupported query type %s", reflect.TypeOf(m.Data).String())
+		}
+
+		// processed in parallel, because query may wait for block
+		go func() {
+			resp := s.query(ctx, q.Data)
+			if err := client.Send(adnl.MessageAnswer{ID: m.ID, Data: resp}); err != nil {
+				liteclient.Logger("[testserver] failed to send response:", err.Error())
+			}
+		}()
+		return nil
+	case liteclient.TCPPing:
+		return client.Send(liteclient.TCPPong{RandomID: m.RandomID})
+	}
+	return fmt.Errorf("unexpected message type %s", reflect.TypeOf(msg).String())
+}
+
+func (s *Server) query(ctx context.Context, req tl.Serializable) tl.Serializable {
+	if list, ok := req.([]tl.Serializable); ok {
+		if len(list) != 2 {
+			return ton.LSError{Code: ErrCodeNotSupported, Text: "unexpected query prefix"}
+		}
+
+		wait, ok := list[0].(ton.WaitMasterchainSeqno)
+		if !ok {
+			return ton.LSError{Code: ErrCodeNotSupported, Text: "unexpected query prefix " + reflect.TypeOf(list[0]).String()}
+		}
+
+		if err := s.waitSeqno(ctx, uint32(wait.Seqno), time.Duration(wait.Timeout)*time.Millisecond); err != nil {
+			return ton.LSError{Code: ErrCodeTimeout, Text: err.Error()}
+		}
+		req = list[1]
+	}
+
+	if q, ok := req.(ton.SendMessage); ok {
+		// not under lock, handler may use server
+		return s.sendMessage(q)
+	}
+
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+
+	switch q := req.(type) {
+	case ton.GetMasterchainInf:
+		last := s.master.last()
+		return ton.MasterchainInfo{
+			Last:          last.id.Copy(),
+			StateRootHash: last.state.Hash(),
+			Init:          s.zeroState(),
+		}
+	case ton.GetMasterchainInfoExt:
+		last := s.master.last()
+		return ton.MasterchainInfoExt{
+			Mode:          q.Mode,
+			Version:       0x101,
+			Last:          last.id.Copy(),
+			LastUTime:     last.genTime,
+			Now:           uint32(time.Now().Unix()),
+			StateRootHash: last.state.Hash(),
+			Init:          s.zeroState(),
+		}
+	case ton.GetTime:
+		return ton.CurrentTime{Now: uint32(time.Now().Unix())}
+	case ton.GetVersion:
+		return ton.Version{Version: 0x101, Now: uint32(time.Now().Unix())}
+	case ton.LookupBlock:
+		return s.lookupBlock(q)
+	case ton.GetBlockHeader:
+		blk, lsErr := s.block(q.ID)
+		if blk == nil {
+			return lsErr
+		}
+		return ton.BlockHeader{ID: blk.id.Copy(), Mode: q.Mode, HeaderProof: proof(blk.root).ToBOCWithFlags(false)}
+	case ton.GetBlockData:
+		blk, lsErr := s.block(q.ID)
+		if blk == nil {
+			return lsErr
+		}
+		return ton.BlockData{ID: blk.id.Copy(), Payload: blk.root.ToBOCWithFlags(false)}
+	case ton.GetAllShardsInfo:
+		blk, lsErr := s.masterBlock(q.ID)
+		if blk == nil {
+			return lsErr
+		}
+		return ton.AllShardsInfo{
+			ID:    blk.id.Copy(),
+			Proof: []*cell.Cell{proof(blk.root)},
+			Data:  cell.BeginCell().MustStoreDict(blk.shardHashes).EndCell(),
+		}
+	case ton.GetState:
+		blk, lsErr := s.block(q.ID)
+		if blk == nil {
+			return lsErr
+		}
+		return ton.BlockState{ID: blk.id.Copy(), RootHash: blk.id.RootHash, FileHash: blk.id.FileHash, Data: blk.state}
+	case ton.GetShardInfo:
+		return s.getShardInfo(q)
+	case ton.ListBlockTransactions:
+		return s.listBlockTransactions(q)
+	case ton.ListBlockTransactionsExt:
+		return s.listBlockTransactionsExt(q)
+	case ton.GetOneTransaction:
+		return s.getOneTransaction(q)
+	case ton.GetTransactions:
+		return s.getTransactions(q)
+	case ton.GetAccountState:
+		return s.getAccountState(q.ID, q.Account)
+	case ton.GetAccountStatePruned:
+		return s.getAccountState(q.ID, q.Account)
+	case ton.RunSmcMethod:
+		return s.runSmcMethod(q)
+	case ton.GetConfigAll:
+		return s.getConfig(q.Mode, q.BlockID)
+	case ton.GetConfigParams:
+		// whole config is always returned, client picks the required params
+		return s.getConfig(q.Mode, q.BlockID)
+	case ton.GetLibraries:
+		res := ton.LibraryResult{Result: []*ton.LibraryEntry{}}
+		for _, hash := range q.LibraryList {
+			if lib := s.libraries[string(hash)]; lib != nil {
+				res.Result = append(res.Result, &ton.LibraryEntry{Hash: hash, Data: lib})
+			}
+		}
+		return res
+	}
+	return ton.LSError{Code: ErrCodeNotSupported, Text: "query " + reflect.TypeOf(req).String() + " is not supported by test server"}
+}
+
+// waitSeqno - waits for master block to be committed, up to timeout
+func (s *Server) waitSeqno(ctx context.Context, seqno uint32, timeout time.Duration) error {
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+
+	for {
+		s.mx.RLock()
+		ok := s.master.last().id.SeqNo >= seqno
+		ch := s.newBlock
+		s.mx.RUnlock()
+
+		if ok {
+			return nil
+		}
+
+		select {
+		case <-ch:
+		case <-deadline.C:
+			return fmt.Errorf("block %d is not committed in a given timeout", seqno)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (s *Server) zeroState() *ton.ZeroStateIDExt {
+	return &ton.ZeroStateIDExt{
+		Workchain: address.MasterchainID,
+		RootHash:  s.master.zero.Hash(),
+		FileHash:  fileHash(s.master.zero),
+	}
+}
+
+func (s *Server) chain(workchain int32) *chain {
+	switch workchain {
+	case address.MasterchainID:
+		return s.master
+	case 0:
+		return s.base
+	}
+	return nil
+}
+
+// block - finds block by its full id
+func (s *Server) block(id *ton.BlockIDExt) (*block, ton.LSError) {
+	if id != nil {
+		if ch := s.chain(id.Workchain); ch != nil {
+			if blk := ch.get(id.SeqNo); blk != nil && bytes.Equal(blk.id.RootHash, id.RootHash) {
+				return blk, ton.LSError{}
+			}
+		}
+	}
+	return nil, ton.LSError{Code: ErrCodeBlockNotFound, Text: "block is not applied"}
+}
+
+func (s *Server) masterBlock(id *ton.BlockIDExt) (*block, ton.LSError) {
+	if id == nil || id.Workchain != address.MasterchainID {
+		return nil, ton.LSError{Code: ErrCodeNotSupported, Text: "block must belong to the masterchain"}
+	}
+	return s.block(id)
+}
+
+func (s *Server) lookupBlock(q ton.LookupBlock) tl.Serializable {
+	var ch *chain
+	if q.ID != nil {
+		ch = s.chain(q.ID.Workchain)
+	}
+	if ch == nil {
+		return ton.LSError{Code: ErrCodeBlockNotFound, Text: "unknown workchain"}
+	}
+
+	var found *block
+	switch {
+	case q.Mode&1 != 0:
+		found = ch.get(uint32(q.ID.Seqno))
+	case q.Mode&2 != 0:
+		for _, blk := range ch.blocks {
+			if q.LT >= blk.startLT && q.LT < blk.endLT {
+				found = blk
+				break
+			}
+		}
+	case q.Mode&4 != 0:
+		// the first block generated after the time
+		for _, blk := range ch.blocks {
+			if blk.genTime >= q.UTime {
+				found = blk
+				break
+			}
+		}
+	}
+
+	if found == nil {
+		return ton.LSError{Code: ErrCodeBlockNotFound, Text: "block not found"}
+	}
+	return ton.BlockHeader{ID: found.id.Copy(), Mode: q.Mode, HeaderProof: proof(found.root).ToBOCWithFlags(false)}
+}
+
+// blockTransactions - transactions of block after the given one, limited by count
+func blockTransactions(blk *block, after *ton.TransactionID3, reverse bool, count uint32) (txs []*txEntry, incomplete bool) {
+	txs = append([]*txEntry{}, blk.txs...)
+	if reverse {
+		for i, j := 0, len(txs)-1; i < j; i, j = i+1, j-1 {
+			txs[i], txs[j] = txs[j], txs[i]
+		}
+	}
+
+	if after != nil {
+		for i, tx := range txs {
+			if bytes.Equal(tx.addr.Data(), after.Account) && tx.tx.LT == after.LT {
+				txs = txs[i+1:]
+				break
+			}
+		}
+	}
+
+	if uint32(len(txs)) > count {
+		return txs[:count], true
+	}
+	return txs, false
+}
+
+func (s *Server) listBlockTransactions(q ton.ListBlockTransactions) tl.Serializable {
+	blk, lsErr := s.block(q.ID)
+	if blk == nil {
+		return lsErr
+	}
+
+	txs, incomplete := blockTransactions(blk, q.After, q.ReverseOrder != nil, q.Count)
+
+	res := ton.BlockTransactions{
+		ID:             blk.id.Copy(),
+		ReqCount:       int32(q.Count),
+		Incomplete:     incomplete,
+		TransactionIds: []ton.TransactionID{},
+	}
+	for _, tx := range txs {
+		res.TransactionIds = append(res.TransactionIds, ton.TransactionID{
+			Flags:   0b111,
+			Account: tx.addr.Data(),
+			LT:      tx.tx.LT,
+			Hash:    tx.tx.Hash,
+		})
+	}
+
+	if q.WantProof != nil {
+		res.Proof = proof(blk.root)
+	}
+	return res
+}
+
+func (s *Server) listBlockTransactionsExt(q ton.ListBlockTransactionsExt) tl.Serializable {
+	blk, lsErr := s.block(q.ID)
+	if blk == nil {
+		return lsErr
+	}
+
+	txs, incomplete := blockTransactions(blk, q.After, q.ReverseOrder != nil, q.Count)
+
+	res := ton.BlockTransactionsExt{
+		ID:         blk.id.Copy(),
+		ReqCount:   int32(q.Count),
+		Incomplete: incomplete,
+		Proof:      []byte{},
+	}
+	for _, tx := range txs {
+		res.Transactions = append(res.Transactions, tx.cell)
+	}
+
+	if q.WantProof != nil {
+		res.Proof = proof(blk.root).ToBOCWithFlags(false)
+	}
+	return res
+}
+
+func (s *Server) getShardInfo(q ton.GetShardInfo) tl.Serializable {
+	master, lsErr := s.masterBlock(q.ID)
+	if master == nil {
+		return lsErr
+	}
+
+	// basechain has single shard, it contains any shard prefix
+	if q.Workchain != 0 || (q.Exact && q.Shard != shardAll) {
+		return ton.LSError{Code: ErrCodeBlockNotFound, Text: "shard not found"}
+	}
+
+	desc, err := buildShardDesc(master.shard)
+	if err != nil {
+		return ton.LSError{Code: ErrCodeNotSupported, Text: err.Error()}
+	}
+
+	return ton.ShardInfo{
+		ID:               master.id.Copy(),
+		ShardBlock:       master.shard.id.Copy(),
+		ShardProof:       []*cell.Cell{proof(master.root), proof(master.state)},
+		ShardDescription: desc,
+	}
+}
+
+func (s *Server) getOneTransaction(q ton.GetOneTransaction) tl.Serializable {
+	blk, lsErr := s.block(q.ID)
+	if blk == nil {
+		return lsErr
+	}
+
+	if q.AccID != nil {
+		for _, tx := range blk.txs {
+			if tx.addr.Workchain() == q.AccID.Workchain && bytes.Equal(tx.addr.Data(), q.AccID.ID) && tx.tx.LT == uint64(q.LT) {
+				return ton.TransactionInfo{
+					ID:          blk.id.Copy(),
+					Proof:       proof(blk.root).ToBOCWithFlags(false),
+					Transaction: tx.cell.ToBOCWithFlags(false),
+				}
+			}
+		}
+	}
+	return ton.TransactionInfo{ID: blk.id.Copy(), Proof: []byte{}, Transaction: []byte{}}
+}
+
+func (s *Server) getTransactions(q ton.GetTransactions) tl.Serializable {
+	if q.AccID == nil {
+		return ton.LSError{Code: ErrCodeNotSupported, Text: "account is not passed"}
+	}
+
+	history := s.history[accKey(address.NewAddress(0, byte(q.AccID.Workchain), q.AccID.ID))]
+
+	// transactions are returned from the requested one to the older ones
+	start := -1
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i].tx.LT == uint64(q.LT) && bytes.Equal(history[i].tx.Hash, q.TxHash) {
+			start = i
+			break
+		}
+	}
+	if start < 0 {
+		return ton.LSError{Code: 0, Text: "transaction not found"}
+	}
+
+	var ids []*ton.BlockIDExt
+	var cells []*cell.Cell
+	for i := start; i >= 0 && len(cells) < int(q.Limit); i-- {
+		ids = append(ids, history[i].block.id.Copy())
+		cells = append(cells, history[i].cell)
+	}
+
+	return ton.TransactionList{
+		IDs:          ids,
+		Transactions: cell.ToBOCWithFlags(cells, false),
+	}
+}
+
+// accountInBlock - finds shard block and ShardAccount cell of account at the given master block, cell is nil when account is not exists
+func (s *Server) accountInBlock(id *ton.BlockIDExt, acc ton.AccountID) (master, shard *block, shardAcc *cell.Cell, lsErr ton.LSError) {
+	master, lsErr = s.masterBlock(id)
+	if master == nil {
+		return nil, nil, nil, lsErr
+	}
+
+	switch acc.Workchain {
+	case address.MasterchainID:
+		shard = master
+	case 0:
+		shard = master.shard
+	default:
+		return nil, nil, nil, ton.LSError{Code: ErrCodeNotSupported, Text: "unknown workchain"}
+	}
+
+	key := accKey(address.NewAddress(0, byte(acc.Workchain), acc.ID))
+	return master, shard, shard.accounts[key], ton.LSError{}
+}
+
+// accountProofs - block and state proofs of the shard, and proofs of shard in master block for basechain
+func accountProofs(master, shard *block) (shardProof, stateProof []*cell.Cell) {
+	stateProof = []*cell.Cell{proof(shard.root), proof(shard.state)}
+	if shard != master {
+		shardProof = []*cell.Cell{proof(master.root), proof(master.state)}
+	}
+	return shardProof, stateProof
+}
+
+// accountCell - Account cell from ShardAccount, nil for account_none
+func accountCell(shardAcc *cell.Cell) *cell.Cell {
+	if shardAcc == nil {
+		return nil
+	}
+	acc := shardAcc.MustPeekRef(0)
+	if acc.BitsSize() == 1 {
+		return nil
+	}
+	return acc
+}
+
+func (s *Server) getAccountState(id *ton.BlockIDExt, acc ton.AccountID) tl.Serializable {
+	master, shard, shardAcc, lsErr := s.accountInBlock(id, acc)
+	if master == nil {
+		return lsErr
+	}
+
+	shardProof, stateProof := accountProofs(master, shard)
+	return ton.AccountState{
+		ID:         master.id.Copy(),
+		Shard:      shard.id.Copy(),
+		ShardProof: shardProof,
+		Proof:      stateProof,
+		State:      accountCell(shardAcc),
+	}
+}
+
+func (s *Server) runSmcMethod(q ton.RunSmcMethod) tl.Serializable {
+	master, shard, shardAcc, lsErr := s.accountInBlock(q.ID, q.Account)
+	if master == nil {
+		return lsErr
+	}
+
+	accCell := accountCell(shardAcc)
+	if accCell == nil {
+		return ton.LSError{Code: ErrCodeNotSupported, Text: "account is not exists"}
+	}
+
+	var st tlb.AccountState
+	if err := st.LoadFromCell(accCell.BeginParse()); err != nil {
+		return ton.LSError{Code: ErrCodeNotSupported, Text: "failed to parse account: " + err.Error()}
+	}
+
+	res := ton.RunMethodResult{
+		Mode:       q.Mode,
+		ID:         master.id.Copy(),
+		ShardBlock: shard.id.Copy(),
+	}
+
+	if st.Status != tlb.AccountStatusActive || st.StateInit == nil || st.StateInit.Code == nil {
+		res.Mode = 0
+		res.ExitCode = ton.ErrCodeContractNotInitialized
+		return res
+	}
+
+	if q.Mode&1 != 0 {
+		res.ShardProof, res.Proof = accountProofs(master, shard)
+	}
+	if q.Mode&2 != 0 {
+		res.StateProof = proof(accCell)
+	}
+
+	var args []any
+	if q.Params != nil {
+		var params tlb.Stack
+		if err := params.LoadFromCell(q.Params.BeginParse()); err != nil {
+			return ton.LSError{Code: ErrCodeNotSupported, Text: "failed to parse params: " + err.Error()}
+		}
+		for params.Depth() > 0 {
+			v, _ := params.Pop()
+			args = append(args, v)
+		}
+	}
+
+	c7, err := tvm.PrepareC7(tvm.C7Params{
+		Address: st.Address,
+		Now:     master.genTime,
+		BlockLT: master.startLT,
+		Balance: st.Balance.Nano(),
+		Config:  master.config,
+		Code:    st.StateInit.Code,
+	})
+	if err != nil {
+		return ton.LSError{Code: ErrCodeNotSupported, Text: "failed to prepare c7: " + err.Error()}
+	}
+
+	vm := tvm.NewTVM()
+	for _, lib := range s.libraries {
+		vm.AddLibraries(lib)
+	}
+
+	result, err := vm.RunGetMethod(st.StateInit.Code, st.StateInit.Data, c7, tvm.GasWithLimit(tvm.DefaultGetMethodGas), q.MethodID, args...)
+	if err != nil {
+		return ton.LSError{Code: ErrCodeNotSupported, Text: "failed to execute get method: " + err.Error()}
+	}
+	res.ExitCode = result.ExitCode
+
+	if q.Mode&4 != 0 {
+		var stack tlb.Stack
+		values := result.Stack.Values()
+		for i := len(values) - 1; i >= 0; i-- {
+			stack.Push(values[i])
+		}
+
+		if res.Result, err = stack.ToCell(); err != nil {
+			return ton.LSError{Code: ErrCodeNotSupported, Text: "failed to serialize result: " + err.Error()}
+		}
+	}
+	return res
+}
+
+func (s *Server) sendMessage(q ton.SendMessage) tl.Serializable {
+	c, err := cell.FromBOC(q.Body)
+	if err != nil {
+		return ton.LSError{Code: ErrCodeNotSupported, Text: "failed to parse message boc: " + err.Error()}
+	}
+
+	var msg tlb.ExternalMessage
+	if err = tlb.LoadFromCell(&msg, c.BeginParse()); err != nil {
+		return ton.LSError{Code: ErrCodeNotSupported, Text: "failed to parse external message: " + err.Error()}
+	}
+
+	s.mx.RLock()
+	handler := s.extHandler
+	s.mx.RUnlock()
+
+	if handler != nil {
+		if err = handler(&msg); err != nil {
+			return ton.LSError{Code: ErrCodeNotSupported, Text: "message rejected: " + err.Error()}
+		}
+	}
+
+	s.mx.Lock()
+	s.extMessages = append(s.extMessages, &msg)
+	s.mx.Unlock()
+	return ton.SendMessageStatus{Status: 1}
+}
+
+func (s *Server) getConfig(mode int32, id *ton.BlockIDExt) tl.Serializable {
+	blk, lsErr := s.masterBlock(id)
+	if blk == nil {
+		return lsErr
+	}
+
+	return ton.ConfigAll{
+		Mode:        int(mode),
+		ID:          blk.id.Copy(),
+		StateProof:  proof(blk.root),
+		ConfigProof: proof(blk.state),
+	}
+}
@@ -0,0 +1,325 @@
+// Package testserver - in-process liteserver with in-memory chain, for end-to-end tests of clients without network access.
+//
+// It keeps master chain and basechain with a single shard, blocks are created only when CommitBlock is called.
+// Blocks, states and proofs are real cells, so clients with ProofCheckPolicyFast can verify them,
+// but blocks are not signed, so ProofCheckPolicySecure and block proof chains are not supported:
+// getBlockProof and getShardBlockProof return ErrCodeNotSupported, as any other not implemented query.
+package testserver
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/liteclient"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+// Server - fake liteserver, served through liteclient.Server, so any lite client can connect to it
+type Server struct {
+	srv *liteclient.Server
+	key ed25519.PrivateKey
+
+	master *chain
+	base   *chain
+
+	accounts map[string]*account
+	pending  []*txEntry
+	// history - committed transactions of accounts, in lt order
+	history map[string][]*txEntry
+	lt      uint64
+
+	config    *cell.Dictionary
+	libraries map[string]*cell.Cell
+
+	extHandler  func(msg *tlb.ExternalMessage) error
+	extMessages []*tlb.ExternalMessage
+
+	// newBlock - closed and replaced when new master block is committed
+	newBlock chan struct{}
+	mx       sync.RWMutex
+}
+
+// NewServer - creates server with the first empty master and shard blocks
+func NewServer() *Server {
+	_, key, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		panic(err.Error())
+	}
+
+	s := &Server{
+		srv:       liteclient.NewServer([]ed25519.PrivateKey{key}),
+		key:       key,
+		master:    newChain(address.MasterchainID),
+		base:      newChain(0),
+		accounts:  map[string]*account{},
+		history:   map[string][]*txEntry{},
+		config:    cell.NewDict(32),
+		libraries: map[string]*cell.Cell{},
+		newBlock:  make(chan struct{}),
+	}
+	s.srv.SetMessageHandler(s.handleMessage)
+
+	// config address, config should not be empty
+	_ = s.config.SetIntKey(big.NewInt(0), cell.BeginCell().
+		MustStoreRef(cell.BeginCell().MustStoreSlice(make([]byte, 32), 256).EndCell()).EndCell())
+
+	if _, err = s.CommitBlock(); err != nil {
+		panic(err.Error())
+	}
+	return s
+}
+
+// Start - starts listening on random local port, returns address and public key for client connection
+func (s *Server) Start() (addr string, key ed25519.PublicKey, err error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to listen: %w", err)
+	}
+
+	go func() {
+		_ = s.srv.Serve(listener)
+	}()
+	return listener.Addr().String(), s.key.Public().(ed25519.PublicKey), nil
+}
+
+// Connect - starts server and returns connection pool connected to it
+func (s *Server) Connect(ctx context.Context) (*liteclient.ConnectionPool, error) {
+	addr, key, err := s.Start()
+	if err != nil {
+		return nil, err
+	}
+
+	pool := liteclient.NewConnectionPool()
+	if err = pool.AddConnection(ctx, addr, base64.StdEncoding.EncodeToString(key)); err != nil {
+		return nil, fmt.Errorf("failed to connect: %w", err)
+	}
+	return pool, nil
+}
+
+func (s *Server) Close() error {
+	return s.srv.Close()
+}
+
+// SetAccount - sets state of account, it will be visible after the next CommitBlock.
+// When code is nil account is uninitialized.
+func (s *Server) SetAccount(addr *address.Address, balance tlb.Coins, code, data *cell.Cell) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	acc := s.account(addr)
+	acc.exists = true
+	acc.balance = balance
+	acc.code = code
+	acc.data = data
+}
+
+// RemoveAccount - deletes account from the state, it will be visible after the next CommitBlock
+func (s *Server) RemoveAccount(addr *address.Address) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	acc := s.account(addr)
+	acc.exists = false
+	acc.balance = tlb.ZeroCoins
+	acc.code = nil
+	acc.data = nil
+}
+
+// AddTransaction - adds transaction of account to the next block. State of account is not changed by it,
+// use SetAccount to reflect transaction result.
+func (s *Server) AddTransaction(addr *address.Address, in *tlb.Message, out ...*tlb.Message) (*tlb.Transaction, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	if addr.Workchain() != address.MasterchainID && addr.Workchain() != 0 {
+		return nil, fmt.Errorf("only master chain and basechain are supported")
+	}
+
+	acc := s.account(addr)
+	status := tlb.AccountStatus(tlb.AccountStatusNonExist)
+	if acc.exists {
+		status = tlb.AccountStatusUninit
+		if acc.code != nil {
+			status = tlb.AccountStatusActive
+		}
+	}
+
+	prevHash := acc.lastTxHash
+	if prevHash == nil {
+		prevHash = make([]byte, 32)
+	}
+
+	s.lt++
+	tx := &tlb.Transaction{
+		AccountAddr: addr.Data(),
+		LT:          s.lt,
+		PrevTxHash:  prevHash,
+		PrevTxLT:    acc.lastTxLT,
+		Now:         uint32(time.Now().Unix()),
+		OutMsgCount: uint16(len(out)),
+		OrigStatus:  status,
+		EndStatus:   status,
+		TotalFees:   zeroCurrency(),
+		StateUpdate: tlb.HashUpdate{
+			OldHash: make([]byte, 32),
+			NewHash: make([]byte, 32),
+		},
+		Description: tlb.TransactionDescription{
+			Description: tlb.TransactionDescriptionOrdinary{
+				ComputePhase: tlb.ComputePhase{
+					Phase: tlb.ComputePhaseSkipped{
+						Reason: tlb.ComputeSkipReason{Type: tlb.ComputeSkipReasonNoState},
+					},
+				},
+			},
+		},
+	}
+	tx.IO.In = in
+
+	if len(out) > 0 {
+		list := cell.NewDict(15)
+		for i, msg := range out {
+			mc, err := tlb.ToCell(msg)
+			if err != nil {
+				return nil, fmt.Errorf("failed to serialize out message %d: %w", i, err)
+			}
+			if err = list.SetIntKey(big.NewInt(int64(i)), cell.BeginCell().MustStoreRef(mc).EndCell()); err != nil {
+				return nil, fmt.Errorf("failed to add out message %d: %w", i, err)
+			}
+		}
+		tx.IO.Out = &tlb.MessagesList{List: list}
+	}
+
+	txCell, err := tlb.ToCell(tx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize transaction: %w", err)
+	}
+	tx.Hash = txCell.Hash()
+
+	acc.lastTxLT = tx.LT
+	acc.lastTxHash = tx.Hash
+
+	s.pending = append(s.pending, &txEntry{
+		addr: addr,
+		tx:   tx,
+		cell: txCell,
+	})
+	return tx, nil
+}
+
+// CommitBlock - creates new shard block with pending basechain transactions,
+// and master block with pending master chain transactions, which commits the shard block
+func (s *Server) CommitBlock() (*ton.BlockIDExt, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	now := uint32(time.Now().Unix())
+	if last := s.master.last(); last != nil && last.genTime > now {
+		now = last.genTime
+	}
+
+	var masterTxs, baseTxs []*txEntry
+	for _, tx := range s.pending {
+		if tx.addr.Workchain() == address.MasterchainID {
+			masterTxs = append(masterTxs, tx)
+		} else {
+			baseTxs = append(baseTxs, tx)
+		}
+	}
+
+	// shard block refers to the previous master block
+	masterRef, _ := s.master.prevRef()
+
+	start, end := s.nextLT(s.base)
+	shard, err := s.base.buildBlock(s.accounts, baseTxs, now, start, end, masterRef, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build shard block: %w", err)
+	}
+
+	start, end = s.nextLT(s.master)
+	master, err := s.master.buildBlock(s.accounts, masterTxs, now, start, end, nil, shard, s.config)
+	if err != nil {
+		// keep chains consistent
+		s.base.blocks = s.base.blocks[:len(s.base.blocks)-1]
+		return nil, fmt.Errorf("failed to build master block: %w", err)
+	}
+
+	for _, tx := range s.pending {
+		key := accKey(tx.addr)
+		s.history[key] = append(s.history[key], tx)
+	}
+	s.pending = nil
+
+	close(s.newBlock)
+	s.newBlock = make(chan struct{})
+
+	return master.id.Copy(), nil
+}
+
+// nextLT - returns logical time range of the next block of chain, it includes all pending transactions
+func (s *Server) nextLT(ch *chain) (start, end uint64) {
+	if last := ch.last(); last != nil {
+		start = last.endLT
+	}
+	s.lt++
+	if s.lt <= start {
+		s.lt = start + 1
+	}
+	return start, s.lt
+}
+
+// LastBlock - returns the last master block
+func (s *Server) LastBlock() *ton.BlockIDExt {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+	return s.master.last().id.Copy()
+}
+
+// SetConfigParam - sets blockchain config param, it will be visible after the next CommitBlock
+func (s *Server) SetConfigParam(id int32, param *cell.Cell) error {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	return s.config.SetIntKey(big.NewInt(int64(id)), cell.BeginCell().MustStoreRef(param).EndCell())
+}
+
+// AddLibrary - adds library cell, which can be requested by its hash
+func (s *Server) AddLibrary(lib *cell.Cell) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	s.libraries[string(lib.Hash())] = lib
+}
+
+// SetExternalMessageHandler - handler is called for each received external message,
+// when it returns error, message is rejected and error is returned to client
+func (s *Server) SetExternalMessageHandler(handler func(msg *tlb.ExternalMessage) error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	s.extHandler = handler
+}
+
+// ExternalMessages - returns all accepted external messages
+func (s *Server) ExternalMessages() []*tlb.ExternalMessage {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+	return append([]*tlb.ExternalMessage{}, s.extMessages...)
+}
+
+// account - returns current state of account, should be called under lock
+func (s *Server) account(addr *address.Address) *account {
+	key := accKey(addr)
+	acc := s.accounts[key]
+	if acc == nil {
+		acc = &account{addr: addr, balance: tlb.ZeroCoins}
+		s.accounts[key] = acc
+	}
+	return acc
+}
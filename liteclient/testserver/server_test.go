@@ -0,0 +1,560 @@
+package testserver
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"math/big"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tl"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/ton/wallet"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+var (
+	testAddr   = address.MustParseAddr("EQCD39VS5jcptHL8vMjEXrzGaRcCVYto7HUn4bpAOg8xqB2N")
+	testMaster = address.MustParseAddr("Ef8zMzMzMzMzMzMzMzMzMzMzMzMzMzMzMzMzMzMzMzMzM0vF")
+)
+
+func startServer(t *testing.T) (*Server, *ton.APIClient) {
+	srv := NewServer()
+	t.Cleanup(func() {
+		_ = srv.Close()
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	pool, err := srv.Connect(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(pool.Stop)
+
+	return srv, ton.NewAPIClient(pool, ton.ProofCheckPolicyFast)
+}
+
+func TestServer_Blocks(t *testing.T) {
+	srv, api := startServer(t)
+	ctx := context.Background()
+
+	committed, err := srv.CommitBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	master, err := api.GetMasterchainInfo(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !master.Equals(committed) || master.SeqNo != 2 {
+		t.Fatal("incorrect master block", master.SeqNo)
+	}
+
+	found, err := api.LookupBlock(ctx, address.MasterchainID, master.Shard, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found.SeqNo != 1 {
+		t.Fatal("incorrect block found", found.SeqNo)
+	}
+
+	if _, err = api.LookupBlock(ctx, address.MasterchainID, master.Shard, 10); !errors.Is(err, ton.ErrBlockNotFound) {
+		t.Fatal("block should not be found, got", err)
+	}
+
+	shards, err := api.GetBlockShardsInfo(ctx, master)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(shards) != 1 || shards[0].Workchain != 0 || shards[0].SeqNo != 2 {
+		t.Fatal("incorrect shards", shards)
+	}
+
+	blk, err := api.GetBlockData(ctx, shards[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if blk.BlockInfo.SeqNo != 2 || blk.BlockInfo.Shard.WorkchainID != 0 {
+		t.Fatal("incorrect block data")
+	}
+
+	if _, err = api.GetBlockchainConfig(ctx, master, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	// wait for the block which is not committed yet
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		_, _ = srv.CommitBlock()
+	}()
+
+	next, err := api.WaitForBlock(3).GetMasterchainInfo(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if next.SeqNo != 3 {
+		t.Fatal("incorrect block after wait", next.SeqNo)
+	}
+}
+
+func TestServer_Accounts(t *testing.T) {
+	srv, api := startServer(t)
+	ctx := context.Background()
+
+	key := ed25519.NewKeyFromSeed(make([]byte, 32)).Public().(ed25519.PublicKey)
+	state, err := wallet.GetStateInit(key, wallet.V4R2, wallet.DefaultSubwallet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv.SetAccount(testAddr, tlb.MustFromTON("1.5"), state.Code, state.Data)
+	srv.SetAccount(testMaster, tlb.MustFromTON("3"), nil, nil)
+	master, err := srv.CommitBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	acc, err := api.GetAccount(ctx, master, testAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !acc.IsActive || acc.State.Status != tlb.AccountStatusActive || acc.State.Balance.String() != "1.5" {
+		t.Fatal("incorrect account state")
+	}
+
+	acc, err = api.GetAccount(ctx, master, testMaster)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !acc.IsActive || acc.State.Status != tlb.AccountStatusUninit || acc.State.Balance.String() != "3" {
+		t.Fatal("incorrect master account state")
+	}
+
+	acc, err = api.GetAccount(ctx, master, address.NewAddress(0, 0, make([]byte, 32)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if acc.IsActive {
+		t.Fatal("account should not exist")
+	}
+
+	res, err := api.RunGetMethod(ctx, master, testAddr, "get_public_key")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pub, err := res.Int(0); err != nil || !bytes.Equal(pub.FillBytes(make([]byte, 32)), key) {
+		t.Fatal("incorrect get method result", err)
+	}
+
+	res, err = api.RunGetMethod(ctx, master, testAddr, "is_plugin_installed", 0, big.NewInt(123))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, err := res.Int(0); err != nil || v.Sign() != 0 {
+		t.Fatal("incorrect get method result with args", err)
+	}
+
+	if _, err = api.RunGetMethod(ctx, master, testMaster, "seqno"); !errors.Is(err, ton.ContractExecError{Code: ton.ErrCodeContractNotInitialized}) {
+		t.Fatal("get method on uninit account should fail, got", err)
+	}
+}
+
+func TestServer_Transactions(t *testing.T) {
+	srv, api := startServer(t)
+	ctx := context.Background()
+
+	srv.SetAccount(testAddr, tlb.MustFromTON("1"), nil, nil)
+
+	in := &tlb.Message{
+		MsgType: tlb.MsgTypeExternalIn,
+		Msg: &tlb.ExternalMessage{
+			DstAddr: testAddr,
+			Body:    cell.BeginCell().MustStoreUInt(1, 32).EndCell(),
+		},
+	}
+	out := &tlb.Message{
+		MsgType: tlb.MsgTypeInternal,
+		Msg: &tlb.InternalMessage{
+			SrcAddr: testAddr,
+			DstAddr: testMaster,
+			Amount:  tlb.MustFromTON("0.1"),
+			Body:    cell.BeginCell().EndCell(),
+		},
+	}
+
+	tx1, err := srv.AddTransaction(testAddr, in, out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = srv.CommitBlock(); err != nil {
+		t.Fatal(err)
+	}
+
+	tx2, err := srv.AddTransaction(testAddr, in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = srv.AddTransaction(testMaster, nil); err != nil {
+		t.Fatal(err)
+	}
+	master, err := srv.CommitBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	acc, err := api.GetAccount(ctx, master, testAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if acc.LastTxLT != tx2.LT || !bytes.Equal(acc.LastTxHash, tx2.Hash) {
+		t.Fatal("incorrect last tx of account")
+	}
+
+	list, err := api.ListTransactions(ctx, testAddr, 10, acc.LastTxLT, acc.LastTxHash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 2 || !bytes.Equal(list[0].Hash, tx1.Hash) || !bytes.Equal(list[1].Hash, tx2.Hash) {
+		t.Fatal("incorrect transactions list")
+	}
+	if list[0].IO.Out == nil {
+		t.Fatal("out messages are not returned")
+	}
+	if msgs, err := list[0].IO.Out.ToSlice(); err != nil || len(msgs) != 1 || msgs[0].AsInternal().Amount.String() != "0.1" {
+		t.Fatal("incorrect out messages", err)
+	}
+
+	shards, err := api.GetBlockShardsInfo(ctx, master)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ids, incomplete, err := api.GetBlockTransactionsV2(ctx, shards[0], 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if incomplete || len(ids) != 1 || ids[0].LT != tx2.LT {
+		t.Fatal("incorrect shard block transactions")
+	}
+
+	tx, err := api.GetTransaction(ctx, shards[0], testAddr, tx2.LT)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(tx.Hash, tx2.Hash) {
+		t.Fatal("incorrect transaction")
+	}
+
+	ids, _, err = api.GetBlockTransactionsV2(ctx, master, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 1 || !bytes.Equal(ids[0].Account, testMaster.Data()) {
+		t.Fatal("incorrect master block transactions")
+	}
+}
+
+func TestServer_SendMessage(t *testing.T) {
+	srv, api := startServer(t)
+	ctx := context.Background()
+
+	msg := &tlb.ExternalMessage{
+		DstAddr: testAddr,
+		Body:    cell.BeginCell().MustStoreUInt(777, 32).EndCell(),
+	}
+
+	if err := api.SendExternalMessage(ctx, msg); err != nil {
+		t.Fatal(err)
+	}
+
+	msgs := srv.ExternalMessages()
+	if len(msgs) != 1 || msgs[0].DstAddr.String() != testAddr.String() || !bytes.Equal(msgs[0].Body.Hash(), msg.Body.Hash()) {
+		t.Fatal("message is not received")
+	}
+
+	srv.SetExternalMessageHandler(func(msg *tlb.ExternalMessage) error {
+		return errors.New("not accepted")
+	})
+	if err := api.SendExternalMessage(ctx, msg); err == nil {
+		t.Fatal("message should be rejected")
+	}
+}
+
+// recordingClient - keeps types of queries sent to server, and can hide libraries from client
+type recordingClient struct {
+	ton.LiteClient
+	hideLibraries bool
+
+	queries []string
+	mx      sync.Mutex
+}
+
+func (c *recordingClient) QueryLiteserver(ctx context.Context, payload tl.Serializable, result tl.Serializable) error {
+	query := any(payload)
+	if raw, ok := payload.(tl.Raw); ok && len(raw) > 12 {
+		// query wrapped by waitMasterchainSeqno prefix
+		var inner tl.Serializable
+		if _, err := tl.Parse(&inner, raw[12:], true); err == nil {
+			query = inner
+		}
+	}
+
+	c.mx.Lock()
+	c.queries = append(c.queries, reflect.Indirect(reflect.ValueOf(query)).Type().Name())
+	c.mx.Unlock()
+
+	if _, ok := payload.(ton.GetLibraries); ok && c.hideLibraries {
+		*result.(*tl.Serializable) = ton.LibraryResult{}
+		return nil
+	}
+	return c.LiteClient.QueryLiteserver(ctx, payload, result)
+}
+
+func (c *recordingClient) sent(name string) bool {
+	c.mx.Lock()
+	defer c.mx.Unlock()
+
+	for _, q := range c.queries {
+		if q == name {
+			return true
+		}
+	}
+	return false
+}
+
+func TestServer_LocalGetMethods(t *testing.T) {
+	srv := NewServer()
+	t.Cleanup(func() {
+		_ = srv.Close()
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	pool, err := srv.Connect(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(pool.Stop)
+
+	// DROP, PUSHINT 7, CONFIGOPTPARAM: returns config param 7, code is stored in library
+	lib := cell.BeginCell().MustStoreSlice([]byte{0x30, 0x77, 0xF8, 0x33}, 32).EndCell()
+	code := cell.BeginCell().MustStoreUInt(uint64(cell.LibraryCellType), 8).MustStoreSlice(lib.Hash(), 256).EndCell()
+	code.UnsafeModify(cell.LevelMask{}, true)
+
+	param := cell.BeginCell().MustStoreUInt(0xBEEF, 16).EndCell()
+	if err = srv.SetConfigParam(7, param); err != nil {
+		t.Fatal(err)
+	}
+	srv.AddLibrary(lib)
+	srv.SetAccount(testMaster, tlb.MustFromTON("1"), code, cell.BeginCell().EndCell())
+
+	master, err := srv.CommitBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	check := func(api *ton.APIClient) {
+		res, err := api.RunGetMethod(ctx, master, testMaster, "get_param")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if c, err := res.Cell(0); err != nil || !bytes.Equal(c.Hash(), param.Hash()) {
+			t.Fatal("incorrect get method result", err)
+		}
+	}
+
+	rc := &recordingClient{LiteClient: pool}
+	api := ton.NewAPIClient(rc, ton.ProofCheckPolicyFast)
+	check(api)
+	if !rc.sent("RunSmcMethod") {
+		t.Fatal("get method should be executed by liteserver by default")
+	}
+
+	rc = &recordingClient{LiteClient: pool}
+	api = ton.NewAPIClient(rc, ton.ProofCheckPolicyFast)
+	api.SetLocalGetMethods(true)
+	check(api)
+	if rc.sent("RunSmcMethod") || !rc.sent("GetConfigAll") || !rc.sent("GetLibraries") {
+		t.Fatal("get method should be executed locally", rc.queries)
+	}
+
+	// without library local execution is not possible, error is returned unless fallback is enabled
+	rc = &recordingClient{LiteClient: pool, hideLibraries: true}
+	api = ton.NewAPIClient(rc, ton.ProofCheckPolicyFast)
+	api.SetLocalGetMethods(true)
+	if _, err = api.RunGetMethod(ctx, master, testMaster, "get_param"); !errors.Is(err, ton.ErrLocalExecution) {
+		t.Fatal("local execution error expected, got", err)
+	}
+	if rc.sent("RunSmcMethod") {
+		t.Fatal("should not fall back to liteserver without opt-in", rc.queries)
+	}
+
+	api.SetLocalGetMethodsFallback(true)
+	check(api)
+	if !rc.sent("GetLibraries") || !rc.sent("RunSmcMethod") {
+		t.Fatal("should fall back to liteserver", rc.queries)
+	}
+
+	if _, err = api.RunGetMethod(ctx, master, testAddr, "seqno"); !errors.Is(err, ton.ContractExecError{Code: ton.ErrCodeContractNotInitialized}) {
+		t.Fatal("get method on not existing account should fail, got", err)
+	}
+}
+
+func TestServer_StateAndShardQueries(t *testing.T) {
+	srv, api := startServer(t)
+	ctx := context.Background()
+
+	srv.SetAccount(testAddr, tlb.MustFromTON("1"), nil, nil)
+	tx1, err := srv.AddTransaction(testAddr, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx2, err := srv.AddTransaction(testAddr, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	master, err := srv.CommitBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var resp tl.Serializable
+	if err = api.Client().QueryLiteserver(ctx, ton.GetShardInfo{ID: master, Workchain: 0, Shard: 0x4000000000000000}, &resp); err != nil {
+		t.Fatal(err)
+	}
+	info, ok := resp.(ton.ShardInfo)
+	if !ok {
+		t.Fatal("unexpected response", reflect.TypeOf(resp))
+	}
+
+	var desc tlb.ShardDesc
+	if err = tlb.LoadFromCell(&desc, info.ShardDescription.BeginParse()); err != nil {
+		t.Fatal(err)
+	}
+	if desc.SeqNo != info.ShardBlock.SeqNo || !bytes.Equal(desc.RootHash, info.ShardBlock.RootHash) || len(info.ShardProof) != 2 {
+		t.Fatal("incorrect shard info")
+	}
+
+	if err = api.Client().QueryLiteserver(ctx, ton.GetShardInfo{ID: master, Workchain: 0, Shard: 0x4000000000000000, Exact: true}, &resp); err != nil {
+		t.Fatal(err)
+	}
+	if lsErr, ok := resp.(ton.LSError); !ok || lsErr.Code != ErrCodeBlockNotFound {
+		t.Fatal("exact shard should not be found", resp)
+	}
+
+	if err = api.Client().QueryLiteserver(ctx, ton.GetState{ID: info.ShardBlock}, &resp); err != nil {
+		t.Fatal(err)
+	}
+	st, ok := resp.(ton.BlockState)
+	if !ok {
+		t.Fatal("unexpected response", reflect.TypeOf(resp))
+	}
+	var state tlb.ShardStateUnsplit
+	if err = tlb.LoadFromCell(&state, st.Data.BeginParse()); err != nil {
+		t.Fatal(err)
+	}
+	if state.Seqno != info.ShardBlock.SeqNo {
+		t.Fatal("incorrect state seqno", state.Seqno)
+	}
+
+	req := ton.ListBlockTransactionsExt{ID: info.ShardBlock, Mode: 1<<5 | 1<<6, Count: 1, ReverseOrder: &ton.True{}, WantProof: &ton.True{}}
+	if err = api.Client().QueryLiteserver(ctx, req, &resp); err != nil {
+		t.Fatal(err)
+	}
+	txs, ok := resp.(ton.BlockTransactionsExt)
+	if !ok {
+		t.Fatal("unexpected response", reflect.TypeOf(resp))
+	}
+	if !txs.Incomplete || len(txs.Transactions) != 1 || !bytes.Equal(txs.Transactions[0].Hash(), tx2.Hash) || len(txs.Proof) == 0 {
+		t.Fatal("incorrect first page of transactions")
+	}
+
+	req.Mode |= 1 << 7
+	req.After = &ton.TransactionID3{Account: testAddr.Data(), LT: tx2.LT}
+	if err = api.Client().QueryLiteserver(ctx, req, &resp); err != nil {
+		t.Fatal(err)
+	}
+	txs = resp.(ton.BlockTransactionsExt)
+	if txs.Incomplete || len(txs.Transactions) != 1 || !bytes.Equal(txs.Transactions[0].Hash(), tx1.Hash) {
+		t.Fatal("incorrect second page of transactions")
+	}
+}
+
+func TestServer_StreamTransactions(t *testing.T) {
+	srv := NewServer()
+	t.Cleanup(func() {
+		_ = srv.Close()
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	pool, err := srv.Connect(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(pool.Stop)
+
+	start, err := srv.CommitBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv.SetAccount(testAddr, tlb.MustFromTON("1"), nil, nil)
+	var hashes [][]byte
+	for i := 0; i < 3; i++ {
+		tx, err := srv.AddTransaction(testAddr, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		hashes = append(hashes, tx.Hash)
+	}
+	if _, err = srv.CommitBlock(); err != nil {
+		t.Fatal(err)
+	}
+
+	rc := &recordingClient{LiteClient: pool}
+	api := ton.NewAPIClient(rc, ton.ProofCheckPolicyFast)
+
+	streamCtx, streamCancel := context.WithCancel(ctx)
+	defer streamCancel()
+
+	ch := make(chan *ton.TransactionsBatch, 10)
+	go func() {
+		_ = api.StreamTransactions(streamCtx, start.SeqNo, ch)
+	}()
+
+	var got [][]byte
+	for batch := range ch {
+		for _, tx := range batch.Transactions {
+			got = append(got, tx.Hash)
+		}
+		if batch.IsCheckpoint() {
+			break
+		}
+	}
+	streamCancel()
+
+	if len(got) != len(hashes) {
+		t.Fatal("incorrect transactions count", len(got))
+	}
+	for i := range hashes {
+		if !bytes.Equal(got[i], hashes[i]) {
+			t.Fatal("incorrect transaction", i)
+		}
+	}
+
+	if !rc.sent("ListBlockTransactionsExt") || rc.sent("GetOneTransaction") {
+		t.Fatal("transactions should be fetched in batches", rc.queries)
+	}
+}
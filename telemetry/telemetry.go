@@ -0,0 +1,150 @@
+// Package telemetry - adapter which reports liteclient, adnl and rldp events to OpenTelemetry-like meter and tracer.
+// Interfaces here are subsets of OpenTelemetry API, so it can be connected with thin wrappers,
+// without adding OpenTelemetry dependency to the library.
+package telemetry
+
+import (
+	"context"
+	"time"
+
+	"github.com/xssnick/tonutils-go/adnl"
+	"github.com/xssnick/tonutils-go/adnl/rldp"
+	"github.com/xssnick/tonutils-go/liteclient"
+)
+
+type Attribute struct {
+	Key   string
+	Value any
+}
+
+type Int64Counter interface {
+	Add(ctx context.Context, incr int64, attrs ...Attribute)
+}
+
+type Float64Histogram interface {
+	Record(ctx context.Context, value float64, attrs ...Attribute)
+}
+
+type Meter interface {
+	Int64Counter(name, description, unit string) Int64Counter
+	Float64Histogram(name, description, unit string) Float64Histogram
+}
+
+type Span interface {
+	RecordError(err error)
+	End(at time.Time)
+}
+
+type Tracer interface {
+	// Start - starts span which began at given time, spans are reported after operation is finished
+	Start(ctx context.Context, name string, at time.Time, attrs ...Attribute) Span
+}
+
+type Adapter struct {
+	tracer Tracer
+
+	queryDuration Float64Histogram
+
+	packetsSent      Int64Counter
+	packetsReceived  Int64Counter
+	bytesSent        Int64Counter
+	bytesReceived    Int64Counter
+	queryRetransmits Int64Counter
+	transferSize     Float64Histogram
+	transferDuration Float64Histogram
+	transferSymbols  Int64Counter
+	transferFailures Int64Counter
+}
+
+// NewAdapter - creates instruments using meter, tracer can be nil when only metrics are needed
+func NewAdapter(meter Meter, tracer Tracer) *Adapter {
+	return &Adapter{
+		tracer: tracer,
+
+		queryDuration: meter.Float64Histogram("liteclient.query.duration", "Duration of liteserver queries", "s"),
+
+		packetsSent:      meter.Int64Counter("adnl.packets.sent", "Number of ADNL packets sent", "{packet}"),
+		packetsReceived:  meter.Int64Counter("adnl.packets.received", "Number of ADNL packets received", "{packet}"),
+		bytesSent:        meter.Int64Counter("adnl.bytes.sent", "Size of ADNL packets sent", "By"),
+		bytesReceived:    meter.Int64Counter("adnl.bytes.received", "Size of ADNL packets received", "By"),
+		queryRetransmits: meter.Int64Counter("adnl.query.retransmits", "Number of ADNL query packets sent again", "{packet}"),
+
+		transferSize:     meter.Float64Histogram("rldp.transfer.size", "Size of RLDP transfers", "By"),
+		transferDuration: meter.Float64Histogram("rldp.transfer.duration", "Duration of RLDP transfers", "s"),
+		transferSymbols:  meter.Int64Counter("rldp.transfer.symbols", "Number of RLDP FEC symbols", "{symbol}"),
+		transferFailures: meter.Int64Counter("rldp.transfer.failures", "Number of not completed outgoing RLDP transfers", "{transfer}"),
+	}
+}
+
+// Install - sets adapter as observer of adnl and rldp packages and of the given pools.
+// Should be called before connections are created.
+func (a *Adapter) Install(pools ...*liteclient.ConnectionPool) {
+	adnl.SetObserver(a)
+	rldp.SetObserver(a)
+	for _, pool := range pools {
+		pool.SetObserver(a)
+	}
+}
+
+func (a *Adapter) OnLiteserverQuery(ctx context.Context, event liteclient.QueryEvent) {
+	attrs := []Attribute{
+		{Key: "method", Value: event.Method},
+		{Key: "node", Value: event.Node},
+		{Key: "error", Value: event.Err != nil},
+	}
+	a.queryDuration.Record(ctx, event.Duration.Seconds(), attrs...)
+
+	if a.tracer != nil {
+		span := a.tracer.Start(ctx, event.Method, event.StartedAt, attrs[:2]...)
+		if event.Err != nil {
+			span.RecordError(event.Err)
+		}
+		span.End(event.StartedAt.Add(event.Duration))
+	}
+}
+
+func (a *Adapter) OnPacketSent(_ string, size int) {
+	a.packetsSent.Add(context.Background(), 1)
+	a.bytesSent.Add(context.Background(), int64(size))
+}
+
+func (a *Adapter) OnPacketReceived(_ string, size int) {
+	a.packetsReceived.Add(context.Background(), 1)
+	a.bytesReceived.Add(context.Background(), int64(size))
+}
+
+func (a *Adapter) OnQueryRetransmit(_ string, packets int) {
+	a.queryRetransmits.Add(context.Background(), int64(packets))
+}
+
+func (a *Adapter) OnTransfer(event rldp.TransferEvent) {
+	ctx := context.Background()
+
+	direction := "received"
+	if event.Outgoing {
+		direction = "sent"
+	}
+	attrs := []Attribute{{Key: "direction", Value: direction}}
+
+	a.transferSize.Record(ctx, float64(event.Size), attrs...)
+	a.transferDuration.Record(ctx, event.Duration.Seconds(), attrs...)
+	a.transferSymbols.Add(ctx, int64(event.Symbols), attrs...)
+	if event.Err != nil {
+		a.transferFailures.Add(ctx, 1)
+	}
+
+	if a.tracer != nil {
+		startedAt := time.Now().Add(-event.Duration)
+		span := a.tracer.Start(ctx, "rldp.transfer", startedAt, append(attrs, Attribute{Key: "peer", Value: event.Addr})...)
+		if event.Err != nil {
+			span.RecordError(event.Err)
+		}
+		span.End(startedAt.Add(event.Duration))
+	}
+}
+
+var (
+	_ liteclient.Observer = (*Adapter)(nil)
+	_ adnl.Observer       = (*Adapter)(nil)
+	_ rldp.Observer       = (*Adapter)(nil)
+)
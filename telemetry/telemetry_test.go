@@ -0,0 +1,189 @@
+package telemetry
+
+import (
+	"context"
+	"crypto/ed25519"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/xssnick/tonutils-go/adnl"
+	"github.com/xssnick/tonutils-go/adnl/rldp"
+	"github.com/xssnick/tonutils-go/liteclient/testserver"
+	"github.com/xssnick/tonutils-go/ton"
+)
+
+type testRecord struct {
+	name  string
+	value float64
+	attrs []Attribute
+}
+
+type testMeter struct {
+	records []testRecord
+	mx      sync.Mutex
+}
+
+type testInstrument struct {
+	name  string
+	meter *testMeter
+}
+
+func (m *testMeter) Int64Counter(name, _, _ string) Int64Counter {
+	return &testInstrument{name: name, meter: m}
+}
+
+func (m *testMeter) Float64Histogram(name, _, _ string) Float64Histogram {
+	return &testInstrument{name: name, meter: m}
+}
+
+func (i *testInstrument) Add(_ context.Context, incr int64, attrs ...Attribute) {
+	i.Record(context.Background(), float64(incr), attrs...)
+}
+
+func (i *testInstrument) Record(_ context.Context, value float64, attrs ...Attribute) {
+	i.meter.mx.Lock()
+	defer i.meter.mx.Unlock()
+	i.meter.records = append(i.meter.records, testRecord{name: i.name, value: value, attrs: attrs})
+}
+
+func (m *testMeter) find(name string) []testRecord {
+	m.mx.Lock()
+	defer m.mx.Unlock()
+
+	var res []testRecord
+	for _, r := range m.records {
+		if r.name == name {
+			res = append(res, r)
+		}
+	}
+	return res
+}
+
+type testSpan struct {
+	name  string
+	start time.Time
+	end   time.Time
+	err   error
+}
+
+type testTracer struct {
+	spans []*testSpan
+	mx    sync.Mutex
+}
+
+func (t *testTracer) Start(_ context.Context, name string, at time.Time, _ ...Attribute) Span {
+	t.mx.Lock()
+	defer t.mx.Unlock()
+
+	s := &testSpan{name: name, start: at}
+	t.spans = append(t.spans, s)
+	return s
+}
+
+func (s *testSpan) RecordError(err error) {
+	s.err = err
+}
+
+func (s *testSpan) End(at time.Time) {
+	s.end = at
+}
+
+func TestAdapter_Liteclient(t *testing.T) {
+	srv := testserver.NewServer()
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	pool, err := srv.Connect(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pool.Stop()
+
+	meter, tracer := &testMeter{}, &testTracer{}
+	NewAdapter(meter, tracer).Install(pool)
+	defer adnl.SetObserver(nil)
+	defer rldp.SetObserver(nil)
+
+	if _, err = ton.NewAPIClient(pool).GetMasterchainInfo(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	records := meter.find("liteclient.query.duration")
+	if len(records) != 1 {
+		t.Fatal("query was not observed")
+	}
+	if records[0].attrs[0].Value != "liteServer.getMasterchainInfo" || records[0].attrs[2].Value != false {
+		t.Fatal("incorrect attributes", records[0].attrs)
+	}
+
+	if len(tracer.spans) != 1 || tracer.spans[0].name != "liteServer.getMasterchainInfo" || tracer.spans[0].end.Before(tracer.spans[0].start) {
+		t.Fatal("incorrect span")
+	}
+}
+
+func TestAdapter_ADNL(t *testing.T) {
+	meter := &testMeter{}
+	NewAdapter(meter, nil).Install()
+	defer adnl.SetObserver(nil)
+	defer rldp.SetObserver(nil)
+
+	srvPub, srvKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := adnl.NewGateway(srvKey)
+	if err = s.StartServer("127.0.0.1:9177"); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	s.SetConnectionHandler(func(client adnl.Peer) error {
+		client.SetQueryHandler(func(msg *adnl.MessageQuery) error {
+			if m, ok := msg.Data.(adnl.MessagePing); ok {
+				return client.Answer(context.Background(), msg.ID, adnl.MessagePong{Value: m.Value})
+			}
+			return nil
+		})
+		return nil
+	})
+
+	cli, err := adnl.Connect(context.Background(), "127.0.0.1:9177", srvPub, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cli.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var res adnl.MessagePong
+	if err = cli.Query(ctx, &adnl.MessagePing{Value: 777}, &res); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(meter.find("adnl.packets.sent")) < 1 || len(meter.find("adnl.packets.received")) < 2 {
+		t.Fatal("packets were not observed")
+	}
+}
+
+func TestAdapter_RLDP(t *testing.T) {
+	meter, tracer := &testMeter{}, &testTracer{}
+	a := NewAdapter(meter, tracer)
+
+	a.OnTransfer(rldp.TransferEvent{Outgoing: true, Size: 1000, Symbols: 3, Duration: time.Second, Err: context.DeadlineExceeded})
+
+	size := meter.find("rldp.transfer.size")
+	if len(size) != 1 || size[0].value != 1000 || size[0].attrs[0].Value != "sent" {
+		t.Fatal("incorrect transfer size record")
+	}
+	if len(meter.find("rldp.transfer.failures")) != 1 {
+		t.Fatal("failure was not observed")
+	}
+	if len(tracer.spans) != 1 || tracer.spans[0].err == nil || tracer.spans[0].end.Sub(tracer.spans[0].start) != time.Second {
+		t.Fatal("incorrect span")
+	}
+}
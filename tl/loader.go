@@ -24,6 +24,7 @@ type TL interface {
 var _SchemaIDByTypeName = map[string]uint32{}
 var _SchemaIDByName = map[string]uint32{}
 var _SchemaByID = map[uint32]reflect.Type{}
+var _SchemaNameByTypeName = map[string]string{}
 
 var BoolTrue = CRC("boolTrue = Bool")
 var BoolFalse = CRC("boolFalse = Bool")
@@ -750,6 +751,7 @@ func Register(typ any, tl string) uint32 {
 	_SchemaByID[id] = t
 	_SchemaIDByTypeName[t.String()] = id
 	_SchemaIDByName[nameParts[0]] = id
+	_SchemaNameByTypeName[t.String()] = nameParts[0]
 
 	b := make([]byte, 4)
 	binary.LittleEndian.PutUint32(b, id)
@@ -758,6 +760,29 @@ func Register(typ any, tl string) uint32 {
 	return id
 }
 
+// Name - returns registered TL name of type, like liteServer.getMasterchainInfo,
+// or go type name when type is not registered. For Raw name is taken by id of serialized boxed object.
+func Name(v any) string {
+	if raw, ok := v.(Raw); ok && len(raw) >= 4 {
+		if typ, ok := _SchemaByID[binary.LittleEndian.Uint32(raw)]; ok {
+			return _SchemaNameByTypeName[typ.String()]
+		}
+	}
+
+	t := reflect.TypeOf(v)
+	if t == nil {
+		return ""
+	}
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if name, ok := _SchemaNameByTypeName[t.String()]; ok {
+		return name
+	}
+	return t.String()
+}
+
 var ieeeTable = crc32.MakeTable(crc32.IEEE)
 
 func CRC(schema string) uint32 {
@@ -85,3 +85,25 @@ func TestHash(t *testing.T) {
 		t.Fatal("incorrect hash " + hex.EncodeToString(hash))
 	}
 }
+
+type TestNamed struct{}
+
+func TestName(t *testing.T) {
+	Register(TestNamed{}, "test.named#aabbccdd = test.Named")
+
+	if name := Name(TestNamed{}); name != "test.named" {
+		t.Fatal("incorrect name", name)
+	}
+	if name := Name(&TestNamed{}); name != "test.named" {
+		t.Fatal("incorrect name of pointer", name)
+	}
+	if name := Name(Raw{}); name != "tl.Raw" {
+		t.Fatal("incorrect name of not registered type", name)
+	}
+	if name := Name(Raw{0xdd, 0xcc, 0xbb, 0xaa, 0x01}); name != "test.named" {
+		t.Fatal("incorrect name of raw object", name)
+	}
+	if name := Name(Raw{0x01, 0x02, 0x03, 0x04}); name != "tl.Raw" {
+		t.Fatal("incorrect name of unknown raw object", name)
+	}
+}
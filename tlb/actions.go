@@ -0,0 +1,137 @@
+package tlb
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+func init() {
+	Register(ActionSendMsg{})
+	Register(ActionSetCode{})
+	Register(ActionReserveCurrency{})
+	Register(ActionChangeLibrary{})
+
+	Register(LibRefHash{})
+	Register(LibRefRef{})
+}
+
+// MaxOutActions - max number of actions which can be processed in action phase
+const MaxOutActions = 255
+
+type ActionSendMsg struct {
+	_    Magic    `tlb:"#0ec3c86d"`
+	Mode uint8    `tlb:"## 8"`
+	Msg  *Message `tlb:"^"`
+}
+
+type ActionSetCode struct {
+	_       Magic      `tlb:"#ad4de08e"`
+	NewCode *cell.Cell `tlb:"^"`
+}
+
+type ActionReserveCurrency struct {
+	_        Magic              `tlb:"#36e6b809"`
+	Mode     uint8              `tlb:"## 8"`
+	Currency CurrencyCollection `tlb:"."`
+}
+
+type LibRefHash struct {
+	_       Magic  `tlb:"$0"`
+	LibHash []byte `tlb:"bits 256"`
+}
+
+type LibRefRef struct {
+	_       Magic      `tlb:"$1"`
+	Library *cell.Cell `tlb:"^"`
+}
+
+type ActionChangeLibrary struct {
+	_      Magic `tlb:"#26fa1dd4"`
+	Mode   uint8 `tlb:"## 7"`
+	LibRef any   `tlb:"[LibRefHash,LibRefRef]"`
+}
+
+type OutAction struct {
+	Action any `tlb:"[ActionSendMsg,ActionSetCode,ActionReserveCurrency,ActionChangeLibrary]"`
+}
+
+// OutList - list of output actions created by contract (c5 register),
+// actions are in the order of their creation, and executed in the same order.
+type OutList struct {
+	Actions []OutAction
+}
+
+// LoadFromCell - loads list from its root, which contains the last action
+func (l *OutList) LoadFromCell(loader *cell.Slice) error {
+	var actions []OutAction
+	err := WalkOutList(loader, func(i int, action *cell.Slice) error {
+		var a OutAction
+		if err := LoadFromCell(&a, action); err != nil {
+			return fmt.Errorf("failed to parse action %d: %w", i, err)
+		}
+		actions = append(actions, a)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	l.Actions = actions
+	return nil
+}
+
+func (l OutList) ToCell() (*cell.Cell, error) {
+	if len(l.Actions) > MaxOutActions {
+		return nil, fmt.Errorf("too many actions, max is %d", MaxOutActions)
+	}
+
+	list := cell.BeginCell().EndCell()
+	for i, a := range l.Actions {
+		c, err := ToCell(a)
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize action %d: %w", i, err)
+		}
+
+		b := cell.BeginCell().MustStoreRef(list)
+		if err = b.StoreBuilder(c.ToBuilder()); err != nil {
+			return nil, fmt.Errorf("failed to store action %d: %w", i, err)
+		}
+		list = b.EndCell()
+	}
+	return list, nil
+}
+
+// WalkOutList - calls fn for each action of the list in the order of execution,
+// action slice contains only the action itself, without the link to the previous list node.
+func WalkOutList(list *cell.Slice, fn func(i int, action *cell.Slice) error) error {
+	var nodes []*cell.Slice
+	for s := list.Copy(); s.BitsLeft() > 0 || s.RefsNum() > 0; {
+		if len(nodes) == MaxOutActions {
+			return fmt.Errorf("too many actions, max is %d", MaxOutActions)
+		}
+
+		prev, err := s.LoadRefCell()
+		if err != nil {
+			return fmt.Errorf("failed to load previous node of action list: %w", err)
+		}
+		nodes = append(nodes, s)
+		s = prev.BeginParse()
+	}
+
+	for i := len(nodes) - 1; i >= 0; i-- {
+		if err := fn(len(nodes)-1-i, nodes[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MatchesOutList - checks that action phase was processing the given list of actions
+func (a *ActionPhase) MatchesOutList(list *cell.Cell) bool {
+	if list == nil {
+		list = cell.BeginCell().EndCell()
+	}
+	return bytes.Equal(a.ActionListHash, list.Hash())
+}
@@ -0,0 +1,106 @@
+package tlb
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+func TestOutList(t *testing.T) {
+	msg := &InternalMessage{
+		IHRDisabled: true,
+		Bounce:      true,
+		DstAddr:     address.MustParseAddr("EQCD39VS5jcptHL8vMjEXrzGaRcCVYto7HUn4bpAOg8xqB2N"),
+		Amount:      MustFromTON("1.5"),
+		Body:        cell.BeginCell().MustStoreUInt(0, 32).MustStoreStringSnake("hello").EndCell(),
+	}
+	code := cell.BeginCell().MustStoreUInt(0xAABB, 16).EndCell()
+	lib := cell.BeginCell().MustStoreUInt(0xCCDD, 16).EndCell()
+
+	list := OutList{Actions: []OutAction{
+		{Action: ActionReserveCurrency{Mode: 4, Currency: CurrencyCollection{Coins: MustFromTON("0.1")}}},
+		{Action: ActionSendMsg{Mode: 3, Msg: &Message{MsgType: MsgTypeInternal, Msg: msg}}},
+		{Action: ActionSetCode{NewCode: code}},
+		{Action: ActionChangeLibrary{Mode: 2, LibRef: LibRefRef{Library: lib}}},
+		{Action: ActionChangeLibrary{Mode: 0, LibRef: LibRefHash{LibHash: lib.Hash()}}},
+	}}
+
+	c, err := ToCell(list)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// root is the last action
+	s := c.BeginParse()
+	s.MustLoadRef()
+	if s.MustLoadUInt(32) != 0x26fa1dd4 || s.MustLoadUInt(7) != 0 || s.MustLoadBoolBit() ||
+		!bytes.Equal(s.MustLoadSlice(256), lib.Hash()) {
+		t.Fatal("incorrect last action layout")
+	}
+
+	msgCell, err := ToCell(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var tags []uint64
+	err = WalkOutList(c.BeginParse(), func(i int, action *cell.Slice) error {
+		if action.RefsNum() > 0 && i == 1 {
+			if action.MustLoadUInt(32) != 0x0ec3c86d || action.MustLoadUInt(8) != 3 ||
+				!bytes.Equal(action.MustLoadRef().MustToCell().Hash(), msgCell.Hash()) {
+				t.Fatal("incorrect send msg layout")
+			}
+			tags = append(tags, 0x0ec3c86d)
+			return nil
+		}
+		tags = append(tags, action.MustLoadUInt(32))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tags) != 5 || tags[0] != 0x36e6b809 || tags[1] != 0x0ec3c86d || tags[2] != 0xad4de08e || tags[3] != 0x26fa1dd4 {
+		t.Fatal("incorrect walk order", tags)
+	}
+
+	var loaded OutList
+	if err = LoadFromCell(&loaded, c.BeginParse()); err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.Actions) != 5 {
+		t.Fatal("incorrect actions num", len(loaded.Actions))
+	}
+
+	send, ok := loaded.Actions[1].Action.(ActionSendMsg)
+	if !ok || send.Mode != 3 || send.Msg.MsgType != MsgTypeInternal || send.Msg.AsInternal().Amount.String() != "1.5" {
+		t.Fatal("incorrect send msg action")
+	}
+	if setCode, ok := loaded.Actions[2].Action.(ActionSetCode); !ok || !bytes.Equal(setCode.NewCode.Hash(), code.Hash()) {
+		t.Fatal("incorrect set code action")
+	}
+	if lc, ok := loaded.Actions[3].Action.(ActionChangeLibrary); !ok || lc.Mode != 2 {
+		t.Fatal("incorrect change library action")
+	} else if ref, ok := lc.LibRef.(LibRefRef); !ok || !bytes.Equal(ref.Library.Hash(), lib.Hash()) {
+		t.Fatal("incorrect library ref")
+	}
+
+	c2, err := ToCell(loaded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(c2.Hash(), c.Hash()) {
+		t.Fatal("reserialized list is different")
+	}
+
+	phase := ActionPhase{ActionListHash: c.Hash()}
+	if !phase.MatchesOutList(c) || phase.MatchesOutList(nil) {
+		t.Fatal("incorrect action list match")
+	}
+
+	var empty OutList
+	if err = LoadFromCell(&empty, cell.BeginCell().EndCell().BeginParse()); err != nil || len(empty.Actions) != 0 {
+		t.Fatal("empty list should be loaded", err)
+	}
+}
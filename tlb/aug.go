@@ -0,0 +1,141 @@
+package tlb
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+// CurrencyCollectionAugExtra - extra of dictionaries augmented with CurrencyCollection,
+// like ShardAccountBlocks, AccountBlock transactions and OutMsgDescr
+var CurrencyCollectionAugExtra = cell.AugDictExtra{
+	Skip:  skipCurrencyCollection,
+	Merge: mergeAugExtra(addCurrencyCollections),
+	Empty: cell.BeginCell().MustStoreBigCoins(big.NewInt(0)).MustStoreMaybeRef(nil).EndCell(),
+}
+
+// ImportFeesAugExtra - extra of InMsgDescr
+var ImportFeesAugExtra = cell.AugDictExtra{
+	Skip: func(s *cell.Slice) error {
+		if _, err := s.LoadBigCoins(); err != nil {
+			return fmt.Errorf("failed to load fees collected: %w", err)
+		}
+		return skipCurrencyCollection(s)
+	},
+	Merge: mergeAugExtra(func(b *cell.Builder, left, right *cell.Slice) error {
+		if err := addCoins(b, left, right); err != nil {
+			return fmt.Errorf("failed to sum fees collected: %w", err)
+		}
+		return addCurrencyCollections(b, left, right)
+	}),
+	Empty: cell.BeginCell().MustStoreBigCoins(big.NewInt(0)).
+		MustStoreBigCoins(big.NewInt(0)).MustStoreMaybeRef(nil).EndCell(),
+}
+
+// DepthBalanceInfoAugExtra - extra of ShardAccounts
+var DepthBalanceInfoAugExtra = cell.AugDictExtra{
+	Skip: func(s *cell.Slice) error {
+		if _, err := s.LoadUInt(5); err != nil {
+			return fmt.Errorf("failed to load split depth: %w", err)
+		}
+		return skipCurrencyCollection(s)
+	},
+	Merge: mergeAugExtra(func(b *cell.Builder, left, right *cell.Slice) error {
+		l, err := left.LoadUInt(5)
+		if err != nil {
+			return fmt.Errorf("failed to load left split depth: %w", err)
+		}
+		r, err := right.LoadUInt(5)
+		if err != nil {
+			return fmt.Errorf("failed to load right split depth: %w", err)
+		}
+		if err = b.StoreUInt(max(l, r), 5); err != nil {
+			return err
+		}
+		return addCurrencyCollections(b, left, right)
+	}),
+	Empty: cell.BeginCell().MustStoreUInt(0, 5).
+		MustStoreBigCoins(big.NewInt(0)).MustStoreMaybeRef(nil).EndCell(),
+}
+
+func mergeAugExtra(add func(b *cell.Builder, left, right *cell.Slice) error) func(left, right *cell.Slice) (*cell.Cell, error) {
+	return func(left, right *cell.Slice) (*cell.Cell, error) {
+		b := cell.BeginCell()
+		if err := add(b, left.Copy(), right.Copy()); err != nil {
+			return nil, err
+		}
+		return b.EndCell(), nil
+	}
+}
+
+func skipCurrencyCollection(s *cell.Slice) error {
+	if _, err := s.LoadBigCoins(); err != nil {
+		return fmt.Errorf("failed to load coins: %w", err)
+	}
+	if _, err := s.LoadMaybeRef(); err != nil {
+		return fmt.Errorf("failed to load extra currencies: %w", err)
+	}
+	return nil
+}
+
+func addCoins(b *cell.Builder, left, right *cell.Slice) error {
+	l, err := left.LoadBigCoins()
+	if err != nil {
+		return fmt.Errorf("failed to load left coins: %w", err)
+	}
+	r, err := right.LoadBigCoins()
+	if err != nil {
+		return fmt.Errorf("failed to load right coins: %w", err)
+	}
+	return b.StoreBigCoins(l.Add(l, r))
+}
+
+func addCurrencyCollections(b *cell.Builder, left, right *cell.Slice) error {
+	if err := addCoins(b, left, right); err != nil {
+		return err
+	}
+
+	l, err := left.LoadDict(32)
+	if err != nil {
+		return fmt.Errorf("failed to load left extra currencies: %w", err)
+	}
+	r, err := right.LoadDict(32)
+	if err != nil {
+		return fmt.Errorf("failed to load right extra currencies: %w", err)
+	}
+
+	if r.IsEmpty() {
+		return b.StoreDict(l)
+	}
+	if l.IsEmpty() {
+		return b.StoreDict(r)
+	}
+
+	sum := l.Copy()
+	kvs, err := r.LoadAll()
+	if err != nil {
+		return fmt.Errorf("failed to load extra currencies: %w", err)
+	}
+
+	for _, kv := range kvs {
+		key := kv.Key.MustToCell()
+		amount, err := kv.Value.LoadVarUInt(32)
+		if err != nil {
+			return fmt.Errorf("failed to load extra currency amount: %w", err)
+		}
+
+		if v, err := sum.LoadValue(key); err == nil {
+			cur, err := v.LoadVarUInt(32)
+			if err != nil {
+				return fmt.Errorf("failed to load extra currency amount: %w", err)
+			}
+			amount.Add(amount, cur)
+		}
+
+		if err = sum.Set(key, cell.BeginCell().MustStoreBigVarUInt(amount, 32).EndCell()); err != nil {
+			return fmt.Errorf("failed to set extra currency: %w", err)
+		}
+	}
+	return b.StoreDict(sum)
+}
@@ -0,0 +1,52 @@
+package tlb
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+func TestCurrencyCollectionAugExtra(t *testing.T) {
+	extra := func(ton string, id, amount int64) *cell.Cell {
+		cc := CurrencyCollection{Coins: MustFromTON(ton)}
+		if amount > 0 {
+			cc.ExtraCurrencies = cell.NewDict(32)
+			_ = cc.ExtraCurrencies.SetIntKey(big.NewInt(id), cell.BeginCell().MustStoreBigVarUInt(big.NewInt(amount), 32).EndCell())
+		}
+		c, err := ToCell(cc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+
+	d := cell.NewAugDict(256, CurrencyCollectionAugExtra)
+	for i, e := range []*cell.Cell{extra("1", 0, 0), extra("0.5", 7, 100), extra("2", 7, 50), extra("0.25", 9, 1)} {
+		if err := d.SetIntKey(big.NewInt(int64(i)), cell.BeginCell().EndCell(), e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	root, err := d.RootExtra()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var total CurrencyCollection
+	if err = LoadFromCell(&total, root); err != nil {
+		t.Fatal(err)
+	}
+	if total.Coins.String() != "3.75" {
+		t.Fatal("incorrect total coins", total.Coins.String())
+	}
+
+	v, err := total.ExtraCurrencies.LoadValueByIntKey(big.NewInt(7))
+	if err != nil || v.MustLoadVarUInt(32).Uint64() != 150 {
+		t.Fatal("incorrect extra currency 7 total", err)
+	}
+	v, err = total.ExtraCurrencies.LoadValueByIntKey(big.NewInt(9))
+	if err != nil || v.MustLoadVarUInt(32).Uint64() != 1 {
+		t.Fatal("incorrect extra currency 9 total", err)
+	}
+}
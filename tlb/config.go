@@ -1,6 +1,10 @@
 package tlb
 
-import "github.com/xssnick/tonutils-go/tvm/cell"
+import (
+	"fmt"
+
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
 
 func init() {
 	Register(ValidatorSet{})
@@ -13,6 +17,14 @@ func init() {
 	Register(ConsensusConfigV2{})
 	Register(ConsensusConfigV3{})
 	Register(ConsensusConfigV4{})
+
+	Register(WorkchainDescrV1{})
+	Register(WorkchainDescrV2{})
+	Register(WorkchainFormatBasic{})
+	Register(WorkchainFormatExt{})
+
+	Register(BlockLimitsV1{})
+	Register(BlockLimitsV2{})
 }
 
 type ValidatorSetAny struct {
@@ -138,3 +150,294 @@ type ConsensusConfigV4 struct {
 	ProtoVersion          uint16 `tlb:"## 16"`
 	CatchainMaxBlocksCoff uint32 `tlb:"## 32"`
 }
+
+// ConfigAddress - address of special masterchain contract, used in params 0, 1 and 2
+type ConfigAddress struct {
+	Addr []byte `tlb:"bits 256"`
+}
+
+// BurningConfig - param 5
+type BurningConfig struct {
+	_             Magic  `tlb:"#01"`
+	BlackholeAddr []byte `tlb:"maybe bits 256"`
+	FeeBurnNum    uint32 `tlb:"## 32"`
+	FeeBurnDenom  uint32 `tlb:"## 32"`
+}
+
+// WorkchainDescr - value of param 12 dictionary
+type WorkchainDescr struct {
+	Descr any `tlb:"[WorkchainDescrV1,WorkchainDescrV2]"`
+}
+
+type WorkchainDescrV1 struct {
+	_                 Magic           `tlb:"#a6"`
+	EnabledSince      uint32          `tlb:"## 32"`
+	MonitorMinSplit   uint8           `tlb:"## 8"`
+	MinSplit          uint8           `tlb:"## 8"`
+	MaxSplit          uint8           `tlb:"## 8"`
+	Basic             bool            `tlb:"bool"`
+	Active            bool            `tlb:"bool"`
+	AcceptMsgs        bool            `tlb:"bool"`
+	Flags             uint16          `tlb:"## 13"`
+	ZeroStateRootHash []byte          `tlb:"bits 256"`
+	ZeroStateFileHash []byte          `tlb:"bits 256"`
+	Version           uint32          `tlb:"## 32"`
+	Format            WorkchainFormat `tlb:"."`
+}
+
+type WorkchainDescrV2 struct {
+	_                 Magic               `tlb:"#a7"`
+	EnabledSince      uint32              `tlb:"## 32"`
+	MonitorMinSplit   uint8               `tlb:"## 8"`
+	MinSplit          uint8               `tlb:"## 8"`
+	MaxSplit          uint8               `tlb:"## 8"`
+	Basic             bool                `tlb:"bool"`
+	Active            bool                `tlb:"bool"`
+	AcceptMsgs        bool                `tlb:"bool"`
+	Flags             uint16              `tlb:"## 13"`
+	ZeroStateRootHash []byte              `tlb:"bits 256"`
+	ZeroStateFileHash []byte              `tlb:"bits 256"`
+	Version           uint32              `tlb:"## 32"`
+	Format            WorkchainFormat     `tlb:"."`
+	SplitMergeTimings WcSplitMergeTimings `tlb:"."`
+}
+
+type WorkchainFormat struct {
+	Format any `tlb:"[WorkchainFormatBasic,WorkchainFormatExt]"`
+}
+
+type WorkchainFormatBasic struct {
+	_         Magic  `tlb:"#1"`
+	VMVersion int32  `tlb:"## 32"`
+	VMMode    uint64 `tlb:"## 64"`
+}
+
+type WorkchainFormatExt struct {
+	_               Magic  `tlb:"#0"`
+	MinAddrLen      uint16 `tlb:"## 12"`
+	MaxAddrLen      uint16 `tlb:"## 12"`
+	AddrLenStep     uint16 `tlb:"## 12"`
+	WorkchainTypeID uint32 `tlb:"## 32"`
+}
+
+type WcSplitMergeTimings struct {
+	_                     Magic  `tlb:"#0"`
+	SplitMergeDelay       uint32 `tlb:"## 32"`
+	SplitMergeInterval    uint32 `tlb:"## 32"`
+	MinSplitMergeInterval uint32 `tlb:"## 32"`
+	MaxSplitMergeDelay    uint32 `tlb:"## 32"`
+}
+
+// ElectionTimings - param 15
+type ElectionTimings struct {
+	ValidatorsElectedFor uint32 `tlb:"## 32"`
+	ElectionsStartBefore uint32 `tlb:"## 32"`
+	ElectionsEndBefore   uint32 `tlb:"## 32"`
+	StakeHeldFor         uint32 `tlb:"## 32"`
+}
+
+// ValidatorsCount - param 16
+type ValidatorsCount struct {
+	MaxValidators     uint16 `tlb:"## 16"`
+	MaxMainValidators uint16 `tlb:"## 16"`
+	MinValidators     uint16 `tlb:"## 16"`
+}
+
+// ValidatorStakes - param 17
+type ValidatorStakes struct {
+	MinStake       Coins  `tlb:"."`
+	MaxStake       Coins  `tlb:"."`
+	MinTotalStake  Coins  `tlb:"."`
+	MaxStakeFactor uint32 `tlb:"## 32"`
+}
+
+// StoragePrices - value of param 18 dictionary, prices are per second and multiplied by 2^16
+type StoragePrices struct {
+	_             Magic  `tlb:"#cc"`
+	UTimeSince    uint32 `tlb:"## 32"`
+	BitPricePS    uint64 `tlb:"## 64"`
+	CellPricePS   uint64 `tlb:"## 64"`
+	MCBitPricePS  uint64 `tlb:"## 64"`
+	MCCellPricePS uint64 `tlb:"## 64"`
+}
+
+// GasLimitsPrices - params 20 and 21, gas price is multiplied by 2^16.
+// Flat values are zero when config has no gas_flat_pfx.
+type GasLimitsPrices struct {
+	FlatGasLimit    uint64
+	FlatGasPrice    uint64
+	GasPrice        uint64
+	GasLimit        uint64
+	SpecialGasLimit uint64
+	GasCredit       uint64
+	BlockGasLimit   uint64
+	FreezeDueLimit  uint64
+	DeleteDueLimit  uint64
+}
+
+// ParamLimits - underload, soft and hard limits of block parameter
+type ParamLimits struct {
+	_         Magic  `tlb:"#c3"`
+	Underload uint32 `tlb:"## 32"`
+	SoftLimit uint32 `tlb:"## 32"`
+	HardLimit uint32 `tlb:"## 32"`
+}
+
+// BlockLimits - params 22 and 23
+type BlockLimits struct {
+	Limits any `tlb:"[BlockLimitsV1,BlockLimitsV2]"`
+}
+
+type BlockLimitsV1 struct {
+	_       Magic       `tlb:"#5d"`
+	Bytes   ParamLimits `tlb:"."`
+	Gas     ParamLimits `tlb:"."`
+	LtDelta ParamLimits `tlb:"."`
+}
+
+type BlockLimitsV2 struct {
+	_                Magic                  `tlb:"#5e"`
+	Bytes            ParamLimits            `tlb:"."`
+	Gas              ParamLimits            `tlb:"."`
+	LtDelta          ParamLimits            `tlb:"."`
+	CollatedData     ParamLimits            `tlb:"."`
+	ImportedMsgQueue ImportedMsgQueueLimits `tlb:"."`
+}
+
+type ImportedMsgQueueLimits struct {
+	_        Magic  `tlb:"#d3"`
+	MaxBytes uint32 `tlb:"## 32"`
+	MaxMsgs  uint32 `tlb:"## 32"`
+}
+
+// MsgForwardPrices - params 24 and 25, bit and cell prices are multiplied by 2^16
+type MsgForwardPrices struct {
+	_              Magic  `tlb:"#ea"`
+	LumpPrice      uint64 `tlb:"## 64"`
+	BitPrice       uint64 `tlb:"## 64"`
+	CellPrice      uint64 `tlb:"## 64"`
+	IHRPriceFactor uint32 `tlb:"## 32"`
+	FirstFrac      uint16 `tlb:"## 16"`
+	NextFrac       uint16 `tlb:"## 16"`
+}
+
+// SizeLimitsConfig - param 43, fields after MaxExtMsgDepth are present only in v2,
+// fields added by later revisions of v2 are zero when config does not have them yet
+type SizeLimitsConfig struct {
+	MaxMsgBits              uint32
+	MaxMsgCells             uint32
+	MaxLibraryCells         uint32
+	MaxVMDataDepth          uint16
+	MaxExtMsgSize           uint32
+	MaxExtMsgDepth          uint16
+	MaxAccStateCells        uint32
+	MaxAccStateBits         uint32
+	MaxAccPublicLibraries   uint32
+	DeferOutQueueSizeLimit  uint32
+	MaxMsgExtraCurrencies   uint32
+	MaxAccFixedPrefixLength uint8
+}
+
+func (g *GasLimitsPrices) LoadFromCell(loader *cell.Slice) error {
+	var res GasLimitsPrices
+	for {
+		tag, err := loader.LoadUInt(8)
+		if err != nil {
+			return fmt.Errorf("failed to load tag: %w", err)
+		}
+
+		switch tag {
+		case 0xd1:
+			if res.FlatGasLimit, err = loader.LoadUInt(64); err != nil {
+				return fmt.Errorf("failed to load flat gas limit: %w", err)
+			}
+			if res.FlatGasPrice, err = loader.LoadUInt(64); err != nil {
+				return fmt.Errorf("failed to load flat gas price: %w", err)
+			}
+			continue
+		case 0xdd, 0xde:
+			fields := []*uint64{&res.GasPrice, &res.GasLimit, &res.SpecialGasLimit, &res.GasCredit,
+				&res.BlockGasLimit, &res.FreezeDueLimit, &res.DeleteDueLimit}
+			if tag == 0xdd {
+				// no special gas limit in old format
+				fields = append(fields[:2], fields[3:]...)
+			}
+
+			for _, f := range fields {
+				if *f, err = loader.LoadUInt(64); err != nil {
+					return fmt.Errorf("failed to load gas prices: %w", err)
+				}
+			}
+			if tag == 0xdd {
+				res.SpecialGasLimit = res.GasLimit
+			}
+			*g = res
+			return nil
+		default:
+			return fmt.Errorf("unknown gas prices tag %x", tag)
+		}
+	}
+}
+
+func (g GasLimitsPrices) ToCell() (*cell.Cell, error) {
+	b := cell.BeginCell()
+	if g.FlatGasLimit != 0 || g.FlatGasPrice != 0 {
+		b.MustStoreUInt(0xd1, 8).MustStoreUInt(g.FlatGasLimit, 64).MustStoreUInt(g.FlatGasPrice, 64)
+	}
+	return b.MustStoreUInt(0xde, 8).MustStoreUInt(g.GasPrice, 64).MustStoreUInt(g.GasLimit, 64).
+		MustStoreUInt(g.SpecialGasLimit, 64).MustStoreUInt(g.GasCredit, 64).MustStoreUInt(g.BlockGasLimit, 64).
+		MustStoreUInt(g.FreezeDueLimit, 64).MustStoreUInt(g.DeleteDueLimit, 64).EndCell(), nil
+}
+
+func (l *SizeLimitsConfig) LoadFromCell(loader *cell.Slice) error {
+	tag, err := loader.LoadUInt(8)
+	if err != nil {
+		return fmt.Errorf("failed to load tag: %w", err)
+	}
+	if tag != 0x01 && tag != 0x02 {
+		return fmt.Errorf("unknown size limits tag %x", tag)
+	}
+
+	sizes := []uint{32, 32, 32, 16, 32, 16, 32, 32, 32, 32, 32, 8}
+	if tag == 0x01 {
+		sizes = sizes[:6]
+	}
+
+	vals := make([]uint64, 12)
+	for i, sz := range sizes {
+		if i >= 6 && loader.BitsLeft() < sz {
+			// v2 was extended several times, so newer fields may be absent
+			break
+		}
+		if vals[i], err = loader.LoadUInt(sz); err != nil {
+			return fmt.Errorf("failed to load size limit %d: %w", i, err)
+		}
+	}
+
+	*l = SizeLimitsConfig{
+		MaxMsgBits:              uint32(vals[0]),
+		MaxMsgCells:             uint32(vals[1]),
+		MaxLibraryCells:         uint32(vals[2]),
+		MaxVMDataDepth:          uint16(vals[3]),
+		MaxExtMsgSize:           uint32(vals[4]),
+		MaxExtMsgDepth:          uint16(vals[5]),
+		MaxAccStateCells:        uint32(vals[6]),
+		MaxAccStateBits:         uint32(vals[7]),
+		MaxAccPublicLibraries:   uint32(vals[8]),
+		DeferOutQueueSizeLimit:  uint32(vals[9]),
+		MaxMsgExtraCurrencies:   uint32(vals[10]),
+		MaxAccFixedPrefixLength: uint8(vals[11]),
+	}
+	return nil
+}
+
+func (l SizeLimitsConfig) ToCell() (*cell.Cell, error) {
+	return cell.BeginCell().MustStoreUInt(0x02, 8).
+		MustStoreUInt(uint64(l.MaxMsgBits), 32).MustStoreUInt(uint64(l.MaxMsgCells), 32).
+		MustStoreUInt(uint64(l.MaxLibraryCells), 32).MustStoreUInt(uint64(l.MaxVMDataDepth), 16).
+		MustStoreUInt(uint64(l.MaxExtMsgSize), 32).MustStoreUInt(uint64(l.MaxExtMsgDepth), 16).
+		MustStoreUInt(uint64(l.MaxAccStateCells), 32).MustStoreUInt(uint64(l.MaxAccStateBits), 32).
+		MustStoreUInt(uint64(l.MaxAccPublicLibraries), 32).MustStoreUInt(uint64(l.DeferOutQueueSizeLimit), 32).
+		MustStoreUInt(uint64(l.MaxMsgExtraCurrencies), 32).MustStoreUInt(uint64(l.MaxAccFixedPrefixLength), 8).
+		EndCell(), nil
+}
@@ -0,0 +1,117 @@
+package tlb
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+func TestGasLimitsPrices(t *testing.T) {
+	// basechain prices from mainnet config
+	c := cell.BeginCell().MustStoreUInt(0xd1, 8).MustStoreUInt(100, 64).MustStoreUInt(40000, 64).
+		MustStoreUInt(0xde, 8).MustStoreUInt(26214400, 64).MustStoreUInt(1000000, 64).MustStoreUInt(1000000, 64).
+		MustStoreUInt(10000, 64).MustStoreUInt(10000000, 64).MustStoreUInt(100000000, 64).MustStoreUInt(1000000000, 64).
+		EndCell()
+
+	var prices GasLimitsPrices
+	if err := LoadFromCell(&prices, c.BeginParse()); err != nil {
+		t.Fatal(err)
+	}
+	if prices.FlatGasLimit != 100 || prices.FlatGasPrice != 40000 || prices.GasPrice != 26214400 ||
+		prices.SpecialGasLimit != 1000000 || prices.GasCredit != 10000 || prices.DeleteDueLimit != 1000000000 {
+		t.Fatal("incorrect prices", prices)
+	}
+
+	c2, err := ToCell(prices)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(c2.Hash(), c.Hash()) {
+		t.Fatal("reserialized prices are different")
+	}
+
+	old := cell.BeginCell().MustStoreUInt(0xdd, 8).MustStoreUInt(1, 64).MustStoreUInt(2, 64).
+		MustStoreUInt(3, 64).MustStoreUInt(4, 64).MustStoreUInt(5, 64).MustStoreUInt(6, 64).EndCell()
+	if err = LoadFromCell(&prices, old.BeginParse()); err != nil {
+		t.Fatal(err)
+	}
+	if prices.FlatGasLimit != 0 || prices.GasLimit != 2 || prices.SpecialGasLimit != 2 || prices.GasCredit != 3 || prices.DeleteDueLimit != 6 {
+		t.Fatal("incorrect old prices", prices)
+	}
+}
+
+func TestSizeLimitsConfig(t *testing.T) {
+	v1 := cell.BeginCell().MustStoreUInt(0x01, 8).MustStoreUInt(1<<21, 32).MustStoreUInt(1<<13, 32).
+		MustStoreUInt(1000, 32).MustStoreUInt(512, 16).MustStoreUInt(65535, 32).MustStoreUInt(512, 16).EndCell()
+
+	var limits SizeLimitsConfig
+	if err := LoadFromCell(&limits, v1.BeginParse()); err != nil {
+		t.Fatal(err)
+	}
+	if limits.MaxMsgBits != 1<<21 || limits.MaxVMDataDepth != 512 || limits.MaxExtMsgSize != 65535 || limits.MaxAccStateCells != 0 {
+		t.Fatal("incorrect v1 limits", limits)
+	}
+
+	// v2 without fields which were added later
+	v2 := cell.BeginCell().MustStoreUInt(0x02, 8).MustStoreUInt(1<<21, 32).MustStoreUInt(1<<13, 32).
+		MustStoreUInt(1000, 32).MustStoreUInt(512, 16).MustStoreUInt(65535, 32).MustStoreUInt(512, 16).
+		MustStoreUInt(1<<16, 32).MustStoreUInt(1<<25, 32).MustStoreUInt(256, 32).EndCell()
+	if err := LoadFromCell(&limits, v2.BeginParse()); err != nil {
+		t.Fatal(err)
+	}
+	if limits.MaxAccStateCells != 1<<16 || limits.MaxAccPublicLibraries != 256 || limits.DeferOutQueueSizeLimit != 0 {
+		t.Fatal("incorrect v2 limits", limits)
+	}
+
+	limits.MaxAccFixedPrefixLength = 8
+	c, err := ToCell(limits)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var limits2 SizeLimitsConfig
+	if err = LoadFromCell(&limits2, c.BeginParse()); err != nil {
+		t.Fatal(err)
+	}
+	if limits2 != limits {
+		t.Fatal("incorrect reloaded limits", limits2)
+	}
+}
+
+func TestWorkchainDescr(t *testing.T) {
+	descr := WorkchainDescr{Descr: WorkchainDescrV2{
+		EnabledSince:      1573821854,
+		MonitorMinSplit:   2,
+		MinSplit:          2,
+		MaxSplit:          8,
+		Basic:             true,
+		Active:            true,
+		AcceptMsgs:        true,
+		ZeroStateRootHash: make([]byte, 32),
+		ZeroStateFileHash: make([]byte, 32),
+		Format:            WorkchainFormat{Format: WorkchainFormatBasic{VMVersion: -1, VMMode: 0}},
+		SplitMergeTimings: WcSplitMergeTimings{SplitMergeDelay: 100, SplitMergeInterval: 100, MinSplitMergeInterval: 30, MaxSplitMergeDelay: 1000},
+	}}
+
+	c, err := ToCell(descr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.BeginParse().MustLoadUInt(8) != 0xa7 {
+		t.Fatal("incorrect tag")
+	}
+
+	var descr2 WorkchainDescr
+	if err = LoadFromCell(&descr2, c.BeginParse()); err != nil {
+		t.Fatal(err)
+	}
+
+	v2, ok := descr2.Descr.(WorkchainDescrV2)
+	if !ok || v2.MaxSplit != 8 || !v2.AcceptMsgs || v2.SplitMergeTimings.MaxSplitMergeDelay != 1000 {
+		t.Fatal("incorrect descr")
+	}
+	if f, ok := v2.Format.Format.(WorkchainFormatBasic); !ok || f.VMVersion != -1 {
+		t.Fatal("incorrect format")
+	}
+}
@@ -0,0 +1,599 @@
+package tlb
+
+import (
+	"fmt"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+func init() {
+	Register(InMsgImportExt{})
+	Register(InMsgImportIHR{})
+	Register(InMsgImportImm{})
+	Register(InMsgImportFin{})
+	Register(InMsgImportTr{})
+	Register(InMsgDiscardFin{})
+	Register(InMsgDiscardTr{})
+	Register(InMsgImportDeferredFin{})
+	Register(InMsgImportDeferredTr{})
+
+	Register(OutMsgExportExt{})
+	Register(OutMsgExportImm{})
+	Register(OutMsgExportNew{})
+	Register(OutMsgExportTr{})
+	Register(OutMsgExportDeq{})
+	Register(OutMsgExportDeqShort{})
+	Register(OutMsgExportTrReq{})
+	Register(OutMsgExportDeqImm{})
+	Register(OutMsgExportNewDefer{})
+	Register(OutMsgExportDeferredTr{})
+}
+
+type IntermediateAddressType string
+
+const (
+	IntermediateAddressRegular IntermediateAddressType = "REGULAR"
+	IntermediateAddressSimple  IntermediateAddressType = "SIMPLE"
+	IntermediateAddressExt     IntermediateAddressType = "EXT"
+)
+
+// IntermediateAddress - position of message during hypercube routing
+type IntermediateAddress struct {
+	Type IntermediateAddressType
+	// UseDestBits - for regular type, number of destination address bits already used in routing
+	UseDestBits uint8
+	// Workchain and AddrPrefix - for simple and ext types
+	Workchain  int32
+	AddrPrefix uint64
+}
+
+type MsgMetadata struct {
+	_             Magic            `tlb:"#0"`
+	Depth         uint32           `tlb:"## 32"`
+	InitiatorAddr *address.Address `tlb:"addr"`
+	InitiatorLT   uint64           `tlb:"## 64"`
+}
+
+// MsgEnvelope - message with its routing info, v2 envelopes have EmittedLT and Metadata
+type MsgEnvelope struct {
+	CurrentAddr     IntermediateAddress
+	NextAddr        IntermediateAddress
+	FwdFeeRemaining Coins
+	Msg             *Message
+
+	EmittedLT *uint64
+	Metadata  *MsgMetadata
+}
+
+type InMsgImportExt struct {
+	_           Magic        `tlb:"$000"`
+	Msg         *Message     `tlb:"^"`
+	Transaction *Transaction `tlb:"^"`
+}
+
+type InMsgImportIHR struct {
+	_            Magic        `tlb:"$010"`
+	Msg          *Message     `tlb:"^"`
+	Transaction  *Transaction `tlb:"^"`
+	IHRFee       Coins        `tlb:"."`
+	ProofCreated *cell.Cell   `tlb:"^"`
+}
+
+type InMsgImportImm struct {
+	_           Magic        `tlb:"$011"`
+	InMsg       *MsgEnvelope `tlb:"^"`
+	Transaction *Transaction `tlb:"^"`
+	FwdFee      Coins        `tlb:"."`
+}
+
+type InMsgImportFin struct {
+	_           Magic        `tlb:"$100"`
+	InMsg       *MsgEnvelope `tlb:"^"`
+	Transaction *Transaction `tlb:"^"`
+	FwdFee      Coins        `tlb:"."`
+}
+
+type InMsgImportTr struct {
+	_          Magic        `tlb:"$101"`
+	InMsg      *MsgEnvelope `tlb:"^"`
+	OutMsg     *MsgEnvelope `tlb:"^"`
+	TransitFee Coins        `tlb:"."`
+}
+
+type InMsgDiscardFin struct {
+	_             Magic        `tlb:"$110"`
+	InMsg         *MsgEnvelope `tlb:"^"`
+	TransactionID uint64       `tlb:"## 64"`
+	FwdFee        Coins        `tlb:"."`
+}
+
+type InMsgDiscardTr struct {
+	_              Magic        `tlb:"$111"`
+	InMsg          *MsgEnvelope `tlb:"^"`
+	TransactionID  uint64       `tlb:"## 64"`
+	FwdFee         Coins        `tlb:"."`
+	ProofDelivered *cell.Cell   `tlb:"^"`
+}
+
+type InMsgImportDeferredFin struct {
+	_           Magic        `tlb:"$00100"`
+	InMsg       *MsgEnvelope `tlb:"^"`
+	Transaction *Transaction `tlb:"^"`
+	FwdFee      Coins        `tlb:"."`
+}
+
+type InMsgImportDeferredTr struct {
+	_      Magic        `tlb:"$00101"`
+	InMsg  *MsgEnvelope `tlb:"^"`
+	OutMsg *MsgEnvelope `tlb:"^"`
+}
+
+type InMsg struct {
+	Msg any `tlb:"[InMsgImportExt,InMsgImportIHR,InMsgImportImm,InMsgImportFin,InMsgImportTr,InMsgDiscardFin,InMsgDiscardTr,InMsgImportDeferredFin,InMsgImportDeferredTr]"`
+}
+
+type OutMsgExportExt struct {
+	_           Magic        `tlb:"$000"`
+	Msg         *Message     `tlb:"^"`
+	Transaction *Transaction `tlb:"^"`
+}
+
+type OutMsgExportImm struct {
+	_           Magic        `tlb:"$010"`
+	OutMsg      *MsgEnvelope `tlb:"^"`
+	Transaction *Transaction `tlb:"^"`
+	Reimport    *InMsg       `tlb:"^"`
+}
+
+type OutMsgExportNew struct {
+	_           Magic        `tlb:"$001"`
+	OutMsg      *MsgEnvelope `tlb:"^"`
+	Transaction *Transaction `tlb:"^"`
+}
+
+type OutMsgExportTr struct {
+	_        Magic        `tlb:"$011"`
+	OutMsg   *MsgEnvelope `tlb:"^"`
+	Imported *InMsg       `tlb:"^"`
+}
+
+type OutMsgExportDeq struct {
+	_             Magic        `tlb:"$1100"`
+	OutMsg        *MsgEnvelope `tlb:"^"`
+	ImportBlockLT uint64       `tlb:"## 63"`
+}
+
+type OutMsgExportDeqShort struct {
+	_             Magic  `tlb:"$1101"`
+	MsgEnvHash    []byte `tlb:"bits 256"`
+	NextWorkchain int32  `tlb:"## 32"`
+	NextAddrPfx   uint64 `tlb:"## 64"`
+	ImportBlockLT uint64 `tlb:"## 64"`
+}
+
+type OutMsgExportTrReq struct {
+	_        Magic        `tlb:"$111"`
+	OutMsg   *MsgEnvelope `tlb:"^"`
+	Imported *InMsg       `tlb:"^"`
+}
+
+type OutMsgExportDeqImm struct {
+	_        Magic        `tlb:"$100"`
+	OutMsg   *MsgEnvelope `tlb:"^"`
+	Reimport *InMsg       `tlb:"^"`
+}
+
+type OutMsgExportNewDefer struct {
+	_           Magic        `tlb:"$10100"`
+	OutMsg      *MsgEnvelope `tlb:"^"`
+	Transaction *Transaction `tlb:"^"`
+}
+
+type OutMsgExportDeferredTr struct {
+	_        Magic        `tlb:"$10101"`
+	OutMsg   *MsgEnvelope `tlb:"^"`
+	Imported *InMsg       `tlb:"^"`
+}
+
+type OutMsg struct {
+	Msg any `tlb:"[OutMsgExportExt,OutMsgExportImm,OutMsgExportNew,OutMsgExportTr,OutMsgExportDeq,OutMsgExportDeqShort,OutMsgExportTrReq,OutMsgExportDeqImm,OutMsgExportNewDefer,OutMsgExportDeferredTr]"`
+}
+
+type ImportFees struct {
+	FeesCollected Coins              `tlb:"."`
+	ValueImported CurrencyCollection `tlb:"."`
+}
+
+type InMsgDescrItem struct {
+	MsgHash []byte
+	Fees    ImportFees
+	InMsg   InMsg
+}
+
+type OutMsgDescrItem struct {
+	MsgHash  []byte
+	Exported CurrencyCollection
+	OutMsg   OutMsg
+}
+
+// InMsgDescr - inbound messages of block, keyed by message hash
+type InMsgDescr struct {
+	Items []InMsgDescrItem
+	// Total - sum of fees and imported value of all messages
+	Total ImportFees
+}
+
+// OutMsgDescr - outbound messages of block, keyed by message hash
+type OutMsgDescr struct {
+	Items []OutMsgDescrItem
+	// Total - sum of exported value of all messages
+	Total CurrencyCollection
+}
+
+func (a *IntermediateAddress) LoadFromCell(loader *cell.Slice) error {
+	isSimple, err := loader.LoadBoolBit()
+	if err != nil {
+		return fmt.Errorf("failed to load type: %w", err)
+	}
+
+	if !isSimple {
+		bits, err := loader.LoadUInt(7)
+		if err != nil {
+			return fmt.Errorf("failed to load use dest bits: %w", err)
+		}
+		if bits > 96 {
+			return fmt.Errorf("use dest bits should be <= 96, got %d", bits)
+		}
+		*a = IntermediateAddress{Type: IntermediateAddressRegular, UseDestBits: uint8(bits)}
+		return nil
+	}
+
+	isExt, err := loader.LoadBoolBit()
+	if err != nil {
+		return fmt.Errorf("failed to load type: %w", err)
+	}
+
+	wcSz, typ := uint(8), IntermediateAddressSimple
+	if isExt {
+		wcSz, typ = 32, IntermediateAddressExt
+	}
+
+	wc, err := loader.LoadInt(wcSz)
+	if err != nil {
+		return fmt.Errorf("failed to load workchain: %w", err)
+	}
+
+	pfx, err := loader.LoadUInt(64)
+	if err != nil {
+		return fmt.Errorf("failed to load address prefix: %w", err)
+	}
+
+	*a = IntermediateAddress{Type: typ, Workchain: int32(wc), AddrPrefix: pfx}
+	return nil
+}
+
+func (a IntermediateAddress) ToCell() (*cell.Cell, error) {
+	switch a.Type {
+	case IntermediateAddressRegular:
+		if a.UseDestBits > 96 {
+			return nil, fmt.Errorf("use dest bits should be <= 96, got %d", a.UseDestBits)
+		}
+		return cell.BeginCell().MustStoreUInt(0, 1).MustStoreUInt(uint64(a.UseDestBits), 7).EndCell(), nil
+	case IntermediateAddressSimple:
+		return cell.BeginCell().MustStoreUInt(0b10, 2).MustStoreInt(int64(a.Workchain), 8).
+			MustStoreUInt(a.AddrPrefix, 64).EndCell(), nil
+	case IntermediateAddressExt:
+		return cell.BeginCell().MustStoreUInt(0b11, 2).MustStoreInt(int64(a.Workchain), 32).
+			MustStoreUInt(a.AddrPrefix, 64).EndCell(), nil
+	}
+	return nil, fmt.Errorf("unknown intermediate address type %s", a.Type)
+}
+
+func (e *MsgEnvelope) LoadFromCell(loader *cell.Slice) error {
+	tag, err := loader.LoadUInt(4)
+	if err != nil {
+		return fmt.Errorf("failed to load tag: %w", err)
+	}
+	if tag != 4 && tag != 5 {
+		return fmt.Errorf("unknown msg envelope tag %d", tag)
+	}
+
+	var env MsgEnvelope
+	if err = env.CurrentAddr.LoadFromCell(loader); err != nil {
+		return fmt.Errorf("failed to load current address: %w", err)
+	}
+	if err = env.NextAddr.LoadFromCell(loader); err != nil {
+		return fmt.Errorf("failed to load next address: %w", err)
+	}
+	if err = LoadFromCell(&env.FwdFeeRemaining, loader); err != nil {
+		return fmt.Errorf("failed to load fwd fee remaining: %w", err)
+	}
+
+	msg, err := loader.LoadRef()
+	if err != nil {
+		return fmt.Errorf("failed to load message ref: %w", err)
+	}
+
+	env.Msg = &Message{}
+	if err = LoadFromCell(env.Msg, msg); err != nil {
+		return fmt.Errorf("failed to load message: %w", err)
+	}
+
+	if tag == 5 {
+		hasLT, err := loader.LoadBoolBit()
+		if err != nil {
+			return fmt.Errorf("failed to load emitted lt flag: %w", err)
+		}
+		if hasLT {
+			lt, err := loader.LoadUInt(64)
+			if err != nil {
+				return fmt.Errorf("failed to load emitted lt: %w", err)
+			}
+			env.EmittedLT = &lt
+		}
+
+		hasMeta, err := loader.LoadBoolBit()
+		if err != nil {
+			return fmt.Errorf("failed to load metadata flag: %w", err)
+		}
+		if hasMeta {
+			env.Metadata = &MsgMetadata{}
+			if err = LoadFromCell(env.Metadata, loader); err != nil {
+				return fmt.Errorf("failed to load metadata: %w", err)
+			}
+		}
+	}
+
+	*e = env
+	return nil
+}
+
+func (e MsgEnvelope) ToCell() (*cell.Cell, error) {
+	if e.Msg == nil {
+		return nil, fmt.Errorf("message is nil")
+	}
+
+	v2 := e.EmittedLT != nil || e.Metadata != nil
+
+	b := cell.BeginCell()
+	if v2 {
+		b.MustStoreUInt(5, 4)
+	} else {
+		b.MustStoreUInt(4, 4)
+	}
+
+	for _, a := range []IntermediateAddress{e.CurrentAddr, e.NextAddr} {
+		c, err := a.ToCell()
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize intermediate address: %w", err)
+		}
+		b.MustStoreBuilder(c.ToBuilder())
+	}
+
+	if err := b.StoreBigCoins(e.FwdFeeRemaining.Nano()); err != nil {
+		return nil, fmt.Errorf("failed to store fwd fee remaining: %w", err)
+	}
+
+	msg, err := ToCell(e.Msg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize message: %w", err)
+	}
+	b.MustStoreRef(msg)
+
+	if v2 {
+		b.MustStoreBoolBit(e.EmittedLT != nil)
+		if e.EmittedLT != nil {
+			b.MustStoreUInt(*e.EmittedLT, 64)
+		}
+
+		b.MustStoreBoolBit(e.Metadata != nil)
+		if e.Metadata != nil {
+			meta, err := ToCell(e.Metadata)
+			if err != nil {
+				return nil, fmt.Errorf("failed to serialize metadata: %w", err)
+			}
+			b.MustStoreBuilder(meta.ToBuilder())
+		}
+	}
+	return b.EndCell(), nil
+}
+
+// Envelope - returns inbound envelope of message, nil for external messages
+func (m *InMsg) Envelope() *MsgEnvelope {
+	switch v := m.Msg.(type) {
+	case InMsgImportImm:
+		return v.InMsg
+	case InMsgImportFin:
+		return v.InMsg
+	case InMsgImportTr:
+		return v.InMsg
+	case InMsgDiscardFin:
+		return v.InMsg
+	case InMsgDiscardTr:
+		return v.InMsg
+	case InMsgImportDeferredFin:
+		return v.InMsg
+	case InMsgImportDeferredTr:
+		return v.InMsg
+	}
+	return nil
+}
+
+// Message - returns imported message
+func (m *InMsg) Message() *Message {
+	switch v := m.Msg.(type) {
+	case InMsgImportExt:
+		return v.Msg
+	case InMsgImportIHR:
+		return v.Msg
+	}
+
+	if env := m.Envelope(); env != nil {
+		return env.Msg
+	}
+	return nil
+}
+
+// Transaction - returns transaction which processed the message, nil for transit and discarded messages
+func (m *InMsg) Transaction() *Transaction {
+	switch v := m.Msg.(type) {
+	case InMsgImportExt:
+		return v.Transaction
+	case InMsgImportIHR:
+		return v.Transaction
+	case InMsgImportImm:
+		return v.Transaction
+	case InMsgImportFin:
+		return v.Transaction
+	case InMsgImportDeferredFin:
+		return v.Transaction
+	}
+	return nil
+}
+
+// Envelope - returns outbound envelope of message, nil for external and short dequeue messages
+func (m *OutMsg) Envelope() *MsgEnvelope {
+	switch v := m.Msg.(type) {
+	case OutMsgExportImm:
+		return v.OutMsg
+	case OutMsgExportNew:
+		return v.OutMsg
+	case OutMsgExportTr:
+		return v.OutMsg
+	case OutMsgExportDeq:
+		return v.OutMsg
+	case OutMsgExportTrReq:
+		return v.OutMsg
+	case OutMsgExportDeqImm:
+		return v.OutMsg
+	case OutMsgExportNewDefer:
+		return v.OutMsg
+	case OutMsgExportDeferredTr:
+		return v.OutMsg
+	}
+	return nil
+}
+
+// Message - returns exported message, nil for short dequeue
+func (m *OutMsg) Message() *Message {
+	if v, ok := m.Msg.(OutMsgExportExt); ok {
+		return v.Msg
+	}
+
+	if env := m.Envelope(); env != nil {
+		return env.Msg
+	}
+	return nil
+}
+
+// Transaction - returns transaction which created the message, nil for transit and dequeued messages
+func (m *OutMsg) Transaction() *Transaction {
+	switch v := m.Msg.(type) {
+	case OutMsgExportExt:
+		return v.Transaction
+	case OutMsgExportImm:
+		return v.Transaction
+	case OutMsgExportNew:
+		return v.Transaction
+	case OutMsgExportNewDefer:
+		return v.Transaction
+	}
+	return nil
+}
+
+func (d *InMsgDescr) LoadFromCell(loader *cell.Slice) error {
+	dict, err := loader.LoadAugDict(256, ImportFeesAugExtra)
+	if err != nil {
+		return fmt.Errorf("failed to load dict: %w", err)
+	}
+
+	total, err := dict.RootExtra()
+	if err != nil {
+		return fmt.Errorf("failed to get total extra: %w", err)
+	}
+	if err = LoadFromCell(&d.Total, total); err != nil {
+		return fmt.Errorf("failed to load total import fees: %w", err)
+	}
+
+	kvs, err := dict.LoadAll()
+	if err != nil {
+		return fmt.Errorf("failed to load items: %w", err)
+	}
+
+	d.Items = make([]InMsgDescrItem, 0, len(kvs))
+	for i, kv := range kvs {
+		item := InMsgDescrItem{
+			MsgHash: kv.Key.MustLoadSlice(256),
+		}
+		if err = LoadFromCell(&item.Fees, kv.Extra); err != nil {
+			return fmt.Errorf("failed to load import fees of item %d: %w", i, err)
+		}
+		if err = LoadFromCell(&item.InMsg, kv.Value); err != nil {
+			return fmt.Errorf("failed to load in msg of item %d: %w", i, err)
+		}
+		d.Items = append(d.Items, item)
+	}
+	return nil
+}
+
+func (d *OutMsgDescr) LoadFromCell(loader *cell.Slice) error {
+	dict, err := loader.LoadAugDict(256, CurrencyCollectionAugExtra)
+	if err != nil {
+		return fmt.Errorf("failed to load dict: %w", err)
+	}
+
+	total, err := dict.RootExtra()
+	if err != nil {
+		return fmt.Errorf("failed to get total extra: %w", err)
+	}
+	if err = LoadFromCell(&d.Total, total); err != nil {
+		return fmt.Errorf("failed to load total exported value: %w", err)
+	}
+
+	kvs, err := dict.LoadAll()
+	if err != nil {
+		return fmt.Errorf("failed to load items: %w", err)
+	}
+
+	d.Items = make([]OutMsgDescrItem, 0, len(kvs))
+	for i, kv := range kvs {
+		item := OutMsgDescrItem{
+			MsgHash: kv.Key.MustLoadSlice(256),
+		}
+		if err = LoadFromCell(&item.Exported, kv.Extra); err != nil {
+			return fmt.Errorf("failed to load exported value of item %d: %w", i, err)
+		}
+		if err = LoadFromCell(&item.OutMsg, kv.Value); err != nil {
+			return fmt.Errorf("failed to load out msg of item %d: %w", i, err)
+		}
+		d.Items = append(d.Items, item)
+	}
+	return nil
+}
+
+// InMsgs - parses inbound messages of block
+func (e *BlockExtra) InMsgs() (*InMsgDescr, error) {
+	if e.InMsgDesc == nil {
+		return nil, fmt.Errorf("in msg descr is not present")
+	}
+
+	var descr InMsgDescr
+	if err := LoadFromCell(&descr, e.InMsgDesc.BeginParse()); err != nil {
+		return nil, fmt.Errorf("failed to parse in msg descr: %w", err)
+	}
+	return &descr, nil
+}
+
+// OutMsgs - parses outbound messages of block
+func (e *BlockExtra) OutMsgs() (*OutMsgDescr, error) {
+	if e.OutMsgDesc == nil {
+		return nil, fmt.Errorf("out msg descr is not present")
+	}
+
+	var descr OutMsgDescr
+	if err := LoadFromCell(&descr, e.OutMsgDesc.BeginParse()); err != nil {
+		return nil, fmt.Errorf("failed to parse out msg descr: %w", err)
+	}
+	return &descr, nil
+}
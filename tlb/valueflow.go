@@ -0,0 +1,141 @@
+package tlb
+
+import (
+	"fmt"
+
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+const (
+	valueFlowV1 = 0xb8e48dfb
+	valueFlowV2 = 0x3ebf98b7
+)
+
+// ValueFlow - global balance changes of block.
+// Burned is present only in v2 layout, when it is nil value flow is serialized as v1.
+type ValueFlow struct {
+	FromPrevBlk   CurrencyCollection
+	ToNextBlk     CurrencyCollection
+	Imported      CurrencyCollection
+	Exported      CurrencyCollection
+	FeesCollected CurrencyCollection
+	Burned        *CurrencyCollection
+	FeesImported  CurrencyCollection
+	Recovered     CurrencyCollection
+	Created       CurrencyCollection
+	Minted        CurrencyCollection
+}
+
+func (v *ValueFlow) LoadFromCell(loader *cell.Slice) error {
+	tag, err := loader.LoadUInt(32)
+	if err != nil {
+		return fmt.Errorf("failed to load tag: %w", err)
+	}
+	if tag != valueFlowV1 && tag != valueFlowV2 {
+		return fmt.Errorf("unknown value flow tag %x", tag)
+	}
+
+	balances, err := loader.LoadRef()
+	if err != nil {
+		return fmt.Errorf("failed to load balances ref: %w", err)
+	}
+
+	var flow ValueFlow
+	for _, f := range []struct {
+		name string
+		cc   *CurrencyCollection
+	}{
+		{"from prev blk", &flow.FromPrevBlk},
+		{"to next blk", &flow.ToNextBlk},
+		{"imported", &flow.Imported},
+		{"exported", &flow.Exported},
+	} {
+		if err = LoadFromCell(f.cc, balances); err != nil {
+			return fmt.Errorf("failed to load %s: %w", f.name, err)
+		}
+	}
+
+	if err = LoadFromCell(&flow.FeesCollected, loader); err != nil {
+		return fmt.Errorf("failed to load fees collected: %w", err)
+	}
+
+	if tag == valueFlowV2 {
+		flow.Burned = &CurrencyCollection{}
+		if err = LoadFromCell(flow.Burned, loader); err != nil {
+			return fmt.Errorf("failed to load burned: %w", err)
+		}
+	}
+
+	minting, err := loader.LoadRef()
+	if err != nil {
+		return fmt.Errorf("failed to load minting ref: %w", err)
+	}
+
+	for _, f := range []struct {
+		name string
+		cc   *CurrencyCollection
+	}{
+		{"fees imported", &flow.FeesImported},
+		{"recovered", &flow.Recovered},
+		{"created", &flow.Created},
+		{"minted", &flow.Minted},
+	} {
+		if err = LoadFromCell(f.cc, minting); err != nil {
+			return fmt.Errorf("failed to load %s: %w", f.name, err)
+		}
+	}
+
+	*v = flow
+	return nil
+}
+
+func (v ValueFlow) ToCell() (*cell.Cell, error) {
+	storeAll := func(b *cell.Builder, list ...CurrencyCollection) error {
+		for _, cc := range list {
+			c, err := ToCell(cc)
+			if err != nil {
+				return err
+			}
+			if err = b.StoreBuilder(c.ToBuilder()); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	balances := cell.BeginCell()
+	if err := storeAll(balances, v.FromPrevBlk, v.ToNextBlk, v.Imported, v.Exported); err != nil {
+		return nil, fmt.Errorf("failed to store balances: %w", err)
+	}
+
+	minting := cell.BeginCell()
+	if err := storeAll(minting, v.FeesImported, v.Recovered, v.Created, v.Minted); err != nil {
+		return nil, fmt.Errorf("failed to store minting: %w", err)
+	}
+
+	tag := uint64(valueFlowV1)
+	collected := []CurrencyCollection{v.FeesCollected}
+	if v.Burned != nil {
+		tag = valueFlowV2
+		collected = append(collected, *v.Burned)
+	}
+
+	b := cell.BeginCell().MustStoreUInt(tag, 32).MustStoreRef(balances.EndCell())
+	if err := storeAll(b, collected...); err != nil {
+		return nil, fmt.Errorf("failed to store fees: %w", err)
+	}
+	return b.MustStoreRef(minting.EndCell()).EndCell(), nil
+}
+
+// GetValueFlow - parses value flow of block
+func (b *Block) GetValueFlow() (*ValueFlow, error) {
+	if b.ValueFlow == nil {
+		return nil, fmt.Errorf("value flow is not present")
+	}
+
+	var flow ValueFlow
+	if err := LoadFromCell(&flow, b.ValueFlow.BeginParse()); err != nil {
+		return nil, fmt.Errorf("failed to parse value flow: %w", err)
+	}
+	return &flow, nil
+}
@@ -0,0 +1,60 @@
+package tlb
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+func TestBlock_GetValueFlow(t *testing.T) {
+	boc, _ := hex.DecodeString(testMasterBlockHex)
+	c, _ := cell.FromBOC(boc)
+
+	var block Block
+	if err := LoadFromCell(&block, c.BeginParse()); err != nil {
+		t.Fatal(err)
+	}
+
+	flow, err := block.GetValueFlow()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if flow.Burned != nil {
+		t.Fatal("v1 value flow has no burned")
+	}
+	if flow.FromPrevBlk.Coins.String() != "2280867924.80587217" || flow.ToNextBlk.Coins.String() != "2280867927.50587217" {
+		t.Fatal("incorrect balances", flow.FromPrevBlk.Coins.String(), flow.ToNextBlk.Coins.String())
+	}
+	if flow.Created.Coins.String() != "1.7" || flow.FeesImported.Coins.String() != "1" ||
+		flow.FeesCollected.Coins.String() != "2.7" || flow.Recovered.Coins.String() != "2.7" {
+		t.Fatal("incorrect fees")
+	}
+
+	c2, err := ToCell(flow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(c2.Hash(), block.ValueFlow.Hash()) {
+		t.Fatal("reserialized value flow is different")
+	}
+
+	flow.Burned = &CurrencyCollection{Coins: MustFromTON("0.5")}
+	c2, err = ToCell(flow)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c2.BeginParse().MustLoadUInt(32) != 0x3ebf98b7 {
+		t.Fatal("incorrect v2 tag")
+	}
+
+	var flow2 ValueFlow
+	if err = LoadFromCell(&flow2, c2.BeginParse()); err != nil {
+		t.Fatal(err)
+	}
+	if flow2.Burned == nil || flow2.Burned.Coins.String() != "0.5" || flow2.Minted.Coins.String() != "0" ||
+		flow2.ToNextBlk.Coins.String() != "2280867927.50587217" {
+		t.Fatal("incorrect v2 value flow")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"reflect"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/xssnick/tonutils-go/address"
@@ -30,6 +31,9 @@ const (
 	ErrCodeContractNotInitialized = -256
 )
 
+// Logger - receives non-fatal errors, like retries of streams, disabled by default
+var Logger = func(v ...any) {}
+
 type LiteClient interface {
 	QueryLiteserver(ctx context.Context, payload tl.Serializable, result tl.Serializable) error
 	StickyContext(ctx context.Context) context.Context
@@ -65,14 +69,19 @@ type APIClientWrapped interface {
 	ListTransactions(ctx context.Context, addr *address.Address, num uint32, lt uint64, txHash []byte) ([]*tlb.Transaction, error)
 	GetTransaction(ctx context.Context, block *BlockIDExt, addr *address.Address, lt uint64) (*tlb.Transaction, error)
 	GetBlockProof(ctx context.Context, known, target *BlockIDExt) (*PartialBlockProof, error)
+	GetShardBlockProof(ctx context.Context, block *BlockIDExt) (*ShardBlockProof, error)
 	CurrentMasterchainInfo(ctx context.Context) (_ *BlockIDExt, err error)
 	SubscribeOnTransactions(workerCtx context.Context, addr *address.Address, lastProcessedLT uint64, channel chan<- *tlb.Transaction)
+	SubscribeOnBlocks(ctx context.Context, lastProcessedMasterSeqno uint32, channel chan<- *BlocksUpdate) error
+	StreamTransactions(ctx context.Context, lastProcessedMasterSeqno uint32, channel chan<- *TransactionsBatch) error
 	VerifyProofChain(ctx context.Context, from, to *BlockIDExt) error
+	VerifyBlock(ctx context.Context, block *BlockIDExt) error
 	WaitForBlock(seqno uint32) APIClientWrapped
 	WithRetry(maxRetries ...int) APIClientWrapped
 	WithTimeout(timeout time.Duration) APIClientWrapped
 	SetTrustedBlock(block *BlockIDExt)
 	SetTrustedBlockFromConfig(cfg *liteclient.GlobalConfig)
+	SetTrustedStateStore(ctx context.Context, store TrustedStateStore) error
 	FindLastTransactionByInMsgHash(ctx context.Context, addr *address.Address, msgHash []byte, maxTxNumToScan ...int) (*tlb.Transaction, error)
 	FindLastTransactionByOutMsgHash(ctx context.Context, addr *address.Address, msgHash []byte, maxTxNumToScan ...int) (*tlb.Transaction, error)
 }
@@ -82,9 +91,16 @@ type APIClient struct {
 	parent *APIClient
 
 	trustedBlock     *BlockIDExt
+	trustedStore     TrustedStateStore
+	extMsgSender     ExternalMessageSender
+	extMsgSenderLock sync.RWMutex
+	trustedSavedAt   time.Time
+	provenBlocks     map[string]bool
 	curMasters       map[uint32]*masterInfo
 	curMastersLock   sync.RWMutex
 	proofCheckPolicy ProofCheckPolicy
+	localGetMethods  atomic.Bool
+	localFallback    atomic.Bool
 
 	trustedLock sync.RWMutex
 }
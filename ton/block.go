@@ -71,17 +71,20 @@ type Version struct {
 	Now          uint32 `tl:"int"`
 }
 
+// GetState - liteServer.getState id:tonNode.blockIdExt = liteServer.BlockState
 type GetState struct {
+	ID *BlockIDExt `tl:"struct"`
+}
+
+// BlockState - liteServer.blockState id:tonNode.blockIdExt root_hash:int256 file_hash:int256 data:bytes,
+// data is the BoC of block state
+type BlockState struct {
 	ID       *BlockIDExt `tl:"struct"`
 	RootHash []byte      `tl:"int256"`
 	FileHash []byte      `tl:"int256"`
 	Data     *cell.Cell  `tl:"cell"`
 }
 
-type BlockState struct {
-	ID *BlockIDExt `tl:"struct"`
-}
-
 type GetShardBlockProof struct {
 	ID *BlockIDExt `tl:"struct"`
 }
@@ -160,6 +163,21 @@ type MasterchainInfoExt struct {
 	Init          *ZeroStateIDExt `tl:"struct"`
 }
 
+// LastMasterchainSeqno - used by connection pool to track how far liteserver is synced
+func (m MasterchainInfo) LastMasterchainSeqno() uint32 {
+	if m.Last == nil {
+		return 0
+	}
+	return m.Last.SeqNo
+}
+
+func (m MasterchainInfoExt) LastMasterchainSeqno() uint32 {
+	if m.Last == nil {
+		return 0
+	}
+	return m.Last.SeqNo
+}
+
 type BlockHeader struct {
 	ID          *BlockIDExt `tl:"struct"`
 	Mode        uint32      `tl:"flags"`
@@ -178,11 +196,12 @@ type AllShardsInfo struct {
 	Data  *cell.Cell   `tl:"cell"`
 }
 
+// ShardInfo - shard_descr is the BoC of ShardDescr of shard in master block state
 type ShardInfo struct {
 	ID               *BlockIDExt  `tl:"struct"`
 	ShardBlock       *BlockIDExt  `tl:"struct"`
 	ShardProof       []*cell.Cell `tl:"cell optional 2"`
-	ShardDescription *cell.Cell   `tl:"bytes"`
+	ShardDescription *cell.Cell   `tl:"cell"`
 }
 
 type BlockTransactions struct {
@@ -193,12 +212,13 @@ type BlockTransactions struct {
 	Proof          *cell.Cell      `tl:"cell optional"`
 }
 
+// BlockTransactionsExt - transactions is a single BoC with a root per transaction
 type BlockTransactionsExt struct {
-	ID           *BlockIDExt `tl:"struct"`
-	ReqCount     int32       `tl:"int"`
-	Incomplete   bool        `tl:"bool"`
-	Transactions *cell.Cell  `tl:"cell optional"`
-	Proof        []byte      `tl:"bytes"`
+	ID           *BlockIDExt  `tl:"struct"`
+	ReqCount     int32        `tl:"int"`
+	Incomplete   bool         `tl:"bool"`
+	Transactions []*cell.Cell `tl:"cell optional"`
+	Proof        []byte       `tl:"bytes"`
 }
 
 type BlockData struct {
@@ -363,13 +383,22 @@ func (c *APIClient) GetMasterchainInfo(ctx context.Context) (*BlockIDExt, error)
 						"For better security you should use SetTrustedBlock(block) method and pass there init block from config on start")
 				}
 			} else {
-				if err := c.VerifyProofChain(ctx, root.trustedBlock, t.Last); err != nil {
+				onProven := func(block *BlockIDExt, isKey bool) error {
+					return c.onTrustedBlockProven(ctx, block, isKey)
+				}
+				if err := c.verifyProofChain(ctx, root.trustedBlock.Copy(), t.Last, onProven); err != nil {
 					return nil, fmt.Errorf("failed to verify proof chain: %w", err)
 				}
 
 				if t.Last.SeqNo > root.trustedBlock.SeqNo {
 					root.trustedBlock = t.Last.Copy()
 				}
+
+				if time.Since(root.trustedSavedAt) >= trustedStateSaveInterval {
+					if err := root.saveTrustedState(ctx); err != nil {
+						return nil, err
+					}
+				}
 			}
 		}
 		return t.Last, nil
@@ -507,6 +536,83 @@ func (c *APIClient) GetBlockTransactionsV2(ctx context.Context, block *BlockIDEx
 	return nil, false, errUnexpectedResponse(resp)
 }
 
+// getBlockTransactionsExt - returns full transactions of block starting after given one, ordered by account and lt.
+// Transactions are checked by single block proof, and with ProofCheckPolicySecure block is verified once per call.
+func (c *APIClient) getBlockTransactionsExt(ctx context.Context, block *BlockIDExt, count uint32, after *TransactionID3) ([]*tlb.Transaction, bool, error) {
+	req := ListBlockTransactionsExt{
+		ID:    block,
+		Count: count,
+		After: after,
+	}
+	if after != nil {
+		req.Mode |= 1 << 7
+	}
+	if c.proofCheckPolicy != ProofCheckPolicyUnsafe {
+		req.Mode |= 1 << 5
+		req.WantProof = &True{}
+	}
+
+	var resp tl.Serializable
+	err := c.client.QueryLiteserver(ctx, req, &resp)
+	if err != nil {
+		return nil, false, err
+	}
+
+	switch t := resp.(type) {
+	case BlockTransactionsExt:
+		if !t.ID.Equals(block) {
+			return nil, false, fmt.Errorf("incorrect block in response")
+		}
+
+		txs := make([]*tlb.Transaction, 0, len(t.Transactions))
+		for _, txCell := range t.Transactions {
+			var tx tlb.Transaction
+			if err = tlb.LoadFromCell(&tx, txCell.BeginParse()); err != nil {
+				return nil, false, fmt.Errorf("failed to load transaction from cell: %w", err)
+			}
+			tx.Hash = txCell.Hash()
+			txs = append(txs, &tx)
+		}
+
+		if c.proofCheckPolicy != ProofCheckPolicyUnsafe {
+			proof, err := cell.FromBOC(t.Proof)
+			if err != nil {
+				return nil, false, fmt.Errorf("failed to parse proof: %w", err)
+			}
+
+			blockProof, err := CheckBlockProof(proof, block.RootHash)
+			if err != nil {
+				return nil, false, fmt.Errorf("failed to check block proof: %w", err)
+			}
+
+			if blockProof.Extra == nil || blockProof.Extra.ShardAccountBlocks == nil {
+				return nil, false, fmt.Errorf("block proof without shard accounts")
+			}
+
+			var shardAccounts tlb.ShardAccountBlocks
+			if err = tlb.LoadFromCellAsProof(&shardAccounts, blockProof.Extra.ShardAccountBlocks.BeginParse()); err != nil {
+				return nil, false, fmt.Errorf("failed to load shard accounts from proof: %w", err)
+			}
+
+			for _, tx := range txs {
+				if err = CheckTransactionProof(tx.Hash, tx.LT, tx.AccountAddr, &shardAccounts); err != nil {
+					return nil, false, fmt.Errorf("incorrect tx %s proof: %w", hex.EncodeToString(tx.Hash), err)
+				}
+			}
+
+			if c.proofCheckPolicy == ProofCheckPolicySecure {
+				if err = c.VerifyBlock(ctx, block); err != nil {
+					return nil, false, fmt.Errorf("failed to verify block: %w", err)
+				}
+			}
+		}
+		return txs, t.Incomplete, nil
+	case LSError:
+		return nil, false, t
+	}
+	return nil, false, errUnexpectedResponse(resp)
+}
+
 // GetBlockShardsInfo - gets the information about workchains and its shards at given masterchain state
 func (c *APIClient) GetBlockShardsInfo(ctx context.Context, master *BlockIDExt) ([]*BlockIDExt, error) {
 	var resp tl.Serializable
@@ -647,6 +753,24 @@ func LoadShardsFromHashes(shardHashes *cell.Dictionary, skipPruned bool) (shards
 	return
 }
 
+// GetShardBlockProof - gets chain of links from master block which committed the shard block to this block,
+// use CheckShardBlockProof to verify it
+func (c *APIClient) GetShardBlockProof(ctx context.Context, block *BlockIDExt) (*ShardBlockProof, error) {
+	var resp tl.Serializable
+	err := c.client.QueryLiteserver(ctx, GetShardBlockProof{ID: block}, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := resp.(type) {
+	case ShardBlockProof:
+		return &t, nil
+	case LSError:
+		return nil, t
+	}
+	return nil, errUnexpectedResponse(resp)
+}
+
 // GetBlockProof - gets proof chain for the block
 func (c *APIClient) GetBlockProof(ctx context.Context, known, target *BlockIDExt) (*PartialBlockProof, error) {
 	var resp tl.Serializable
@@ -0,0 +1,146 @@
+package fees
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+)
+
+// ConfigParams - ids of config params which are used for fee calculation
+var ConfigParams = []int32{18, 20, 21, 24, 25}
+
+// Estimator - calculates fees using prices from blockchain config, the same way as node does.
+type Estimator struct {
+	gasBasechain, gasMaster *tlb.GasLimitsPrices
+	msgBasechain, msgMaster *tlb.MsgForwardPrices
+	storage                 []tlb.StoragePrices
+}
+
+// ForwardFees - fees of outbound internal message
+type ForwardFees struct {
+	// Total - forward and ihr fees, which are deducted from the sender
+	Total *big.Int
+	// ActionFee - part of forward fee which is collected in the sender's transaction
+	ActionFee *big.Int
+	// FwdFee - remaining part of forward fee, which is stored in the message and collected later
+	FwdFee *big.Int
+	// IHRFee - zero when ihr is disabled
+	IHRFee *big.Int
+}
+
+// NewEstimator - creates estimator, config should contain params 20, 21, 24 and 25,
+// param 18 is optional, without it storage fees are zero
+func NewEstimator(cfg *ton.BlockchainConfig) (*Estimator, error) {
+	if cfg == nil {
+		return nil, fmt.Errorf("config is nil")
+	}
+
+	e := &Estimator{}
+	var err error
+	if e.gasMaster, err = cfg.GasPrices(address.MasterchainID); err != nil {
+		return nil, fmt.Errorf("failed to get masterchain gas prices: %w", err)
+	}
+	if e.gasBasechain, err = cfg.GasPrices(0); err != nil {
+		return nil, fmt.Errorf("failed to get basechain gas prices: %w", err)
+	}
+	if e.msgMaster, err = cfg.MsgForwardPrices(address.MasterchainID); err != nil {
+		return nil, fmt.Errorf("failed to get masterchain msg prices: %w", err)
+	}
+	if e.msgBasechain, err = cfg.MsgForwardPrices(0); err != nil {
+		return nil, fmt.Errorf("failed to get basechain msg prices: %w", err)
+	}
+	if e.storage, err = cfg.StoragePrices(); err != nil && !errors.Is(err, ton.ErrConfigParamNotFound) {
+		return nil, fmt.Errorf("failed to get storage prices: %w", err)
+	}
+	return e, nil
+}
+
+// GasPrices - gas prices of workchain
+func (e *Estimator) GasPrices(workchain int32) *tlb.GasLimitsPrices {
+	if workchain == address.MasterchainID {
+		return e.gasMaster
+	}
+	return e.gasBasechain
+}
+
+// MsgForwardPrices - message prices of workchain
+func (e *Estimator) MsgForwardPrices(workchain int32) *tlb.MsgForwardPrices {
+	if workchain == address.MasterchainID {
+		return e.msgMaster
+	}
+	return e.msgBasechain
+}
+
+// StoragePrices - storage prices sorted by activation time
+func (e *Estimator) StoragePrices() []tlb.StoragePrices {
+	return e.storage
+}
+
+// GasFee - fee for the gas used by compute phase of account in workchain
+func (e *Estimator) GasFee(workchain int32, gasUsed uint64) tlb.Coins {
+	return tlb.FromNanoTON(ComputeGasFee(e.GasPrices(workchain), gasUsed))
+}
+
+// StorageFee - storage fee of account in workchain, for the period from lastPaid to now
+func (e *Estimator) StorageFee(workchain int32, used tlb.StorageUsed, lastPaid, now uint32) tlb.Coins {
+	var cells, bits uint64
+	if used.CellsUsed != nil {
+		cells = used.CellsUsed.Uint64()
+	}
+	if used.BitsUsed != nil {
+		bits = used.BitsUsed.Uint64()
+	}
+	return tlb.FromNanoTON(ComputeStorageFee(e.storage, workchain == address.MasterchainID, cells, bits, lastPaid, now))
+}
+
+// ImportFee - fee for the import of external message, it is paid by destination account
+func (e *Estimator) ImportFee(msg *tlb.ExternalMessage) (tlb.Coins, error) {
+	if msg == nil || msg.DstAddr == nil {
+		return tlb.Coins{}, fmt.Errorf("message destination is not set")
+	}
+
+	c, err := tlb.ToCell(msg)
+	if err != nil {
+		return tlb.Coins{}, fmt.Errorf("failed to serialize message: %w", err)
+	}
+
+	cells, bits := CellStats(c, true)
+	return tlb.FromNanoTON(ComputeFwdFee(e.MsgForwardPrices(msg.DstAddr.Workchain()), cells, bits)), nil
+}
+
+// ForwardFee - fees of internal message sent by account with src address,
+// masterchain prices are used when sender or receiver is in masterchain
+func (e *Estimator) ForwardFee(src *address.Address, msg *tlb.InternalMessage) (*ForwardFees, error) {
+	if msg == nil || msg.DstAddr == nil {
+		return nil, fmt.Errorf("message destination is not set")
+	}
+
+	c, err := tlb.ToCell(msg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize message: %w", err)
+	}
+
+	workchain := msg.DstAddr.Workchain()
+	if src != nil && src.Workchain() == address.MasterchainID {
+		workchain = address.MasterchainID
+	}
+	prices := e.MsgForwardPrices(workchain)
+
+	cells, bits := CellStats(c, true)
+	fwd := ComputeFwdFee(prices, cells, bits)
+
+	res := &ForwardFees{
+		ActionFee: FwdFeeFirstFrac(prices, fwd),
+		IHRFee:    big.NewInt(0),
+	}
+	if !msg.IHRDisabled {
+		res.IHRFee = ComputeIHRFee(prices, fwd)
+	}
+	res.FwdFee = new(big.Int).Sub(fwd, res.ActionFee)
+	res.Total = new(big.Int).Add(fwd, res.IHRFee)
+	return res, nil
+}
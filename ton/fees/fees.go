@@ -0,0 +1,130 @@
+package fees
+
+import (
+	"math/big"
+
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+// ComputeGasFee - calculates fee for the used gas, in nanotons
+func ComputeGasFee(prices *tlb.GasLimitsPrices, gasUsed uint64) *big.Int {
+	if gasUsed <= prices.FlatGasLimit {
+		return new(big.Int).SetUint64(prices.FlatGasPrice)
+	}
+
+	fee := new(big.Int).SetUint64(gasUsed - prices.FlatGasLimit)
+	fee.Mul(fee, new(big.Int).SetUint64(prices.GasPrice))
+	fee.Add(fee, big.NewInt(0xffff))
+	fee.Rsh(fee, 16)
+	return fee.Add(fee, new(big.Int).SetUint64(prices.FlatGasPrice))
+}
+
+// GasBoughtFor - calculates amount of gas which can be bought for the given amount of nanotons, up to limit
+func GasBoughtFor(prices *tlb.GasLimitsPrices, amount *big.Int, limit uint64) uint64 {
+	if amount.Sign() <= 0 || amount.Cmp(new(big.Int).SetUint64(prices.FlatGasPrice)) < 0 {
+		return 0
+	}
+	if prices.GasPrice == 0 {
+		return limit
+	}
+
+	res := new(big.Int).Sub(amount, new(big.Int).SetUint64(prices.FlatGasPrice))
+	res.Lsh(res, 16)
+	res.Div(res, new(big.Int).SetUint64(prices.GasPrice))
+	res.Add(res, new(big.Int).SetUint64(prices.FlatGasLimit))
+	if !res.IsUint64() || res.Uint64() > limit {
+		return limit
+	}
+	return res.Uint64()
+}
+
+// ComputeFwdFee - calculates forward fee for message with given stats, root cell should not be counted
+func ComputeFwdFee(prices *tlb.MsgForwardPrices, cells, bits uint64) *big.Int {
+	fee := new(big.Int).Mul(new(big.Int).SetUint64(prices.BitPrice), new(big.Int).SetUint64(bits))
+	fee.Add(fee, new(big.Int).Mul(new(big.Int).SetUint64(prices.CellPrice), new(big.Int).SetUint64(cells)))
+	fee.Add(fee, big.NewInt(0xffff))
+	fee.Rsh(fee, 16)
+	return fee.Add(fee, new(big.Int).SetUint64(prices.LumpPrice))
+}
+
+// FwdFeeFirstFrac - returns part of forward fee which is collected by the validators of sender,
+// it is also known as action fee
+func FwdFeeFirstFrac(prices *tlb.MsgForwardPrices, fwdFee *big.Int) *big.Int {
+	res := new(big.Int).Mul(fwdFee, big.NewInt(int64(prices.FirstFrac)))
+	return res.Rsh(res, 16)
+}
+
+// ComputeIHRFee - calculates instant hypercube routing fee from forward fee
+func ComputeIHRFee(prices *tlb.MsgForwardPrices, fwdFee *big.Int) *big.Int {
+	res := new(big.Int).Mul(fwdFee, big.NewInt(int64(prices.IHRPriceFactor)))
+	return res.Rsh(res, 16)
+}
+
+// ComputeStorageFee - calculates storage fee for the period from lastPaid to now,
+// prices should be sorted by activation time
+func ComputeStorageFee(prices []tlb.StoragePrices, masterchain bool, cells, bits uint64, lastPaid, now uint32) *big.Int {
+	if len(prices) == 0 || lastPaid == 0 || now <= lastPaid || now <= prices[0].UTimeSince {
+		return big.NewInt(0)
+	}
+
+	total := new(big.Int)
+	upto := lastPaid
+	if prices[0].UTimeSince > upto {
+		upto = prices[0].UTimeSince
+	}
+
+	for i, p := range prices {
+		validUntil := now
+		if i < len(prices)-1 && prices[i+1].UTimeSince < now {
+			validUntil = prices[i+1].UTimeSince
+		}
+
+		if upto < validUntil {
+			bitPrice, cellPrice := p.BitPricePS, p.CellPricePS
+			if masterchain {
+				bitPrice, cellPrice = p.MCBitPricePS, p.MCCellPricePS
+			}
+
+			v := new(big.Int).Mul(new(big.Int).SetUint64(cells), new(big.Int).SetUint64(cellPrice))
+			v.Add(v, new(big.Int).Mul(new(big.Int).SetUint64(bits), new(big.Int).SetUint64(bitPrice)))
+			v.Mul(v, big.NewInt(int64(validUntil-upto)))
+			total.Add(total, v)
+		}
+		if validUntil > upto {
+			upto = validUntil
+		}
+	}
+
+	total.Add(total, big.NewInt(0xffff))
+	return total.Rsh(total, 16)
+}
+
+// CellStats - counts unique cells and their bits, root can be excluded,
+// messages are counted without root when forward and import fees are calculated
+func CellStats(root *cell.Cell, skipRoot bool) (cells, bits uint64) {
+	visited := map[string]bool{}
+	var walk func(c *cell.Cell)
+	walk = func(c *cell.Cell) {
+		key := string(c.Hash())
+		if visited[key] {
+			return
+		}
+		visited[key] = true
+		cells++
+		bits += uint64(c.BitsSize())
+		for i := 0; i < int(c.RefsNum()); i++ {
+			walk(c.MustPeekRef(i))
+		}
+	}
+
+	if !skipRoot {
+		walk(root)
+		return cells, bits
+	}
+
+	for i := 0; i < int(root.RefsNum()); i++ {
+		walk(root.MustPeekRef(i))
+	}
+	return cells, bits
+}
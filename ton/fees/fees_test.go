@@ -0,0 +1,144 @@
+package fees
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+// basechain prices from mainnet config
+var (
+	testGasPrices = tlb.GasLimitsPrices{
+		FlatGasLimit: 100, FlatGasPrice: 40000, GasPrice: 26214400, GasLimit: 1000000, SpecialGasLimit: 1000000,
+		GasCredit: 10000, BlockGasLimit: 10000000, FreezeDueLimit: 100000000, DeleteDueLimit: 1000000000,
+	}
+	testMsgPrices = tlb.MsgForwardPrices{
+		LumpPrice: 400000, BitPrice: 26214400, CellPrice: 2621440000, IHRPriceFactor: 98304, FirstFrac: 21845, NextFrac: 21845,
+	}
+)
+
+func TestComputeFees(t *testing.T) {
+	if v := ComputeGasFee(&testGasPrices, 50).Uint64(); v != 40000 {
+		t.Fatal("incorrect flat gas fee", v)
+	}
+	if v := ComputeGasFee(&testGasPrices, 3308).Uint64(); v != 40000+3208*400 {
+		t.Fatal("incorrect gas fee", v)
+	}
+
+	if v := GasBoughtFor(&testGasPrices, big.NewInt(40000+1000*400), testGasPrices.GasLimit); v != 1100 {
+		t.Fatal("incorrect gas bought", v)
+	}
+	if v := GasBoughtFor(&testGasPrices, big.NewInt(1), testGasPrices.GasLimit); v != 0 {
+		t.Fatal("gas should not be bought", v)
+	}
+	if v := GasBoughtFor(&testGasPrices, tlb.MustFromTON("1000").Nano(), testGasPrices.GasLimit); v != testGasPrices.GasLimit {
+		t.Fatal("gas should be limited", v)
+	}
+
+	fwd := ComputeFwdFee(&testMsgPrices, 1, 100)
+	if fwd.Uint64() != 400000+40000+100*400 {
+		t.Fatal("incorrect fwd fee", fwd.Uint64())
+	}
+	if v := FwdFeeFirstFrac(&testMsgPrices, fwd).Uint64(); v != 480000*21845>>16 {
+		t.Fatal("incorrect first frac", v)
+	}
+	if v := ComputeIHRFee(&testMsgPrices, fwd).Uint64(); v != 480000*98304>>16 {
+		t.Fatal("incorrect ihr fee", v)
+	}
+}
+
+func TestComputeStorageFee(t *testing.T) {
+	prices := []tlb.StoragePrices{
+		{UTimeSince: 0, BitPricePS: 1, CellPricePS: 500, MCBitPricePS: 1000, MCCellPricePS: 500000},
+		{UTimeSince: 2000, BitPricePS: 2, CellPricePS: 1000, MCBitPricePS: 2000, MCCellPricePS: 1000000},
+	}
+
+	// 1000 seconds by first price and 500 by second
+	exp := (uint64(10*500+1000*1)*1000 + uint64(10*1000+1000*2)*500 + 0xffff) >> 16
+	if v := ComputeStorageFee(prices, false, 10, 1000, 1000, 2500).Uint64(); v != exp {
+		t.Fatal("incorrect storage fee", v, exp)
+	}
+
+	exp = (uint64(10*500000+1000*1000)*500 + 0xffff) >> 16
+	if v := ComputeStorageFee(prices, true, 10, 1000, 1000, 1500).Uint64(); v != exp {
+		t.Fatal("incorrect masterchain storage fee", v, exp)
+	}
+
+	if ComputeStorageFee(prices, false, 10, 1000, 2500, 2500).Sign() != 0 {
+		t.Fatal("fee should be zero when nothing to pay")
+	}
+}
+
+func TestEstimator(t *testing.T) {
+	gas, err := tlb.ToCell(testGasPrices)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, err := tlb.ToCell(testMsgPrices)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mcMsgPrices := testMsgPrices
+	mcMsgPrices.LumpPrice, mcMsgPrices.BitPrice, mcMsgPrices.CellPrice = 10000000, 655360000, 65536000000
+	mcMsg, err := tlb.ToCell(mcMsgPrices)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	est, err := NewEstimator(ton.NewBlockchainConfig(map[int32]*cell.Cell{
+		20: gas, 21: gas, 24: mcMsg, 25: msg,
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if v := est.GasFee(0, 3308); v.Nano().Uint64() != 40000+3208*400 {
+		t.Fatal("incorrect gas fee", v.String())
+	}
+	if est.StorageFee(0, tlb.StorageUsed{CellsUsed: big.NewInt(10), BitsUsed: big.NewInt(1000)}, 1000, 2000).Nano().Sign() != 0 {
+		t.Fatal("storage fee should be zero without prices")
+	}
+
+	// body is stored in the message root, so only its ref is counted
+	body := cell.BeginCell().MustStoreUInt(0, 32).MustStoreRef(cell.BeginCell().MustStoreUInt(7, 64).EndCell()).EndCell()
+	src := address.MustParseAddr("EQCD39VS5jcptHL8vMjEXrzGaRcCVYto7HUn4bpAOg8xqB2N")
+	internal := &tlb.InternalMessage{
+		IHRDisabled: true,
+		Bounce:      true,
+		SrcAddr:     src,
+		DstAddr:     address.MustParseAddr("EQDnYZIpTwo9RN_84KZX3qIkLVIUJSo8d1yz1vMlKAp2uRtK"),
+		Amount:      tlb.MustFromTON("1"),
+		Body:        body,
+	}
+
+	ff, err := est.ForwardFee(src, internal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fwd := ComputeFwdFee(&testMsgPrices, 1, 64)
+	if ff.Total.Cmp(fwd) != 0 || ff.IHRFee.Sign() != 0 ||
+		new(big.Int).Add(ff.ActionFee, ff.FwdFee).Cmp(fwd) != 0 || ff.ActionFee.Cmp(FwdFeeFirstFrac(&testMsgPrices, fwd)) != 0 {
+		t.Fatal("incorrect forward fees", ff.Total, ff.ActionFee, ff.FwdFee)
+	}
+
+	// masterchain prices are used when sender is in masterchain
+	ff, err = est.ForwardFee(address.NewAddress(0, 255, src.Data()), internal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ff.Total.Cmp(ComputeFwdFee(&mcMsgPrices, 1, 64)) != 0 {
+		t.Fatal("incorrect masterchain forward fee", ff.Total)
+	}
+
+	importFee, err := est.ImportFee(&tlb.ExternalMessage{DstAddr: internal.DstAddr, Body: body})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if importFee.Nano().Cmp(fwd) != 0 {
+		t.Fatal("incorrect import fee", importFee.String())
+	}
+}
@@ -3,10 +3,14 @@ package ton
 import (
 	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"math/big"
+	"sort"
 
+	"github.com/xssnick/tonutils-go/address"
 	"github.com/xssnick/tonutils-go/tl"
+	"github.com/xssnick/tonutils-go/tlb"
 	"github.com/xssnick/tonutils-go/tvm/cell"
 )
 
@@ -156,7 +160,14 @@ func (c *APIClient) GetBlockchainConfig(ctx context.Context, block *BlockIDExt,
 	return nil, errUnexpectedResponse(resp)
 }
 
-// TODO: add methods to BlockchainConfig to easily get gas price and etc
+// NewBlockchainConfig - creates config from already known params, useful for offline calculations and tests
+func NewBlockchainConfig(params map[int32]*cell.Cell) *BlockchainConfig {
+	data := make(map[int32]*cell.Cell, len(params))
+	for k, v := range params {
+		data[k] = v
+	}
+	return &BlockchainConfig{data: data}
+}
 
 func (b *BlockchainConfig) Get(id int32) *cell.Cell {
 	return b.data[id]
@@ -165,3 +176,258 @@ func (b *BlockchainConfig) Get(id int32) *cell.Cell {
 func (b *BlockchainConfig) All() map[int32]*cell.Cell {
 	return b.data
 }
+
+// ErrConfigParamNotFound - returned by typed accessors when config has no requested param
+var ErrConfigParamNotFound = errors.New("config param not found")
+
+func (b *BlockchainConfig) loadParam(id int32, v any) error {
+	c := b.data[id]
+	if c == nil {
+		return fmt.Errorf("%w: %d", ErrConfigParamNotFound, id)
+	}
+	if err := tlb.LoadFromCell(v, c.BeginParse()); err != nil {
+		return fmt.Errorf("failed to parse config param %d: %w", id, err)
+	}
+	return nil
+}
+
+func (b *BlockchainConfig) loadAddress(id int32) (*address.Address, error) {
+	var addr tlb.ConfigAddress
+	if err := b.loadParam(id, &addr); err != nil {
+		return nil, err
+	}
+	return address.NewAddress(0, 255, addr.Addr), nil
+}
+
+// ConfigAddress - address of config contract, param 0
+func (b *BlockchainConfig) ConfigAddress() (*address.Address, error) {
+	return b.loadAddress(0)
+}
+
+// ElectorAddress - address of elector contract, param 1
+func (b *BlockchainConfig) ElectorAddress() (*address.Address, error) {
+	return b.loadAddress(1)
+}
+
+// MinterAddress - address of minter contract, param 2
+func (b *BlockchainConfig) MinterAddress() (*address.Address, error) {
+	return b.loadAddress(2)
+}
+
+// BurningConfig - param 5
+func (b *BlockchainConfig) BurningConfig() (*tlb.BurningConfig, error) {
+	var res tlb.BurningConfig
+	if err := b.loadParam(5, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// GlobalVersion - param 8
+func (b *BlockchainConfig) GlobalVersion() (*tlb.GlobalVersion, error) {
+	var res tlb.GlobalVersion
+	if err := b.loadParam(8, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// Workchains - workchain descriptors by id, param 12
+func (b *BlockchainConfig) Workchains() (map[int32]*tlb.WorkchainDescr, error) {
+	c := b.data[12]
+	if c == nil {
+		return nil, fmt.Errorf("%w: %d", ErrConfigParamNotFound, 12)
+	}
+
+	dict, err := c.BeginParse().LoadDict(32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load workchains dict: %w", err)
+	}
+
+	kvs, err := dict.LoadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load workchains: %w", err)
+	}
+
+	res := make(map[int32]*tlb.WorkchainDescr, len(kvs))
+	for _, kv := range kvs {
+		id := int32(kv.Key.MustLoadInt(32))
+
+		var descr tlb.WorkchainDescr
+		if err = tlb.LoadFromCell(&descr, kv.Value); err != nil {
+			return nil, fmt.Errorf("failed to parse workchain %d descr: %w", id, err)
+		}
+		res[id] = &descr
+	}
+	return res, nil
+}
+
+// ElectionTimings - param 15
+func (b *BlockchainConfig) ElectionTimings() (*tlb.ElectionTimings, error) {
+	var res tlb.ElectionTimings
+	if err := b.loadParam(15, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// ValidatorsCount - param 16
+func (b *BlockchainConfig) ValidatorsCount() (*tlb.ValidatorsCount, error) {
+	var res tlb.ValidatorsCount
+	if err := b.loadParam(16, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// ValidatorStakes - param 17
+func (b *BlockchainConfig) ValidatorStakes() (*tlb.ValidatorStakes, error) {
+	var res tlb.ValidatorStakes
+	if err := b.loadParam(17, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// StoragePrices - param 18, sorted by activation time
+func (b *BlockchainConfig) StoragePrices() ([]tlb.StoragePrices, error) {
+	c := b.data[18]
+	if c == nil {
+		return nil, fmt.Errorf("%w: %d", ErrConfigParamNotFound, 18)
+	}
+
+	kvs, err := c.AsDict(32).LoadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load storage prices dict: %w", err)
+	}
+
+	res := make([]tlb.StoragePrices, 0, len(kvs))
+	for _, kv := range kvs {
+		var p tlb.StoragePrices
+		if err = tlb.LoadFromCell(&p, kv.Value); err != nil {
+			return nil, fmt.Errorf("failed to parse storage prices: %w", err)
+		}
+		res = append(res, p)
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].UTimeSince < res[j].UTimeSince
+	})
+	return res, nil
+}
+
+// GasPrices - gas limits and prices of workchain, param 20 for masterchain and 21 for others
+func (b *BlockchainConfig) GasPrices(workchain int32) (*tlb.GasLimitsPrices, error) {
+	id := int32(21)
+	if workchain == address.MasterchainID {
+		id = 20
+	}
+
+	var res tlb.GasLimitsPrices
+	if err := b.loadParam(id, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// BlockLimits - block limits of workchain, param 22 for masterchain and 23 for others
+func (b *BlockchainConfig) BlockLimits(workchain int32) (*tlb.BlockLimits, error) {
+	id := int32(23)
+	if workchain == address.MasterchainID {
+		id = 22
+	}
+
+	var res tlb.BlockLimits
+	if err := b.loadParam(id, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// MsgForwardPrices - message forwarding prices of workchain, param 24 for masterchain and 25 for others
+func (b *BlockchainConfig) MsgForwardPrices(workchain int32) (*tlb.MsgForwardPrices, error) {
+	id := int32(25)
+	if workchain == address.MasterchainID {
+		id = 24
+	}
+
+	var res tlb.MsgForwardPrices
+	if err := b.loadParam(id, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// CatchainConfig - param 28
+func (b *BlockchainConfig) CatchainConfig() (*tlb.CatchainConfig, error) {
+	var res tlb.CatchainConfig
+	if err := b.loadParam(28, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// ConsensusConfig - param 29
+func (b *BlockchainConfig) ConsensusConfig() (*tlb.ConsensusConfig, error) {
+	var res tlb.ConsensusConfig
+	if err := b.loadParam(29, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// FundamentalSmcAddresses - addresses of masterchain contracts with special fees, param 31
+func (b *BlockchainConfig) FundamentalSmcAddresses() ([]*address.Address, error) {
+	c := b.data[31]
+	if c == nil {
+		return nil, fmt.Errorf("%w: %d", ErrConfigParamNotFound, 31)
+	}
+
+	dict, err := c.BeginParse().LoadDict(256)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load fundamental smc dict: %w", err)
+	}
+
+	kvs, err := dict.LoadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load fundamental smc addresses: %w", err)
+	}
+
+	res := make([]*address.Address, 0, len(kvs))
+	for _, kv := range kvs {
+		res = append(res, address.NewAddress(0, 255, kv.Key.MustLoadSlice(256)))
+	}
+	return res, nil
+}
+
+// PrevValidators - previous validator set, param 32
+func (b *BlockchainConfig) PrevValidators() (*tlb.ValidatorSetAny, error) {
+	return b.loadValidators(32)
+}
+
+// CurrentValidators - current validator set, param 34
+func (b *BlockchainConfig) CurrentValidators() (*tlb.ValidatorSetAny, error) {
+	return b.loadValidators(34)
+}
+
+// NextValidators - next validator set, param 36, present only during elections
+func (b *BlockchainConfig) NextValidators() (*tlb.ValidatorSetAny, error) {
+	return b.loadValidators(36)
+}
+
+func (b *BlockchainConfig) loadValidators(id int32) (*tlb.ValidatorSetAny, error) {
+	var res tlb.ValidatorSetAny
+	if err := b.loadParam(id, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
+
+// SizeLimits - param 43
+func (b *BlockchainConfig) SizeLimits() (*tlb.SizeLimitsConfig, error) {
+	var res tlb.SizeLimitsConfig
+	if err := b.loadParam(43, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
@@ -0,0 +1,98 @@
+package ton
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+func TestBlockchainConfig_Accessors(t *testing.T) {
+	elector := make([]byte, 32)
+	for i := range elector {
+		elector[i] = 0x33
+	}
+
+	gas, err := tlb.ToCell(tlb.GasLimitsPrices{FlatGasLimit: 100, FlatGasPrice: 1000000, GasPrice: 655360000, GasLimit: 1000000, SpecialGasLimit: 70000000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, err := tlb.ToCell(tlb.MsgForwardPrices{LumpPrice: 400000, BitPrice: 26214400, CellPrice: 2621440000, FirstFrac: 21845, NextFrac: 21845})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	storage := cell.NewDict(32)
+	for i, since := range []uint32{1000, 0} {
+		p, err := tlb.ToCell(tlb.StoragePrices{UTimeSince: since, BitPricePS: uint64(i + 1)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = storage.SetIntKey(big.NewInt(int64(i)), p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	burning, err := tlb.ToCell(tlb.BurningConfig{FeeBurnNum: 1, FeeBurnDenom: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := NewBlockchainConfig(map[int32]*cell.Cell{
+		1:  cell.BeginCell().MustStoreSlice(elector, 256).EndCell(),
+		5:  burning,
+		18: storage.AsCell(),
+		20: gas,
+		24: msg,
+	})
+
+	addr, err := cfg.ElectorAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if addr.String() != address.NewAddress(0, 255, elector).String() {
+		t.Fatal("incorrect elector address", addr.String())
+	}
+
+	if _, err = cfg.ConfigAddress(); !errors.Is(err, ErrConfigParamNotFound) {
+		t.Fatal("config address should not be found", err)
+	}
+
+	gp, err := cfg.GasPrices(address.MasterchainID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gp.GasPrice != 655360000 || gp.FlatGasPrice != 1000000 || gp.SpecialGasLimit != 70000000 {
+		t.Fatal("incorrect gas prices")
+	}
+	if _, err = cfg.GasPrices(0); !errors.Is(err, ErrConfigParamNotFound) {
+		t.Fatal("basechain gas prices should not be found", err)
+	}
+
+	mp, err := cfg.MsgForwardPrices(address.MasterchainID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mp.CellPrice != 2621440000 || mp.FirstFrac != 21845 {
+		t.Fatal("incorrect msg prices")
+	}
+
+	sp, err := cfg.StoragePrices()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sp) != 2 || sp[0].UTimeSince != 0 || sp[1].BitPricePS != 1 {
+		t.Fatal("storage prices should be sorted")
+	}
+
+	bc, err := cfg.BurningConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bc.BlackholeAddr != nil || bc.FeeBurnNum != 1 || bc.FeeBurnDenom != 2 {
+		t.Fatal("incorrect burning config")
+	}
+}
@@ -16,6 +16,11 @@ type CurrentTime struct {
 	Now uint32 `tl:"int"`
 }
 
+// ServerTime - used by connection pool health checks
+func (t CurrentTime) ServerTime() uint32 {
+	return t.Now
+}
+
 func (c *APIClient) GetTime(ctx context.Context) (uint32, error) {
 	var resp tl.Serializable
 	err := c.client.QueryLiteserver(ctx, GetTime{}, &resp)
@@ -0,0 +1,100 @@
+package indexer
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/xssnick/tonutils-go/internal/jsonfile"
+)
+
+// Checkpoint - progress of indexer, it is saved only after all transactions of master block are handled.
+// Last seen shard blocks are not stored, on resume they are derived from shards info of master block MasterSeqNo,
+// which contains tops of all shards processed with it, so shard blocks are neither skipped nor handled twice.
+type Checkpoint struct {
+	// MasterSeqNo - last fully processed master block
+	MasterSeqNo uint32 `json:"master_seqno"`
+}
+
+// CheckpointStore - persistent storage of indexer progress
+type CheckpointStore interface {
+	// LoadCheckpoint - returns nil checkpoint without error when nothing was saved yet
+	LoadCheckpoint(ctx context.Context) (*Checkpoint, error)
+	SaveCheckpoint(ctx context.Context, cp *Checkpoint) error
+}
+
+// MemoryCheckpointStore - keeps checkpoint in memory, useful for tests and when progress should not survive restarts
+type MemoryCheckpointStore struct {
+	cp *Checkpoint
+	mx sync.RWMutex
+}
+
+func NewMemoryCheckpointStore() *MemoryCheckpointStore {
+	return &MemoryCheckpointStore{}
+}
+
+func (s *MemoryCheckpointStore) LoadCheckpoint(_ context.Context) (*Checkpoint, error) {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+
+	if s.cp == nil {
+		return nil, nil
+	}
+	return copyCheckpoint(s.cp), nil
+}
+
+func (s *MemoryCheckpointStore) SaveCheckpoint(_ context.Context, cp *Checkpoint) error {
+	if cp == nil {
+		return fmt.Errorf("checkpoint is nil")
+	}
+
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	s.cp = copyCheckpoint(cp)
+	return nil
+}
+
+// FileCheckpointStore - keeps checkpoint in json file, file is replaced atomically on each save,
+// so it is never left in a partially written state after crash
+type FileCheckpointStore struct {
+	path string
+	mx   sync.Mutex
+}
+
+func NewFileCheckpointStore(path string) *FileCheckpointStore {
+	return &FileCheckpointStore{path: path}
+}
+
+func (s *FileCheckpointStore) LoadCheckpoint(_ context.Context) (*Checkpoint, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	var cp Checkpoint
+	found, err := jsonfile.Load(s.path, &cp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load checkpoint: %w", err)
+	}
+	if !found {
+		return nil, nil
+	}
+	return &cp, nil
+}
+
+func (s *FileCheckpointStore) SaveCheckpoint(_ context.Context, cp *Checkpoint) error {
+	if cp == nil {
+		return fmt.Errorf("checkpoint is nil")
+	}
+
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	if err := jsonfile.Save(s.path, cp); err != nil {
+		return fmt.Errorf("failed to save checkpoint: %w", err)
+	}
+	return nil
+}
+
+func copyCheckpoint(cp *Checkpoint) *Checkpoint {
+	return &Checkpoint{MasterSeqNo: cp.MasterSeqNo}
+}
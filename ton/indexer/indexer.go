@@ -0,0 +1,238 @@
+package indexer
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+// Transaction - transaction with the context of block where it was found
+type Transaction struct {
+	*tlb.Transaction
+	Address *address.Address
+	Block   *ton.BlockIDExt
+	// Master - master block which committed Block
+	Master *ton.BlockIDExt
+}
+
+// Handler - processes single transaction, when error is returned indexer stops without saving checkpoint,
+// so the whole master block will be processed again after restart, handlers should be idempotent
+type Handler func(ctx context.Context, tx *Transaction) error
+
+// Indexer - processes all transactions of the network, master block by master block.
+// Only blocks committed by master chain are processed, they are final and cannot be rolled back,
+// so there is no need to handle reorgs. Checkpoint is saved after all handlers of master block are completed,
+// so after crash processing continues from the first not fully processed master block.
+type Indexer struct {
+	api   ton.APIClientWrapped
+	store CheckpointStore
+
+	workers    int
+	startSeqno uint32
+
+	all    []Handler
+	byAddr map[string][]Handler
+	byOp   map[uint32][]Handler
+	mx     sync.RWMutex
+}
+
+func NewIndexer(api ton.APIClientWrapped, store CheckpointStore) *Indexer {
+	return &Indexer{
+		api:     api,
+		store:   store,
+		workers: 16,
+		byAddr:  map[string][]Handler{},
+		byOp:    map[uint32][]Handler{},
+	}
+}
+
+// SetWorkers - number of accounts which transactions are handled in parallel, default is 16.
+// Transactions of the same account are always handled sequentially, in lt order.
+func (i *Indexer) SetWorkers(num int) {
+	if num < 1 {
+		num = 1
+	}
+	i.workers = num
+}
+
+// SetStartSeqno - master block to start from when store has no checkpoint,
+// processing starts from the block after it. Default is 0, it means the current block.
+func (i *Indexer) SetStartSeqno(seqno uint32) {
+	i.startSeqno = seqno
+}
+
+// OnTransaction - handler will be called for every transaction
+func (i *Indexer) OnTransaction(h Handler) {
+	i.mx.Lock()
+	defer i.mx.Unlock()
+	i.all = append(i.all, h)
+}
+
+// OnAddress - handler will be called for transactions of the given account
+func (i *Indexer) OnAddress(addr *address.Address, h Handler) {
+	i.mx.Lock()
+	defer i.mx.Unlock()
+	key := addrKey(addr)
+	i.byAddr[key] = append(i.byAddr[key], h)
+}
+
+// OnOpcode - handler will be called for transactions which inbound message body starts with the given op code
+func (i *Indexer) OnOpcode(op uint32, h Handler) {
+	i.mx.Lock()
+	defer i.mx.Unlock()
+	i.byOp[op] = append(i.byOp[op], h)
+}
+
+// Run - loads checkpoint and processes blocks until context is done or handler fails.
+// Blocking.
+func (i *Indexer) Run(ctx context.Context) error {
+	cp, err := i.store.LoadCheckpoint(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load checkpoint: %w", err)
+	}
+
+	start := i.startSeqno
+	if cp != nil {
+		start = cp.MasterSeqNo
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	ch := make(chan *ton.TransactionsBatch, 8)
+	streamErr := make(chan error, 1)
+	go func() {
+		streamErr <- i.api.StreamTransactions(ctx, start, ch)
+	}()
+
+	var pending []*Transaction
+	for batch := range ch {
+		for _, tx := range batch.Transactions {
+			pending = append(pending, &Transaction{
+				Transaction: tx,
+				Address:     address.NewAddress(0, byte(batch.Block.Workchain), tx.AccountAddr),
+				Block:       batch.Block,
+				Master:      batch.Master,
+			})
+		}
+
+		if !batch.IsCheckpoint() {
+			continue
+		}
+
+		if err = i.dispatch(ctx, pending); err != nil {
+			return fmt.Errorf("failed to handle transactions of master block %d: %w", batch.Master.SeqNo, err)
+		}
+		pending = nil
+
+		if err = i.store.SaveCheckpoint(ctx, &Checkpoint{MasterSeqNo: batch.Master.SeqNo}); err != nil {
+			return fmt.Errorf("failed to save checkpoint of master block %d: %w", batch.Master.SeqNo, err)
+		}
+	}
+
+	if err = <-streamErr; err != nil {
+		return fmt.Errorf("stream stopped: %w", err)
+	}
+	return nil
+}
+
+// dispatch - handles transactions grouped by account, accounts are processed in parallel
+func (i *Indexer) dispatch(ctx context.Context, list []*Transaction) error {
+	var order []string
+	groups := map[string][]*Transaction{}
+	for _, tx := range list {
+		key := addrKey(tx.Address)
+		if _, ok := groups[key]; !ok {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], tx)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	var once sync.Once
+	var firstErr error
+	sem := make(chan struct{}, i.workers)
+
+	for _, key := range order {
+		select {
+		case <-ctx.Done():
+		case sem <- struct{}{}:
+			wg.Add(1)
+			go func(txs []*Transaction) {
+				defer func() {
+					<-sem
+					wg.Done()
+				}()
+
+				for _, tx := range txs {
+					if err := i.handle(ctx, tx); err != nil {
+						once.Do(func() {
+							firstErr = err
+							cancel()
+						})
+						return
+					}
+				}
+			}(groups[key])
+		}
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
+
+func (i *Indexer) handle(ctx context.Context, tx *Transaction) error {
+	i.mx.RLock()
+	handlers := append([]Handler{}, i.all...)
+	handlers = append(handlers, i.byAddr[addrKey(tx.Address)]...)
+	if op, ok := tx.Opcode(); ok {
+		handlers = append(handlers, i.byOp[op]...)
+	}
+	i.mx.RUnlock()
+
+	for _, h := range handlers {
+		if err := h(ctx, tx); err != nil {
+			return fmt.Errorf("handler failed on tx %d of %s: %w", tx.LT, tx.Address.String(), err)
+		}
+	}
+	return nil
+}
+
+// Opcode - first 32 bits of inbound message body, false when there is no inbound message or body is shorter
+func (t *Transaction) Opcode() (uint32, bool) {
+	if t.IO.In == nil {
+		return 0, false
+	}
+
+	var body *cell.Cell
+	switch t.IO.In.MsgType {
+	case tlb.MsgTypeInternal:
+		body = t.IO.In.AsInternal().Payload()
+	case tlb.MsgTypeExternalIn:
+		body = t.IO.In.AsExternalIn().Payload()
+	}
+	if body == nil {
+		return 0, false
+	}
+
+	op, err := body.BeginParse().LoadUInt(32)
+	if err != nil {
+		return 0, false
+	}
+	return uint32(op), true
+}
+
+func addrKey(addr *address.Address) string {
+	return fmt.Sprintf("%d:%x", addr.Workchain(), addr.Data())
+}
@@ -0,0 +1,192 @@
+package indexer
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+type streamMock struct {
+	ton.APIClientWrapped
+
+	masters map[uint32][]*ton.TransactionsBatch
+	started []uint32
+}
+
+func (s *streamMock) StreamTransactions(ctx context.Context, lastProcessedMasterSeqno uint32, channel chan<- *ton.TransactionsBatch) error {
+	defer close(channel)
+	s.started = append(s.started, lastProcessedMasterSeqno)
+
+	for seqno := lastProcessedMasterSeqno + 1; ; seqno++ {
+		batches, ok := s.masters[seqno]
+		if !ok {
+			return nil
+		}
+		for _, b := range batches {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case channel <- b:
+			}
+		}
+	}
+}
+
+var testAccA = make([]byte, 32)
+var testAccB = append(make([]byte, 31), 1)
+
+func testTx(acc []byte, lt uint64, op int64) *tlb.Transaction {
+	tx := &tlb.Transaction{AccountAddr: acc, LT: lt}
+	body := cell.BeginCell()
+	if op >= 0 {
+		body.MustStoreUInt(uint64(op), 32)
+	}
+	tx.IO.In = &tlb.Message{MsgType: tlb.MsgTypeInternal, Msg: &tlb.InternalMessage{Body: body.EndCell()}}
+	return tx
+}
+
+func testMasters() map[uint32][]*ton.TransactionsBatch {
+	master := func(seqno uint32) *ton.BlockIDExt {
+		return &ton.BlockIDExt{Workchain: address.MasterchainID, Shard: -0x8000000000000000, SeqNo: seqno}
+	}
+	shard := &ton.BlockIDExt{Workchain: 0, Shard: -0x8000000000000000, SeqNo: 500}
+
+	return map[uint32][]*ton.TransactionsBatch{
+		11: {
+			{Master: master(11), Block: shard, Transactions: []*tlb.Transaction{testTx(testAccA, 1, 0x7362d09c), testTx(testAccB, 2, -1), testTx(testAccA, 3, 5)}},
+			{Master: master(11), Block: master(11)},
+		},
+		12: {
+			{Master: master(12), Block: master(12), Transactions: []*tlb.Transaction{testTx(testAccB, 4, 0x7362d09c)}},
+		},
+	}
+}
+
+func TestIndexer_Run(t *testing.T) {
+	api := &streamMock{masters: testMasters()}
+	store := NewMemoryCheckpointStore()
+
+	idx := NewIndexer(api, store)
+	idx.SetStartSeqno(10)
+
+	var mx sync.Mutex
+	var all, byA, byOp []uint64
+	idx.OnTransaction(func(ctx context.Context, tx *Transaction) error {
+		mx.Lock()
+		defer mx.Unlock()
+		all = append(all, tx.LT)
+		return nil
+	})
+	idx.OnAddress(address.NewAddress(0, 0, testAccA), func(ctx context.Context, tx *Transaction) error {
+		mx.Lock()
+		defer mx.Unlock()
+		byA = append(byA, tx.LT)
+		return nil
+	})
+	idx.OnOpcode(0x7362d09c, func(ctx context.Context, tx *Transaction) error {
+		mx.Lock()
+		defer mx.Unlock()
+		byOp = append(byOp, tx.LT)
+		return nil
+	})
+
+	if err := idx.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(all) != 4 {
+		t.Fatal("incorrect number of handled transactions", all)
+	}
+	if len(byA) != 2 || byA[0] != 1 || byA[1] != 3 {
+		t.Fatal("incorrect address handler calls", byA)
+	}
+	if len(byOp) != 2 {
+		t.Fatal("incorrect opcode handler calls", byOp)
+	}
+
+	cp, err := store.LoadCheckpoint(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cp == nil || cp.MasterSeqNo != 12 {
+		t.Fatal("incorrect checkpoint", cp)
+	}
+	if api.started[0] != 10 {
+		t.Fatal("incorrect start seqno", api.started)
+	}
+}
+
+func TestIndexer_RunResume(t *testing.T) {
+	api := &streamMock{masters: testMasters()}
+	store := NewMemoryCheckpointStore()
+
+	idx := NewIndexer(api, store)
+	idx.SetStartSeqno(10)
+
+	failOn := uint64(4)
+	errTest := errors.New("test")
+	var handled []uint64
+	idx.OnTransaction(func(ctx context.Context, tx *Transaction) error {
+		if tx.LT == failOn {
+			return errTest
+		}
+		handled = append(handled, tx.LT)
+		return nil
+	})
+	idx.SetWorkers(1)
+
+	if err := idx.Run(context.Background()); !errors.Is(err, errTest) {
+		t.Fatal("expected handler error, got", err)
+	}
+
+	cp, err := store.LoadCheckpoint(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cp == nil || cp.MasterSeqNo != 11 {
+		t.Fatal("checkpoint should stay on the last fully processed master", cp)
+	}
+
+	failOn = 0
+	if err = idx.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if api.started[1] != 11 {
+		t.Fatal("should continue after checkpoint", api.started)
+	}
+	if len(handled) != 4 || handled[3] != 4 {
+		t.Fatal("incorrect handled transactions", handled)
+	}
+}
+
+func TestFileCheckpointStore(t *testing.T) {
+	store := NewFileCheckpointStore(filepath.Join(t.TempDir(), "checkpoint.json"))
+
+	cp, err := store.LoadCheckpoint(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cp != nil {
+		t.Fatal("checkpoint should be nil when file not exists")
+	}
+
+	err = store.SaveCheckpoint(context.Background(), &Checkpoint{MasterSeqNo: 777})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cp, err = store.LoadCheckpoint(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cp.MasterSeqNo != 777 {
+		t.Fatal("incorrect loaded checkpoint", cp)
+	}
+}
@@ -655,6 +655,40 @@ func TestAPIClient_GetBlockProofForward(t *testing.T) {
 	}
 }
 
+func TestAPIClient_VerifyBlock(t *testing.T) {
+	ctx := api.Client().StickyContext(context.Background())
+
+	master, err := api.CurrentMasterchainInfo(ctx)
+	if err != nil {
+		t.Fatal("get block err:", err.Error())
+		return
+	}
+
+	shards, err := api.GetBlockShardsInfo(ctx, master)
+	if err != nil {
+		t.Fatal("get shards err:", err.Error())
+		return
+	}
+
+	prev, err := api.LookupBlock(ctx, shards[0].Workchain, shards[0].Shard, shards[0].SeqNo-3)
+	if err != nil {
+		t.Fatal("lookup shard block err:", err.Error())
+		return
+	}
+
+	for _, b := range []*BlockIDExt{master, shards[0], prev} {
+		if err = api.VerifyBlock(ctx, b); err != nil {
+			t.Fatal("failed to verify block:", err.Error())
+		}
+	}
+
+	fake := prev.Copy()
+	fake.RootHash[0] ^= 0xFF
+	if err = api.VerifyBlock(ctx, fake); err == nil {
+		t.Fatal("fake block should not be verified")
+	}
+}
+
 func TestAPIClient_SubscribeOnTransactions(t *testing.T) {
 	_ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
@@ -0,0 +1,410 @@
+package multisig
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/ton/wallet"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+// Multisig v2 contract, https://github.com/ton-blockchain/multisig-contract-v2
+
+const (
+	OpNewOrder        uint32 = 0xf718510f
+	OpExecute         uint32 = 0x75097f5d
+	OpExecuteInternal uint32 = 0xa32c59bf
+
+	OpOrderInit            uint32 = 0x9c73fba2
+	OpOrderApprove         uint32 = 0xa762230f
+	OpOrderApproveAccepted uint32 = 0x82609bf6
+	OpOrderApproveRejected uint32 = 0xafaf283e
+)
+
+// MaxSigners - signers and proposers are indexed by uint8
+const MaxSigners = 255
+
+var ErrNotSignerOrProposer = errors.New("address is not a signer or proposer of multisig")
+var ErrOrderSeqnoRequired = errors.New("multisig allows arbitrary order seqno, it should be set by creator")
+
+// Config - initial parameters of multisig
+type Config struct {
+	Threshold uint8
+	Signers   []*address.Address
+	Proposers []*address.Address
+	// AllowArbitrarySeqno - when true, order seqno can be chosen by its creator,
+	// otherwise it should be equal to the next order seqno of multisig
+	AllowArbitrarySeqno bool
+	// NextOrderSeqno - zero when nil
+	NextOrderSeqno *big.Int
+}
+
+// Data - state of multisig returned by get_multisig_data
+type Data struct {
+	// NextOrderSeqno - nil when AllowArbitrarySeqno is true
+	NextOrderSeqno *big.Int
+	// AllowArbitrarySeqno - contract reports next order seqno as -1 in this mode
+	AllowArbitrarySeqno bool
+	Threshold           uint8
+	Signers             []*address.Address
+	Proposers           []*address.Address
+}
+
+// NewOrder - parameters for order creation
+type NewOrder struct {
+	QueryID uint64
+	// Seqno - order seqno, next order seqno of multisig is fetched when nil,
+	// it is required when multisig allows arbitrary seqno
+	Seqno *big.Int
+	// ExpiresAt - order can not be approved after this time
+	ExpiresAt time.Time
+	Actions   []Action
+}
+
+type Client struct {
+	addr *address.Address
+	api  wallet.TonAPI
+}
+
+func NewClient(api wallet.TonAPI, multisigAddr *address.Address) *Client {
+	return &Client{
+		addr: multisigAddr,
+		api:  api,
+	}
+}
+
+// Address - address of multisig contract
+func (c *Client) Address() *address.Address {
+	return c.addr
+}
+
+// GetStateInit - builds multisig state init, code is the compiled multisig v2 contract
+func GetStateInit(code *cell.Cell, cfg Config) (*tlb.StateInit, error) {
+	if code == nil {
+		return nil, fmt.Errorf("multisig code is not set")
+	}
+
+	data, err := BuildData(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build multisig data: %w", err)
+	}
+
+	return &tlb.StateInit{
+		Code: code,
+		Data: data,
+	}, nil
+}
+
+// AddressFromConfig - calculates address of multisig with the given code and config
+func AddressFromConfig(code *cell.Cell, cfg Config, workchain int8) (*address.Address, error) {
+	state, err := GetStateInit(code, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	stateCell, err := tlb.ToCell(state)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize state init: %w", err)
+	}
+	return address.NewAddress(0, byte(workchain), stateCell.Hash()), nil
+}
+
+// BuildData - builds initial data cell of multisig
+func BuildData(cfg Config) (*cell.Cell, error) {
+	if len(cfg.Signers) == 0 {
+		return nil, fmt.Errorf("at least one signer is required")
+	}
+	if len(cfg.Signers) > MaxSigners || len(cfg.Proposers) > MaxSigners {
+		return nil, fmt.Errorf("too many signers or proposers, max is %d", MaxSigners)
+	}
+	if cfg.Threshold == 0 || int(cfg.Threshold) > len(cfg.Signers) {
+		return nil, fmt.Errorf("threshold should be in range 1..%d", len(cfg.Signers))
+	}
+
+	seqno := cfg.NextOrderSeqno
+	if seqno == nil {
+		seqno = big.NewInt(0)
+	}
+
+	signers, err := addressesToDict(cfg.Signers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build signers dict: %w", err)
+	}
+
+	proposers, err := addressesToDict(cfg.Proposers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build proposers dict: %w", err)
+	}
+
+	b := cell.BeginCell()
+	if err = b.StoreBigUInt(seqno, 256); err != nil {
+		return nil, fmt.Errorf("failed to store next order seqno: %w", err)
+	}
+	return b.MustStoreUInt(uint64(cfg.Threshold), 8).
+		MustStoreRef(signers.AsCell()).
+		MustStoreUInt(uint64(len(cfg.Signers)), 8).
+		MustStoreDict(proposers).
+		MustStoreBoolBit(cfg.AllowArbitrarySeqno).
+		EndCell(), nil
+}
+
+// Deploy - deploys multisig from the wallet and waits for transaction, code is the compiled multisig v2 contract
+func Deploy(ctx context.Context, from *wallet.Wallet, code *cell.Cell, cfg Config, amount tlb.Coins) (*address.Address, error) {
+	state, err := GetStateInit(code, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	addr, _, _, err := from.DeployContractWaitTransaction(ctx, amount,
+		cell.BeginCell().MustStoreUInt(0, 32).MustStoreUInt(0, 64).EndCell(), state.Code, state.Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to deploy multisig: %w", err)
+	}
+
+	return addr, nil
+}
+
+func (c *Client) GetData(ctx context.Context) (*Data, error) {
+	b, err := c.api.CurrentMasterchainInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get masterchain info: %w", err)
+	}
+	return c.GetDataAtBlock(ctx, b)
+}
+
+func (c *Client) GetDataAtBlock(ctx context.Context, b *ton.BlockIDExt) (*Data, error) {
+	res, err := c.api.WaitForBlock(b.SeqNo).RunGetMethod(ctx, b, c.addr, "get_multisig_data")
+	if err != nil {
+		return nil, fmt.Errorf("failed to run get_multisig_data method: %w", err)
+	}
+	return parseData(res)
+}
+
+func parseData(res *ton.ExecutionResult) (*Data, error) {
+	seqno, err := res.Int(0)
+	if err != nil {
+		return nil, fmt.Errorf("next order seqno get err: %w", err)
+	}
+
+	threshold, err := res.Int(1)
+	if err != nil {
+		return nil, fmt.Errorf("threshold get err: %w", err)
+	}
+
+	signersCell, err := res.Cell(2)
+	if err != nil {
+		return nil, fmt.Errorf("signers get err: %w", err)
+	}
+
+	signers, err := dictToAddresses(signersCell)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse signers: %w", err)
+	}
+
+	var proposers []*address.Address
+	if isNil, _ := res.IsNil(3); !isNil {
+		proposersCell, err := res.Cell(3)
+		if err != nil {
+			return nil, fmt.Errorf("proposers get err: %w", err)
+		}
+
+		if proposers, err = dictToAddresses(proposersCell); err != nil {
+			return nil, fmt.Errorf("failed to parse proposers: %w", err)
+		}
+	}
+
+	data := &Data{
+		NextOrderSeqno: seqno,
+		Threshold:      uint8(threshold.Uint64()),
+		Signers:        signers,
+		Proposers:      proposers,
+	}
+	if seqno.Sign() < 0 {
+		data.NextOrderSeqno = nil
+		data.AllowArbitrarySeqno = true
+	}
+	return data, nil
+}
+
+// GetOrderAddress - returns address of order contract with the given seqno
+func (c *Client) GetOrderAddress(ctx context.Context, seqno *big.Int) (*address.Address, error) {
+	b, err := c.api.CurrentMasterchainInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get masterchain info: %w", err)
+	}
+	return c.GetOrderAddressAtBlock(ctx, seqno, b)
+}
+
+func (c *Client) GetOrderAddressAtBlock(ctx context.Context, seqno *big.Int, b *ton.BlockIDExt) (*address.Address, error) {
+	res, err := c.api.WaitForBlock(b.SeqNo).RunGetMethod(ctx, b, c.addr, "get_order_address", seqno)
+	if err != nil {
+		return nil, fmt.Errorf("failed to run get_order_address method: %w", err)
+	}
+
+	x, err := res.Slice(0)
+	if err != nil {
+		return nil, fmt.Errorf("result get err: %w", err)
+	}
+
+	addr, err := x.LoadAddr()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load address from result slice: %w", err)
+	}
+	return addr, nil
+}
+
+// GetOrderEstimate - returns amount required for order storage and execution until expiration
+func (c *Client) GetOrderEstimate(ctx context.Context, actions []Action, expiresAt time.Time) (tlb.Coins, error) {
+	order, err := BuildOrder(actions)
+	if err != nil {
+		return tlb.Coins{}, err
+	}
+
+	b, err := c.api.CurrentMasterchainInfo(ctx)
+	if err != nil {
+		return tlb.Coins{}, fmt.Errorf("failed to get masterchain info: %w", err)
+	}
+
+	res, err := c.api.WaitForBlock(b.SeqNo).RunGetMethod(ctx, b, c.addr, "get_order_estimate", order, expiresAt.Unix())
+	if err != nil {
+		return tlb.Coins{}, fmt.Errorf("failed to run get_order_estimate method: %w", err)
+	}
+
+	amount, err := res.Int(0)
+	if err != nil {
+		return tlb.Coins{}, fmt.Errorf("result get err: %w", err)
+	}
+	return tlb.FromNanoTON(amount), nil
+}
+
+// BuildNewOrderPayload - builds body of new order message,
+// signer flag and index are the position of sender in signers or proposers list
+func BuildNewOrderPayload(order NewOrder, isSigner bool, index uint8) (*cell.Cell, error) {
+	if order.Seqno == nil {
+		return nil, fmt.Errorf("order seqno is not set")
+	}
+	if order.Seqno.Sign() < 0 {
+		return nil, fmt.Errorf("order seqno should not be negative")
+	}
+
+	actions, err := BuildOrder(order.Actions)
+	if err != nil {
+		return nil, err
+	}
+
+	b := cell.BeginCell().
+		MustStoreUInt(uint64(OpNewOrder), 32).
+		MustStoreUInt(order.QueryID, 64)
+	if err = b.StoreBigUInt(order.Seqno, 256); err != nil {
+		return nil, fmt.Errorf("failed to store order seqno: %w", err)
+	}
+
+	return b.MustStoreBoolBit(isSigner).
+		MustStoreUInt(uint64(index), 8).
+		MustStoreUInt(uint64(order.ExpiresAt.Unix()), 48).
+		MustStoreRef(actions).
+		EndCell(), nil
+}
+
+// CreateOrder - sends new order from the wallet of signer or proposer and waits for transaction,
+// amount should cover order estimate, excess is returned back.
+func (c *Client) CreateOrder(ctx context.Context, from *wallet.Wallet, order NewOrder, amount tlb.Coins) (*OrderClient, error) {
+	data, err := c.GetData(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get multisig data: %w", err)
+	}
+
+	isSigner := true
+	index, ok := findAddress(data.Signers, from.WalletAddress())
+	if !ok {
+		isSigner = false
+		if index, ok = findAddress(data.Proposers, from.WalletAddress()); !ok {
+			return nil, ErrNotSignerOrProposer
+		}
+	}
+
+	if order.Seqno == nil {
+		if data.AllowArbitrarySeqno {
+			return nil, ErrOrderSeqnoRequired
+		}
+		order.Seqno = data.NextOrderSeqno
+	}
+	if order.QueryID == 0 {
+		order.QueryID = randomQueryID()
+	}
+
+	body, err := BuildNewOrderPayload(order, isSigner, index)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build new order payload: %w", err)
+	}
+
+	if _, _, err = from.SendWaitTransaction(ctx, wallet.SimpleMessage(c.addr, amount, body)); err != nil {
+		return nil, fmt.Errorf("failed to send new order: %w", err)
+	}
+
+	orderAddr, err := c.GetOrderAddress(ctx, order.Seqno)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order address: %w", err)
+	}
+	return NewOrderClient(c.api, orderAddr), nil
+}
+
+func findAddress(list []*address.Address, addr *address.Address) (uint8, bool) {
+	for i, a := range list {
+		if a != nil && a.Workchain() == addr.Workchain() && bytes.Equal(a.Data(), addr.Data()) {
+			return uint8(i), true
+		}
+	}
+	return 0, false
+}
+
+func addressesToDict(list []*address.Address) (*cell.Dictionary, error) {
+	dict := cell.NewDict(8)
+	for i, a := range list {
+		v := cell.BeginCell()
+		if err := v.StoreAddr(a); err != nil {
+			return nil, fmt.Errorf("failed to store address %d: %w", i, err)
+		}
+		if err := dict.SetIntKey(big.NewInt(int64(i)), v.EndCell()); err != nil {
+			return nil, fmt.Errorf("failed to set address %d: %w", i, err)
+		}
+	}
+	return dict, nil
+}
+
+func dictToAddresses(root *cell.Cell) ([]*address.Address, error) {
+	kvs, err := root.AsDict(8).LoadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load dict: %w", err)
+	}
+
+	list := make([]*address.Address, len(kvs))
+	for _, kv := range kvs {
+		idx := kv.Key.MustLoadUInt(8)
+		if idx >= uint64(len(kvs)) {
+			return nil, fmt.Errorf("address index %d is out of range", idx)
+		}
+
+		if list[idx], err = kv.Value.LoadAddr(); err != nil {
+			return nil, fmt.Errorf("failed to load address %d: %w", idx, err)
+		}
+	}
+	return list, nil
+}
+
+func randomQueryID() uint64 {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return uint64(time.Now().UnixNano())
+	}
+	return binary.LittleEndian.Uint64(buf)
+}
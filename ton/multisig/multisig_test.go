@@ -0,0 +1,205 @@
+package multisig
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/ton/wallet"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+var (
+	signer1  = address.MustParseAddr("EQCD39VS5jcptHL8vMjEXrzGaRcCVYto7HUn4bpAOg8xqB2N")
+	signer2  = address.MustParseAddr("EQDnYZIpTwo9RN_84KZX3qIkLVIUJSo8d1yz1vMlKAp2uRtK")
+	proposer = address.MustParseAddr("EQBx6tZZWa2Tbv6BvgcvegoOQxkRrVaBVwBOoW85nbP37_Go")
+)
+
+func TestBuildData(t *testing.T) {
+	data, err := BuildData(Config{
+		Threshold: 2,
+		Signers:   []*address.Address{signer1, signer2},
+		Proposers: []*address.Address{proposer},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := data.BeginParse()
+	if s.MustLoadBigUInt(256).Sign() != 0 {
+		t.Fatal("seqno should be zero")
+	}
+	if s.MustLoadUInt(8) != 2 {
+		t.Fatal("incorrect threshold")
+	}
+
+	signers, err := dictToAddresses(s.MustLoadRef().MustToCell())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(signers) != 2 || signers[1].String() != signer2.String() {
+		t.Fatal("incorrect signers")
+	}
+	if s.MustLoadUInt(8) != 2 {
+		t.Fatal("incorrect signers num")
+	}
+
+	proposers := s.MustLoadDict(8)
+	if proposers.Size() != 1 {
+		t.Fatal("incorrect proposers")
+	}
+	if s.MustLoadBoolBit() {
+		t.Fatal("arbitrary seqno should be disabled")
+	}
+	if s.BitsLeft() != 0 || s.RefsNum() != 0 {
+		t.Fatal("data has extra fields")
+	}
+
+	if _, err = BuildData(Config{Threshold: 3, Signers: []*address.Address{signer1, signer2}}); err == nil {
+		t.Fatal("threshold above signers num should be rejected")
+	}
+}
+
+func TestOrder(t *testing.T) {
+	messages := []*wallet.Message{
+		wallet.SimpleMessage(signer1, tlb.MustFromTON("1.5"), cell.BeginCell().MustStoreUInt(0, 32).EndCell()),
+		wallet.SimpleMessage(signer2, tlb.MustFromTON("0.1"), nil),
+	}
+	messages[1].Mode = 1
+
+	actions := append(ActionsFromMessages(messages), &UpdateParamsAction{
+		Threshold: 1,
+		Signers:   []*address.Address{signer2},
+	})
+
+	order, err := BuildOrder(actions)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := ParseOrder(order)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parsed) != 3 {
+		t.Fatal("incorrect actions num", len(parsed))
+	}
+
+	for i, m := range messages {
+		send, ok := parsed[i].(*SendMessageAction)
+		if !ok {
+			t.Fatal("incorrect action type", i)
+		}
+		if send.Message.Mode != m.Mode || send.Message.InternalMessage.Amount.String() != m.InternalMessage.Amount.String() ||
+			send.Message.InternalMessage.DstAddr.String() != m.InternalMessage.DstAddr.String() {
+			t.Fatal("incorrect message", i)
+		}
+	}
+
+	upd, ok := parsed[2].(*UpdateParamsAction)
+	if !ok || upd.Threshold != 1 || len(upd.Signers) != 1 || upd.Signers[0].String() != signer2.String() || upd.Proposers != nil {
+		t.Fatal("incorrect update action")
+	}
+
+	body, err := BuildNewOrderPayload(NewOrder{
+		QueryID:   7,
+		Seqno:     big.NewInt(3),
+		ExpiresAt: time.Unix(1700000000, 0),
+		Actions:   actions,
+	}, false, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := body.BeginParse()
+	if uint32(s.MustLoadUInt(32)) != OpNewOrder || s.MustLoadUInt(64) != 7 || s.MustLoadBigUInt(256).Uint64() != 3 {
+		t.Fatal("incorrect order header")
+	}
+	if s.MustLoadBoolBit() || s.MustLoadUInt(8) != 5 || s.MustLoadUInt(48) != 1700000000 {
+		t.Fatal("incorrect order params")
+	}
+	if string(s.MustLoadRef().MustToCell().Hash()) != string(order.Hash()) {
+		t.Fatal("incorrect order cell")
+	}
+
+	if _, err = BuildNewOrderPayload(NewOrder{Seqno: big.NewInt(-1), Actions: actions}, true, 0); err == nil {
+		t.Fatal("negative seqno should be rejected")
+	}
+
+	if _, err = BuildOrder(nil); err == nil {
+		t.Fatal("empty order should be rejected")
+	}
+}
+
+func TestParseOrderData(t *testing.T) {
+	signers, err := addressesToDict([]*address.Address{signer1, signer2})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	order, err := BuildOrder(ActionsFromMessages([]*wallet.Message{
+		wallet.SimpleMessage(signer1, tlb.MustFromTON("1"), nil),
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ms := cell.BeginCell().MustStoreAddr(proposer).EndCell()
+
+	data, err := parseOrderData(ton.NewExecutionResult([]any{
+		ms.BeginParse(), big.NewInt(3), nil, nil, nil, nil, nil, nil, nil,
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data.Initialized || data.Seqno.Uint64() != 3 {
+		t.Fatal("order should be not initialized")
+	}
+
+	data, err = parseOrderData(ton.NewExecutionResult([]any{
+		ms.BeginParse(), big.NewInt(3), big.NewInt(2), big.NewInt(0), signers.AsCell(),
+		big.NewInt(0b10), big.NewInt(1), big.NewInt(1700000000), order,
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !data.Initialized || data.SentForExecution || data.Threshold != 2 || data.ApprovalsNum != 1 {
+		t.Fatal("incorrect order data")
+	}
+	if data.IsApprovedBy(0) || !data.IsApprovedBy(1) {
+		t.Fatal("incorrect approvals")
+	}
+	if len(data.Signers) != 2 || len(data.Actions) != 1 || data.ExpiresAt.Unix() != 1700000000 {
+		t.Fatal("incorrect order contents")
+	}
+
+	msData, err := parseData(ton.NewExecutionResult([]any{
+		big.NewInt(4), big.NewInt(2), signers.AsCell(), nil,
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msData.NextOrderSeqno.Uint64() != 4 || msData.Threshold != 2 || len(msData.Signers) != 2 || msData.Proposers != nil {
+		t.Fatal("incorrect multisig data")
+	}
+
+	arbitrary, err := parseData(ton.NewExecutionResult([]any{
+		big.NewInt(-1), big.NewInt(2), signers.AsCell(), nil,
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !arbitrary.AllowArbitrarySeqno || arbitrary.NextOrderSeqno != nil {
+		t.Fatal("arbitrary seqno mode should be detected")
+	}
+
+	if idx, ok := findAddress(msData.Signers, signer2); !ok || idx != 1 {
+		t.Fatal("signer should be found")
+	}
+	if _, ok := findAddress(msData.Signers, proposer); ok {
+		t.Fatal("proposer is not a signer")
+	}
+}
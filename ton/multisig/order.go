@@ -0,0 +1,370 @@
+package multisig
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/ton/wallet"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+const (
+	ActionSendMessage          uint32 = 0xf1381e5b
+	ActionUpdateMultisigParams uint32 = 0x1d0cfbd3
+)
+
+// Action - order action, executed by multisig when threshold is reached
+type Action interface {
+	ToCell() (*cell.Cell, error)
+}
+
+// SendMessageAction - multisig sends message with the given mode
+type SendMessageAction struct {
+	Message *wallet.Message
+}
+
+// UpdateParamsAction - multisig replaces its threshold, signers and proposers
+type UpdateParamsAction struct {
+	Threshold uint8
+	Signers   []*address.Address
+	Proposers []*address.Address
+}
+
+// OrderData - state of order returned by get_order_data
+type OrderData struct {
+	Multisig *address.Address
+	Seqno    *big.Int
+	// Initialized - false when order contract is deployed but not initialized by multisig yet
+	Initialized bool
+
+	Threshold        uint8
+	SentForExecution bool
+	Signers          []*address.Address
+	ApprovalsMask    *big.Int
+	ApprovalsNum     uint8
+	ExpiresAt        time.Time
+	Actions          []Action
+}
+
+// IsApprovedBy - checks if signer with the given index approved order
+func (d *OrderData) IsApprovedBy(index uint8) bool {
+	return d.ApprovalsMask != nil && d.ApprovalsMask.Bit(int(index)) == 1
+}
+
+func (a *SendMessageAction) ToCell() (*cell.Cell, error) {
+	if a.Message == nil || a.Message.InternalMessage == nil {
+		return nil, fmt.Errorf("message is not set")
+	}
+
+	msg, err := tlb.ToCell(a.Message.InternalMessage)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert internal message to cell: %w", err)
+	}
+
+	return cell.BeginCell().
+		MustStoreUInt(uint64(ActionSendMessage), 32).
+		MustStoreUInt(uint64(a.Message.Mode), 8).
+		MustStoreRef(msg).
+		EndCell(), nil
+}
+
+func (a *UpdateParamsAction) ToCell() (*cell.Cell, error) {
+	if len(a.Signers) == 0 {
+		return nil, fmt.Errorf("at least one signer is required")
+	}
+	if len(a.Signers) > MaxSigners || len(a.Proposers) > MaxSigners {
+		return nil, fmt.Errorf("too many signers or proposers, max is %d", MaxSigners)
+	}
+	if a.Threshold == 0 || int(a.Threshold) > len(a.Signers) {
+		return nil, fmt.Errorf("threshold should be in range 1..%d", len(a.Signers))
+	}
+
+	signers, err := addressesToDict(a.Signers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build signers dict: %w", err)
+	}
+
+	proposers, err := addressesToDict(a.Proposers)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build proposers dict: %w", err)
+	}
+
+	return cell.BeginCell().
+		MustStoreUInt(uint64(ActionUpdateMultisigParams), 32).
+		MustStoreUInt(uint64(a.Threshold), 8).
+		MustStoreRef(signers.AsCell()).
+		MustStoreDict(proposers).
+		EndCell(), nil
+}
+
+// ActionsFromMessages - wraps wallet messages to send message actions
+func ActionsFromMessages(messages []*wallet.Message) []Action {
+	actions := make([]Action, 0, len(messages))
+	for _, m := range messages {
+		actions = append(actions, &SendMessageAction{Message: m})
+	}
+	return actions
+}
+
+// BuildOrder - serializes actions to order dictionary, which is stored in order contract
+func BuildOrder(actions []Action) (*cell.Cell, error) {
+	if len(actions) == 0 {
+		return nil, fmt.Errorf("order should contain at least one action")
+	}
+	if len(actions) > 255 {
+		return nil, fmt.Errorf("max 255 actions allowed in order")
+	}
+
+	dict := cell.NewDict(8)
+	for i, action := range actions {
+		c, err := action.ToCell()
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize action %d: %w", i, err)
+		}
+
+		if err = dict.SetIntKey(big.NewInt(int64(i)), cell.BeginCell().MustStoreRef(c).EndCell()); err != nil {
+			return nil, fmt.Errorf("failed to set action %d: %w", i, err)
+		}
+	}
+	return dict.AsCell(), nil
+}
+
+// ParseOrder - parses order dictionary to actions, to review it before approval
+func ParseOrder(order *cell.Cell) ([]Action, error) {
+	kvs, err := order.AsDict(8).LoadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load order dict: %w", err)
+	}
+
+	actions := make([]Action, len(kvs))
+	for _, kv := range kvs {
+		idx := kv.Key.MustLoadUInt(8)
+		if idx >= uint64(len(kvs)) {
+			return nil, fmt.Errorf("action index %d is out of range", idx)
+		}
+
+		ref, err := kv.Value.LoadRef()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load action %d: %w", idx, err)
+		}
+
+		if actions[idx], err = parseAction(ref); err != nil {
+			return nil, fmt.Errorf("failed to parse action %d: %w", idx, err)
+		}
+	}
+	return actions, nil
+}
+
+func parseAction(s *cell.Slice) (Action, error) {
+	op, err := s.LoadUInt(32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load op: %w", err)
+	}
+
+	switch uint32(op) {
+	case ActionSendMessage:
+		mode, err := s.LoadUInt(8)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load mode: %w", err)
+		}
+
+		msgCell, err := s.LoadRef()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load message: %w", err)
+		}
+
+		var msg tlb.InternalMessage
+		if err = tlb.LoadFromCell(&msg, msgCell); err != nil {
+			return nil, fmt.Errorf("failed to parse message: %w", err)
+		}
+
+		return &SendMessageAction{Message: &wallet.Message{
+			Mode:            uint8(mode),
+			InternalMessage: &msg,
+		}}, nil
+	case ActionUpdateMultisigParams:
+		threshold, err := s.LoadUInt(8)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load threshold: %w", err)
+		}
+
+		signersCell, err := s.LoadRefCell()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load signers: %w", err)
+		}
+
+		signers, err := dictToAddresses(signersCell)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse signers: %w", err)
+		}
+
+		proposersCell, err := s.LoadMaybeRef()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load proposers: %w", err)
+		}
+
+		var proposers []*address.Address
+		if proposersCell != nil {
+			if proposers, err = dictToAddresses(proposersCell.MustToCell()); err != nil {
+				return nil, fmt.Errorf("failed to parse proposers: %w", err)
+			}
+		}
+
+		return &UpdateParamsAction{
+			Threshold: uint8(threshold),
+			Signers:   signers,
+			Proposers: proposers,
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown action op %x", op)
+}
+
+type OrderClient struct {
+	addr *address.Address
+	api  wallet.TonAPI
+}
+
+func NewOrderClient(api wallet.TonAPI, orderAddr *address.Address) *OrderClient {
+	return &OrderClient{
+		addr: orderAddr,
+		api:  api,
+	}
+}
+
+// Address - address of order contract
+func (c *OrderClient) Address() *address.Address {
+	return c.addr
+}
+
+func (c *OrderClient) GetData(ctx context.Context) (*OrderData, error) {
+	b, err := c.api.CurrentMasterchainInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get masterchain info: %w", err)
+	}
+	return c.GetDataAtBlock(ctx, b)
+}
+
+func (c *OrderClient) GetDataAtBlock(ctx context.Context, b *ton.BlockIDExt) (*OrderData, error) {
+	res, err := c.api.WaitForBlock(b.SeqNo).RunGetMethod(ctx, b, c.addr, "get_order_data")
+	if err != nil {
+		return nil, fmt.Errorf("failed to run get_order_data method: %w", err)
+	}
+	return parseOrderData(res)
+}
+
+func parseOrderData(res *ton.ExecutionResult) (*OrderData, error) {
+	msRes, err := res.Slice(0)
+	if err != nil {
+		return nil, fmt.Errorf("multisig get err: %w", err)
+	}
+
+	multisig, err := msRes.LoadAddr()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load multisig address from result slice: %w", err)
+	}
+
+	seqno, err := res.Int(1)
+	if err != nil {
+		return nil, fmt.Errorf("seqno get err: %w", err)
+	}
+
+	data := &OrderData{
+		Multisig: multisig,
+		Seqno:    seqno,
+	}
+
+	// fields are null until order is initialized by multisig
+	if isNil, _ := res.IsNil(2); isNil {
+		return data, nil
+	}
+	data.Initialized = true
+
+	threshold, err := res.Int(2)
+	if err != nil {
+		return nil, fmt.Errorf("threshold get err: %w", err)
+	}
+	data.Threshold = uint8(threshold.Uint64())
+
+	executed, err := res.Int(3)
+	if err != nil {
+		return nil, fmt.Errorf("sent for execution get err: %w", err)
+	}
+	data.SentForExecution = executed.Sign() != 0
+
+	signersCell, err := res.Cell(4)
+	if err != nil {
+		return nil, fmt.Errorf("signers get err: %w", err)
+	}
+
+	if data.Signers, err = dictToAddresses(signersCell); err != nil {
+		return nil, fmt.Errorf("failed to parse signers: %w", err)
+	}
+
+	if data.ApprovalsMask, err = res.Int(5); err != nil {
+		return nil, fmt.Errorf("approvals mask get err: %w", err)
+	}
+
+	approvals, err := res.Int(6)
+	if err != nil {
+		return nil, fmt.Errorf("approvals num get err: %w", err)
+	}
+	data.ApprovalsNum = uint8(approvals.Uint64())
+
+	expiration, err := res.Int(7)
+	if err != nil {
+		return nil, fmt.Errorf("expiration date get err: %w", err)
+	}
+	data.ExpiresAt = time.Unix(expiration.Int64(), 0)
+
+	order, err := res.Cell(8)
+	if err != nil {
+		return nil, fmt.Errorf("order get err: %w", err)
+	}
+
+	if data.Actions, err = ParseOrder(order); err != nil {
+		return nil, fmt.Errorf("failed to parse order: %w", err)
+	}
+	return data, nil
+}
+
+// BuildApprovePayload - builds body of approve message, index is the position of sender in signers list
+func BuildApprovePayload(queryID uint64, signerIndex uint8) *cell.Cell {
+	return cell.BeginCell().
+		MustStoreUInt(uint64(OpOrderApprove), 32).
+		MustStoreUInt(queryID, 64).
+		MustStoreUInt(uint64(signerIndex), 8).
+		EndCell()
+}
+
+// Approve - sends approval from the signer wallet and waits for transaction, excess of amount is returned back
+func (c *OrderClient) Approve(ctx context.Context, from *wallet.Wallet, amount tlb.Coins) error {
+	data, err := c.GetData(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get order data: %w", err)
+	}
+
+	if !data.Initialized {
+		return fmt.Errorf("order is not initialized")
+	}
+	if data.SentForExecution {
+		return fmt.Errorf("order is already executed")
+	}
+
+	index, ok := findAddress(data.Signers, from.WalletAddress())
+	if !ok {
+		return fmt.Errorf("address is not a signer of order")
+	}
+	if data.IsApprovedBy(index) {
+		return fmt.Errorf("order is already approved by this signer")
+	}
+
+	if _, _, err = from.SendWaitTransaction(ctx, wallet.SimpleMessage(c.addr, amount, BuildApprovePayload(randomQueryID(), index))); err != nil {
+		return fmt.Errorf("failed to send approval: %w", err)
+	}
+	return nil
+}
@@ -297,41 +297,67 @@ func CheckForwardBlockProof(from, to *BlockIDExt, toKey bool, configProof, destP
 		return fmt.Errorf("failed to check source block proof: %w", err)
 	}
 
-	if fromBlock.Extra == nil || fromBlock.Extra.Custom == nil {
-		return fmt.Errorf("source block proof is lack of info")
+	catchainCfg, blockValidators, err := KeyBlockValidatorsConfig(fromBlock)
+	if err != nil {
+		return fmt.Errorf("failed to get validators config of source block: %w", err)
+	}
+
+	validators, err := getMainValidators(to, *catchainCfg, *blockValidators, toBlock.BlockInfo.GenCatchainSeqno)
+	if err != nil {
+		return fmt.Errorf("failed to verify and get main block validators: %w", err)
+	}
+
+	if err = checkBlockSignatures(to, signatures, validators); err != nil {
+		return fmt.Errorf("failed to check validators signatures: %w", err)
+	}
+
+	return nil
+}
+
+// KeyBlockValidatorsConfig - returns catchain config (param 28) and validators (param 34) from config of key block,
+// they are used to verify signatures of next masterchain blocks
+func KeyBlockValidatorsConfig(block *tlb.Block) (*tlb.CatchainConfig, *tlb.ValidatorSetAny, error) {
+	if block.Extra == nil || block.Extra.Custom == nil || block.Extra.Custom.ConfigParams == nil {
+		return nil, nil, fmt.Errorf("block has no config")
 	}
 
-	catchainCfgCell := fromBlock.Extra.Custom.ConfigParams.Config.Params.GetByIntKey(big.NewInt(28))
-	blockValidatorsCell := fromBlock.Extra.Custom.ConfigParams.Config.Params.GetByIntKey(big.NewInt(34))
+	catchainCfgCell := block.Extra.Custom.ConfigParams.Config.Params.GetByIntKey(big.NewInt(28))
+	blockValidatorsCell := block.Extra.Custom.ConfigParams.Config.Params.GetByIntKey(big.NewInt(34))
 	if catchainCfgCell == nil || blockValidatorsCell == nil {
-		return fmt.Errorf("not all required configs are in proof")
+		return nil, nil, fmt.Errorf("not all required configs are in block")
 	}
+
+	var err error
 	if catchainCfgCell, err = catchainCfgCell.PeekRef(0); err != nil {
-		return fmt.Errorf("no ref in catchain cell")
+		return nil, nil, fmt.Errorf("no ref in catchain cell")
 	}
 	if blockValidatorsCell, err = blockValidatorsCell.PeekRef(0); err != nil {
-		return fmt.Errorf("no ref in validators cell")
+		return nil, nil, fmt.Errorf("no ref in validators cell")
 	}
 
 	var catchainCfg tlb.CatchainConfig
 	if err = tlb.LoadFromCell(&catchainCfg, catchainCfgCell.BeginParse()); err != nil {
-		return fmt.Errorf("failed to parse catchain config: %w", err)
+		return nil, nil, fmt.Errorf("failed to parse catchain config: %w", err)
 	}
 
 	var blockValidators tlb.ValidatorSetAny
 	if err = tlb.LoadFromCell(&blockValidators, blockValidatorsCell.BeginParse()); err != nil {
-		return fmt.Errorf("failed to parse validators config: %w", err)
+		return nil, nil, fmt.Errorf("failed to parse validators config: %w", err)
 	}
+	return &catchainCfg, &blockValidators, nil
+}
 
-	validators, err := getMainValidators(to, catchainCfg, blockValidators, toBlock.BlockInfo.GenCatchainSeqno)
+// CheckMasterBlockSignatures - verifies that masterchain block is signed by more than 2/3 of validators weight,
+// catchain config and validators should be taken from config of the latest key block before it.
+func CheckMasterBlockSignatures(block *BlockIDExt, signatures *SignatureSet, catchainCfg tlb.CatchainConfig, validators tlb.ValidatorSetAny) error {
+	list, err := getMainValidators(block, catchainCfg, validators, uint32(signatures.CatchainSeqno))
 	if err != nil {
-		return fmt.Errorf("failed to verify and get main block validators: %w", err)
+		return fmt.Errorf("failed to get main block validators: %w", err)
 	}
 
-	if err = checkBlockSignatures(to, signatures, validators); err != nil {
+	if err = checkBlockSignatures(block, signatures, list); err != nil {
 		return fmt.Errorf("failed to check validators signatures: %w", err)
 	}
-
 	return nil
 }
 
@@ -530,6 +556,11 @@ func calcValidatorSetHash(ccSeqno uint32, validators []*tlb.ValidatorAddr) (uint
 }
 
 func (c *APIClient) VerifyProofChain(ctx context.Context, from, to *BlockIDExt) error {
+	return c.verifyProofChain(ctx, from, to, nil)
+}
+
+// verifyProofChain - same as VerifyProofChain, onProven is called for each verified block of forward chain
+func (c *APIClient) verifyProofChain(ctx context.Context, from, to *BlockIDExt, onProven func(block *BlockIDExt, isKey bool) error) error {
 	isForward := to.SeqNo > from.SeqNo
 
 	for from.SeqNo != to.SeqNo {
@@ -604,6 +635,12 @@ func (c *APIClient) VerifyProofChain(ctx context.Context, from, to *BlockIDExt)
 				if err != nil {
 					return fmt.Errorf("invalid forward block from %d to %d proof: %w", fwd.From.SeqNo, fwd.To.SeqNo, err)
 				}
+
+				if onProven != nil {
+					if err = onProven(fwd.To, fwd.ToKeyBlock); err != nil {
+						return err
+					}
+				}
 			}
 		} else {
 			for _, step := range part.Steps {
@@ -625,3 +662,153 @@ func (c *APIClient) VerifyProofChain(ctx context.Context, from, to *BlockIDExt)
 	}
 	return nil
 }
+
+// CheckShardBlockProof - verifies links from trusted master block to the shard block,
+// first link proves shard top block by shard hashes of master block, next ones prove previous blocks of the shard
+func CheckShardBlockProof(master, block *BlockIDExt, links []ShardBlockLink) error {
+	if master.Workchain != address.MasterchainID {
+		return fmt.Errorf("proof should start from master block")
+	}
+
+	cur := master
+	for i, link := range links {
+		if link.ID == nil {
+			return fmt.Errorf("link %d has no block id", i)
+		}
+
+		proof, err := cell.FromBOC(link.Proof)
+		if err != nil {
+			return fmt.Errorf("failed to parse proof of link %d: %w", i, err)
+		}
+
+		blk, err := CheckBlockProof(proof, cur.RootHash)
+		if err != nil {
+			return fmt.Errorf("failed to check proof of link %d: %w", i, err)
+		}
+
+		var candidates []*BlockIDExt
+		if cur.Workchain == address.MasterchainID {
+			if blk.Extra == nil || blk.Extra.Custom == nil {
+				return fmt.Errorf("master block proof of link %d has no shard hashes", i)
+			}
+
+			candidates, err = LoadShardsFromHashes(blk.Extra.Custom.ShardHashes, true)
+			if err != nil {
+				return fmt.Errorf("failed to load shard hashes of link %d: %w", i, err)
+			}
+		} else {
+			candidates, err = blk.BlockInfo.GetParentBlocks()
+			if err != nil {
+				return fmt.Errorf("failed to get parent blocks of link %d: %w", i, err)
+			}
+		}
+
+		found := false
+		for _, candidate := range candidates {
+			if candidate.Equals(link.ID) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("block of link %d is not referenced by previous link", i)
+		}
+		cur = link.ID
+	}
+
+	if !cur.Equals(block) {
+		return fmt.Errorf("proof chain ends on another block")
+	}
+	return nil
+}
+
+// VerifyBlock - checks that block is committed by master chain which is proven from the trusted block.
+// Master blocks are verified by proof chain, shard blocks additionally by shard block proof.
+// Verified blocks are cached, so repeated checks of the same block are cheap.
+func (c *APIClient) VerifyBlock(ctx context.Context, block *BlockIDExt) error {
+	if c.isBlockProven(block) {
+		return nil
+	}
+
+	if block.Workchain == address.MasterchainID {
+		return c.verifyMasterBlock(ctx, block)
+	}
+
+	proof, err := c.GetShardBlockProof(ctx, block)
+	if err != nil {
+		return fmt.Errorf("failed to get shard block proof: %w", err)
+	}
+
+	if proof.MasterchainID == nil {
+		return fmt.Errorf("shard block proof has no master block")
+	}
+
+	if err = c.verifyMasterBlock(ctx, proof.MasterchainID); err != nil {
+		return fmt.Errorf("failed to verify master block of shard block proof: %w", err)
+	}
+
+	if err = CheckShardBlockProof(proof.MasterchainID, block, proof.Links); err != nil {
+		return fmt.Errorf("invalid shard block proof: %w", err)
+	}
+
+	c.setBlockProven(block)
+	return nil
+}
+
+func (c *APIClient) verifyMasterBlock(ctx context.Context, master *BlockIDExt) error {
+	if c.isBlockProven(master) {
+		return nil
+	}
+
+	root := c.root()
+	root.trustedLock.RLock()
+	trusted := root.trustedBlock
+	root.trustedLock.RUnlock()
+
+	if trusted == nil {
+		// it will initialize trusted block
+		if _, err := c.GetMasterchainInfo(ctx); err != nil {
+			return fmt.Errorf("failed to init trusted block: %w", err)
+		}
+
+		root.trustedLock.RLock()
+		trusted = root.trustedBlock
+		root.trustedLock.RUnlock()
+
+		if trusted == nil {
+			return fmt.Errorf("trusted block is not set")
+		}
+	}
+
+	if !trusted.Equals(master) {
+		if err := c.VerifyProofChain(ctx, trusted.Copy(), master); err != nil {
+			return fmt.Errorf("failed to verify proof chain: %w", err)
+		}
+	}
+
+	c.setBlockProven(master)
+	return nil
+}
+
+func (c *APIClient) isBlockProven(block *BlockIDExt) bool {
+	root := c.root()
+	root.trustedLock.RLock()
+	defer root.trustedLock.RUnlock()
+
+	return root.provenBlocks[provenBlockKey(block)]
+}
+
+func (c *APIClient) setBlockProven(block *BlockIDExt) {
+	root := c.root()
+	root.trustedLock.Lock()
+	defer root.trustedLock.Unlock()
+
+	if root.provenBlocks == nil || len(root.provenBlocks) >= 4096 {
+		root.provenBlocks = map[string]bool{}
+	}
+	root.provenBlocks[provenBlockKey(block)] = true
+}
+
+func provenBlockKey(block *BlockIDExt) string {
+	return fmt.Sprintf("%d:%d:%d:%x:%x", block.Workchain, block.Shard, block.SeqNo, block.RootHash, block.FileHash)
+}
@@ -0,0 +1,251 @@
+package ton
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tl"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/tvm"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+// LocalGetMethodParams - optional execution context for local get methods
+type LocalGetMethodParams struct {
+	// Now - unix time, current is used when zero
+	Now uint32
+	// Config - blockchain config dictionary root, can be nil
+	Config *cell.Cell
+	// Libraries - library cells which can be referenced by code
+	Libraries []*cell.Cell
+	// GasLimit - tvm.DefaultGetMethodGas is used when zero
+	GasLimit int64
+}
+
+// RunLocalGetMethod - executes get method of account locally, without liteserver
+func RunLocalGetMethod(acc *tlb.Account, method string, params ...any) (*ExecutionResult, error) {
+	return RunLocalGetMethodWithParams(acc, LocalGetMethodParams{}, method, params...)
+}
+
+// RunLocalGetMethodWithParams - executes get method of account locally, using the given context
+func RunLocalGetMethodWithParams(acc *tlb.Account, lp LocalGetMethodParams, method string, params ...any) (*ExecutionResult, error) {
+	if acc == nil || !acc.IsActive || acc.State == nil || acc.Code == nil {
+		return nil, ContractExecError{ErrCodeContractNotInitialized}
+	}
+
+	var balance *big.Int
+	if acc.State.IsValid {
+		balance = acc.State.Balance.Nano()
+	}
+	return runLocalGetMethod(acc.State.Address, acc.Code, acc.Data, balance, lp, method, params...)
+}
+
+// RunLocalGetMethodOnStateInit - executes get method on contract state which is not deployed yet
+func RunLocalGetMethodOnStateInit(addr *address.Address, state *tlb.StateInit, lp LocalGetMethodParams, method string, params ...any) (*ExecutionResult, error) {
+	if state == nil || state.Code == nil {
+		return nil, ContractExecError{ErrCodeContractNotInitialized}
+	}
+	return runLocalGetMethod(addr, state.Code, state.Data, nil, lp, method, params...)
+}
+
+func runLocalGetMethod(addr *address.Address, code, data *cell.Cell, balance *big.Int, lp LocalGetMethodParams, method string, params ...any) (*ExecutionResult, error) {
+	c7, err := tvm.PrepareC7(tvm.C7Params{
+		Address: addr,
+		Now:     lp.Now,
+		Balance: balance,
+		Config:  lp.Config,
+		Code:    code,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare c7: %w", err)
+	}
+
+	gasLimit := lp.GasLimit
+	if gasLimit == 0 {
+		gasLimit = tvm.DefaultGetMethodGas
+	}
+
+	vm := tvm.NewTVM()
+	vm.AddLibraries(lp.Libraries...)
+
+	res, err := vm.RunGetMethod(code, data, c7, tvm.GasWithLimit(gasLimit), tlb.MethodNameHash(method), params...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute get method: %w", err)
+	}
+
+	if res.ExitCode != 0 && res.ExitCode != 1 {
+		return nil, ContractExecError{
+			res.ExitCode,
+		}
+	}
+
+	return NewExecutionResult(res.Stack.Values()), nil
+}
+
+// ErrLocalExecution - get method cannot be executed locally, for example library is missing or tvm cannot be set up
+var ErrLocalExecution = errors.New("local execution is not possible")
+
+// SetLocalGetMethods - when enabled, RunGetMethod does not trust the result returned by liteserver,
+// account state, config and libraries are checked by proofs (and block is verified with ProofCheckPolicySecure),
+// then method is executed locally by tvm. When tvm cannot execute the method, ErrLocalExecution is returned.
+func (c *APIClient) SetLocalGetMethods(enabled bool) {
+	c.root().localGetMethods.Store(enabled)
+}
+
+// SetLocalGetMethodsFallback - when enabled, result of liteserver is returned when local execution is not possible.
+// Liteserver proves only account state, not the result of execution, so such result should be trusted.
+func (c *APIClient) SetLocalGetMethodsFallback(enabled bool) {
+	c.root().localFallback.Store(enabled)
+}
+
+// runGetMethodVerified - executes get method locally on account state which is checked by proofs,
+// so the result does not depend on liteserver honesty.
+func (c *APIClient) runGetMethodVerified(ctx context.Context, block *BlockIDExt, addr *address.Address, method string, params ...any) (*ExecutionResult, error) {
+	if c.proofCheckPolicy == ProofCheckPolicySecure {
+		if err := c.VerifyBlock(ctx, block); err != nil {
+			return nil, fmt.Errorf("failed to verify block: %w", err)
+		}
+	}
+
+	acc, err := c.GetAccount(ctx, block, addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account state: %w", err)
+	}
+
+	if !acc.IsActive || acc.State == nil || acc.State.Status != tlb.AccountStatusActive || acc.Code == nil {
+		return nil, ContractExecError{ErrCodeContractNotInitialized}
+	}
+
+	now, err := c.getBlockTime(ctx, block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get block time: %w", err)
+	}
+
+	lp := LocalGetMethodParams{Now: now}
+	if block.Workchain == address.MasterchainID {
+		// config is stored in master state only
+		if lp.Config, err = c.getConfigRoot(ctx, block); err != nil {
+			return nil, fmt.Errorf("failed to get config: %w", err)
+		}
+	}
+
+	if hashes := findLibraryRefs(acc.Code); len(hashes) > 0 {
+		libs, err := c.GetLibraries(ctx, hashes...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get libraries: %w", err)
+		}
+
+		for i, lib := range libs {
+			if lib == nil {
+				return nil, fmt.Errorf("%w: library %x is not found", ErrLocalExecution, hashes[i])
+			}
+		}
+		lp.Libraries = libs
+	}
+
+	res, err := RunLocalGetMethodWithParams(acc, lp, method, params...)
+	if err != nil {
+		var execErr ContractExecError
+		if errors.As(err, &execErr) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %w", ErrLocalExecution, err)
+	}
+	return res, nil
+}
+
+// getConfigRoot - returns config dictionary of master block, checked by proof
+func (c *APIClient) getConfigRoot(ctx context.Context, block *BlockIDExt) (*cell.Cell, error) {
+	var resp tl.Serializable
+	err := c.client.QueryLiteserver(ctx, GetConfigAll{
+		Mode:    0,
+		BlockID: block,
+	}, &resp)
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := resp.(type) {
+	case ConfigAll:
+		stateExtra, err := CheckShardMcStateExtraProof(block, []*cell.Cell{t.StateProof, t.ConfigProof})
+		if err != nil {
+			return nil, fmt.Errorf("incorrect proof: %w", err)
+		}
+		return stateExtra.ConfigParams.Config.Params.AsCell(), nil
+	case LSError:
+		return nil, t
+	}
+	return nil, errUnexpectedResponse(resp)
+}
+
+// getBlockTime - returns generation time of block, taken from verified header proof
+func (c *APIClient) getBlockTime(ctx context.Context, block *BlockIDExt) (uint32, error) {
+	var resp tl.Serializable
+	err := c.client.QueryLiteserver(ctx, LookupBlock{
+		Mode: 1,
+		ID: &BlockInfoShort{
+			Workchain: block.Workchain,
+			Shard:     block.Shard,
+			Seqno:     int32(block.SeqNo),
+		},
+	}, &resp)
+	if err != nil {
+		return 0, err
+	}
+
+	switch t := resp.(type) {
+	case BlockHeader:
+		if !t.ID.Equals(block) {
+			return 0, fmt.Errorf("incorrect block in response")
+		}
+
+		proof, err := cell.FromBOC(t.HeaderProof)
+		if err != nil {
+			return 0, fmt.Errorf("failed to parse header proof: %w", err)
+		}
+
+		blk, err := CheckBlockProof(proof, block.RootHash)
+		if err != nil {
+			return 0, fmt.Errorf("failed to check header proof: %w", err)
+		}
+		return blk.BlockInfo.GenUtime, nil
+	case LSError:
+		return 0, t
+	}
+	return 0, errUnexpectedResponse(resp)
+}
+
+// findLibraryRefs - returns hashes of libraries referenced from the code
+func findLibraryRefs(code *cell.Cell) [][]byte {
+	var hashes [][]byte
+	visited := map[string]bool{}
+
+	var walk func(c *cell.Cell)
+	walk = func(c *cell.Cell) {
+		key := string(c.Hash())
+		if visited[key] {
+			return
+		}
+		visited[key] = true
+
+		if c.GetType() == cell.LibraryCellType {
+			s := c.BeginParse()
+			if _, err := s.LoadUInt(8); err == nil {
+				if hash, err := s.LoadSlice(256); err == nil {
+					hashes = append(hashes, hash)
+				}
+			}
+			return
+		}
+
+		for i := 0; i < int(c.RefsNum()); i++ {
+			walk(c.MustPeekRef(i))
+		}
+	}
+	walk(code)
+
+	return hashes
+}
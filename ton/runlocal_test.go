@@ -0,0 +1,41 @@
+package ton
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+func TestFindLibraryRefs(t *testing.T) {
+	libCell := func(lib *cell.Cell) *cell.Cell {
+		c := cell.BeginCell().MustStoreUInt(uint64(cell.LibraryCellType), 8).MustStoreSlice(lib.Hash(), 256).EndCell()
+		c.UnsafeModify(cell.LevelMask{}, true)
+		return c
+	}
+
+	lib1 := cell.BeginCell().MustStoreUInt(1, 8).EndCell()
+	lib2 := cell.BeginCell().MustStoreUInt(2, 8).EndCell()
+
+	code := cell.BeginCell().
+		MustStoreRef(libCell(lib1)).
+		MustStoreRef(cell.BeginCell().MustStoreRef(libCell(lib1)).EndCell()).
+		MustStoreRef(libCell(lib2)).
+		EndCell()
+
+	hashes := findLibraryRefs(code)
+	if len(hashes) != 2 {
+		t.Fatal("expected 2 libraries, got", len(hashes))
+	}
+	if !bytes.Equal(hashes[0], lib1.Hash()) || !bytes.Equal(hashes[1], lib2.Hash()) {
+		t.Fatal("incorrect library hashes")
+	}
+
+	if len(findLibraryRefs(lib1)) != 0 {
+		t.Fatal("ordinary code should have no libraries")
+	}
+
+	if hashes = findLibraryRefs(libCell(lib2)); len(hashes) != 1 || !bytes.Equal(hashes[0], lib2.Hash()) {
+		t.Fatal("library code root should be found")
+	}
+}
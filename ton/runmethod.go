@@ -48,7 +48,15 @@ func NewExecutionResult(data []any) *ExecutionResult {
 	return &ExecutionResult{data}
 }
 
+// RunGetMethod - executes get method of account on liteserver, or locally when it is enabled with SetLocalGetMethods
 func (c *APIClient) RunGetMethod(ctx context.Context, blockInfo *BlockIDExt, addr *address.Address, method string, params ...any) (*ExecutionResult, error) {
+	if c.root().localGetMethods.Load() {
+		res, err := c.runGetMethodVerified(ctx, blockInfo, addr, method, params...)
+		if !errors.Is(err, ErrLocalExecution) || !c.root().localFallback.Load() {
+			return res, err
+		}
+	}
+
 	var stack tlb.Stack
 	for i := len(params) - 1; i >= 0; i-- {
 		// push args in reverse order
@@ -25,7 +25,49 @@ type SendMessageStatus struct {
 var ErrMessageNotAccepted = errors.New("message was not accepted by the contract")
 var ErrNoTransactionsWereFound = errors.New("no transactions were found")
 
+// ExternalMessageSender - alternative transport for external messages,
+// for example node.ExternalMessageSender which broadcasts them directly to full nodes
+type ExternalMessageSender interface {
+	SendExternalMessage(ctx context.Context, msg *tlb.ExternalMessage) error
+}
+
+// SetExternalMessageSender - messages will be sent using sender, and using liteserver only when sender fails, nil resets it.
+// It is set for the root client, so it is used by all clients wrapped from it.
+func (c *APIClient) SetExternalMessageSender(sender ExternalMessageSender) {
+	root := c.root()
+	root.extMsgSenderLock.Lock()
+	root.extMsgSender = sender
+	root.extMsgSenderLock.Unlock()
+}
+
+func (c *APIClient) getExternalMessageSender() ExternalMessageSender {
+	root := c.root()
+	root.extMsgSenderLock.RLock()
+	defer root.extMsgSenderLock.RUnlock()
+	return root.extMsgSender
+}
+
 func (c *APIClient) SendExternalMessage(ctx context.Context, msg *tlb.ExternalMessage) error {
+	sender := c.getExternalMessageSender()
+	if sender == nil {
+		return c.sendExternalMessageLiteserver(ctx, msg)
+	}
+
+	senderErr := sender.SendExternalMessage(ctx, msg)
+	if senderErr == nil {
+		return nil
+	}
+	senderErr = fmt.Errorf("external message sender failed: %w", senderErr)
+
+	if err := c.sendExternalMessageLiteserver(ctx, msg); err != nil {
+		return errors.Join(senderErr, err)
+	}
+
+	Logger("external message was sent using liteserver,", senderErr.Error())
+	return nil
+}
+
+func (c *APIClient) sendExternalMessageLiteserver(ctx context.Context, msg *tlb.ExternalMessage) error {
 	req, err := tlb.ToCell(msg)
 	if err != nil {
 		return fmt.Errorf("failed to serialize external message, err: %w", err)
@@ -0,0 +1,80 @@
+package ton
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tl"
+	"github.com/xssnick/tonutils-go/tlb"
+)
+
+type testMessageSender struct {
+	err  error
+	sent []*tlb.ExternalMessage
+}
+
+func (s *testMessageSender) SendExternalMessage(ctx context.Context, msg *tlb.ExternalMessage) error {
+	s.sent = append(s.sent, msg)
+	return s.err
+}
+
+func TestAPIClient_SendExternalMessageWithSender(t *testing.T) {
+	c := NewAPIClient(&failingClient{})
+	msg := &tlb.ExternalMessage{DstAddr: address.MustParseAddr("EQCD39VS5jcptHL8vMjEXrzGaRcCVYto7HUn4bpAOg8xqB2N")}
+
+	sender := &testMessageSender{}
+	c.SetExternalMessageSender(sender)
+
+	// sender is used by wrapped clients too
+	if err := c.WithRetry(1).SendExternalMessage(context.Background(), msg); err != nil {
+		t.Fatal(err)
+	}
+	if len(sender.sent) != 1 || sender.sent[0] != msg {
+		t.Fatal("message was not sent using sender")
+	}
+
+	sender.err = fmt.Errorf("no peers")
+	err := c.SendExternalMessage(context.Background(), msg)
+	if err == nil || !errors.Is(err, sender.err) || !strings.Contains(err.Error(), "not available") {
+		t.Fatal("liteserver should be used when sender fails and both errors returned, got", err)
+	}
+
+	var logged []any
+	Logger = func(v ...any) {
+		logged = append(logged, v...)
+	}
+	defer func() {
+		Logger = func(v ...any) {}
+	}()
+
+	c2 := NewAPIClient(&sendMessageClient{})
+	c2.SetExternalMessageSender(sender)
+	if err = c2.SendExternalMessage(context.Background(), msg); err != nil {
+		t.Fatal("liteserver fallback should succeed, got", err)
+	}
+	if len(logged) == 0 || !strings.Contains(fmt.Sprint(logged...), "no peers") {
+		t.Fatal("sender failure should be logged, got", logged)
+	}
+
+	c.SetExternalMessageSender(nil)
+	_ = c.SendExternalMessage(context.Background(), msg)
+	if len(sender.sent) != 3 {
+		t.Fatal("sender should not be used after reset")
+	}
+}
+
+type sendMessageClient struct {
+	failingClient
+}
+
+func (s *sendMessageClient) QueryLiteserver(ctx context.Context, payload tl.Serializable, result tl.Serializable) error {
+	if _, ok := payload.(SendMessage); !ok {
+		return fmt.Errorf("unexpected query %T", payload)
+	}
+	*result.(*tl.Serializable) = SendMessageStatus{Status: 1}
+	return nil
+}
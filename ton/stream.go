@@ -0,0 +1,299 @@
+package ton
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"time"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/liteclient"
+	"github.com/xssnick/tonutils-go/tlb"
+)
+
+// BlocksUpdate - master block and all shard blocks which were committed by it
+type BlocksUpdate struct {
+	Master *BlockIDExt
+	// Shards - new shard blocks since the previous master block, parents go before children,
+	// blocks which were skipped in master chain (holes) and blocks before splits and merges are included too
+	Shards []*BlockIDExt
+}
+
+// TransactionsBatch - all transactions of a single block, sent by StreamTransactions
+type TransactionsBatch struct {
+	// Master - master block which committed Block, for master block itself it is the same as Block
+	Master *BlockIDExt
+	Block  *BlockIDExt
+	// Transactions - sorted by lt
+	Transactions []*tlb.Transaction
+}
+
+// IsCheckpoint - true for the last batch of master block, when it is processed,
+// Master.SeqNo can be stored and passed later as lastProcessedMasterSeqno to continue without gaps
+func (b *TransactionsBatch) IsCheckpoint() bool {
+	return b.Block.Workchain == address.MasterchainID
+}
+
+var streamRetryWait = 3 * time.Second
+
+// errStreamNotReady - marks errors which mean that data is not produced yet, such requests are retried
+var errStreamNotReady = errors.New("not ready yet")
+
+type shardKey struct {
+	workchain int32
+	shard     int64
+}
+
+// SubscribeOnBlocks - follows master chain starting from the block after lastProcessedMasterSeqno,
+// for each master block sends its new shard blocks into channel. If lastProcessedMasterSeqno is 0 - starts from the current block.
+// Requests failed with network errors and liteserver timeouts are retried until the context is done, so there are no gaps,
+// retries are reported to Logger. Other errors, like missing start block or incorrect proofs, are returned.
+// Blocking, channel is closed on exit.
+func (c *APIClient) SubscribeOnBlocks(ctx context.Context, lastProcessedMasterSeqno uint32, channel chan<- *BlocksUpdate) error {
+	defer close(channel)
+
+	return c.followBlocks(ctx, lastProcessedMasterSeqno, func(update *BlocksUpdate) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case channel <- update:
+			return nil
+		}
+	})
+}
+
+// StreamTransactions - same as SubscribeOnBlocks, but sends transactions of every new block in all shards.
+// For each master block, batches of its shard blocks are sent first, and the batch of master block itself is the last one,
+// see TransactionsBatch.IsCheckpoint. Batches of blocks without transactions are also sent, to be able to track progress.
+// Blocking, channel is closed on exit.
+func (c *APIClient) StreamTransactions(ctx context.Context, lastProcessedMasterSeqno uint32, channel chan<- *TransactionsBatch) error {
+	defer close(channel)
+
+	return c.followBlocks(ctx, lastProcessedMasterSeqno, func(update *BlocksUpdate) error {
+		for _, block := range append(update.Shards, update.Master) {
+			var list []*tlb.Transaction
+			err := retryStream(ctx, func() (err error) {
+				list, err = c.getBlockTransactions(ctx, update.Master, block)
+				return err
+			})
+			if err != nil {
+				return err
+			}
+
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case channel <- &TransactionsBatch{Master: update.Master, Block: block, Transactions: list}:
+			}
+		}
+		return nil
+	})
+}
+
+func (c *APIClient) followBlocks(ctx context.Context, lastProcessedMasterSeqno uint32, handler func(update *BlocksUpdate) error) error {
+	var prev *BlockIDExt
+	err := retryStream(ctx, func() (err error) {
+		if lastProcessedMasterSeqno == 0 {
+			var cur *BlockIDExt
+			if cur, err = c.CurrentMasterchainInfo(ctx); err != nil {
+				return err
+			}
+			lastProcessedMasterSeqno = cur.SeqNo - 1
+		}
+		prev, err = c.LookupBlock(ctx, address.MasterchainID, -0x8000000000000000, lastProcessedMasterSeqno)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to lookup start block: %w", err)
+	}
+
+	var shards []*BlockIDExt
+	if err = retryStream(ctx, func() (err error) {
+		shards, err = c.GetBlockShardsInfo(ctx, prev)
+		return err
+	}); err != nil {
+		return fmt.Errorf("failed to get shards of start block: %w", err)
+	}
+
+	lastSeen := map[shardKey]uint32{}
+	for _, shard := range shards {
+		lastSeen[shardKey{shard.Workchain, shard.Shard}] = shard.SeqNo
+	}
+
+	for {
+		seqno := prev.SeqNo + 1
+
+		var master *BlockIDExt
+		if err = retryStream(ctx, func() (err error) {
+			master, err = c.WaitForBlock(seqno).LookupBlock(ctx, address.MasterchainID, prev.Shard, seqno)
+			if errors.Is(err, ErrBlockNotFound) {
+				// block is not produced yet, or not synced by liteserver
+				return fmt.Errorf("%w: %w", errStreamNotReady, err)
+			}
+			return err
+		}); err != nil {
+			return fmt.Errorf("failed to lookup master block %d: %w", seqno, err)
+		}
+
+		if err = retryStream(ctx, func() (err error) {
+			shards, err = c.GetBlockShardsInfo(ctx, master)
+			return err
+		}); err != nil {
+			return fmt.Errorf("failed to get shards of master block %d: %w", seqno, err)
+		}
+
+		var newShards []*BlockIDExt
+		if err = retryStream(ctx, func() (err error) {
+			newShards, err = resolveNewShardBlocks(ctx, shards, lastSeen, c.getParentBlocks)
+			return err
+		}); err != nil {
+			return fmt.Errorf("failed to resolve shard blocks of master block %d: %w", seqno, err)
+		}
+
+		for _, shard := range newShards {
+			key := shardKey{shard.Workchain, shard.Shard}
+			if shard.SeqNo > lastSeen[key] {
+				lastSeen[key] = shard.SeqNo
+			}
+		}
+
+		if err = handler(&BlocksUpdate{Master: master, Shards: newShards}); err != nil {
+			return err
+		}
+		prev = master
+	}
+}
+
+func (c *APIClient) getParentBlocks(ctx context.Context, block *BlockIDExt) ([]*BlockIDExt, error) {
+	data, err := c.GetBlockData(ctx, block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get block data: %w", err)
+	}
+
+	parents, err := data.BlockInfo.GetParentBlocks()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get parent blocks (%d:%x:%d): %w", block.Workchain, uint64(block.Shard), block.SeqNo, err)
+	}
+	return parents, nil
+}
+
+// resolveNewShardBlocks - walks back from the given top shard blocks till already seen ones,
+// lastSeen is not modified, result is ordered so parents go before children
+func resolveNewShardBlocks(ctx context.Context, shards []*BlockIDExt, lastSeen map[shardKey]uint32,
+	getParents func(ctx context.Context, block *BlockIDExt) ([]*BlockIDExt, error)) ([]*BlockIDExt, error) {
+	var res []*BlockIDExt
+	visited := map[shardKey]map[uint32]bool{}
+
+	var walk func(block *BlockIDExt) error
+	walk = func(block *BlockIDExt) error {
+		key := shardKey{block.Workchain, block.Shard}
+		if seqno, ok := lastSeen[key]; ok && block.SeqNo <= seqno {
+			return nil
+		}
+		if visited[key][block.SeqNo] {
+			return nil
+		}
+
+		parents, err := getParents(ctx, block)
+		if err != nil {
+			return err
+		}
+
+		for _, parent := range parents {
+			if err = walk(parent); err != nil {
+				return err
+			}
+		}
+
+		if visited[key] == nil {
+			visited[key] = map[uint32]bool{}
+		}
+		visited[key][block.SeqNo] = true
+		res = append(res, block)
+		return nil
+	}
+
+	for _, shard := range shards {
+		if err := walk(shard); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+func (c *APIClient) getBlockTransactions(ctx context.Context, master, block *BlockIDExt) ([]*tlb.Transaction, error) {
+	api := c.WaitForBlock(master.SeqNo).(*APIClient)
+
+	var list []*tlb.Transaction
+	var after *TransactionID3
+	for more := true; more; {
+		txs, hasMore, err := api.getBlockTransactionsExt(ctx, block, 100, after)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get transactions: %w", err)
+		}
+		more = hasMore && len(txs) > 0
+		list = append(list, txs...)
+
+		if more {
+			last := txs[len(txs)-1]
+			after = &TransactionID3{Account: last.AccountAddr, LT: last.LT}
+		}
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].LT < list[j].LT
+	})
+	return list, nil
+}
+
+// retryStream - repeats fn until it succeeds or context is done, only temporary errors are retried
+func retryStream(ctx context.Context, fn func() error) error {
+	for {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+
+		if !isTemporaryStreamError(ctx, err) {
+			return err
+		}
+		Logger("stream request failed, retrying:", err.Error())
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%w, last error: %s", ctx.Err(), err.Error())
+		case <-time.After(streamRetryWait):
+		}
+	}
+}
+
+// isTemporaryStreamError - true for network errors and liteserver errors which may disappear on the next try
+func isTemporaryStreamError(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	if errors.Is(err, errStreamNotReady) ||
+		errors.Is(err, liteclient.ErrADNLReqTimeout) ||
+		errors.Is(err, liteclient.ErrNoActiveConnections) ||
+		errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+
+	var lsErr LSError
+	if errors.As(err, &lsErr) {
+		// timeout, not ready and connection errors of liteserver
+		switch lsErr.Code {
+		case 652, -400, -503:
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,136 @@
+package ton
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/xssnick/tonutils-go/liteclient"
+)
+
+func TestResolveNewShardBlocks(t *testing.T) {
+	const (
+		full  = -0x8000000000000000
+		left  = 0x4000000000000000
+		right = -0x4000000000000000
+	)
+
+	blk := func(shard int64, seqno uint32) *BlockIDExt {
+		return &BlockIDExt{Workchain: 0, Shard: shard, SeqNo: seqno}
+	}
+	str := func(b *BlockIDExt) string {
+		return fmt.Sprintf("%x:%d", uint64(b.Shard), b.SeqNo)
+	}
+
+	tests := []struct {
+		name     string
+		lastSeen map[shardKey]uint32
+		shards   []*BlockIDExt
+		parents  map[string][]*BlockIDExt
+		want     []string
+	}{
+		{
+			name:     "split with hole",
+			lastSeen: map[shardKey]uint32{{0, full}: 10},
+			shards:   []*BlockIDExt{blk(left, 12), blk(right, 11)},
+			parents: map[string][]*BlockIDExt{
+				str(blk(left, 12)):  {blk(left, 11)},
+				str(blk(left, 11)):  {blk(full, 10)},
+				str(blk(right, 11)): {blk(full, 10)},
+			},
+			want: []string{str(blk(left, 11)), str(blk(left, 12)), str(blk(right, 11))},
+		},
+		{
+			name:     "merge",
+			lastSeen: map[shardKey]uint32{{0, left}: 5, {0, right}: 7},
+			shards:   []*BlockIDExt{blk(full, 9)},
+			parents: map[string][]*BlockIDExt{
+				str(blk(full, 9)): {blk(full, 8)},
+				str(blk(full, 8)): {blk(left, 6), blk(right, 7)},
+				str(blk(left, 6)): {blk(left, 5)},
+			},
+			want: []string{str(blk(left, 6)), str(blk(full, 8)), str(blk(full, 9))},
+		},
+		{
+			name:     "common unseen parent",
+			lastSeen: map[shardKey]uint32{{0, full}: 10},
+			shards:   []*BlockIDExt{blk(left, 12), blk(right, 12)},
+			parents: map[string][]*BlockIDExt{
+				str(blk(left, 12)):  {blk(left, 11)},
+				str(blk(left, 11)):  {blk(full, 11)},
+				str(blk(right, 12)): {blk(full, 11)},
+				str(blk(full, 11)):  {blk(full, 10)},
+			},
+			want: []string{str(blk(full, 11)), str(blk(left, 11)), str(blk(left, 12)), str(blk(right, 12))},
+		},
+		{
+			name:     "nothing new",
+			lastSeen: map[shardKey]uint32{{0, full}: 10},
+			shards:   []*BlockIDExt{blk(full, 10)},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := resolveNewShardBlocks(context.Background(), tt.shards, tt.lastSeen, func(ctx context.Context, block *BlockIDExt) ([]*BlockIDExt, error) {
+				p, ok := tt.parents[str(block)]
+				if !ok {
+					return nil, fmt.Errorf("unexpected parents request for %s", str(block))
+				}
+				return p, nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(res) != len(tt.want) {
+				t.Fatalf("expected %d blocks, got %d", len(tt.want), len(res))
+			}
+			for i, b := range res {
+				if str(b) != tt.want[i] {
+					t.Fatalf("block %d: expected %s, got %s", i, tt.want[i], str(b))
+				}
+			}
+		})
+	}
+}
+
+func TestRetryStream(t *testing.T) {
+	ctx := context.Background()
+
+	wait := streamRetryWait
+	streamRetryWait = 10 * time.Millisecond
+	defer func() {
+		streamRetryWait = wait
+	}()
+
+	for _, tt := range []struct {
+		name  string
+		err   error
+		retry bool
+	}{
+		{"adnl timeout", fmt.Errorf("query failed: %w", liteclient.ErrADNLReqTimeout), true},
+		{"ls timeout", LSError{Code: 652, Text: "timeout"}, true},
+		{"next block", fmt.Errorf("%w: %w", errStreamNotReady, ErrBlockNotFound), true},
+		{"start block", ErrBlockNotFound, false},
+		{"proof", fmt.Errorf("failed to check proof"), false},
+	} {
+		calls := 0
+		err := retryStream(ctx, func() error {
+			calls++
+			if calls == 1 {
+				return tt.err
+			}
+			return nil
+		})
+
+		if tt.retry && (err != nil || calls != 2) {
+			t.Fatal(tt.name, "should be retried", err, calls)
+		}
+		if !tt.retry && (!errors.Is(err, tt.err) || calls != 1) {
+			t.Fatal(tt.name, "should be returned", err, calls)
+		}
+	}
+}
@@ -48,6 +48,7 @@ type GetTransactions struct {
 
 // ListTransactions - returns list of transactions before (including) passed lt and hash, the oldest one is first in result slice
 // Transactions will be verified to match final tx hash, which should be taken from proved account state, then it is safe.
+// With ProofCheckPolicySecure the newest transaction is additionally proven to be in the block committed to trusted master chain.
 func (c *APIClient) ListTransactions(ctx context.Context, addr *address.Address, limit uint32, lt uint64, txHash []byte) ([]*tlb.Transaction, error) {
 	var resp tl.Serializable
 	err := c.client.QueryLiteserver(ctx, GetTransactions{
@@ -92,6 +93,24 @@ func (c *APIClient) ListTransactions(ctx context.Context, addr *address.Address,
 			txHash = tx.PrevTxHash
 			res[(len(txList)-1)-i] = &tx
 		}
+
+		if c.proofCheckPolicy == ProofCheckPolicySecure {
+			if len(t.IDs) != len(txList) {
+				return nil, fmt.Errorf("incorrect number of block ids in response")
+			}
+
+			// transactions are linked by hashes, so it is enough to prove the newest one,
+			// it is verified to be included in the block which is committed to trusted master chain
+			last := res[len(res)-1]
+			proved, err := c.GetTransaction(ctx, t.IDs[0], addr, last.LT)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get proof of transaction: %w", err)
+			}
+
+			if !bytes.Equal(proved.Hash, last.Hash) {
+				return nil, fmt.Errorf("proved transaction hash not matches")
+			}
+		}
 		return res, nil
 	case LSError:
 		if t.Code == 0 {
@@ -103,6 +122,8 @@ func (c *APIClient) ListTransactions(ctx context.Context, addr *address.Address,
 	return nil, errors.New("unknown response type")
 }
 
+// GetTransaction - returns transaction of account in block, when proof checks are enabled transaction is verified to be in the block,
+// and with ProofCheckPolicySecure the block is verified to be committed to trusted master chain
 func (c *APIClient) GetTransaction(ctx context.Context, block *BlockIDExt, addr *address.Address, lt uint64) (*tlb.Transaction, error) {
 	var resp tl.Serializable
 	err := c.client.QueryLiteserver(ctx, GetOneTransaction{
@@ -163,6 +184,13 @@ func (c *APIClient) GetTransaction(ctx context.Context, block *BlockIDExt, addr
 			if err = CheckTransactionProof(tx.Hash, tx.LT, tx.AccountAddr, &shardAccounts); err != nil {
 				return nil, fmt.Errorf("incorrect tx proof: %w", err)
 			}
+
+			if c.proofCheckPolicy == ProofCheckPolicySecure {
+				// block hash is verified by tx proof, now we check that block itself is committed to trusted master chain
+				if err = c.VerifyBlock(ctx, block); err != nil {
+					return nil, fmt.Errorf("failed to verify tx block: %w", err)
+				}
+			}
 		}
 
 		return &tx, nil
@@ -0,0 +1,162 @@
+package ton
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/xssnick/tonutils-go/internal/jsonfile"
+)
+
+// TrustedState - verified state of master chain, which can be used as a starting point of proof checks after restart
+type TrustedState struct {
+	// Block - latest verified master block, proof chain checks are started from it
+	Block *BlockIDExt `json:"block"`
+}
+
+// TrustedStateStore - persistent storage of trusted state
+type TrustedStateStore interface {
+	// LoadTrustedState - returns nil state without error when nothing was saved yet
+	LoadTrustedState(ctx context.Context) (*TrustedState, error)
+	SaveTrustedState(ctx context.Context, st *TrustedState) error
+}
+
+// trustedStateSaveInterval - how often state is saved when no new key blocks are proven
+const trustedStateSaveInterval = 1 * time.Minute
+
+// SetTrustedStateStore - loads trusted state from the store and uses its block as trusted, if it is newer than the current one,
+// so proof chain is verified only from the last verified block, not from the init block.
+// After each verification of the new master block chain, state is saved to the store.
+// Should be called after SetTrustedBlock or SetTrustedBlockFromConfig, if they are used.
+func (c *APIClient) SetTrustedStateStore(ctx context.Context, store TrustedStateStore) error {
+	st, err := store.LoadTrustedState(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load trusted state: %w", err)
+	}
+
+	root := c.root()
+	root.trustedLock.Lock()
+	defer root.trustedLock.Unlock()
+
+	root.trustedStore = store
+	if st == nil || st.Block == nil {
+		return nil
+	}
+
+	if root.trustedBlock == nil || st.Block.SeqNo > root.trustedBlock.SeqNo {
+		root.trustedBlock = st.Block.Copy()
+	}
+	root.trustedSavedAt = time.Now()
+	return nil
+}
+
+// onTrustedBlockProven - called under trusted lock for each proven block of the forward proof chain,
+// it moves trusted block forward, so progress is not lost when chain verification is interrupted
+func (c *APIClient) onTrustedBlockProven(ctx context.Context, block *BlockIDExt, isKey bool) error {
+	root := c.root()
+	if root.trustedBlock != nil && block.SeqNo <= root.trustedBlock.SeqNo {
+		return nil
+	}
+	root.trustedBlock = block.Copy()
+
+	if !isKey {
+		return nil
+	}
+	// proof chains go through key blocks, so state is saved right away to not prove them again after restart
+	return root.saveTrustedState(ctx)
+}
+
+// saveTrustedState - should be called under trusted lock
+func (c *APIClient) saveTrustedState(ctx context.Context) error {
+	if c.trustedStore == nil || c.trustedBlock == nil {
+		return nil
+	}
+
+	st := &TrustedState{
+		Block: c.trustedBlock.Copy(),
+	}
+
+	if err := c.trustedStore.SaveTrustedState(ctx, st); err != nil {
+		return fmt.Errorf("failed to save trusted state: %w", err)
+	}
+	c.trustedSavedAt = time.Now()
+	return nil
+}
+
+// MemoryTrustedStateStore - keeps trusted state in memory, it can be shared between clients
+type MemoryTrustedStateStore struct {
+	st *TrustedState
+	mx sync.RWMutex
+}
+
+func NewMemoryTrustedStateStore() *MemoryTrustedStateStore {
+	return &MemoryTrustedStateStore{}
+}
+
+func (s *MemoryTrustedStateStore) LoadTrustedState(_ context.Context) (*TrustedState, error) {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+
+	if s.st == nil {
+		return nil, nil
+	}
+	return copyTrustedState(s.st), nil
+}
+
+func (s *MemoryTrustedStateStore) SaveTrustedState(_ context.Context, st *TrustedState) error {
+	if st == nil || st.Block == nil {
+		return fmt.Errorf("trusted state is empty")
+	}
+
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	s.st = copyTrustedState(st)
+	return nil
+}
+
+// FileTrustedStateStore - keeps trusted state in json file, file is replaced atomically on each save
+type FileTrustedStateStore struct {
+	path string
+	mx   sync.Mutex
+}
+
+func NewFileTrustedStateStore(path string) *FileTrustedStateStore {
+	return &FileTrustedStateStore{path: path}
+}
+
+func (s *FileTrustedStateStore) LoadTrustedState(_ context.Context) (*TrustedState, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	var st TrustedState
+	found, err := jsonfile.Load(s.path, &st)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load trusted state: %w", err)
+	}
+	if !found {
+		return nil, nil
+	}
+	return &st, nil
+}
+
+func (s *FileTrustedStateStore) SaveTrustedState(_ context.Context, st *TrustedState) error {
+	if st == nil || st.Block == nil {
+		return fmt.Errorf("trusted state is empty")
+	}
+
+	s.mx.Lock()
+	defer s.mx.Unlock()
+
+	if err := jsonfile.Save(s.path, st); err != nil {
+		return fmt.Errorf("failed to save trusted state: %w", err)
+	}
+	return nil
+}
+
+func copyTrustedState(st *TrustedState) *TrustedState {
+	return &TrustedState{
+		Block: st.Block.Copy(),
+	}
+}
@@ -0,0 +1,105 @@
+package ton
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tl"
+)
+
+func TestFileTrustedStateStore(t *testing.T) {
+	store := NewFileTrustedStateStore(filepath.Join(t.TempDir(), "trusted.json"))
+
+	st, err := store.LoadTrustedState(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st != nil {
+		t.Fatal("state should be nil when file not exists")
+	}
+
+	block := &BlockIDExt{Workchain: address.MasterchainID, Shard: -0x8000000000000000, SeqNo: 100, RootHash: make([]byte, 32), FileHash: make([]byte, 32)}
+	if err = store.SaveTrustedState(context.Background(), &TrustedState{Block: block}); err != nil {
+		t.Fatal(err)
+	}
+
+	st, err = store.LoadTrustedState(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !st.Block.Equals(block) {
+		t.Fatal("incorrect loaded block")
+	}
+}
+
+func TestAPIClient_SetTrustedStateStore(t *testing.T) {
+	block := &BlockIDExt{Workchain: address.MasterchainID, Shard: -0x8000000000000000, SeqNo: 100, RootHash: make([]byte, 32), FileHash: make([]byte, 32)}
+	store := NewMemoryTrustedStateStore()
+	if err := store.SaveTrustedState(context.Background(), &TrustedState{Block: block}); err != nil {
+		t.Fatal(err)
+	}
+
+	older := block.Copy()
+	older.SeqNo = 10
+
+	c := NewAPIClient(nil, ProofCheckPolicySecure)
+	c.SetTrustedBlock(older)
+	if err := c.SetTrustedStateStore(context.Background(), store); err != nil {
+		t.Fatal(err)
+	}
+	if !c.trustedBlock.Equals(block) {
+		t.Fatal("trusted block should be taken from store")
+	}
+
+	newer := block.Copy()
+	newer.SeqNo = 1000
+
+	c = NewAPIClient(nil, ProofCheckPolicySecure)
+	c.SetTrustedBlock(newer)
+	if err := c.SetTrustedStateStore(context.Background(), store); err != nil {
+		t.Fatal(err)
+	}
+	if !c.trustedBlock.Equals(newer) {
+		t.Fatal("newer trusted block should not be replaced by stored one")
+	}
+
+	// proven key block should be saved
+	key := newer.Copy()
+	key.SeqNo = 1001
+	c.trustedLock.Lock()
+	c.trustedStore = NewMemoryTrustedStateStore()
+	c.trustedLock.Unlock()
+
+	if err := c.onTrustedBlockProven(context.Background(), key, true); err != nil {
+		t.Fatal(err)
+	}
+
+	st, err := c.trustedStore.LoadTrustedState(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st == nil || !st.Block.Equals(key) {
+		t.Fatal("incorrect saved state", st)
+	}
+}
+
+type failingClient struct{}
+
+func (f *failingClient) QueryLiteserver(ctx context.Context, payload tl.Serializable, result tl.Serializable) error {
+	return fmt.Errorf("not available")
+}
+
+func (f *failingClient) StickyContext(ctx context.Context) context.Context {
+	return ctx
+}
+
+func (f *failingClient) StickyContextNextNode(ctx context.Context) (context.Context, error) {
+	return ctx, fmt.Errorf("no more nodes")
+}
+
+func (f *failingClient) StickyNodeID(ctx context.Context) uint32 {
+	return 0
+}
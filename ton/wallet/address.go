@@ -24,7 +24,12 @@ func AddressFromPubKey(key ed25519.PublicKey, version VersionConfig, subwallet u
 		return nil, fmt.Errorf("failed to get state cell: %w", err)
 	}
 
-	addr := address.NewAddress(0, 0, stateCell.Hash())
+	var workchain byte
+	if cfg, ok := version.(ConfigV5R1); ok {
+		workchain = byte(cfg.Workchain)
+	}
+
+	addr := address.NewAddress(0, workchain, stateCell.Hash())
 
 	return addr, nil
 }
@@ -55,9 +60,13 @@ func GetStateInit(pubKey ed25519.PublicKey, version VersionConfig, subWallet uin
 		switch ver {
 		case HighloadV3:
 			return nil, fmt.Errorf("use ConfigHighloadV3 for highload v3 spec")
+		case V5R1:
+			return nil, fmt.Errorf("use ConfigV5R1 for v5r1 spec")
 		}
 	case ConfigHighloadV3:
 		ver = HighloadV3
+	case ConfigV5R1:
+		ver = V5R1
 	}
 
 	code, ok := walletCode[ver]
@@ -99,6 +108,18 @@ func GetStateInit(pubKey ed25519.PublicKey, version VersionConfig, subWallet uin
 			MustStoreUInt(0, 66).
 			MustStoreUInt(uint64(timeout), 22).
 			EndCell()
+	case V5R1:
+		if subWallet >= 1<<15 {
+			return nil, fmt.Errorf("too big subwallet number for v5r1, max is %d", 1<<15-1)
+		}
+
+		data = cell.BeginCell().
+			MustStoreBoolBit(true). // signature allowed
+			MustStoreUInt(0, 32).   // seqno
+			MustStoreUInt(uint64(version.(ConfigV5R1).WalletID(subWallet)), 32).
+			MustStoreSlice(pubKey, 256).
+			MustStoreDict(nil). // empty dict of extensions
+			EndCell()
 	default:
 		return nil, ErrUnsupportedWalletVersion
 	}
@@ -0,0 +1,124 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/ton/fees"
+	"github.com/xssnick/tonutils-go/tvm/emulator"
+)
+
+var ErrMessageNotAccepted = errors.New("external message will not be accepted by wallet")
+
+// FeesEstimate - expected fees of wallet transaction
+type FeesEstimate struct {
+	// ImportFee - fee for the import of external message
+	ImportFee tlb.Coins
+	// StorageFee - storage fee collected in transaction, including due payment
+	StorageFee tlb.Coins
+	// GasFee - fee for the gas used by wallet contract
+	GasFee  tlb.Coins
+	GasUsed uint64
+	// ForwardFee - forward and ihr fees of all sent messages, including action fees
+	ForwardFee tlb.Coins
+	// Total - sum of all fees, which will be deducted from wallet balance in addition to sent amounts
+	Total tlb.Coins
+}
+
+// EstimateFees - emulates sending of messages against the current wallet state and returns expected fees.
+// Messages are not sent, but note that for highload wallets new query id is still generated.
+func (w *Wallet) EstimateFees(ctx context.Context, messages []*Message) (*FeesEstimate, error) {
+	block, err := w.api.CurrentMasterchainInfo(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get block: %w", err)
+	}
+	api := w.api.WaitForBlock(block.SeqNo)
+
+	acc, err := api.GetAccount(ctx, block, w.addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account state: %w", err)
+	}
+
+	initialized := acc.IsActive && acc.State.Status == tlb.AccountStatusActive
+	ext, err := w.PrepareExternalMessageForMany(ctx, !initialized, messages)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err := api.GetBlockchainConfig(ctx, block, emulator.ConfigParams...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get blockchain config: %w", err)
+	}
+
+	return EstimateExternalMessageFees(cfg, acc, ext, emulator.Params{})
+}
+
+// EstimateExternalMessageFees - emulates external message against the given account state and returns expected fees,
+// can be used for offline calculation. Config should contain params listed in emulator.ConfigParams.
+func EstimateExternalMessageFees(cfg *ton.BlockchainConfig, acc *tlb.Account, ext *tlb.ExternalMessage, params emulator.Params) (*FeesEstimate, error) {
+	emu, err := emulator.NewEmulator(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to init emulator: %w", err)
+	}
+
+	est, err := fees.NewEstimator(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to init fee estimator: %w", err)
+	}
+
+	shardAcc, err := emulator.ShardAccountFromAccount(acc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare account: %w", err)
+	}
+
+	res, err := emu.EmulateTransaction(shardAcc, &tlb.Message{MsgType: tlb.MsgTypeExternalIn, Msg: ext}, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to emulate transaction: %w", err)
+	}
+	if !res.Accepted {
+		return nil, fmt.Errorf("%w, exit code %d", ErrMessageNotAccepted, res.ExitCode)
+	}
+
+	importFee, err := est.ImportFee(ext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calc import fee: %w", err)
+	}
+
+	desc, ok := res.Transaction.Description.Description.(tlb.TransactionDescriptionOrdinary)
+	if !ok {
+		return nil, fmt.Errorf("unexpected transaction type")
+	}
+
+	estimate := &FeesEstimate{
+		ImportFee:  importFee,
+		StorageFee: tlb.ZeroCoins,
+		GasFee:     tlb.ZeroCoins,
+		GasUsed:    res.GasUsed,
+		ForwardFee: tlb.ZeroCoins,
+	}
+	if desc.StoragePhase != nil {
+		estimate.StorageFee = desc.StoragePhase.StorageFeesCollected
+	}
+	if vm, ok := desc.ComputePhase.Phase.(tlb.ComputePhaseVM); ok {
+		estimate.GasFee = vm.GasFees
+	}
+
+	// total fees of transaction already include action fees, which are part of forward fees
+	total := new(big.Int).Set(res.Transaction.TotalFees.Coins.Nano())
+	if ap := desc.ActionPhase; ap != nil {
+		if ap.TotalFwdFees != nil {
+			estimate.ForwardFee = *ap.TotalFwdFees
+			total.Add(total, ap.TotalFwdFees.Nano())
+		}
+		if ap.TotalActionFees != nil {
+			total.Sub(total, ap.TotalActionFees.Nano())
+		}
+	}
+	estimate.Total = tlb.FromNanoTON(total)
+
+	return estimate, nil
+}
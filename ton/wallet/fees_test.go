@@ -0,0 +1,103 @@
+package wallet
+
+import (
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"math/big"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/ton/fees"
+)
+
+func TestWallet_EstimateFees(t *testing.T) {
+	timeNow = time.Now
+
+	var balance = tlb.MustFromTON("10")
+	var requested []int32
+	m := &MockAPI{
+		getBlockInfo: func(ctx context.Context) (*ton.BlockIDExt, error) {
+			return &ton.BlockIDExt{SeqNo: 100}, nil
+		},
+		runGetMethod: func(ctx context.Context, blockInfo *ton.BlockIDExt, addr *address.Address, method string, params ...interface{}) (*ton.ExecutionResult, error) {
+			return nil, ton.ContractExecError{Code: ton.ErrCodeContractNotInitialized}
+		},
+		getConfig: func(ctx context.Context, block *ton.BlockIDExt, onlyParams ...int32) (*ton.BlockchainConfig, error) {
+			requested = onlyParams
+			return testBlockchainConfig(), nil
+		},
+	}
+
+	pkey := ed25519.NewKeyFromSeed([]byte("12345678901234567890123456789012"))
+	w, err := FromPrivateKey(m, pkey, V4R2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m.getAccount = func(ctx context.Context, block *ton.BlockIDExt, addr *address.Address) (*tlb.Account, error) {
+		return &tlb.Account{
+			State: &tlb.AccountState{
+				IsValid:     true,
+				Address:     w.Address(),
+				StorageInfo: tlb.StorageInfo{LastPaid: uint32(time.Now().Unix()) - 1000},
+				AccountStorage: tlb.AccountStorage{
+					Status:  tlb.AccountStatusUninit,
+					Balance: balance,
+				},
+			},
+		}, nil
+	}
+
+	transfer, err := w.BuildTransfer(address.MustParseAddr("EQC9bWZd29foipyPOGWlVNVCQzpGAjvi1rGWF7EbNcSVClpA"), tlb.MustFromTON("1.5"), true, "hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	est, err := w.EstimateFees(context.Background(), []*Message{transfer})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// emulator also reads capabilities and size limits
+	for _, id := range []int32{8, 18, 20, 21, 24, 25, 43} {
+		if !slices.Contains(requested, id) {
+			t.Fatal("config param is not requested", id, requested)
+		}
+	}
+
+	if est.GasUsed == 0 || est.ImportFee.Nano().Sign() <= 0 || est.StorageFee.Nano().Sign() <= 0 {
+		t.Fatal("fees should be calculated", est.GasUsed, est.ImportFee.String(), est.StorageFee.String())
+	}
+
+	calc, err := fees.NewEstimator(testBlockchainConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if est.GasFee.Nano().Cmp(calc.GasFee(0, est.GasUsed).Nano()) != 0 {
+		t.Fatal("incorrect gas fee", est.GasFee.String())
+	}
+
+	fwd, err := calc.ForwardFee(w.Address(), transfer.InternalMessage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if est.ForwardFee.Nano().Cmp(fwd.Total) != 0 {
+		t.Fatal("incorrect forward fee", est.ForwardFee.String(), fwd.Total)
+	}
+
+	sum := new(big.Int).Add(est.ImportFee.Nano(), est.StorageFee.Nano())
+	sum.Add(sum, est.GasFee.Nano())
+	sum.Add(sum, est.ForwardFee.Nano())
+	if sum.Cmp(est.Total.Nano()) != 0 {
+		t.Fatal("incorrect total", est.Total.String(), sum)
+	}
+
+	balance = tlb.ZeroCoins
+	if _, err = w.EstimateFees(context.Background(), []*Message{transfer}); !errors.Is(err, ErrMessageNotAccepted) {
+		t.Fatal("message should not be accepted without balance", err)
+	}
+}
@@ -98,27 +98,19 @@ func (s *SpecHighloadV3) packActions(queryId uint64, messages []*Message) (*Mess
 	}
 
 	var amt = big.NewInt(0)
-	var list = cell.BeginCell().EndCell()
+	list := tlb.OutList{Actions: make([]tlb.OutAction, 0, len(messages))}
 	for _, message := range messages {
 		amt = amt.Add(amt, message.InternalMessage.Amount.Nano())
 
-		outMsg, err := tlb.ToCell(message.InternalMessage)
-		if err != nil {
-			return nil, err
-		}
+		list.Actions = append(list.Actions, tlb.OutAction{Action: tlb.ActionSendMsg{
+			Mode: message.Mode,
+			Msg:  &tlb.Message{MsgType: tlb.MsgTypeInternal, Msg: message.InternalMessage},
+		}})
+	}
 
-		/*
-			out_list_empty$_ = OutList 0;
-			out_list$_ {n:#} prev:^(OutList n) action:OutAction
-			  = OutList (n + 1);
-			action_send_msg#0ec3c86d mode:(## 8)
-			  out_msg:^(MessageRelaxed Any) = OutAction;
-		*/
-		msg := cell.BeginCell().MustStoreUInt(0x0ec3c86d, 32).
-			MustStoreUInt(uint64(message.Mode), 8).
-			MustStoreRef(outMsg)
-
-		list = cell.BeginCell().MustStoreRef(list).MustStoreBuilder(msg).EndCell()
+	listCell, err := tlb.ToCell(list)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert out list to cell: %w", err)
 	}
 
 	return &Message{
@@ -131,7 +123,7 @@ func (s *SpecHighloadV3) packActions(queryId uint64, messages []*Message) (*Mess
 			Body: cell.BeginCell().
 				MustStoreUInt(0xae42e5a4, 32).
 				MustStoreUInt(queryId, 64).
-				MustStoreRef(list).
+				MustStoreRef(listCell).
 				EndCell(),
 		},
 	}, nil
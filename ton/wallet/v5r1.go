@@ -0,0 +1,183 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+// https://github.com/ton-blockchain/wallet-contract-v5/blob/main/build/wallet_v5.compiled.json
+const _V5R1CodeHex = "b5ee9c7241021401000281000114ff00f4a413f4bcf2c80b01020120020d020148030402dcd020d749c120915b8f6320d70b1f2082106578746ebd21821073696e74bdb0925f03e082106578746eba8eb48020d72101d074d721fa4030fa44f828fa443058bd915be0ed44d0810141d721f4058307f40e6fa1319130e18040d721707fdb3ce03120d749810280b99130e070e2100f020120050c020120060902016e07080019adce76a2684020eb90eb85ffc00019af1df6a2684010eb90eb858fc00201480a0b0017b325fb51341c75c875c2c7e00011b262fb513435c280200019be5f0f6a2684080a0eb90fa02c0102f20e011e20d70b1f82107369676ebaf2e08a7f0f01e68ef0eda2edfb218308d722028308d723208020d721d31fd31fd31fed44d0d200d31f20d31fd3ffd70a000af90140ccf9109a28945f0adb31e1f2c087df02b35007b0f2d0845125baf2e0855036baf2e086f823bbf2d0882292f800de01a47fc8ca00cb1f01cf16c9ed542092f80fde70db3cd81003f6eda2edfb02f404216e926c218e4c0221d73930709421c700b38e2d01d72820761e436c20d749c008f2e09320d74ac002f2e09320d71d06c712c2005230b0f2d089d74cd7393001a4e86c128407bbf2e093d74ac000f2e093ed55e2d20001c000915be0ebd72c08142091709601d72c081c12e25210b1e30f20d74a111213009601fa4001fa44f828fa443058baf2e091ed44d0810141d718f405049d7fc8ca0040048307f453f2e08b8e14038307f45bf2e08c22d70a00216e01b3b0f2d090e2c85003cf1612f400c9ed54007230d72c08248e2d21f2e092d200ed44d0d2005113baf2d08f54503091319c01810140d721d70a00f2e08ee2c8ca0058cf16c9ed5493f2c08de20010935bdb31e1d74cd0b4d6c35e"
+
+const (
+	MainnetGlobalID int32 = -239
+	TestnetGlobalID int32 = -3
+)
+
+// DefaultSubwalletV5R1 - subwallet number which is used by most of v5 wallet applications
+const DefaultSubwalletV5R1 = 0
+
+type ConfigV5R1 struct {
+	// NetworkGlobalID - id of the network, MainnetGlobalID or TestnetGlobalID, it is a part of wallet id,
+	// so the same key gives different addresses in different networks
+	NetworkGlobalID int32
+	// Workchain - workchain of the wallet, usually 0
+	Workchain int8
+}
+
+// WalletID - calculates wallet id of client context for the given subwallet number (15 bits)
+func (c ConfigV5R1) WalletID(subwallet uint32) uint32 {
+	ctxID := uint32(1)<<31 | // client context
+		uint32(uint8(c.Workchain))<<23 |
+		0<<15 | // wallet version, 0 for v5r1
+		subwallet&0x7FFF
+	return uint32(c.NetworkGlobalID) ^ ctxID
+}
+
+type V5R1ActionType uint8
+
+const (
+	V5R1ActionAddExtension        V5R1ActionType = 0x02
+	V5R1ActionRemoveExtension     V5R1ActionType = 0x03
+	V5R1ActionSetSignatureAllowed V5R1ActionType = 0x04
+)
+
+// V5R1ExtendedAction - wallet settings change, executed together with messages sending
+type V5R1ExtendedAction struct {
+	Type V5R1ActionType
+	// Address - extension contract, for add and remove actions
+	Address *address.Address
+	// SignatureAllowed - for set signature allowed action, when disabled,
+	// wallet can be operated only by extensions, so at least one should be installed.
+	// This action is accepted only from extension, use BuildV5R1ExtensionRequest for it.
+	SignatureAllowed bool
+}
+
+type SpecV5R1 struct {
+	SpecRegular
+	SpecSeqno
+
+	config ConfigV5R1
+}
+
+func (s *SpecV5R1) BuildMessage(ctx context.Context, _ bool, _ *ton.BlockIDExt, messages []*Message) (_ *cell.Cell, err error) {
+	return s.BuildMessageWithActions(ctx, messages, nil)
+}
+
+// BuildMessageWithActions - builds signed external message body, which sends messages and applies extended actions
+func (s *SpecV5R1) BuildMessageWithActions(ctx context.Context, messages []*Message, actions []V5R1ExtendedAction) (_ *cell.Cell, err error) {
+	inner, err := buildV5R1Request(messages, actions)
+	if err != nil {
+		return nil, err
+	}
+
+	seq, err := s.seqnoFetcher(ctx, s.wallet.subwallet)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch seqno: %w", err)
+	}
+
+	payload := cell.BeginCell().
+		MustStoreUInt(0x7369676e, 32). // external signed request
+		MustStoreUInt(uint64(s.config.WalletID(s.wallet.subwallet)), 32).
+		MustStoreUInt(uint64(timeNow().Add(time.Duration(s.messagesTTL)*time.Second).UTC().Unix()), 32).
+		MustStoreUInt(uint64(seq), 32)
+	if err = payload.StoreBuilder(inner); err != nil {
+		return nil, fmt.Errorf("failed to store request: %w", err)
+	}
+
+	// signature is in the end of the message for v5
+	sign := payload.EndCell().Sign(s.wallet.key)
+	msg := cell.BeginCell().MustStoreBuilder(payload)
+	if err = msg.StoreSlice(sign, 512); err != nil {
+		return nil, fmt.Errorf("failed to store signature: %w", err)
+	}
+	return msg.EndCell(), nil
+}
+
+// BuildV5R1ExtensionRequest - builds body of internal message from installed extension to v5 wallet,
+// wallet will send messages and apply actions without signature check
+func BuildV5R1ExtensionRequest(queryID uint64, messages []*Message, actions []V5R1ExtendedAction) (*cell.Cell, error) {
+	inner, err := buildV5R1Request(messages, actions)
+	if err != nil {
+		return nil, err
+	}
+
+	b := cell.BeginCell().
+		MustStoreUInt(0x6578746e, 32). // extension action
+		MustStoreUInt(queryID, 64)
+	if err = b.StoreBuilder(inner); err != nil {
+		return nil, fmt.Errorf("failed to store request: %w", err)
+	}
+	return b.EndCell(), nil
+}
+
+func buildV5R1Request(messages []*Message, actions []V5R1ExtendedAction) (*cell.Builder, error) {
+	if len(messages) > 255 {
+		return nil, errors.New("for this type of wallet max 255 messages can be sent in the same time")
+	}
+	if len(messages) == 0 && len(actions) == 0 {
+		return nil, errors.New("should have at least one message or action")
+	}
+
+	b := cell.BeginCell()
+	if len(messages) > 0 {
+		list := tlb.OutList{Actions: make([]tlb.OutAction, 0, len(messages))}
+		for _, message := range messages {
+			list.Actions = append(list.Actions, tlb.OutAction{Action: tlb.ActionSendMsg{
+				Mode: message.Mode,
+				Msg:  &tlb.Message{MsgType: tlb.MsgTypeInternal, Msg: message.InternalMessage},
+			}})
+		}
+
+		listCell, err := tlb.ToCell(list)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert out list to cell: %w", err)
+		}
+		b.MustStoreMaybeRef(listCell)
+	} else {
+		b.MustStoreMaybeRef(nil)
+	}
+
+	if len(actions) == 0 {
+		return b.MustStoreBoolBit(false), nil
+	}
+	b.MustStoreBoolBit(true)
+
+	// first action is stored inline, every next one is in the ref of previous
+	var next *cell.Cell
+	for i := len(actions) - 1; i >= 0; i-- {
+		ab := cell.BeginCell()
+		if i == 0 {
+			ab = b
+		}
+
+		if err := actions[i].store(ab); err != nil {
+			return nil, fmt.Errorf("failed to store action %d: %w", i, err)
+		}
+		if next != nil {
+			ab.MustStoreRef(next)
+		}
+		next = ab.EndCell()
+	}
+	return b, nil
+}
+
+func (a V5R1ExtendedAction) store(b *cell.Builder) error {
+	b.MustStoreUInt(uint64(a.Type), 8)
+	switch a.Type {
+	case V5R1ActionAddExtension, V5R1ActionRemoveExtension:
+		if a.Address == nil || a.Address.Type() != address.StdAddress {
+			return fmt.Errorf("extension address should be std address")
+		}
+		return b.StoreAddr(a.Address)
+	case V5R1ActionSetSignatureAllowed:
+		return b.StoreBoolBit(a.SignatureAllowed)
+	}
+	return fmt.Errorf("unknown action type %d", a.Type)
+}
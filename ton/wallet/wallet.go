@@ -36,6 +36,7 @@ const (
 	V3                         = V3R2
 	V4R1               Version = 41
 	V4R2               Version = 42
+	V5R1               Version = 51
 	HighloadV2R2       Version = 122
 	HighloadV2Verified Version = 123
 	HighloadV3         Version = 300
@@ -70,6 +71,7 @@ var (
 		V2R1: _V2R1CodeHex, V2R2: _V2R2CodeHex,
 		V3R1: _V3R1CodeHex, V3R2: _V3R2CodeHex,
 		V4R1: _V4R1CodeHex, V4R2: _V4R2CodeHex,
+		V5R1:         _V5R1CodeHex,
 		HighloadV2R2: _HighloadV2R2CodeHex, HighloadV2Verified: _HighloadV2VerifiedCodeHex,
 		HighloadV3: _HighloadV3CodeHex,
 		Lockup:     _LockupCodeHex,
@@ -141,7 +143,12 @@ type Wallet struct {
 }
 
 func FromPrivateKey(api TonAPI, key ed25519.PrivateKey, version VersionConfig) (*Wallet, error) {
-	addr, err := AddressFromPubKey(key.Public().(ed25519.PublicKey), version, DefaultSubwallet)
+	subwallet := uint32(DefaultSubwallet)
+	if _, ok := version.(ConfigV5R1); ok {
+		subwallet = DefaultSubwalletV5R1
+	}
+
+	addr, err := AddressFromPubKey(key.Public().(ed25519.PublicKey), version, subwallet)
 	if err != nil {
 		return nil, err
 	}
@@ -151,7 +158,7 @@ func FromPrivateKey(api TonAPI, key ed25519.PrivateKey, version VersionConfig) (
 		key:       key,
 		addr:      addr,
 		ver:       version,
-		subwallet: DefaultSubwallet,
+		subwallet: subwallet,
 	}
 
 	w.spec, err = getSpec(w)
@@ -163,34 +170,34 @@ func FromPrivateKey(api TonAPI, key ed25519.PrivateKey, version VersionConfig) (
 }
 
 func getSpec(w *Wallet) (any, error) {
-	switch v := w.ver.(type) {
-	case Version:
-		regular := SpecRegular{
-			wallet:      w,
-			messagesTTL: 60 * 3, // default ttl 3 min
-		}
+	regular := SpecRegular{
+		wallet:      w,
+		messagesTTL: 60 * 3, // default ttl 3 min
+	}
 
-		seqnoFetcher := func(ctx context.Context, subWallet uint32) (uint32, error) {
-			block, err := w.api.CurrentMasterchainInfo(ctx)
-			if err != nil {
-				return 0, fmt.Errorf("failed to get block: %w", err)
-			}
+	seqnoFetcher := func(ctx context.Context, subWallet uint32) (uint32, error) {
+		block, err := w.api.CurrentMasterchainInfo(ctx)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get block: %w", err)
+		}
 
-			resp, err := w.api.WaitForBlock(block.SeqNo).RunGetMethod(ctx, block, w.addr, "seqno")
-			if err != nil {
-				if cErr, ok := err.(ton.ContractExecError); ok && cErr.Code == ton.ErrCodeContractNotInitialized {
-					return 0, nil
-				}
-				return 0, fmt.Errorf("get seqno err: %w", err)
+		resp, err := w.api.WaitForBlock(block.SeqNo).RunGetMethod(ctx, block, w.addr, "seqno")
+		if err != nil {
+			if cErr, ok := err.(ton.ContractExecError); ok && cErr.Code == ton.ErrCodeContractNotInitialized {
+				return 0, nil
 			}
+			return 0, fmt.Errorf("get seqno err: %w", err)
+		}
 
-			iSeq, err := resp.Int(0)
-			if err != nil {
-				return 0, fmt.Errorf("failed to parse seqno: %w", err)
-			}
-			return uint32(iSeq.Uint64()), nil
+		iSeq, err := resp.Int(0)
+		if err != nil {
+			return 0, fmt.Errorf("failed to parse seqno: %w", err)
 		}
+		return uint32(iSeq.Uint64()), nil
+	}
 
+	switch v := w.ver.(type) {
+	case Version:
 		switch v {
 		case V3R1, V3R2:
 			return &SpecV3{regular, SpecSeqno{seqnoFetcher: seqnoFetcher}}, nil
@@ -200,9 +207,13 @@ func getSpec(w *Wallet) (any, error) {
 			return &SpecHighloadV2R2{regular, SpecQuery{}}, nil
 		case HighloadV3:
 			return nil, fmt.Errorf("use ConfigHighloadV3 for highload v3 spec")
+		case V5R1:
+			return nil, fmt.Errorf("use ConfigV5R1 for v5r1 spec")
 		}
 	case ConfigHighloadV3:
 		return &SpecHighloadV3{wallet: w, config: v}, nil
+	case ConfigV5R1:
+		return &SpecV5R1{regular, SpecSeqno{seqnoFetcher: seqnoFetcher}, v}, nil
 	}
 
 	return nil, fmt.Errorf("cannot init spec: %w", ErrUnsupportedWalletVersion)
@@ -314,6 +325,8 @@ func (w *Wallet) PrepareExternalMessageForMany(ctx context.Context, withStateIni
 			}
 		case HighloadV3:
 			return nil, fmt.Errorf("use ConfigHighloadV3 for highload v3 spec")
+		case V5R1:
+			return nil, fmt.Errorf("use ConfigV5R1 for v5r1 spec")
 		default:
 			return nil, fmt.Errorf("send is not yet supported: %w", ErrUnsupportedWalletVersion)
 		}
@@ -322,6 +335,11 @@ func (w *Wallet) PrepareExternalMessageForMany(ctx context.Context, withStateIni
 		if err != nil {
 			return nil, fmt.Errorf("build message err: %w", err)
 		}
+	case ConfigV5R1:
+		msg, err = w.spec.(*SpecV5R1).BuildMessage(ctx, !withStateInit, nil, messages)
+		if err != nil {
+			return nil, fmt.Errorf("build message err: %w", err)
+		}
 	default:
 		return nil, fmt.Errorf("send is not yet supported: %w", ErrUnsupportedWalletVersion)
 	}
@@ -17,6 +17,7 @@ import (
 	"github.com/xssnick/tonutils-go/address"
 	"github.com/xssnick/tonutils-go/tlb"
 	"github.com/xssnick/tonutils-go/tvm/cell"
+	"github.com/xssnick/tonutils-go/tvm/emulator"
 )
 
 type MockAPI struct {
@@ -25,6 +26,7 @@ type MockAPI struct {
 	sendExternalMessage func(ctx context.Context, msg *tlb.ExternalMessage) error
 	runGetMethod        func(ctx context.Context, blockInfo *ton.BlockIDExt, addr *address.Address, method string, params ...interface{}) (*ton.ExecutionResult, error)
 	listTransactions    func(ctx context.Context, addr *address.Address, limit uint32, lt uint64, txHash []byte) ([]*tlb.Transaction, error)
+	getConfig           func(ctx context.Context, block *ton.BlockIDExt, onlyParams ...int32) (*ton.BlockchainConfig, error)
 
 	extMsgSent *tlb.ExternalMessage
 }
@@ -46,6 +48,7 @@ func (m MockAPI) WaitForBlock(seqno uint32) ton.APIClientWrapped {
 		MSendExternalMessage: m.sendExternalMessage,
 		MRunGetMethod:        m.runGetMethod,
 		MListTransactions:    m.listTransactions,
+		MGetBlockchainConfig: m.getConfig,
 	}
 }
 
@@ -506,16 +509,36 @@ func (w WaiterMock) SetTrustedBlockFromConfig(cfg *liteclient.GlobalConfig) {
 	panic("implement me")
 }
 
+func (w WaiterMock) SetTrustedStateStore(ctx context.Context, store ton.TrustedStateStore) error {
+	//TODO implement me
+	panic("implement me")
+}
+
 func (w WaiterMock) SubscribeOnTransactions(workerCtx context.Context, addr *address.Address, lastProcessedLT uint64, channel chan<- *tlb.Transaction) {
 	//TODO implement me
 	panic("implement me")
 }
 
+func (w WaiterMock) SubscribeOnBlocks(ctx context.Context, lastProcessedMasterSeqno uint32, channel chan<- *ton.BlocksUpdate) error {
+	//TODO implement me
+	panic("implement me")
+}
+
+func (w WaiterMock) StreamTransactions(ctx context.Context, lastProcessedMasterSeqno uint32, channel chan<- *ton.TransactionsBatch) error {
+	//TODO implement me
+	panic("implement me")
+}
+
 func (w WaiterMock) VerifyProofChain(ctx context.Context, from, to *ton.BlockIDExt) error {
 	//TODO implement me
 	panic("implement me")
 }
 
+func (w WaiterMock) VerifyBlock(ctx context.Context, block *ton.BlockIDExt) error {
+	//TODO implement me
+	panic("implement me")
+}
+
 func (w WaiterMock) Client() ton.LiteClient {
 	//TODO implement me
 	panic("implement me")
@@ -541,6 +564,11 @@ func (w WaiterMock) GetBlockProof(ctx context.Context, known, target *ton.BlockI
 	return w.MGetBlockProof(ctx, known, target)
 }
 
+func (w WaiterMock) GetShardBlockProof(ctx context.Context, block *ton.BlockIDExt) (*ton.ShardBlockProof, error) {
+	//TODO implement me
+	panic("implement me")
+}
+
 func (w WaiterMock) GetTime(ctx context.Context) (uint32, error) {
 	return w.MGetTime(ctx)
 }
@@ -628,3 +656,194 @@ func TestCreateEncryptedCommentCell(t *testing.T) {
 		}
 	}
 }
+
+func testBlockchainConfig() *ton.BlockchainConfig {
+	gas := cell.BeginCell().
+		MustStoreUInt(0xd1, 8).MustStoreUInt(100, 64).MustStoreUInt(40000, 64).
+		MustStoreUInt(0xde, 8).MustStoreUInt(26214400, 64).MustStoreUInt(1000000, 64).MustStoreUInt(1000000, 64).
+		MustStoreUInt(10000, 64).MustStoreUInt(10000000, 64).MustStoreUInt(100000000, 64).MustStoreUInt(1000000000, 64).
+		EndCell()
+	msg := cell.BeginCell().
+		MustStoreUInt(0xea, 8).MustStoreUInt(400000, 64).MustStoreUInt(26214400, 64).MustStoreUInt(2621440000, 64).
+		MustStoreUInt(98304, 32).MustStoreUInt(21845, 16).MustStoreUInt(21845, 16).
+		EndCell()
+	storage := cell.NewDict(32)
+	_ = storage.SetIntKey(big.NewInt(0), cell.BeginCell().
+		MustStoreUInt(0xcc, 8).MustStoreUInt(0, 32).
+		MustStoreUInt(1, 64).MustStoreUInt(500, 64).MustStoreUInt(1000, 64).MustStoreUInt(500000, 64).
+		EndCell())
+
+	return ton.NewBlockchainConfig(map[int32]*cell.Cell{
+		18: storage.AsCell(), 20: gas, 21: gas, 24: msg, 25: msg,
+	})
+}
+
+func testEmulator(t *testing.T) *emulator.Emulator {
+	emu, err := emulator.NewEmulator(testBlockchainConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return emu
+}
+
+func TestSpecV5R1(t *testing.T) {
+	timeNow = func() time.Time {
+		return time.Unix(1700000000, 0)
+	}
+
+	if hex := fmt.Sprintf("%x", walletCode[V5R1].Hash()); hex != "20834b7b72b112147e1b2fb457b84e74d1a30f04f737d4f62a668e9552d2b72f" {
+		t.Fatal("incorrect v5r1 code hash", hex)
+	}
+
+	cfg := ConfigV5R1{NetworkGlobalID: MainnetGlobalID}
+	if id := cfg.WalletID(DefaultSubwalletV5R1); id != 2147483409 {
+		t.Fatal("incorrect mainnet wallet id", id)
+	}
+	if id := (ConfigV5R1{NetworkGlobalID: TestnetGlobalID}).WalletID(DefaultSubwalletV5R1); id != 2147483645 {
+		t.Fatal("incorrect testnet wallet id", id)
+	}
+
+	pkey := ed25519.NewKeyFromSeed([]byte("12345678901234567890123456789012"))
+	w, err := FromPrivateKey(&MockAPI{}, pkey, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if GetWalletVersion(&tlb.Account{IsActive: true, State: &tlb.AccountState{AccountStorage: tlb.AccountStorage{Status: tlb.AccountStatusActive}},
+		Code: walletCode[V5R1]}) != V5R1 {
+		t.Fatal("v5r1 is not detected")
+	}
+
+	var seqno uint32
+	spec := w.GetSpec().(*SpecV5R1)
+	spec.SetSeqnoFetcher(func(ctx context.Context, subWallet uint32) (uint32, error) {
+		return seqno, nil
+	})
+
+	shardAcc, err := emulator.ShardAccountFromAccount(&tlb.Account{
+		State: &tlb.AccountState{
+			IsValid:     true,
+			Address:     w.Address(),
+			StorageInfo: tlb.StorageInfo{LastPaid: 1700000000},
+			AccountStorage: tlb.AccountStorage{
+				Status:  tlb.AccountStatusUninit,
+				Balance: tlb.MustFromTON("10"),
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	emu := testEmulator(t)
+	lt := uint64(1000)
+	apply := func(ext *tlb.ExternalMessage) *emulator.Result {
+		res, err := emu.EmulateTransaction(shardAcc, &tlb.Message{MsgType: tlb.MsgTypeExternalIn, Msg: ext}, emulator.Params{Now: 1700000000, LT: lt})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Accepted {
+			shardAcc = res.ShardAccount
+			lt = res.Transaction.LT + 10
+		}
+		return res
+	}
+	walletData := func() *cell.Slice {
+		var st tlb.AccountState
+		if err := st.LoadFromCell(shardAcc.Account.BeginParse()); err != nil {
+			t.Fatal(err)
+		}
+		return st.StateInit.Data.BeginParse()
+	}
+
+	dst := address.MustParseAddr("EQC9bWZd29foipyPOGWlVNVCQzpGAjvi1rGWF7EbNcSVClpA")
+	transfer, err := w.BuildTransfer(dst, tlb.MustFromTON("1.5"), true, "hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ext, err := w.PrepareExternalMessageForMany(context.Background(), true, []*Message{transfer})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res := apply(ext)
+	if !res.Accepted || res.ExitCode != 0 || len(res.OutMessages) != 1 {
+		t.Fatal("transfer with deploy failed", res.Accepted, res.ExitCode)
+	}
+	out := res.OutMessages[0].AsInternal()
+	if out.DstAddr.String() != dst.String() || out.Amount.String() != "1.5" || out.Comment() != "hello" {
+		t.Fatal("incorrect out message", out.Dump())
+	}
+
+	// replay should be rejected
+	if res = apply(ext); res.Accepted {
+		t.Fatal("replay accepted")
+	}
+
+	ext1 := address.MustParseAddr("EQDnYZIpTwo9RN_84KZX3qIkLVIUJSo8d1yz1vMlKAp2uRtK")
+	seqno = 1
+	body, err := spec.BuildMessageWithActions(context.Background(), nil, []V5R1ExtendedAction{
+		{Type: V5R1ActionAddExtension, Address: ext1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res = apply(&tlb.ExternalMessage{DstAddr: w.Address(), Body: body})
+	if !res.Accepted || res.ExitCode != 0 {
+		t.Fatal("add extension failed", res.Accepted, res.ExitCode)
+	}
+
+	data := walletData()
+	if !data.MustLoadBoolBit() || data.MustLoadUInt(32) != 2 {
+		t.Fatal("seqno should be incremented")
+	}
+	data.MustLoadUInt(32)
+	data.MustLoadSlice(256)
+	if data.MustLoadDict(256).Get(cell.BeginCell().MustStoreSlice(ext1.Data(), 256).EndCell()) == nil {
+		t.Fatal("extension is not installed")
+	}
+
+	fromExtension := func(body *cell.Cell) *emulator.Result {
+		res, err := emu.EmulateTransaction(shardAcc, &tlb.Message{MsgType: tlb.MsgTypeInternal, Msg: &tlb.InternalMessage{
+			IHRDisabled: true,
+			Bounce:      true,
+			SrcAddr:     ext1,
+			DstAddr:     w.Address(),
+			Amount:      tlb.MustFromTON("0.1"),
+			CreatedLT:   lt,
+			Body:        body,
+		}}, emulator.Params{Now: 1700000000})
+		if err != nil {
+			t.Fatal(err)
+		}
+		shardAcc = res.ShardAccount
+		lt = res.Transaction.LT + 10
+		return res
+	}
+
+	// extension disables signature and sends transfer
+	body, err = BuildV5R1ExtensionRequest(7, []*Message{transfer}, []V5R1ExtendedAction{
+		{Type: V5R1ActionSetSignatureAllowed, SignatureAllowed: false},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	res = fromExtension(body)
+	if res.ExitCode != 0 || len(res.OutMessages) != 1 || res.OutMessages[0].AsInternal().Amount.String() != "1.5" {
+		t.Fatal("extension request failed", res.ExitCode, len(res.OutMessages))
+	}
+	if walletData().MustLoadBoolBit() {
+		t.Fatal("signature should be disabled")
+	}
+
+	// signed messages are not allowed anymore
+	seqno = 2
+	if ext, err = w.PrepareExternalMessageForMany(context.Background(), false, []*Message{transfer}); err != nil {
+		t.Fatal(err)
+	}
+	if res = apply(ext); res.Accepted {
+		t.Fatal("signed message accepted when signature is disabled")
+	}
+}
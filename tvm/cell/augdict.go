@@ -0,0 +1,394 @@
+package cell
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// AugDictExtra - describes extra (augmentation) stored in every node of augmented dictionary
+type AugDictExtra struct {
+	// Skip - reads extra from the beginning of slice, required for parsing
+	Skip func(s *Slice) error
+	// Merge - calculates extra of fork from extras of its children, required for Set
+	Merge func(left, right *Slice) (*Cell, error)
+	// Empty - extra of empty dictionary, required for storing empty HashmapAugE
+	Empty *Cell
+}
+
+// AugDictionary - HashmapAug / HashmapAugE, every node contains extra,
+// fork extra is calculated from extras of its children, so root extra is a total of all leaves.
+type AugDictionary struct {
+	keySz uint
+	extra AugDictExtra
+
+	root *Cell
+	// rootExtra - extra of HashmapAugE stored next to root
+	rootExtra *Cell
+}
+
+type AugDictKV struct {
+	Key   *Slice
+	Extra *Slice
+	Value *Slice
+}
+
+func NewAugDict(keySz uint, extra AugDictExtra) *AugDictionary {
+	return &AugDictionary{
+		keySz: keySz,
+		extra: extra,
+	}
+}
+
+// AsAugDict - uses cell as root of non-empty HashmapAug
+func (c *Cell) AsAugDict(keySz uint, extra AugDictExtra) *AugDictionary {
+	return &AugDictionary{
+		keySz: keySz,
+		extra: extra,
+		root:  c,
+	}
+}
+
+func (c *Slice) MustLoadAugDict(keySz uint, extra AugDictExtra) *AugDictionary {
+	ld, err := c.LoadAugDict(keySz, extra)
+	if err != nil {
+		panic(err)
+	}
+	return ld
+}
+
+// LoadAugDict - loads HashmapAugE, which is maybe ref to root and total extra
+func (c *Slice) LoadAugDict(keySz uint, extra AugDictExtra) (*AugDictionary, error) {
+	if extra.Skip == nil {
+		return nil, fmt.Errorf("extra skip func is not set")
+	}
+
+	root, err := c.LoadMaybeRef()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load ref for dict, err: %w", err)
+	}
+
+	rootExtra, err := cutExtra(c, extra.Skip)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load root extra: %w", err)
+	}
+
+	d := &AugDictionary{
+		keySz: keySz,
+		extra: extra,
+	}
+
+	if d.rootExtra, err = rootExtra.ToCell(); err != nil {
+		return nil, fmt.Errorf("failed to convert root extra to cell: %w", err)
+	}
+
+	if root != nil {
+		if d.root, err = root.ToCell(); err != nil {
+			return nil, fmt.Errorf("failed to convert root to cell: %w", err)
+		}
+	}
+	return d, nil
+}
+
+func (d *AugDictionary) GetKeySize() uint {
+	return d.keySz
+}
+
+// AsCell - returns root of HashmapAug, nil when dictionary is empty
+func (d *AugDictionary) AsCell() *Cell {
+	return d.root
+}
+
+func (d *AugDictionary) IsEmpty() bool {
+	return d == nil || d.root == nil
+}
+
+// RootExtra - returns total extra of dictionary
+func (d *AugDictionary) RootExtra() (*Slice, error) {
+	if d.root == nil {
+		if d.rootExtra != nil {
+			return d.rootExtra.BeginParse(), nil
+		}
+		if d.extra.Empty == nil {
+			return nil, fmt.Errorf("extra of empty dict is not set")
+		}
+		return d.extra.Empty.BeginParse(), nil
+	}
+
+	// extra of root node is a total, even if HashmapAugE extra was loaded
+	return d.nodeExtra(d.root, d.keySz)
+}
+
+// LoadAll - parses all leaves of dictionary, in the order of keys
+func (d *AugDictionary) LoadAll() ([]AugDictKV, error) {
+	if d.root == nil {
+		return []AugDictKV{}, nil
+	}
+
+	kvs, err := (&Dictionary{keySz: d.keySz}).mapInner(d.keySz, d.keySz, d.root.BeginParse(), BeginCell())
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]AugDictKV, 0, len(kvs))
+	for _, kv := range kvs {
+		extra, err := cutExtra(kv.Value, d.extra.Skip)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load extra of leaf: %w", err)
+		}
+
+		res = append(res, AugDictKV{
+			Key:   kv.Key,
+			Extra: extra,
+			Value: kv.Value,
+		})
+	}
+	return res, nil
+}
+
+// LoadValueByIntKey - same as LoadValue, but constructs cell key from int
+func (d *AugDictionary) LoadValueByIntKey(key *big.Int) (*Slice, error) {
+	return d.LoadValue(BeginCell().MustStoreBigInt(key, d.keySz).EndCell())
+}
+
+// LoadValue - searches key and returns its value without extra
+//
+//	If key is not found ErrNoSuchKeyInDict will be returned
+func (d *AugDictionary) LoadValue(key *Cell) (*Slice, error) {
+	value, _, _, err := d.LoadValueWithProof(key, nil)
+	return value, err
+}
+
+// LoadValueAndExtra - searches key and returns its value and extra of leaf
+func (d *AugDictionary) LoadValueAndExtra(key *Cell) (value *Slice, extra *Slice, err error) {
+	value, extra, _, err = d.LoadValueWithProof(key, nil)
+	return value, extra, err
+}
+
+// LoadValueWithProof - searches key, constructs proof path and returns value and extra of leaf
+//
+//	If key is not found ErrNoSuchKeyInDict will be returned,
+//	and path with proof of non-existing key will be attached to skeleton (if passed)
+func (d *AugDictionary) LoadValueWithProof(key *Cell, skeleton *ProofSkeleton) (value *Slice, extra *Slice, sk *ProofSkeleton, err error) {
+	if key.BitsSize() != d.keySz {
+		return nil, nil, nil, fmt.Errorf("incorrect key size")
+	}
+
+	// forks have children refs first, so lookup is the same as for regular dict
+	value, sk, err = (&Dictionary{keySz: d.keySz}).findKey(d.root, key, skeleton)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	if extra, err = cutExtra(value, d.extra.Skip); err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to load extra of leaf: %w", err)
+	}
+	return value, extra, sk, nil
+}
+
+// SetIntKey - same as Set, but constructs cell key from int
+func (d *AugDictionary) SetIntKey(key *big.Int, value, extra *Cell) error {
+	return d.Set(BeginCell().MustStoreBigInt(key, d.keySz).EndCell(), value, extra)
+}
+
+// Set - sets value with its extra, extras of forks on the path are recalculated using Merge
+func (d *AugDictionary) Set(key, value, extra *Cell) error {
+	if key.BitsSize() != d.keySz {
+		return fmt.Errorf("invalid key size")
+	}
+	if value == nil || extra == nil {
+		return fmt.Errorf("value and extra should be set")
+	}
+	if d.extra.Merge == nil || d.extra.Skip == nil {
+		return fmt.Errorf("extra skip and merge funcs should be set")
+	}
+
+	leaf := BeginCell()
+	if err := leaf.StoreBuilder(extra.ToBuilder()); err != nil {
+		return fmt.Errorf("failed to store extra: %w", err)
+	}
+	if err := leaf.StoreBuilder(value.ToBuilder()); err != nil {
+		return fmt.Errorf("failed to store value: %w", err)
+	}
+
+	root, err := d.insert(d.root, key.BeginParse(), d.keySz, leaf)
+	if err != nil {
+		return fmt.Errorf("failed to set value in dict, err: %w", err)
+	}
+
+	d.root = root
+	d.rootExtra = nil
+	return nil
+}
+
+func (d *AugDictionary) insert(node *Cell, key *Slice, keyOffset uint, leaf *Builder) (*Cell, error) {
+	if node == nil {
+		return d.storeNode(key, keyOffset, leaf)
+	}
+
+	s := node.BeginParse()
+	sz, label, err := loadLabel(keyOffset, s, BeginCell())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load label: %w", err)
+	}
+
+	labelSlice := label.ToSlice()
+	keyPfx := key.Copy()
+	var matches uint
+	for ; matches < sz; matches++ {
+		if labelSlice.MustLoadUInt(1) != keyPfx.MustLoadUInt(1) {
+			break
+		}
+	}
+
+	if matches == sz {
+		if sz == keyOffset {
+			// same key, replace leaf
+			return d.storeNode(key, keyOffset, leaf)
+		}
+
+		pfx := key.MustLoadSlice(sz)
+		idx := key.MustLoadUInt(1)
+
+		var children [2]*Cell
+		for i := range children {
+			if children[i], err = node.PeekRef(i); err != nil {
+				return nil, fmt.Errorf("failed to peek %d ref of fork: %w", i, err)
+			}
+		}
+
+		if children[idx], err = d.insert(children[idx], key, keyOffset-(sz+1), leaf); err != nil {
+			return nil, fmt.Errorf("failed to dive into %d ref of fork: %w", idx, err)
+		}
+		return d.storeFork(BeginCell().MustStoreSlice(pfx, sz).ToSlice(), keyOffset, children)
+	}
+
+	// label differs from the key, split it to fork with existing node and new leaf
+	pfx := key.MustLoadSlice(matches)
+	idx := key.MustLoadUInt(1)
+
+	oldLabel := label.ToSlice()
+	oldLabel.MustLoadSlice(matches + 1)
+
+	old := BeginCell()
+	if err = storeAugLabel(old, oldLabel, keyOffset-(matches+1)); err != nil {
+		return nil, fmt.Errorf("failed to store label of existing node: %w", err)
+	}
+	if err = old.StoreBuilder(s.ToBuilder()); err != nil {
+		return nil, fmt.Errorf("failed to store existing node: %w", err)
+	}
+
+	newLeaf, err := d.storeNode(key, keyOffset-(matches+1), leaf)
+	if err != nil {
+		return nil, err
+	}
+
+	var children [2]*Cell
+	children[idx], children[idx^1] = newLeaf, old.EndCell()
+	return d.storeFork(BeginCell().MustStoreSlice(pfx, matches).ToSlice(), keyOffset, children)
+}
+
+func (d *AugDictionary) storeNode(label *Slice, keyOffset uint, body *Builder) (*Cell, error) {
+	b := BeginCell()
+	if err := storeAugLabel(b, label, keyOffset); err != nil {
+		return nil, fmt.Errorf("failed to store label: %w", err)
+	}
+	if err := b.StoreBuilder(body); err != nil {
+		return nil, fmt.Errorf("failed to store leaf: %w", err)
+	}
+	return b.EndCell(), nil
+}
+
+func (d *AugDictionary) storeFork(label *Slice, keyOffset uint, children [2]*Cell) (*Cell, error) {
+	childOffset := keyOffset - (label.BitsLeft() + 1)
+
+	left, err := d.nodeExtra(children[0], childOffset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load extra of left child: %w", err)
+	}
+
+	right, err := d.nodeExtra(children[1], childOffset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load extra of right child: %w", err)
+	}
+
+	extra, err := d.extra.Merge(left, right)
+	if err != nil {
+		return nil, fmt.Errorf("failed to merge extras: %w", err)
+	}
+
+	b := BeginCell()
+	if err = storeAugLabel(b, label, keyOffset); err != nil {
+		return nil, fmt.Errorf("failed to store label: %w", err)
+	}
+	if err = b.StoreRef(children[0]); err != nil {
+		return nil, err
+	}
+	if err = b.StoreRef(children[1]); err != nil {
+		return nil, err
+	}
+	if err = b.StoreBuilder(extra.ToBuilder()); err != nil {
+		return nil, fmt.Errorf("failed to store extra: %w", err)
+	}
+	return b.EndCell(), nil
+}
+
+// nodeExtra - returns extra of leaf or fork
+func (d *AugDictionary) nodeExtra(node *Cell, keyOffset uint) (*Slice, error) {
+	s := node.BeginParse()
+	sz, _, err := loadLabel(keyOffset, s, BeginCell())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load label: %w", err)
+	}
+
+	if sz == keyOffset {
+		return cutExtra(s, d.extra.Skip)
+	}
+
+	// fork, extra is after children refs
+	if _, err = s.LoadRef(); err != nil {
+		return nil, err
+	}
+	if _, err = s.LoadRef(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func storeAugLabel(b *Builder, label *Slice, keyOffset uint) error {
+	return (&Dictionary{}).storeLabel(b, label, keyOffset)
+}
+
+// cutExtra - reads extra from slice using skip func, and returns it as a separate slice
+func cutExtra(s *Slice, skip func(s *Slice) error) (*Slice, error) {
+	if skip == nil {
+		return nil, fmt.Errorf("extra skip func is not set")
+	}
+
+	from := s.Copy()
+	if err := skip(s); err != nil {
+		return nil, err
+	}
+
+	bits := from.BitsLeft() - s.BitsLeft()
+	refs := from.RefsNum() - s.RefsNum()
+
+	b := BeginCell()
+	data, err := from.LoadSlice(bits)
+	if err != nil {
+		return nil, err
+	}
+	if err = b.StoreSlice(data, bits); err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < refs; i++ {
+		ref, err := from.LoadRefCell()
+		if err != nil {
+			return nil, err
+		}
+		if err = b.StoreRef(ref); err != nil {
+			return nil, err
+		}
+	}
+	return b.ToSlice(), nil
+}
@@ -0,0 +1,134 @@
+package cell
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"testing"
+)
+
+var testSumExtra = AugDictExtra{
+	Skip: func(s *Slice) error {
+		_, err := s.LoadUInt(32)
+		return err
+	},
+	Merge: func(left, right *Slice) (*Cell, error) {
+		l, err := left.Copy().LoadUInt(32)
+		if err != nil {
+			return nil, err
+		}
+		r, err := right.Copy().LoadUInt(32)
+		if err != nil {
+			return nil, err
+		}
+		return BeginCell().MustStoreUInt(l+r, 32).EndCell(), nil
+	},
+	Empty: BeginCell().MustStoreUInt(0, 32).EndCell(),
+}
+
+func TestAugDictionary(t *testing.T) {
+	d := NewAugDict(32, testSumExtra)
+	if !d.IsEmpty() {
+		t.Fatal("should be empty")
+	}
+
+	total, err := d.RootExtra()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total.MustLoadUInt(32) != 0 {
+		t.Fatal("empty dict total should be zero")
+	}
+
+	keys := []int64{7, 1, 1000, 3, 255, 256}
+	var sum uint64
+	for _, k := range keys {
+		sum += uint64(k) * 10
+		err = d.SetIntKey(big.NewInt(k),
+			BeginCell().MustStoreUInt(uint64(k)*2, 64).EndCell(),
+			BeginCell().MustStoreUInt(uint64(k)*10, 32).EndCell())
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// overwrite should replace extra in total
+	err = d.SetIntKey(big.NewInt(3), BeginCell().MustStoreUInt(6, 64).EndCell(), BeginCell().MustStoreUInt(1, 32).EndCell())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum = sum - 30 + 1
+
+	total, err = d.RootExtra()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v := total.MustLoadUInt(32); v != sum {
+		t.Fatal("incorrect total", v, sum)
+	}
+
+	kvs, err := d.LoadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(kvs) != len(keys) {
+		t.Fatal("incorrect items num", len(kvs))
+	}
+	for _, kv := range kvs {
+		k := kv.Key.MustLoadUInt(32)
+		extra := kv.Extra.MustLoadUInt(32)
+		if (k == 3 && extra != 1) || (k != 3 && extra != k*10) {
+			t.Fatal("incorrect extra of", k, extra)
+		}
+		if kv.Value.MustLoadUInt(64) != k*2 {
+			t.Fatal("incorrect value of", k)
+		}
+	}
+
+	c := BeginCell().MustStoreAugDict(d).EndCell()
+	d2, err := c.BeginParse().LoadAugDict(32, testSumExtra)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(d2.AsCell().Hash(), d.AsCell().Hash()) {
+		t.Fatal("reloaded dict is different")
+	}
+
+	total, err = d2.RootExtra()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total.MustLoadUInt(32) != sum {
+		t.Fatal("incorrect reloaded total")
+	}
+
+	key := BeginCell().MustStoreUInt(1000, 32).EndCell()
+	sk := CreateProofSkeleton()
+	value, extra, _, err := d2.LoadValueWithProof(key, sk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value.MustLoadUInt(64) != 2000 || extra.MustLoadUInt(32) != 10000 {
+		t.Fatal("incorrect value")
+	}
+
+	proof, err := d2.AsCell().CreateProof(sk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof, err = UnwrapProof(proof, d2.AsCell().Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	value, extra, err = proof.AsAugDict(32, testSumExtra).LoadValueAndExtra(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value.MustLoadUInt(64) != 2000 || extra.MustLoadUInt(32) != 10000 {
+		t.Fatal("incorrect value from proof")
+	}
+
+	if _, err = d2.LoadValueByIntKey(big.NewInt(2)); !errors.Is(err, ErrNoSuchKeyInDict) {
+		t.Fatal("key should not exist", err)
+	}
+}
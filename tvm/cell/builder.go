@@ -408,6 +408,27 @@ func (b *Builder) StoreDict(dict *Dictionary) error {
 	return b.StoreMaybeRef(c)
 }
 
+func (b *Builder) MustStoreAugDict(dict *AugDictionary) *Builder {
+	err := b.StoreAugDict(dict)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// StoreAugDict - stores dictionary as HashmapAugE, root and then total extra
+func (b *Builder) StoreAugDict(dict *AugDictionary) error {
+	extra, err := dict.RootExtra()
+	if err != nil {
+		return err
+	}
+
+	if err = b.StoreMaybeRef(dict.AsCell()); err != nil {
+		return err
+	}
+	return b.StoreBuilder(extra.ToBuilder())
+}
+
 func (b *Builder) StoreMaybeRef(ref *Cell) error {
 	if ref == nil {
 		return b.StoreUInt(0, 1)
@@ -0,0 +1,281 @@
+package tvm
+
+import (
+	"math/big"
+
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+// Continuation - executable TVM value, it is a code with a saved context
+type Continuation interface {
+	// controlData - returns nil when continuation has no control data
+	controlData() *controlData
+	// clone - returns a shallow copy with a separate control data, to do copy-on-write modifications
+	clone() Continuation
+	jump(st *State) error
+}
+
+type controlData struct {
+	stack   *Stack
+	numArgs int
+	cp      int
+	save    registers
+}
+
+func newControlData(cp int) controlData {
+	return controlData{
+		numArgs: -1,
+		cp:      cp,
+	}
+}
+
+func (d *controlData) copy() controlData {
+	cp := *d
+	if d.stack != nil {
+		cp.stack = d.stack.copy()
+	}
+	return cp
+}
+
+// OrdinaryContinuation - continuation with a code slice
+type OrdinaryContinuation struct {
+	data controlData
+	code *cell.Slice
+}
+
+// QuitContinuation - stops execution with the given exit code
+type QuitContinuation struct {
+	ExitCode int32
+}
+
+// ExcQuitContinuation - default exception handler, stops execution with exit code taken from stack
+type ExcQuitContinuation struct{}
+
+type repeatContinuation struct {
+	body, after Continuation
+	count       int64
+}
+
+type againContinuation struct {
+	body Continuation
+}
+
+type untilContinuation struct {
+	body, after Continuation
+}
+
+type whileContinuation struct {
+	cond, body, after Continuation
+	checkCond         bool
+}
+
+type argExtContinuation struct {
+	data controlData
+	ext  Continuation
+}
+
+type pushIntContinuation struct {
+	value int64
+	next  Continuation
+}
+
+func newOrdinaryContinuation(code *cell.Slice, cp int) *OrdinaryContinuation {
+	return &OrdinaryContinuation{
+		data: newControlData(cp),
+		code: code,
+	}
+}
+
+func (c *OrdinaryContinuation) controlData() *controlData {
+	return &c.data
+}
+
+func (c *OrdinaryContinuation) clone() Continuation {
+	return &OrdinaryContinuation{
+		data: c.data.copy(),
+		code: c.code,
+	}
+}
+
+func (c *OrdinaryContinuation) jump(st *State) error {
+	st.reg.adjust(&c.data.save)
+	st.setCode(c.code.Copy(), c.data.cp)
+	return nil
+}
+
+// Code - returns a copy of continuation's code slice
+func (c *OrdinaryContinuation) Code() *cell.Slice {
+	return c.code.Copy()
+}
+
+func (c *QuitContinuation) controlData() *controlData {
+	return nil
+}
+
+func (c *QuitContinuation) clone() Continuation {
+	return &QuitContinuation{ExitCode: c.ExitCode}
+}
+
+func (c *QuitContinuation) jump(st *State) error {
+	st.halt(c.ExitCode)
+	return nil
+}
+
+func (c *ExcQuitContinuation) controlData() *controlData {
+	return nil
+}
+
+func (c *ExcQuitContinuation) clone() Continuation {
+	return &ExcQuitContinuation{}
+}
+
+func (c *ExcQuitContinuation) jump(st *State) error {
+	code, err := st.stack.popIntRange(0, 0xffff)
+	if err != nil {
+		code = ErrCodeUnknown
+	}
+	st.halt(int32(code))
+	return nil
+}
+
+func (c *repeatContinuation) controlData() *controlData {
+	return nil
+}
+
+func (c *repeatContinuation) clone() Continuation {
+	cp := *c
+	return &cp
+}
+
+func (c *repeatContinuation) jump(st *State) error {
+	if c.count <= 0 {
+		return st.jump(c.after)
+	}
+	if hasC0(c.body) {
+		return st.jump(c.body)
+	}
+	st.reg.c[0] = &repeatContinuation{body: c.body, after: c.after, count: c.count - 1}
+	return st.jump(c.body)
+}
+
+func (c *againContinuation) controlData() *controlData {
+	return nil
+}
+
+func (c *againContinuation) clone() Continuation {
+	cp := *c
+	return &cp
+}
+
+func (c *againContinuation) jump(st *State) error {
+	if !hasC0(c.body) {
+		st.reg.c[0] = c
+	}
+	return st.jump(c.body)
+}
+
+func (c *untilContinuation) controlData() *controlData {
+	return nil
+}
+
+func (c *untilContinuation) clone() Continuation {
+	cp := *c
+	return &cp
+}
+
+func (c *untilContinuation) jump(st *State) error {
+	terminated, err := st.stack.popBool()
+	if err != nil {
+		return err
+	}
+	if terminated {
+		return st.jump(c.after)
+	}
+	if !hasC0(c.body) {
+		st.reg.c[0] = c
+	}
+	return st.jump(c.body)
+}
+
+func (c *whileContinuation) controlData() *controlData {
+	return nil
+}
+
+func (c *whileContinuation) clone() Continuation {
+	cp := *c
+	return &cp
+}
+
+func (c *whileContinuation) jump(st *State) error {
+	if c.checkCond {
+		ok, err := st.stack.popBool()
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return st.jump(c.after)
+		}
+		if !hasC0(c.body) {
+			st.reg.c[0] = &whileContinuation{cond: c.cond, body: c.body, after: c.after, checkCond: false}
+		}
+		return st.jump(c.body)
+	}
+
+	if !hasC0(c.cond) {
+		st.reg.c[0] = &whileContinuation{cond: c.cond, body: c.body, after: c.after, checkCond: true}
+	}
+	return st.jump(c.cond)
+}
+
+func (c *argExtContinuation) controlData() *controlData {
+	return &c.data
+}
+
+func (c *argExtContinuation) clone() Continuation {
+	return &argExtContinuation{
+		data: c.data.copy(),
+		ext:  c.ext,
+	}
+}
+
+func (c *argExtContinuation) jump(st *State) error {
+	st.reg.adjust(&c.data.save)
+	if c.data.cp != -1 {
+		st.cp = c.data.cp
+	}
+	return c.ext.jump(st)
+}
+
+func (c *pushIntContinuation) controlData() *controlData {
+	return nil
+}
+
+func (c *pushIntContinuation) clone() Continuation {
+	cp := *c
+	return &cp
+}
+
+func (c *pushIntContinuation) jump(st *State) error {
+	st.stack.push(big.NewInt(c.value))
+	return st.jump(c.next)
+}
+
+func hasC0(c Continuation) bool {
+	d := c.controlData()
+	return d != nil && d.save.c[0] != nil
+}
+
+// forceControlData - returns a copy of continuation with control data,
+// continuations without control data are wrapped to argExtContinuation
+func forceControlData(c Continuation) (Continuation, *controlData) {
+	if c.controlData() != nil {
+		c = c.clone()
+		return c, c.controlData()
+	}
+
+	ext := &argExtContinuation{
+		data: newControlData(-1),
+		ext:  c,
+	}
+	return ext, &ext.data
+}
@@ -0,0 +1,189 @@
+package emulator
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton/fees"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+// account - mutable representation of account during transaction
+type account struct {
+	addr   *address.Address
+	status tlb.AccountStatus
+
+	balance *big.Int
+	extra   *cell.Dictionary
+
+	lastPaid    uint32
+	duePayment  *big.Int
+	lastTransLT uint64
+
+	state     *tlb.StateInit
+	stateHash []byte
+}
+
+func loadAccount(shardAcc *tlb.ShardAccount, dst *address.Address) (*account, error) {
+	acc := &account{
+		addr:    dst,
+		status:  tlb.AccountStatusNonExist,
+		balance: big.NewInt(0),
+	}
+
+	if shardAcc == nil || shardAcc.Account == nil {
+		return acc, nil
+	}
+
+	var st tlb.AccountState
+	if err := st.LoadFromCell(shardAcc.Account.BeginParse()); err != nil {
+		return nil, fmt.Errorf("failed to parse account: %w", err)
+	}
+	if !st.IsValid {
+		return acc, nil
+	}
+
+	if dst != nil && (st.Address.Workchain() != dst.Workchain() || string(st.Address.Data()) != string(dst.Data())) {
+		return nil, fmt.Errorf("account address is not equal to message destination")
+	}
+
+	return accountFromState(&st), nil
+}
+
+func accountFromState(st *tlb.AccountState) *account {
+	acc := &account{
+		addr:        st.Address,
+		status:      st.Status,
+		balance:     st.Balance.Nano(),
+		extra:       st.ExtraCurrencies,
+		lastPaid:    st.StorageInfo.LastPaid,
+		lastTransLT: st.LastTransactionLT,
+		state:       st.StateInit,
+		stateHash:   st.StateHash,
+	}
+	if st.StorageInfo.DuePayment != nil {
+		acc.duePayment = st.StorageInfo.DuePayment.Nano()
+	}
+	return acc
+}
+
+func (a *account) code() *cell.Cell {
+	if a.state == nil {
+		return nil
+	}
+	return a.state.Code
+}
+
+func (a *account) data() *cell.Cell {
+	if a.state == nil {
+		return nil
+	}
+	return a.state.Data
+}
+
+func (a *account) storageCell() (*cell.Cell, error) {
+	b := cell.BeginCell().
+		MustStoreUInt(a.lastTransLT, 64)
+	if err := b.StoreBigCoins(a.balance); err != nil {
+		return nil, fmt.Errorf("failed to store balance: %w", err)
+	}
+	if err := b.StoreDict(a.extra); err != nil {
+		return nil, fmt.Errorf("failed to store extra currencies: %w", err)
+	}
+
+	switch a.status {
+	case tlb.AccountStatusActive:
+		st, err := tlb.ToCell(a.state)
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize state init: %w", err)
+		}
+		b.MustStoreUInt(1, 1).MustStoreBuilder(st.ToBuilder())
+	case tlb.AccountStatusFrozen:
+		b.MustStoreUInt(0b01, 2).MustStoreSlice(a.stateHash, 256)
+	default:
+		b.MustStoreUInt(0b00, 2)
+	}
+	return b.EndCell(), nil
+}
+
+// toCell - serializes account, storage stats are recalculated
+func (a *account) toCell() (*cell.Cell, error) {
+	if a.status == tlb.AccountStatusNonExist {
+		return cell.BeginCell().MustStoreUInt(0, 1).EndCell(), nil
+	}
+
+	storage, err := a.storageCell()
+	if err != nil {
+		return nil, err
+	}
+	cells, bits := fees.CellStats(storage, false)
+
+	info := tlb.StorageInfo{
+		StorageUsed: tlb.StorageUsed{
+			CellsUsed:       new(big.Int).SetUint64(cells),
+			BitsUsed:        new(big.Int).SetUint64(bits),
+			PublicCellsUsed: big.NewInt(0),
+		},
+		LastPaid: a.lastPaid,
+	}
+	if a.duePayment != nil && a.duePayment.Sign() > 0 {
+		due := tlb.FromNanoTON(a.duePayment)
+		info.DuePayment = &due
+	}
+
+	infoCell, err := tlb.ToCell(info)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize storage info: %w", err)
+	}
+
+	b := cell.BeginCell().MustStoreUInt(1, 1)
+	if err = b.StoreAddr(a.addr); err != nil {
+		return nil, fmt.Errorf("failed to store address: %w", err)
+	}
+	if err = b.StoreBuilder(infoCell.ToBuilder()); err != nil {
+		return nil, fmt.Errorf("failed to store storage info: %w", err)
+	}
+	if err = b.StoreBuilder(storage.ToBuilder()); err != nil {
+		return nil, fmt.Errorf("failed to store account storage: %w", err)
+	}
+	return b.EndCell(), nil
+}
+
+// storageStats - returns number of cells and bits used by account storage
+func (a *account) storageStats() (uint64, uint64, error) {
+	storage, err := a.storageCell()
+	if err != nil {
+		return 0, 0, err
+	}
+	cells, bits := fees.CellStats(storage, false)
+	return cells, bits, nil
+}
+
+// ShardAccountFromAccount - builds shard account from the account state returned by GetAccount,
+// storage stats are recalculated from the state.
+func ShardAccountFromAccount(acc *tlb.Account) (*tlb.ShardAccount, error) {
+	if acc == nil {
+		return nil, fmt.Errorf("account is nil")
+	}
+
+	res := &tlb.ShardAccount{
+		LastTransHash: acc.LastTxHash,
+		LastTransLT:   acc.LastTxLT,
+	}
+	if len(res.LastTransHash) == 0 {
+		res.LastTransHash = make([]byte, 32)
+	}
+
+	a := &account{status: tlb.AccountStatusNonExist, balance: big.NewInt(0)}
+	if acc.State != nil && acc.State.IsValid {
+		a = accountFromState(acc.State)
+	}
+
+	var err error
+	if res.Account, err = a.toCell(); err != nil {
+		return nil, fmt.Errorf("failed to serialize account: %w", err)
+	}
+	return res, nil
+}
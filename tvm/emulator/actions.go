@@ -0,0 +1,513 @@
+package emulator
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton/fees"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+// result codes of action phase
+const (
+	actionErrInvalidList      = 32
+	actionErrTooManyActions   = 33
+	actionErrInvalidAction    = 34
+	actionErrInvalidSrcAddr   = 35
+	actionErrInvalidDstAddr   = 36
+	actionErrNotEnoughBalance = 37
+	actionErrNotEnoughValue   = 40
+	actionErrLibraryNotFound  = 41
+)
+
+// send message modes
+const (
+	sendModePayFeesSeparately = 1
+	sendModeIgnoreErrors      = 2
+	sendModeDestroyIfZero     = 32
+	sendModeCarryInbound      = 64
+	sendModeCarryAll          = 128
+)
+
+// reserve modes
+const (
+	reserveModeAllBut      = 1
+	reserveModeIgnoreError = 2
+	reserveModeOriginal    = 4
+	reserveModeNegate      = 8
+	reserveModeBounce      = 16
+)
+
+// actionState - balances and counters of action phase, applied to account only when phase is successful
+type actionState struct {
+	t *transaction
+
+	balance      *big.Int
+	reserved     *big.Int
+	origBalance  *big.Int
+	msgRemaining *big.Int
+
+	code    *cell.Cell
+	libs    *cell.Dictionary
+	destroy bool
+
+	outMsgs    []*cell.Cell
+	fwdFees    *big.Int
+	actionFees *big.Int
+	msgCells   uint64
+	msgBits    uint64
+}
+
+// action - processes actions list created by compute phase, returns false when phase was failed
+func (t *transaction) action(cr *computeResult) (bool, error) {
+	actions := cr.actions
+	if actions == nil {
+		actions = cell.BeginCell().EndCell()
+	}
+
+	phase := &tlb.ActionPhase{
+		Valid:          true,
+		StatusChange:   tlb.AccStatusChange{Type: tlb.AccStatusChangeUnchanged},
+		ActionListHash: actions.Hash(),
+	}
+	t.actionPhase = phase
+
+	as := &actionState{
+		t:            t,
+		balance:      new(big.Int).Set(t.acc.balance),
+		reserved:     big.NewInt(0),
+		msgRemaining: new(big.Int).Set(t.msgBalanceRemaining),
+		code:         cr.state.Code,
+		libs:         cr.state.Lib,
+		fwdFees:      big.NewInt(0),
+		actionFees:   big.NewInt(0),
+	}
+	as.origBalance = new(big.Int).Sub(as.balance, as.msgRemaining)
+	if as.origBalance.Sign() < 0 {
+		as.origBalance.SetInt64(0)
+	}
+
+	fail := func(code int32, idx int) (bool, error) {
+		arg := int32(idx)
+		phase.Success = false
+		phase.ResultCode = code
+		phase.ResultArg = &arg
+		phase.NoFunds = code == actionErrNotEnoughBalance || code == actionErrNotEnoughValue
+		if code == actionErrInvalidList || code == actionErrTooManyActions || code == actionErrInvalidAction {
+			phase.Valid = false
+		}
+		phase.MessagesCreated = 0
+		phase.TotalMsgSize = tlb.StorageUsedShort{Cells: big.NewInt(0), Bits: big.NewInt(0)}
+		return false, nil
+	}
+
+	list, code := parseActionList(actions)
+	if code != 0 {
+		return fail(code, len(list))
+	}
+	phase.TotalActions = uint16(len(list))
+
+	for i, a := range list {
+		// message is kept in the layout it was created with, fees depend on it
+		msgCell, _ := a.PreloadRefCell()
+
+		var act tlb.OutAction
+		if err := tlb.LoadFromCell(&act, a); err != nil || a.BitsLeft() != 0 || a.RefsNum() != 0 {
+			return fail(actionErrInvalidAction, i)
+		}
+
+		var canSkip bool
+		switch x := act.Action.(type) {
+		case tlb.ActionSendMsg:
+			code, canSkip = as.sendMsg(x.Mode, x.Msg, msgCell), x.Mode&sendModeIgnoreErrors != 0
+		case tlb.ActionReserveCurrency:
+			code, canSkip = as.reserve(x.Mode, x.Currency.Coins.Nano()), x.Mode&reserveModeIgnoreError != 0
+		case tlb.ActionSetCode:
+			as.code = x.NewCode
+			phase.SpecActions++
+			continue
+		case tlb.ActionChangeLibrary:
+			code = as.changeLibrary(x.Mode, x.LibRef)
+			if code == 0 {
+				phase.SpecActions++
+			}
+		default:
+			return fail(actionErrInvalidAction, i)
+		}
+
+		if code != 0 {
+			if code != actionErrInvalidAction && canSkip {
+				phase.SkippedActions++
+				continue
+			}
+			return fail(code, i)
+		}
+	}
+
+	acc := t.acc
+	acc.balance = as.balance.Add(as.balance, as.reserved)
+	t.msgBalanceRemaining = as.msgRemaining
+	t.totalFees.Add(t.totalFees, as.actionFees)
+	t.outMsgs = append(t.outMsgs, as.outMsgs...)
+
+	acc.status = tlb.AccountStatusActive
+	acc.state = &tlb.StateInit{
+		Depth:    cr.state.Depth,
+		TickTock: cr.state.TickTock,
+		Code:     as.code,
+		Data:     cr.data,
+		Lib:      as.libs,
+	}
+
+	if as.destroy && acc.balance.Sign() == 0 {
+		acc.status = tlb.AccountStatusNonExist
+		acc.state = nil
+		t.destroyed = true
+		phase.StatusChange.Type = tlb.AccStatusChangeDeleted
+	}
+
+	phase.Success = true
+	phase.MessagesCreated = uint16(len(as.outMsgs))
+	if as.fwdFees.Sign() > 0 {
+		v := tlb.FromNanoTON(as.fwdFees)
+		phase.TotalFwdFees = &v
+	}
+	if as.actionFees.Sign() > 0 {
+		v := tlb.FromNanoTON(as.actionFees)
+		phase.TotalActionFees = &v
+	}
+	phase.TotalMsgSize = tlb.StorageUsedShort{
+		Cells: new(big.Int).SetUint64(as.msgCells),
+		Bits:  new(big.Int).SetUint64(as.msgBits),
+	}
+	return true, nil
+}
+
+// parseActionList - converts linked list of actions to slice, in the order they were created
+func parseActionList(c *cell.Cell) ([]*cell.Slice, int32) {
+	var list []*cell.Slice
+	for {
+		s := c.BeginParse()
+		if s.BitsLeft() == 0 && s.RefsNum() == 0 {
+			break
+		}
+		if len(list) == tlb.MaxOutActions {
+			return nil, actionErrTooManyActions
+		}
+
+		prev, err := s.LoadRefCell()
+		if err != nil {
+			return list, actionErrInvalidList
+		}
+		list = append(list, s)
+		c = prev
+	}
+
+	for i, j := 0, len(list)-1; i < j; i, j = i+1, j-1 {
+		list[i], list[j] = list[j], list[i]
+	}
+	return list, 0
+}
+
+func (as *actionState) sendMsg(mode uint8, msg *tlb.Message, msgCell *cell.Cell) int32 {
+	t := as.t
+
+	cells, bits := fees.CellStats(msgCell, true)
+	createdLT := t.lt + 1 + uint64(len(t.outMsgs)+len(as.outMsgs))
+
+	switch m := msg.Msg.(type) {
+	case *tlb.InternalMessage:
+		if m.DstAddr == nil || (m.DstAddr.Type() != address.StdAddress && m.DstAddr.Type() != address.VarAddress) {
+			return actionErrInvalidDstAddr
+		}
+		if m.SrcAddr != nil && !m.SrcAddr.IsAddrNone() && !sameAddr(m.SrcAddr, t.acc.addr) {
+			return actionErrInvalidSrcAddr
+		}
+
+		_, prices := t.e.prices(t.acc.addr.Workchain() == address.MasterchainID || m.DstAddr.Workchain() == address.MasterchainID)
+		fwd := fees.ComputeFwdFee(prices, cells, bits)
+		ihr := big.NewInt(0)
+		if !m.IHRDisabled {
+			ihr = fees.ComputeIHRFee(prices, fwd)
+		}
+		msgFees := new(big.Int).Add(fwd, ihr)
+
+		value := m.Amount.Nano()
+		if mode&sendModeCarryAll != 0 {
+			value = new(big.Int).Set(as.balance)
+		} else if mode&sendModeCarryInbound != 0 {
+			value = new(big.Int).Add(value, as.msgRemaining)
+		}
+
+		required := new(big.Int).Set(value)
+		if mode&sendModePayFeesSeparately != 0 && mode&sendModeCarryAll == 0 {
+			required.Add(required, msgFees)
+		} else {
+			if value.Cmp(msgFees) < 0 {
+				return actionErrNotEnoughValue
+			}
+			value = new(big.Int).Sub(value, msgFees)
+		}
+		if required.Cmp(as.balance) > 0 {
+			return actionErrNotEnoughBalance
+		}
+
+		mine := fees.FwdFeeFirstFrac(prices, fwd)
+		m.SrcAddr = t.acc.addr
+		m.Bounced = false
+		m.Amount = tlb.FromNanoTON(value)
+		m.IHRFee = tlb.FromNanoTON(ihr)
+		m.FwdFee = tlb.FromNanoTON(new(big.Int).Sub(fwd, mine))
+		m.CreatedLT = createdLT
+		m.CreatedAt = t.now
+
+		res, err := rebuildMessage(msgCell, m)
+		if err != nil {
+			return actionErrInvalidAction
+		}
+
+		as.balance.Sub(as.balance, required)
+		as.fwdFees.Add(as.fwdFees, msgFees)
+		as.actionFees.Add(as.actionFees, mine)
+		if mode&sendModeCarryInbound != 0 {
+			as.msgRemaining.SetInt64(0)
+		}
+		if mode&sendModeCarryAll != 0 && mode&sendModeDestroyIfZero != 0 {
+			as.destroy = true
+		}
+		as.addMsg(res)
+	case *tlb.ExternalMessageOut:
+		if m.SrcAddr != nil && !m.SrcAddr.IsAddrNone() && !sameAddr(m.SrcAddr, t.acc.addr) {
+			return actionErrInvalidSrcAddr
+		}
+
+		_, prices := t.e.prices(t.acc.addr.Workchain() == address.MasterchainID)
+		fwd := fees.ComputeFwdFee(prices, cells, bits)
+		if fwd.Cmp(as.balance) > 0 {
+			return actionErrNotEnoughBalance
+		}
+
+		m.SrcAddr = t.acc.addr
+		m.CreatedLT = createdLT
+		m.CreatedAt = t.now
+
+		res, err := rebuildMessage(msgCell, m)
+		if err != nil {
+			return actionErrInvalidAction
+		}
+
+		as.balance.Sub(as.balance, fwd)
+		as.fwdFees.Add(as.fwdFees, fwd)
+		as.actionFees.Add(as.actionFees, fwd)
+		as.addMsg(res)
+	default:
+		return actionErrInvalidAction
+	}
+	return 0
+}
+
+func (as *actionState) addMsg(c *cell.Cell) {
+	cells, bits := fees.CellStats(c, false)
+	as.msgCells += cells
+	as.msgBits += bits
+	as.outMsgs = append(as.outMsgs, c)
+}
+
+func (as *actionState) reserve(mode uint8, amount *big.Int) int32 {
+	if mode&^uint8(reserveModeAllBut|reserveModeIgnoreError|reserveModeOriginal|reserveModeNegate|reserveModeBounce) != 0 {
+		return actionErrInvalidAction
+	}
+
+	amount = new(big.Int).Set(amount)
+	if mode&reserveModeOriginal != 0 {
+		if mode&reserveModeNegate != 0 {
+			amount.Sub(as.origBalance, amount)
+		} else {
+			amount.Add(amount, as.origBalance)
+		}
+	} else if mode&reserveModeNegate != 0 {
+		return actionErrInvalidAction
+	}
+	if amount.Sign() < 0 {
+		return actionErrInvalidAction
+	}
+
+	if mode&reserveModeAllBut != 0 {
+		amount.Sub(as.balance, amount)
+		if amount.Sign() < 0 {
+			amount.SetInt64(0)
+		}
+	}
+
+	if amount.Cmp(as.balance) > 0 {
+		if mode&reserveModeIgnoreError == 0 {
+			return actionErrNotEnoughBalance
+		}
+		amount.Set(as.balance)
+	}
+
+	as.balance.Sub(as.balance, amount)
+	as.reserved.Add(as.reserved, amount)
+	return 0
+}
+
+// changeLibrary - adds or removes library of account, mode 0 removes, 1 adds private, 2 adds public library
+func (as *actionState) changeLibrary(mode uint8, ref any) int32 {
+	var hash []byte
+	var lib *cell.Cell
+	switch r := ref.(type) {
+	case tlb.LibRefRef:
+		lib, hash = r.Library, r.Library.Hash()
+	case tlb.LibRefHash:
+		hash = r.LibHash
+	default:
+		return actionErrInvalidAction
+	}
+	// +16 is bounce on failure flag, it is not affects library change
+	mode &^= 16
+	if mode > 2 {
+		return actionErrInvalidAction
+	}
+
+	var err error
+
+	libs := cell.NewDict(256)
+	if as.libs != nil {
+		libs = as.libs.Copy()
+	}
+	key := cell.BeginCell().MustStoreSlice(hash, 256).EndCell()
+
+	if mode == 0 {
+		if err = libs.Delete(key); err != nil {
+			return actionErrInvalidAction
+		}
+	} else {
+		if lib == nil {
+			v, err := libs.LoadValue(key)
+			if err != nil {
+				return actionErrLibraryNotFound
+			}
+			if _, err = v.LoadBoolBit(); err != nil {
+				return actionErrInvalidAction
+			}
+			if lib, err = v.LoadRefCell(); err != nil {
+				return actionErrInvalidAction
+			}
+		}
+
+		value := cell.BeginCell().MustStoreBoolBit(mode == 2).MustStoreRef(lib).EndCell()
+		if err = libs.Set(key, value); err != nil {
+			return actionErrInvalidAction
+		}
+	}
+
+	if libs.IsEmpty() {
+		libs = nil
+	}
+	as.libs = libs
+	return 0
+}
+
+// rebuildMessage - serializes message with updated header, keeping state init and body layout of original message when possible
+func rebuildMessage(orig *cell.Cell, msg tlb.AnyMessage) (*cell.Cell, error) {
+	s := orig.BeginParse()
+
+	b := cell.BeginCell()
+	switch m := msg.(type) {
+	case *tlb.InternalMessage:
+		if err := skipIntMsgHeader(s); err != nil {
+			return nil, err
+		}
+
+		b.MustStoreUInt(0, 1).
+			MustStoreBoolBit(m.IHRDisabled).
+			MustStoreBoolBit(m.Bounce).
+			MustStoreBoolBit(m.Bounced)
+		if err := b.StoreAddr(m.SrcAddr); err != nil {
+			return nil, err
+		}
+		if err := b.StoreAddr(m.DstAddr); err != nil {
+			return nil, err
+		}
+		if err := b.StoreBigCoins(m.Amount.Nano()); err != nil {
+			return nil, err
+		}
+		if err := b.StoreDict(m.ExtraCurrencies); err != nil {
+			return nil, err
+		}
+		if err := b.StoreBigCoins(m.IHRFee.Nano()); err != nil {
+			return nil, err
+		}
+		if err := b.StoreBigCoins(m.FwdFee.Nano()); err != nil {
+			return nil, err
+		}
+		b.MustStoreUInt(m.CreatedLT, 64).MustStoreUInt(uint64(m.CreatedAt), 32)
+	case *tlb.ExternalMessageOut:
+		if err := skipExtOutMsgHeader(s); err != nil {
+			return nil, err
+		}
+
+		b.MustStoreUInt(0b11, 2)
+		if err := b.StoreAddr(m.SrcAddr); err != nil {
+			return nil, err
+		}
+		if err := b.StoreAddr(m.DstAddr); err != nil {
+			return nil, err
+		}
+		b.MustStoreUInt(m.CreatedLT, 64).MustStoreUInt(uint64(m.CreatedAt), 32)
+	default:
+		return nil, fmt.Errorf("unsupported message type")
+	}
+
+	if err := b.StoreBuilder(s.ToBuilder()); err != nil {
+		// header became bigger and tail is not fits anymore, serialize with default layout
+		return tlb.ToCell(msg)
+	}
+	return b.EndCell(), nil
+}
+
+func skipIntMsgHeader(s *cell.Slice) error {
+	if _, err := s.LoadUInt(4); err != nil {
+		return err
+	}
+	if _, err := s.LoadAddr(); err != nil {
+		return err
+	}
+	if _, err := s.LoadAddr(); err != nil {
+		return err
+	}
+	if _, err := s.LoadBigCoins(); err != nil {
+		return err
+	}
+	if _, err := s.LoadMaybeRef(); err != nil {
+		return err
+	}
+	if _, err := s.LoadBigCoins(); err != nil {
+		return err
+	}
+	if _, err := s.LoadBigCoins(); err != nil {
+		return err
+	}
+	_, err := s.LoadUInt(96)
+	return err
+}
+
+func skipExtOutMsgHeader(s *cell.Slice) error {
+	if _, err := s.LoadUInt(2); err != nil {
+		return err
+	}
+	if _, err := s.LoadAddr(); err != nil {
+		return err
+	}
+	if _, err := s.LoadAddr(); err != nil {
+		return err
+	}
+	_, err := s.LoadUInt(96)
+	return err
+}
+
+func sameAddr(a, b *address.Address) bool {
+	return a.Workchain() == b.Workchain() && string(a.Data()) == string(b.Data())
+}
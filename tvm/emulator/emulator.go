@@ -0,0 +1,664 @@
+package emulator
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/ton/fees"
+	"github.com/xssnick/tonutils-go/tvm"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+// ConfigParams - ids of config params which should be fetched for emulation, prices listed in fees.ConfigParams,
+// global version with capabilities (8) and size limits (43), they are also available for contracts through c7
+var ConfigParams = append([]int32{8, 43}, fees.ConfigParams...)
+
+// Emulator - executes transactions locally, using prices from blockchain config.
+// It is safe for concurrent use, every emulation has its own state.
+type Emulator struct {
+	configRoot *cell.Cell
+
+	fees *fees.Estimator
+}
+
+// Params - context of emulated transaction
+type Params struct {
+	// Now - unix time of transaction, current time is used when zero
+	Now uint32
+	// LT - logical time of transaction, will be increased when it is less than account or message lt
+	LT uint64
+	// RandSeed - 32 bytes block random seed, zero seed is used when empty
+	RandSeed []byte
+	// Libraries - library cells which can be referenced by contract code
+	Libraries []*cell.Cell
+}
+
+// Result - result of emulation
+type Result struct {
+	// Accepted - false when external message was not accepted, other fields except ExitCode and GasUsed are empty in this case
+	Accepted bool
+	// ExitCode - exit code of compute phase, zero when it was skipped
+	ExitCode int32
+	// GasUsed - gas used by compute phase
+	GasUsed uint64
+
+	Transaction     *tlb.Transaction
+	TransactionCell *cell.Cell
+	// ShardAccount - new state of account, with hash and lt of emulated transaction
+	ShardAccount *tlb.ShardAccount
+	OutMessages  []*tlb.Message
+}
+
+// NewEmulator - creates emulator, config should contain params listed in ConfigParams
+func NewEmulator(cfg *ton.BlockchainConfig) (*Emulator, error) {
+	if cfg == nil {
+		return nil, fmt.Errorf("config is nil")
+	}
+
+	e := &Emulator{}
+	var err error
+	if e.fees, err = fees.NewEstimator(cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse prices: %w", err)
+	}
+
+	dict := cell.NewDict(32)
+	for id, param := range cfg.All() {
+		key := cell.BeginCell().MustStoreInt(int64(id), 32).EndCell()
+		if err = dict.Set(key, cell.BeginCell().MustStoreRef(param).EndCell()); err != nil {
+			return nil, fmt.Errorf("failed to store config param %d: %w", id, err)
+		}
+	}
+	if e.configRoot, err = dict.ToCell(); err != nil {
+		return nil, fmt.Errorf("failed to serialize config dict: %w", err)
+	}
+	return e, nil
+}
+
+func (e *Emulator) prices(masterchain bool) (*tlb.GasLimitsPrices, *tlb.MsgForwardPrices) {
+	wc := int32(0)
+	if masterchain {
+		wc = address.MasterchainID
+	}
+	return e.fees.GasPrices(wc), e.fees.MsgForwardPrices(wc)
+}
+
+// EmulateTransaction - applies inbound message to account and builds resulting transaction,
+// shard account can be nil or contain account_none when account does not exist yet.
+//
+//	When external message is not accepted, result with Accepted = false is returned, without error.
+func (e *Emulator) EmulateTransaction(shardAcc *tlb.ShardAccount, msg *tlb.Message, p Params) (*Result, error) {
+	if msg == nil || msg.Msg == nil {
+		return nil, fmt.Errorf("message is nil")
+	}
+
+	msgCell, err := tlb.ToCell(msg.Msg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize message: %w", err)
+	}
+
+	dst := msg.Msg.DestAddr()
+	if dst == nil || dst.Type() != address.StdAddress {
+		return nil, fmt.Errorf("message destination should be std address")
+	}
+
+	acc, err := loadAccount(shardAcc, dst)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &transaction{
+		e:          e,
+		acc:        acc,
+		msg:        msg,
+		msgCell:    msgCell,
+		now:        p.Now,
+		seed:       p.RandSeed,
+		libs:       p.Libraries,
+		totalFees:  big.NewInt(0),
+		origStatus: acc.status,
+	}
+	t.gas, t.fwd = e.prices(dst.Workchain() == address.MasterchainID)
+	if t.now == 0 {
+		t.now = uint32(time.Now().Unix())
+	}
+
+	t.lt = p.LT
+	if t.lt < acc.lastTransLT+1 {
+		t.lt = acc.lastTransLT + 1
+	}
+	if shardAcc != nil {
+		t.prevTxHash, t.prevTxLT = shardAcc.LastTransHash, shardAcc.LastTransLT
+		if t.lt < shardAcc.LastTransLT+1 {
+			t.lt = shardAcc.LastTransLT + 1
+		}
+	}
+	if len(t.prevTxHash) == 0 {
+		t.prevTxHash = make([]byte, 32)
+	}
+
+	if shardAcc != nil && shardAcc.Account != nil {
+		t.oldAccountCell = shardAcc.Account
+	} else if t.oldAccountCell, err = acc.toCell(); err != nil {
+		return nil, fmt.Errorf("failed to serialize original account: %w", err)
+	}
+
+	switch m := msg.Msg.(type) {
+	case *tlb.InternalMessage:
+		if t.lt < m.CreatedLT+1 {
+			t.lt = m.CreatedLT + 1
+		}
+		return t.runInternal(m)
+	case *tlb.ExternalMessage:
+		return t.runExternal()
+	default:
+		return nil, fmt.Errorf("only internal and external inbound messages can be emulated")
+	}
+}
+
+// transaction - state of single emulated transaction
+type transaction struct {
+	e   *Emulator
+	gas *tlb.GasLimitsPrices
+	fwd *tlb.MsgForwardPrices
+
+	acc     *account
+	msg     *tlb.Message
+	msgCell *cell.Cell
+
+	now        uint32
+	lt         uint64
+	seed       []byte
+	libs       []*cell.Cell
+	prevTxHash []byte
+	prevTxLT   uint64
+
+	origStatus     tlb.AccountStatus
+	oldAccountCell *cell.Cell
+
+	msgBalanceRemaining *big.Int
+	storageFees         *big.Int
+	totalFees           *big.Int
+	outMsgs             []*cell.Cell
+
+	storagePhase *tlb.StoragePhase
+	creditPhase  *tlb.CreditPhase
+	computePhase tlb.ComputePhase
+	actionPhase  *tlb.ActionPhase
+	bouncePhase  *tlb.BouncePhase
+	creditFirst  bool
+	destroyed    bool
+
+	exitCode int32
+	gasUsed  uint64
+}
+
+func (t *transaction) runInternal(m *tlb.InternalMessage) (*Result, error) {
+	t.creditFirst = !m.Bounce
+	if t.creditFirst {
+		t.credit(m)
+		t.storage()
+	} else {
+		t.storage()
+		t.credit(m)
+	}
+
+	cp, err := t.compute(m.Amount.Nano(), false)
+	if err != nil {
+		return nil, err
+	}
+
+	actionsOk := true
+	if cp != nil && cp.success {
+		if actionsOk, err = t.action(cp); err != nil {
+			return nil, err
+		}
+	}
+
+	if m.Bounce && (cp == nil || !cp.success || !actionsOk) {
+		if err = t.bounce(m); err != nil {
+			return nil, err
+		}
+	}
+	return t.finish(cp == nil || !cp.success || !actionsOk)
+}
+
+func (t *transaction) runExternal() (*Result, error) {
+	cells, bits := fees.CellStats(t.msgCell, true)
+	importFee := fees.ComputeFwdFee(t.fwd, cells, bits)
+	if t.acc.balance.Cmp(importFee) < 0 {
+		return &Result{}, nil
+	}
+	t.acc.balance.Sub(t.acc.balance, importFee)
+	t.totalFees.Add(t.totalFees, importFee)
+	t.msgBalanceRemaining = big.NewInt(0)
+
+	t.storage()
+
+	cp, err := t.compute(big.NewInt(0), true)
+	if err != nil {
+		return nil, err
+	}
+	if cp == nil || !cp.accepted {
+		res := &Result{}
+		if cp != nil {
+			res.ExitCode, res.GasUsed = cp.exitCode, cp.gasUsed
+		}
+		return res, nil
+	}
+
+	actionsOk := true
+	if cp.success {
+		if actionsOk, err = t.action(cp); err != nil {
+			return nil, err
+		}
+	}
+	return t.finish(!cp.success || !actionsOk)
+}
+
+func (t *transaction) credit(m *tlb.InternalMessage) {
+	value := m.Amount.Nano()
+	t.msgBalanceRemaining = new(big.Int).Set(value)
+
+	if t.acc.status == tlb.AccountStatusNonExist {
+		t.acc.status = tlb.AccountStatusUninit
+		t.acc.lastPaid = t.now
+	}
+	t.acc.balance.Add(t.acc.balance, value)
+
+	t.creditPhase = &tlb.CreditPhase{
+		Credit: tlb.CurrencyCollection{
+			Coins:           tlb.FromNanoTON(value),
+			ExtraCurrencies: m.ExtraCurrencies,
+		},
+	}
+}
+
+func (t *transaction) storage() {
+	acc := t.acc
+	fee := big.NewInt(0)
+	if acc.status != tlb.AccountStatusNonExist {
+		cells, bits, err := acc.storageStats()
+		if err == nil {
+			fee = fees.ComputeStorageFee(t.e.fees.StoragePrices(), acc.addr.Workchain() == address.MasterchainID, cells, bits, acc.lastPaid, t.now)
+		}
+		if acc.duePayment != nil {
+			fee.Add(fee, acc.duePayment)
+		}
+		acc.lastPaid = t.now
+	}
+
+	phase := &tlb.StoragePhase{
+		StatusChange: tlb.AccStatusChange{Type: tlb.AccStatusChangeUnchanged},
+	}
+
+	collected := fee
+	if acc.balance.Cmp(fee) >= 0 {
+		acc.balance.Sub(acc.balance, fee)
+		acc.duePayment = nil
+	} else {
+		collected = new(big.Int).Set(acc.balance)
+		acc.duePayment = new(big.Int).Sub(fee, acc.balance)
+		acc.balance.SetInt64(0)
+
+		due := tlb.FromNanoTON(acc.duePayment)
+		phase.StorageFeesDue = &due
+
+		if acc.status == tlb.AccountStatusActive && acc.duePayment.Cmp(new(big.Int).SetUint64(t.gas.FreezeDueLimit)) > 0 {
+			if st, err := tlb.ToCell(acc.state); err == nil {
+				acc.stateHash = st.Hash()
+				acc.state = nil
+				acc.status = tlb.AccountStatusFrozen
+				phase.StatusChange.Type = tlb.AccStatusChangeFrozen
+			}
+		} else if acc.status != tlb.AccountStatusActive && acc.duePayment.Cmp(new(big.Int).SetUint64(t.gas.DeleteDueLimit)) > 0 {
+			acc.status = tlb.AccountStatusNonExist
+			phase.StatusChange.Type = tlb.AccStatusChangeDeleted
+		}
+	}
+
+	phase.StorageFeesCollected = tlb.FromNanoTON(collected)
+	t.storageFees = collected
+	t.totalFees.Add(t.totalFees, collected)
+	t.storagePhase = phase
+}
+
+// computeResult - outcome of compute phase, which is needed by next phases
+type computeResult struct {
+	success  bool
+	accepted bool
+	exitCode int32
+	gasUsed  uint64
+
+	state     *tlb.StateInit
+	activated bool
+	data      *cell.Cell
+	actions   *cell.Cell
+}
+
+// compute - runs contract code, returns nil when phase was skipped
+func (t *transaction) compute(msgValue *big.Int, external bool) (*computeResult, error) {
+	acc := t.acc
+
+	skip := func(reason tlb.ComputeSkipReasonType) (*computeResult, error) {
+		t.computePhase.Phase = tlb.ComputePhaseSkipped{
+			Reason: tlb.ComputeSkipReason{Type: reason},
+		}
+		return nil, nil
+	}
+
+	state, activated := acc.state, false
+	if acc.status != tlb.AccountStatusActive {
+		var init *tlb.StateInit
+		switch m := t.msg.Msg.(type) {
+		case *tlb.InternalMessage:
+			init = m.StateInit
+		case *tlb.ExternalMessage:
+			init = m.StateInit
+		}
+		if init == nil {
+			return skip(tlb.ComputeSkipReasonNoState)
+		}
+
+		initCell, err := tlb.ToCell(init)
+		if err != nil {
+			return nil, fmt.Errorf("failed to serialize message state init: %w", err)
+		}
+
+		expected := acc.addr.Data()
+		if acc.status == tlb.AccountStatusFrozen {
+			expected = acc.stateHash
+		}
+		if string(initCell.Hash()) != string(expected) {
+			return skip(tlb.ComputeSkipReasonBadState)
+		}
+		state, activated = init, true
+	}
+
+	if state == nil || state.Code == nil {
+		return skip(tlb.ComputeSkipReasonNoState)
+	}
+
+	gasMax := fees.GasBoughtFor(t.gas, acc.balance, t.gas.GasLimit)
+	var limit, credit uint64
+	if external {
+		credit = t.gas.GasCredit
+		if credit > gasMax {
+			credit = gasMax
+		}
+	} else {
+		limit = fees.GasBoughtFor(t.gas, t.msgBalanceRemaining, t.gas.GasLimit)
+		if limit > gasMax {
+			limit = gasMax
+		}
+	}
+	if limit == 0 && credit == 0 {
+		return skip(tlb.ComputeSkipReasonNoGas)
+	}
+
+	seed := t.seed
+	if len(seed) == 0 {
+		seed = make([]byte, 32)
+	}
+	addrSeed := sha256.Sum256(append(append([]byte{}, seed...), acc.addr.Data()...))
+
+	c7, err := tvm.PrepareC7(tvm.C7Params{
+		Address:     acc.addr,
+		Now:         t.now,
+		BlockLT:     t.lt,
+		TxLT:        t.lt,
+		RandSeed:    addrSeed[:],
+		Balance:     acc.balance,
+		Config:      t.e.configRoot,
+		Code:        state.Code,
+		InMsgValue:  msgValue,
+		StorageFees: t.storageFees,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare c7: %w", err)
+	}
+
+	body := t.msg.Msg.Payload()
+	if body == nil {
+		body = cell.BeginCell().EndCell()
+	}
+
+	selector := int64(0)
+	if external {
+		selector = -1
+	}
+
+	stack := tvm.NewStack()
+	for _, v := range []any{new(big.Int).Set(acc.balance), new(big.Int).Set(msgValue), t.msgCell, body.BeginParse(), big.NewInt(selector)} {
+		if err = stack.Push(v); err != nil {
+			return nil, fmt.Errorf("failed to prepare stack: %w", err)
+		}
+	}
+
+	vm := tvm.NewTVM()
+	vm.AddLibraries(t.libs...)
+	if state.Lib != nil {
+		if kvs, err := state.Lib.LoadAll(); err == nil {
+			for _, kv := range kvs {
+				if lib, err := kv.Value.LoadRef(); err == nil {
+					vm.AddLibraries(lib.MustToCell())
+				}
+			}
+		}
+	}
+
+	res, err := vm.Execute(state.Code, state.Data, c7, tvm.NewGas(int64(limit), int64(gasMax), int64(credit)), stack)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute contract: %w", err)
+	}
+
+	// gas of internal message is bought by its value, so only external messages should be accepted by contract
+	cr := &computeResult{
+		accepted:  !external || res.Accepted,
+		exitCode:  res.ExitCode,
+		gasUsed:   uint64(res.GasUsed),
+		state:     state,
+		activated: activated,
+	}
+	cr.success = cr.accepted && res.Committed
+	if cr.gasUsed > gasMax {
+		cr.gasUsed = gasMax
+	}
+	t.exitCode, t.gasUsed = cr.exitCode, cr.gasUsed
+	if !cr.accepted {
+		return cr, nil
+	}
+	if cr.success {
+		cr.data, cr.actions = res.Data, res.Actions
+	}
+
+	gasFees := fees.ComputeGasFee(t.gas, cr.gasUsed)
+	if gasFees.Cmp(acc.balance) > 0 {
+		gasFees = new(big.Int).Set(acc.balance)
+	}
+	acc.balance.Sub(acc.balance, gasFees)
+	t.totalFees.Add(t.totalFees, gasFees)
+	if t.msgBalanceRemaining.Cmp(gasFees) > 0 {
+		t.msgBalanceRemaining.Sub(t.msgBalanceRemaining, gasFees)
+	} else {
+		t.msgBalanceRemaining.SetInt64(0)
+	}
+
+	phase := tlb.ComputePhaseVM{
+		Success:          cr.success,
+		MsgStateUsed:     activated,
+		AccountActivated: activated,
+		GasFees:          tlb.FromNanoTON(gasFees),
+	}
+	// node records zero limit for external messages, gas is bought from credit in this case
+	phase.Details.GasUsed = new(big.Int).SetUint64(cr.gasUsed)
+	phase.Details.GasLimit = new(big.Int).SetUint64(limit)
+	if credit > 0 {
+		phase.Details.GasCredit = new(big.Int).SetUint64(credit)
+	}
+	phase.Details.ExitCode = cr.exitCode
+	phase.Details.VMSteps = uint32(res.Steps)
+	phase.Details.VMInitStateHash = make([]byte, 32)
+	phase.Details.VMFinalStateHash = make([]byte, 32)
+	t.computePhase.Phase = phase
+
+	return cr, nil
+}
+
+func (t *transaction) bounce(m *tlb.InternalMessage) error {
+	body := cell.BeginCell().MustStoreUInt(0xffffffff, 32)
+	if m.Body != nil {
+		s := m.Body.BeginParse()
+		sz := s.BitsLeft()
+		if sz > 256 {
+			sz = 256
+		}
+		body.MustStoreSlice(s.MustLoadSlice(sz), sz)
+	}
+
+	bounceMsg := &tlb.InternalMessage{
+		IHRDisabled: true,
+		Bounced:     true,
+		SrcAddr:     t.acc.addr,
+		DstAddr:     m.SrcAddr,
+		Amount:      tlb.ZeroCoins,
+		IHRFee:      tlb.ZeroCoins,
+		FwdFee:      tlb.ZeroCoins,
+		CreatedLT:   t.lt + 1 + uint64(len(t.outMsgs)),
+		CreatedAt:   t.now,
+		Body:        body.EndCell(),
+	}
+
+	c, err := tlb.ToCell(bounceMsg)
+	if err != nil {
+		return fmt.Errorf("failed to serialize bounce message: %w", err)
+	}
+	cells, bits := fees.CellStats(c, true)
+	size := tlb.StorageUsedShort{
+		Cells: new(big.Int).SetUint64(cells),
+		Bits:  new(big.Int).SetUint64(bits),
+	}
+
+	fee := fees.ComputeFwdFee(t.fwd, cells, bits)
+	remaining := t.msgBalanceRemaining
+	if remaining.Cmp(t.acc.balance) > 0 {
+		remaining = new(big.Int).Set(t.acc.balance)
+	}
+
+	if remaining.Cmp(fee) < 0 {
+		t.bouncePhase = &tlb.BouncePhase{Phase: tlb.BouncePhaseNoFunds{
+			MsgSize:    size,
+			ReqFwdFees: tlb.FromNanoTON(fee),
+		}}
+		return nil
+	}
+
+	t.acc.balance.Sub(t.acc.balance, remaining)
+	mine := fees.FwdFeeFirstFrac(t.fwd, fee)
+	t.totalFees.Add(t.totalFees, mine)
+
+	bounceMsg.Amount = tlb.FromNanoTON(new(big.Int).Sub(remaining, fee))
+	bounceMsg.FwdFee = tlb.FromNanoTON(new(big.Int).Sub(fee, mine))
+	if c, err = tlb.ToCell(bounceMsg); err != nil {
+		return fmt.Errorf("failed to serialize bounce message: %w", err)
+	}
+	t.outMsgs = append(t.outMsgs, c)
+
+	t.bouncePhase = &tlb.BouncePhase{Phase: tlb.BouncePhaseOk{
+		MsgSize: size,
+		MsgFees: tlb.FromNanoTON(mine),
+		FwdFees: tlb.FromNanoTON(new(big.Int).Sub(fee, mine)),
+	}}
+	return nil
+}
+
+// finish - assembles transaction and new account state
+func (t *transaction) finish(aborted bool) (*Result, error) {
+	acc := t.acc
+	if acc.status == tlb.AccountStatusUninit && acc.balance.Sign() == 0 && t.origStatus == tlb.AccountStatusNonExist {
+		// nothing left to store, account is not created
+		acc.status = tlb.AccountStatusNonExist
+	}
+
+	endLT := t.lt + 1 + uint64(len(t.outMsgs))
+	acc.lastTransLT = endLT
+
+	newAccCell, err := acc.toCell()
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize new account: %w", err)
+	}
+
+	var outList *tlb.MessagesList
+	outMsgs := make([]*tlb.Message, 0, len(t.outMsgs))
+	if len(t.outMsgs) > 0 {
+		dict := cell.NewDict(15)
+		for i, c := range t.outMsgs {
+			if err = dict.SetIntKey(big.NewInt(int64(i)), cell.BeginCell().MustStoreRef(c).EndCell()); err != nil {
+				return nil, fmt.Errorf("failed to store out message %d: %w", i, err)
+			}
+
+			var m tlb.Message
+			if err = tlb.LoadFromCell(&m, c.BeginParse()); err != nil {
+				return nil, fmt.Errorf("failed to parse out message %d: %w", i, err)
+			}
+			outMsgs = append(outMsgs, &m)
+		}
+		outList = &tlb.MessagesList{List: dict}
+	}
+
+	tx := &tlb.Transaction{
+		AccountAddr: acc.addr.Data(),
+		LT:          t.lt,
+		PrevTxHash:  t.prevTxHash,
+		PrevTxLT:    t.prevTxLT,
+		Now:         t.now,
+		OutMsgCount: uint16(len(t.outMsgs)),
+		OrigStatus:  t.origStatus,
+		EndStatus:   acc.status,
+		TotalFees: tlb.CurrencyCollection{
+			Coins: tlb.FromNanoTON(t.totalFees),
+		},
+		StateUpdate: tlb.HashUpdate{
+			OldHash: t.oldAccountCell.Hash(),
+			NewHash: newAccCell.Hash(),
+		},
+		Description: tlb.TransactionDescription{
+			Description: tlb.TransactionDescriptionOrdinary{
+				CreditFirst:  t.creditFirst,
+				StoragePhase: t.storagePhase,
+				CreditPhase:  t.creditPhase,
+				ComputePhase: t.computePhase,
+				ActionPhase:  t.actionPhase,
+				Aborted:      aborted,
+				BouncePhase:  t.bouncePhase,
+				Destroyed:    t.destroyed,
+			},
+		},
+	}
+	tx.IO.In = t.msg
+	tx.IO.Out = outList
+
+	txCell, err := tlb.ToCell(tx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize transaction: %w", err)
+	}
+	tx.Hash = txCell.Hash()
+
+	return &Result{
+		Accepted:        true,
+		ExitCode:        t.exitCode,
+		GasUsed:         t.gasUsed,
+		Transaction:     tx,
+		TransactionCell: txCell,
+		ShardAccount: &tlb.ShardAccount{
+			Account:       newAccCell,
+			LastTransHash: tx.Hash,
+			LastTransLT:   t.lt,
+		},
+		OutMessages: outMsgs,
+	}, nil
+}
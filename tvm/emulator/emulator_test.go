@@ -0,0 +1,397 @@
+package emulator
+
+import (
+	"crypto/ed25519"
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/ton"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+const walletV4R2CodeHex = "B5EE9C72410214010002D4000114FF00F4A413F4BCF2C80B010201200203020148040504F8F28308D71820D31FD31FD31F02F823BBF264ED44D0D31FD31FD3FFF404D15143BAF2A15151BAF2A205F901541064F910F2A3F80024A4C8CB1F5240CB1F5230CBFF5210F400C9ED54F80F01D30721C0009F6C519320D74A96D307D402FB00E830E021C001E30021C002E30001C0039130E30D03A4C8CB1F12CB1FCBFF1011121302E6D001D0D3032171B0925F04E022D749C120925F04E002D31F218210706C7567BD22821064737472BDB0925F05E003FA403020FA4401C8CA07CBFFC9D0ED44D0810140D721F404305C810108F40A6FA131B3925F07E005D33FC8258210706C7567BA923830E30D03821064737472BA925F06E30D06070201200809007801FA00F40430F8276F2230500AA121BEF2E0508210706C7567831EB17080185004CB0526CF1658FA0219F400CB6917CB1F5260CB3F20C98040FB0006008A5004810108F45930ED44D0810140D720C801CF16F400C9ED540172B08E23821064737472831EB17080185005CB055003CF1623FA0213CB6ACB1FCB3FC98040FB00925F03E20201200A0B0059BD242B6F6A2684080A06B90FA0218470D4080847A4937D29910CE6903E9FF9837812801B7810148987159F31840201580C0D0011B8C97ED44D0D70B1F8003DB29DFB513420405035C87D010C00B23281F2FFF274006040423D029BE84C600201200E0F0019ADCE76A26840206B90EB85FFC00019AF1DF6A26840106B90EB858FC0006ED207FA00D4D422F90005C8CA0715CBFFC9D077748018C8CB05CB0222CF165005FA0214CB6B12CCCCC973FB00C84014810108F451F2A7020070810108D718FA00D33FC8542047810108F451F2A782106E6F746570748018C8CB05CB025006CF165004FA0214CB6A12CB1FCB3FC973FB0002006C810108D718FA00D33F305224810108F459F2A782106473747270748018C8CB05CB025005CF165003FA0213CB6ACB1F12CB3FC973FB00000AF400C9ED54696225E5"
+
+const testNow = 1700000000
+
+// testConfig - config with prices close to mainnet ones
+func testConfig() *ton.BlockchainConfig {
+	gas := cell.BeginCell().
+		MustStoreUInt(0xd1, 8).MustStoreUInt(100, 64).MustStoreUInt(40000, 64).
+		MustStoreUInt(0xde, 8).
+		MustStoreUInt(26214400, 64).
+		MustStoreUInt(1000000, 64).
+		MustStoreUInt(1000000, 64).
+		MustStoreUInt(10000, 64).
+		MustStoreUInt(10000000, 64).
+		MustStoreUInt(100000000, 64).
+		MustStoreUInt(1000000000, 64).
+		EndCell()
+
+	msg := cell.BeginCell().
+		MustStoreUInt(0xea, 8).
+		MustStoreUInt(400000, 64).
+		MustStoreUInt(26214400, 64).
+		MustStoreUInt(2621440000, 64).
+		MustStoreUInt(98304, 32).
+		MustStoreUInt(21845, 16).
+		MustStoreUInt(21845, 16).
+		EndCell()
+
+	storage := cell.NewDict(32)
+	_ = storage.SetIntKey(big.NewInt(0), cell.BeginCell().
+		MustStoreUInt(0xcc, 8).
+		MustStoreUInt(0, 32).
+		MustStoreUInt(1, 64).
+		MustStoreUInt(500, 64).
+		MustStoreUInt(1000, 64).
+		MustStoreUInt(500000, 64).
+		EndCell())
+
+	return ton.NewBlockchainConfig(map[int32]*cell.Cell{
+		18: storage.AsCell(),
+		20: gas,
+		21: gas,
+		24: msg,
+		25: msg,
+	})
+}
+
+func testWallet(t *testing.T, key ed25519.PublicKey) (*tlb.StateInit, *address.Address) {
+	boc, _ := hex.DecodeString(walletV4R2CodeHex)
+	code, err := cell.FromBOC(boc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state := &tlb.StateInit{
+		Code: code,
+		Data: cell.BeginCell().
+			MustStoreUInt(0, 32).
+			MustStoreUInt(698983191, 32).
+			MustStoreSlice(key, 256).
+			MustStoreDict(nil).
+			EndCell(),
+	}
+
+	sc, err := tlb.ToCell(state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return state, address.NewAddress(0, 0, sc.Hash())
+}
+
+func uninitAccount(t *testing.T, addr *address.Address, balance uint64) *tlb.ShardAccount {
+	acc := &account{
+		addr:     addr,
+		status:   tlb.AccountStatusUninit,
+		balance:  new(big.Int).SetUint64(balance),
+		lastPaid: testNow - 1000,
+	}
+	c, err := acc.toCell()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &tlb.ShardAccount{Account: c, LastTransHash: make([]byte, 32)}
+}
+
+func accountBalance(t *testing.T, sa *tlb.ShardAccount) (*big.Int, tlb.AccountStatus) {
+	acc, err := loadAccount(sa, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return acc.balance, acc.status
+}
+
+// checkBalanceFlow - balance before should be equal to balance after + fees + everything sent
+func checkBalanceFlow(t *testing.T, before, in *big.Int, res *Result) {
+	after, _ := accountBalance(t, res.ShardAccount)
+
+	spent := new(big.Int).Add(after, res.Transaction.TotalFees.Coins.Nano())
+	for _, m := range res.OutMessages {
+		if m.MsgType == tlb.MsgTypeInternal {
+			im := m.AsInternal()
+			spent.Add(spent, im.Amount.Nano())
+			spent.Add(spent, im.FwdFee.Nano())
+			spent.Add(spent, im.IHRFee.Nano())
+		}
+	}
+
+	if spent.Cmp(new(big.Int).Add(before, in)) != 0 {
+		t.Fatal("balance flow mismatch", before.String(), in.String(), spent.String())
+	}
+}
+
+func TestEmulator_DeployAndTransfer(t *testing.T) {
+	priv := ed25519.NewKeyFromSeed(make([]byte, 32))
+	state, addr := testWallet(t, priv.Public().(ed25519.PublicKey))
+
+	emu, err := NewEmulator(testConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dst := address.NewAddress(0, 0, make([]byte, 32))
+	transfer, err := tlb.ToCell(&tlb.InternalMessage{
+		IHRDisabled: true,
+		Bounce:      true,
+		DstAddr:     dst,
+		Amount:      tlb.MustFromTON("1"),
+		Body:        cell.BeginCell().EndCell(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	external := func(seqno uint64, sign bool) *tlb.Message {
+		payload := cell.BeginCell().
+			MustStoreUInt(698983191, 32).
+			MustStoreUInt(testNow+60, 32).
+			MustStoreUInt(seqno, 32).
+			MustStoreUInt(0, 8).
+			MustStoreUInt(3, 8).
+			MustStoreRef(transfer).
+			EndCell()
+
+		sig := make([]byte, 64)
+		if sign {
+			sig = payload.Sign(priv)
+		}
+
+		msg := &tlb.ExternalMessage{
+			DstAddr: addr,
+			Body: cell.BeginCell().
+				MustStoreSlice(sig, 512).
+				MustStoreBuilder(payload.ToBuilder()).
+				EndCell(),
+		}
+		if seqno == 0 {
+			msg.StateInit = state
+		}
+		return &tlb.Message{MsgType: tlb.MsgTypeExternalIn, Msg: msg}
+	}
+
+	initial := new(big.Int).SetUint64(5_000_000_000)
+	shardAcc := uninitAccount(t, addr, initial.Uint64())
+
+	res, err := emu.EmulateTransaction(shardAcc, external(0, false), Params{Now: testNow, LT: 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Accepted || res.Transaction != nil {
+		t.Fatal("message with bad signature should not be accepted")
+	}
+
+	res, err = emu.EmulateTransaction(shardAcc, external(0, true), Params{Now: testNow, LT: 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Accepted || res.ExitCode != 0 {
+		t.Fatal("unexpected result", res.Accepted, res.ExitCode)
+	}
+
+	desc := res.Transaction.Description.Description.(tlb.TransactionDescriptionOrdinary)
+	if desc.Aborted || desc.ActionPhase == nil || !desc.ActionPhase.Success {
+		t.Fatal("transaction should be successful")
+	}
+	if cp := desc.ComputePhase.Phase.(tlb.ComputePhaseVM); !cp.AccountActivated || cp.GasFees.Nano().Sign() <= 0 {
+		t.Fatal("account should be activated and gas paid")
+	}
+	if cp := desc.ComputePhase.Phase.(tlb.ComputePhaseVM); cp.Details.GasLimit.Sign() != 0 || cp.Details.GasCredit == nil || cp.Details.GasCredit.Sign() <= 0 {
+		t.Fatal("external message should be executed with zero gas limit and credit", cp.Details.GasLimit, cp.Details.GasCredit)
+	}
+	if res.Transaction.OrigStatus != tlb.AccountStatusUninit || res.Transaction.EndStatus != tlb.AccountStatusActive {
+		t.Fatal("unexpected statuses", res.Transaction.OrigStatus, res.Transaction.EndStatus)
+	}
+
+	if len(res.OutMessages) != 1 {
+		t.Fatal("expected single out message", len(res.OutMessages))
+	}
+	out := res.OutMessages[0].AsInternal()
+	if out.Amount.Nano().Cmp(tlb.MustFromTON("1").Nano()) != 0 || out.SrcAddr.String() != addr.String() ||
+		out.CreatedLT != res.Transaction.LT+1 || out.FwdFee.Nano().Sign() <= 0 {
+		t.Fatal("bad out message", out.Dump())
+	}
+	checkBalanceFlow(t, initial, big.NewInt(0), res)
+
+	var parsed tlb.Transaction
+	if err = tlb.LoadFromCell(&parsed, res.TransactionCell.BeginParse()); err != nil {
+		t.Fatal("failed to parse emulated transaction", err)
+	}
+	if res.ShardAccount.LastTransLT != res.Transaction.LT || string(res.ShardAccount.LastTransHash) != string(res.TransactionCell.Hash()) {
+		t.Fatal("bad shard account")
+	}
+
+	// next transfer uses the new state
+	res2, err := emu.EmulateTransaction(res.ShardAccount, external(1, true), Params{Now: testNow + 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res2.Accepted || res2.Transaction.LT <= res.Transaction.LT || string(res2.Transaction.PrevTxHash) != string(res.Transaction.Hash) {
+		t.Fatal("bad second transaction")
+	}
+	balance, _ := accountBalance(t, res.ShardAccount)
+	checkBalanceFlow(t, balance, big.NewInt(0), res2)
+
+	// replay of the same seqno is rejected by contract
+	res3, err := emu.EmulateTransaction(res2.ShardAccount, external(1, true), Params{Now: testNow + 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res3.Accepted || res3.ExitCode != 33 {
+		t.Fatal("replay should not be accepted", res3.ExitCode)
+	}
+}
+
+func TestEmulator_Bounce(t *testing.T) {
+	emu, err := NewEmulator(testConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	src := address.NewAddress(0, 0, make([]byte, 32))
+	dst := address.NewAddress(0, 0, append(make([]byte, 31), 1))
+	value := tlb.MustFromTON("1")
+
+	msg := func(bounce bool) *tlb.Message {
+		return &tlb.Message{MsgType: tlb.MsgTypeInternal, Msg: &tlb.InternalMessage{
+			IHRDisabled: true,
+			Bounce:      bounce,
+			SrcAddr:     src,
+			DstAddr:     dst,
+			Amount:      value,
+			CreatedLT:   500,
+			CreatedAt:   testNow,
+			Body:        cell.BeginCell().MustStoreUInt(0x12345678, 32).EndCell(),
+		}}
+	}
+
+	res, err := emu.EmulateTransaction(nil, msg(true), Params{Now: testNow})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	desc := res.Transaction.Description.Description.(tlb.TransactionDescriptionOrdinary)
+	if skip, ok := desc.ComputePhase.Phase.(tlb.ComputePhaseSkipped); !ok || skip.Reason.Type != tlb.ComputeSkipReasonNoState {
+		t.Fatal("compute phase should be skipped")
+	}
+	if _, ok := desc.BouncePhase.Phase.(tlb.BouncePhaseOk); !ok || !desc.Aborted {
+		t.Fatal("message should be bounced")
+	}
+	if res.Transaction.LT != 501 || res.Transaction.EndStatus != tlb.AccountStatusNonExist {
+		t.Fatal("unexpected transaction", res.Transaction.LT, res.Transaction.EndStatus)
+	}
+
+	if len(res.OutMessages) != 1 {
+		t.Fatal("expected bounced message")
+	}
+	out := res.OutMessages[0].AsInternal()
+	if !out.Bounced || out.Bounce || out.DstAddr.String() != src.String() ||
+		out.Body.BeginParse().MustLoadUInt(32) != 0xffffffff || out.Amount.Nano().Cmp(value.Nano()) >= 0 {
+		t.Fatal("bad bounced message", out.Dump())
+	}
+	checkBalanceFlow(t, big.NewInt(0), value.Nano(), res)
+
+	// not bounceable message stays on uninit account
+	res, err = emu.EmulateTransaction(nil, msg(false), Params{Now: testNow})
+	if err != nil {
+		t.Fatal(err)
+	}
+	balance, status := accountBalance(t, res.ShardAccount)
+	if status != tlb.AccountStatusUninit || balance.Cmp(value.Nano()) != 0 || len(res.OutMessages) != 0 {
+		t.Fatal("value should be credited to uninit account", status, balance.String())
+	}
+}
+
+func TestEmulator_Actions(t *testing.T) {
+	emu, err := NewEmulator(testConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	src := address.NewAddress(0, 0, make([]byte, 32))
+	dst := address.NewAddress(0, 0, append(make([]byte, 31), 1))
+	newCode := cell.BeginCell().MustStoreUInt(0xABCD, 16).EndCell()
+	lib := cell.BeginCell().MustStoreUInt(0x1234, 16).EndCell()
+
+	actions, err := tlb.ToCell(tlb.OutList{Actions: []tlb.OutAction{
+		{Action: tlb.ActionReserveCurrency{Mode: 0, Currency: tlb.CurrencyCollection{Coins: tlb.MustFromTON("0.5")}}},
+		{Action: tlb.ActionSetCode{NewCode: newCode}},
+		{Action: tlb.ActionChangeLibrary{Mode: 1, LibRef: tlb.LibRefRef{Library: lib}}},
+		{Action: tlb.ActionSendMsg{Mode: 128, Msg: &tlb.Message{MsgType: tlb.MsgTypeInternal, Msg: &tlb.InternalMessage{
+			IHRDisabled: true,
+			DstAddr:     src,
+			Amount:      tlb.ZeroCoins,
+		}}}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// PUSHROOT; CTOS; LDREF; DROP; POPCTR c5 - sets actions list stored in data
+	acc := &account{
+		addr:     dst,
+		status:   tlb.AccountStatusActive,
+		balance:  tlb.MustFromTON("2").Nano(),
+		lastPaid: testNow,
+		state: &tlb.StateInit{
+			Code: codeFromHex(t, "ED44D0D430ED55"),
+			Data: cell.BeginCell().MustStoreRef(actions).EndCell(),
+		},
+	}
+	c, err := acc.toCell()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	value := tlb.MustFromTON("1")
+	res, err := emu.EmulateTransaction(&tlb.ShardAccount{Account: c, LastTransHash: make([]byte, 32)}, &tlb.Message{
+		MsgType: tlb.MsgTypeInternal,
+		Msg: &tlb.InternalMessage{
+			IHRDisabled: true,
+			SrcAddr:     src,
+			DstAddr:     dst,
+			Amount:      value,
+			CreatedLT:   500,
+			CreatedAt:   testNow,
+			Body:        cell.BeginCell().EndCell(),
+		},
+	}, Params{Now: testNow})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	desc := res.Transaction.Description.Description.(tlb.TransactionDescriptionOrdinary)
+	ap := desc.ActionPhase
+	if ap == nil || !ap.Success || ap.TotalActions != 4 || ap.SpecActions != 2 || ap.MessagesCreated != 1 || !ap.MatchesOutList(actions) {
+		t.Fatal("unexpected action phase", ap)
+	}
+
+	after, err := loadAccount(res.ShardAccount, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after.balance.Cmp(tlb.MustFromTON("0.5").Nano()) != 0 {
+		t.Fatal("only reserved amount should stay", after.balance.String())
+	}
+	if string(after.state.Code.Hash()) != string(newCode.Hash()) {
+		t.Fatal("code should be updated")
+	}
+	if after.state.Lib == nil {
+		t.Fatal("library should be added")
+	}
+	if _, err = after.state.Lib.LoadValueByIntKey(new(big.Int).SetBytes(lib.Hash())); err != nil {
+		t.Fatal("library should be added", err)
+	}
+	checkBalanceFlow(t, tlb.MustFromTON("2").Nano(), value.Nano(), res)
+}
+
+func codeFromHex(t *testing.T, h string) *cell.Cell {
+	data, err := hex.DecodeString(h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cell.BeginCell().MustStoreSlice(data, uint(len(data)*8)).EndCell()
+}
@@ -0,0 +1,64 @@
+package tvm
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Standard TVM exception codes
+const (
+	ErrCodeStackUnderflow = 2
+	ErrCodeStackOverflow  = 3
+	ErrCodeIntOverflow    = 4
+	ErrCodeRangeCheck     = 5
+	ErrCodeInvalidOpcode  = 6
+	ErrCodeTypeCheck      = 7
+	ErrCodeCellOverflow   = 8
+	ErrCodeCellUnderflow  = 9
+	ErrCodeDictionary     = 10
+	ErrCodeUnknown        = 11
+	ErrCodeFatal          = 12
+	ErrCodeOutOfGas       = 13
+	ErrCodeVirtualization = 14
+)
+
+var ErrNoCode = errors.New("code is not set")
+
+// VMError - exception thrown during execution, it can be caught by contract code,
+// if it is not caught, code becomes an exit code of execution.
+type VMError struct {
+	Code int32
+	Arg  any
+	Msg  string
+
+	// hasArg - when false, zero is used as an argument
+	hasArg bool
+}
+
+type errOutOfGas struct{}
+
+func (e VMError) Error() string {
+	if e.Msg == "" {
+		return fmt.Sprintf("vm exception %d", e.Code)
+	}
+	return fmt.Sprintf("vm exception %d: %s", e.Code, e.Msg)
+}
+
+func (e errOutOfGas) Error() string {
+	return "out of gas"
+}
+
+func vmError(code int32, msg string) error {
+	return VMError{Code: code, Msg: msg}
+}
+
+var (
+	errStackUnderflow = vmError(ErrCodeStackUnderflow, "stack underflow")
+	errIntOverflow    = vmError(ErrCodeIntOverflow, "integer overflow")
+	errRangeCheck     = vmError(ErrCodeRangeCheck, "integer out of range")
+	errInvalidOpcode  = vmError(ErrCodeInvalidOpcode, "invalid opcode")
+	errTypeCheck      = vmError(ErrCodeTypeCheck, "type check error")
+	errCellOverflow   = vmError(ErrCodeCellOverflow, "cell overflow")
+	errCellUnderflow  = vmError(ErrCodeCellUnderflow, "cell underflow")
+	errDict           = vmError(ErrCodeDictionary, "dictionary error")
+)
@@ -0,0 +1,70 @@
+package tvm
+
+const (
+	gasPerInstruction   = 10
+	gasPerBit           = 1
+	gasCellLoad         = 100
+	gasCellReload       = 25
+	gasCellCreate       = 500
+	gasException        = 50
+	gasTupleEntry       = 1
+	gasImplicitJumpRef  = 10
+	gasImplicitRet      = 5
+	gasFreeStackDepth   = 32
+	gasStackEntry       = 1
+	gasChkSignFree      = 10
+	gasChkSignAfterFree = 4000
+)
+
+// Gas - gas limits and counters of execution, in terms of TVM gas units
+type Gas struct {
+	Max       int64
+	Limit     int64
+	Credit    int64
+	Remaining int64
+	Base      int64
+}
+
+// NewGas - creates gas limits, credit is used for external messages,
+// it is set to zero after contract accepts the message.
+func NewGas(limit, max, credit int64) Gas {
+	base := limit + credit
+	return Gas{
+		Max:       max,
+		Limit:     limit,
+		Credit:    credit,
+		Remaining: base,
+		Base:      base,
+	}
+}
+
+// GasWithLimit - creates gas limits without credit
+func GasWithLimit(limit int64) Gas {
+	return NewGas(limit, limit, 0)
+}
+
+// Used - returns amount of consumed gas
+func (g *Gas) Used() int64 {
+	return g.Base - g.Remaining
+}
+
+func (g *Gas) consume(amount int64) error {
+	g.Remaining -= amount
+	if g.Remaining < 0 {
+		return errOutOfGas{}
+	}
+	return nil
+}
+
+func (g *Gas) changeLimit(limit int64) {
+	if limit < 0 {
+		limit = 0
+	}
+	if limit > g.Max {
+		limit = g.Max
+	}
+	g.Credit = 0
+	g.Limit = limit
+	g.Remaining += limit - g.Base
+	g.Base = limit
+}
@@ -0,0 +1,118 @@
+package tvm
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+const c7Magic = 0x076ef1ea
+
+// DefaultGetMethodGas - gas limit used for get methods by liteservers
+const DefaultGetMethodGas = 1_000_000
+
+// C7Params - blockchain context which is available for contract through c7 register
+type C7Params struct {
+	Address *address.Address
+	// Now - unix time, current time is used when zero
+	Now     uint32
+	BlockLT uint64
+	TxLT    uint64
+	// RandSeed - 32 bytes, random value is used when empty
+	RandSeed        []byte
+	Balance         *big.Int
+	ExtraCurrencies *cell.Cell
+	// Config - root cell of blockchain config dictionary, can be nil
+	Config *cell.Cell
+	Code   *cell.Cell
+	// InMsgValue - value of inbound message, for transactions
+	InMsgValue *big.Int
+	// StorageFees - storage fees collected in storage phase, for transactions
+	StorageFees *big.Int
+}
+
+// PrepareC7 - builds c7 register value (SmartContractInfo tuple wrapped into tuple)
+func PrepareC7(p C7Params) ([]any, error) {
+	now := p.Now
+	if now == 0 {
+		now = uint32(time.Now().Unix())
+	}
+
+	seed := p.RandSeed
+	if len(seed) == 0 {
+		seed = make([]byte, 32)
+		if _, err := rand.Read(seed); err != nil {
+			return nil, fmt.Errorf("failed to generate random seed: %w", err)
+		}
+	}
+	if len(seed) != 32 {
+		return nil, fmt.Errorf("random seed should be 32 bytes")
+	}
+
+	var addr any
+	if p.Address != nil {
+		b := cell.BeginCell()
+		if err := b.StoreAddr(p.Address); err != nil {
+			return nil, fmt.Errorf("failed to store address: %w", err)
+		}
+		addr = b.ToSlice()
+	}
+
+	balance := p.Balance
+	if balance == nil {
+		balance = big.NewInt(0)
+	}
+	inValue := p.InMsgValue
+	if inValue == nil {
+		inValue = big.NewInt(0)
+	}
+	storageFees := p.StorageFees
+	if storageFees == nil {
+		storageFees = big.NewInt(0)
+	}
+
+	params := []any{
+		big.NewInt(c7Magic),
+		big.NewInt(0), // actions
+		big.NewInt(0), // msgs sent
+		big.NewInt(int64(now)),
+		new(big.Int).SetUint64(p.BlockLT),
+		new(big.Int).SetUint64(p.TxLT),
+		new(big.Int).SetBytes(seed),
+		[]any{new(big.Int).Set(balance), maybeCell(p.ExtraCurrencies)},
+		addr,
+		maybeCell(p.Config),
+		maybeCell(p.Code),
+		[]any{new(big.Int).Set(inValue), nil},
+		new(big.Int).Set(storageFees),
+		nil, // prev blocks info
+	}
+	return []any{params}, nil
+}
+
+func maybeCell(c *cell.Cell) any {
+	if c == nil {
+		return nil
+	}
+	return c
+}
+
+// RunGetMethod - executes get method of contract, args are pushed to stack in the given order,
+// method id is pushed after them, then code is executed from the beginning.
+func (t *TVM) RunGetMethod(code, data *cell.Cell, c7 []any, gas Gas, methodID uint64, args ...any) (*Result, error) {
+	stack := NewStack()
+	for i, arg := range args {
+		if err := stack.Push(arg); err != nil {
+			return nil, fmt.Errorf("failed to push argument %d: %w", i, err)
+		}
+	}
+	if err := stack.Push(new(big.Int).SetUint64(methodID)); err != nil {
+		return nil, fmt.Errorf("failed to push method id: %w", err)
+	}
+
+	return t.Execute(code, data, c7, gas, stack)
+}
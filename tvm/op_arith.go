@@ -0,0 +1,572 @@
+package tvm
+
+import (
+	"math/big"
+
+	"github.com/xssnick/tonutils-go/tlb"
+)
+
+const (
+	roundFloor   = 0
+	roundNearest = 1
+	roundCeil    = 2
+)
+
+func init() {
+	for _, quiet := range []bool{false, true} {
+		q := quiet
+		pfx, name := "", ""
+		if q {
+			pfx, name = "B7", "Q"
+		}
+
+		registerOp(name+"ADD", pfx+"A0", 0, func(st *State, _ uint32) error {
+			return arith2(st, q, func(x, y *big.Int) *big.Int {
+				return new(big.Int).Add(x, y)
+			})
+		})
+		registerOp(name+"SUB", pfx+"A1", 0, func(st *State, _ uint32) error {
+			return arith2(st, q, func(x, y *big.Int) *big.Int {
+				return new(big.Int).Sub(x, y)
+			})
+		})
+		registerOp(name+"SUBR", pfx+"A2", 0, func(st *State, _ uint32) error {
+			return arith2(st, q, func(x, y *big.Int) *big.Int {
+				return new(big.Int).Sub(y, x)
+			})
+		})
+		registerOp(name+"NEGATE", pfx+"A3", 0, func(st *State, _ uint32) error {
+			return arith1(st, q, func(x *big.Int) *big.Int {
+				return new(big.Int).Neg(x)
+			})
+		})
+		registerOp(name+"INC", pfx+"A4", 0, func(st *State, _ uint32) error {
+			return arith1(st, q, func(x *big.Int) *big.Int {
+				return new(big.Int).Add(x, big.NewInt(1))
+			})
+		})
+		registerOp(name+"DEC", pfx+"A5", 0, func(st *State, _ uint32) error {
+			return arith1(st, q, func(x *big.Int) *big.Int {
+				return new(big.Int).Sub(x, big.NewInt(1))
+			})
+		})
+		registerOp(name+"ADDCONST", pfx+"A6", 8, func(st *State, args uint32) error {
+			return arith1(st, q, func(x *big.Int) *big.Int {
+				return new(big.Int).Add(x, big.NewInt(int64(int8(args))))
+			})
+		})
+		registerOp(name+"MULCONST", pfx+"A7", 8, func(st *State, args uint32) error {
+			return arith1(st, q, func(x *big.Int) *big.Int {
+				return new(big.Int).Mul(x, big.NewInt(int64(int8(args))))
+			})
+		})
+		registerOp(name+"MUL", pfx+"A8", 0, func(st *State, _ uint32) error {
+			return arith2(st, q, func(x, y *big.Int) *big.Int {
+				return new(big.Int).Mul(x, y)
+			})
+		})
+		registerOp(name+"AND", pfx+"B0", 0, func(st *State, _ uint32) error {
+			return arith2(st, q, func(x, y *big.Int) *big.Int {
+				return new(big.Int).And(x, y)
+			})
+		})
+		registerOp(name+"OR", pfx+"B1", 0, func(st *State, _ uint32) error {
+			return arith2(st, q, func(x, y *big.Int) *big.Int {
+				return new(big.Int).Or(x, y)
+			})
+		})
+		registerOp(name+"XOR", pfx+"B2", 0, func(st *State, _ uint32) error {
+			return arith2(st, q, func(x, y *big.Int) *big.Int {
+				return new(big.Int).Xor(x, y)
+			})
+		})
+		registerOp(name+"NOT", pfx+"B3", 0, func(st *State, _ uint32) error {
+			return arith1(st, q, func(x *big.Int) *big.Int {
+				return new(big.Int).Not(x)
+			})
+		})
+		registerOp(name+"SGN", pfx+"B8", 0, func(st *State, _ uint32) error {
+			return arith1(st, q, func(x *big.Int) *big.Int {
+				return big.NewInt(int64(x.Sign()))
+			})
+		})
+		registerOp(name+"LESS", pfx+"B9", 0, func(st *State, _ uint32) error {
+			return compare(st, q, func(c int) bool { return c < 0 })
+		})
+		registerOp(name+"EQUAL", pfx+"BA", 0, func(st *State, _ uint32) error {
+			return compare(st, q, func(c int) bool { return c == 0 })
+		})
+		registerOp(name+"LEQ", pfx+"BB", 0, func(st *State, _ uint32) error {
+			return compare(st, q, func(c int) bool { return c <= 0 })
+		})
+		registerOp(name+"GREATER", pfx+"BC", 0, func(st *State, _ uint32) error {
+			return compare(st, q, func(c int) bool { return c > 0 })
+		})
+		registerOp(name+"NEQ", pfx+"BD", 0, func(st *State, _ uint32) error {
+			return compare(st, q, func(c int) bool { return c != 0 })
+		})
+		registerOp(name+"GEQ", pfx+"BE", 0, func(st *State, _ uint32) error {
+			return compare(st, q, func(c int) bool { return c >= 0 })
+		})
+		registerOp(name+"CMP", pfx+"BF", 0, func(st *State, _ uint32) error {
+			return arith2(st, q, func(x, y *big.Int) *big.Int {
+				return big.NewInt(int64(x.Cmp(y)))
+			})
+		})
+		registerOp(name+"EQINT", pfx+"C0", 8, func(st *State, args uint32) error {
+			return compareConst(st, q, int64(int8(args)), func(c int) bool { return c == 0 })
+		})
+		registerOp(name+"LESSINT", pfx+"C1", 8, func(st *State, args uint32) error {
+			return compareConst(st, q, int64(int8(args)), func(c int) bool { return c < 0 })
+		})
+		registerOp(name+"GTINT", pfx+"C2", 8, func(st *State, args uint32) error {
+			return compareConst(st, q, int64(int8(args)), func(c int) bool { return c > 0 })
+		})
+		registerOp(name+"NEQINT", pfx+"C3", 8, func(st *State, args uint32) error {
+			return compareConst(st, q, int64(int8(args)), func(c int) bool { return c != 0 })
+		})
+	}
+
+	registerOpCheck("DIVMOD", "A90", 4, checkDivArgs, func(st *State, args uint32) error {
+		var w *big.Int
+		y, err := st.stack.popInt()
+		if err != nil {
+			return err
+		}
+		if args>>2 == 0 {
+			if w, err = st.stack.popInt(); err != nil {
+				return err
+			}
+		}
+		x, err := st.stack.popInt()
+		if err != nil {
+			return err
+		}
+		if w != nil {
+			x = new(big.Int).Add(x, w)
+		}
+		return divResult(st, x, y, args)
+	})
+	registerOpCheck("SHRMOD", "A92", 4, checkDivArgs, func(st *State, args uint32) error {
+		z, err := st.stack.popSmall(256)
+		if err != nil {
+			return err
+		}
+		return shrMod(st, z, args)
+	})
+	registerOpCheck("SHRMOD_CONST", "A93", 12, checkDivArgsConst, func(st *State, args uint32) error {
+		return shrMod(st, int(args&0xFF)+1, args>>8)
+	})
+	registerOpCheck("MULDIVMOD", "A98", 4, checkDivArgs, func(st *State, args uint32) error {
+		var w *big.Int
+		z, err := st.stack.popInt()
+		if err != nil {
+			return err
+		}
+		if args>>2 == 0 {
+			if w, err = st.stack.popInt(); err != nil {
+				return err
+			}
+		}
+		y, err := st.stack.popInt()
+		if err != nil {
+			return err
+		}
+		x, err := st.stack.popInt()
+		if err != nil {
+			return err
+		}
+		x = new(big.Int).Mul(x, y)
+		if w != nil {
+			x.Add(x, w)
+		}
+		return divResult(st, x, z, args)
+	})
+	registerOpCheck("MULSHRMOD", "A9A", 4, checkDivArgs, func(st *State, args uint32) error {
+		z, err := st.stack.popSmall(256)
+		if err != nil {
+			return err
+		}
+		return mulShrMod(st, z, args)
+	})
+	registerOpCheck("MULSHRMOD_CONST", "A9B", 12, checkDivArgsConst, func(st *State, args uint32) error {
+		return mulShrMod(st, int(args&0xFF)+1, args>>8)
+	})
+	registerOpCheck("SHLDIVMOD", "A9C", 4, checkDivArgs, func(st *State, args uint32) error {
+		z, err := st.stack.popSmall(256)
+		if err != nil {
+			return err
+		}
+		return shlDivMod(st, z, args)
+	})
+	registerOpCheck("SHLDIVMOD_CONST", "A9D", 12, checkDivArgsConst, func(st *State, args uint32) error {
+		return shlDivMod(st, int(args&0xFF)+1, args>>8)
+	})
+
+	registerOp("LSHIFT_CONST", "AA", 8, func(st *State, args uint32) error {
+		return arith1(st, false, func(x *big.Int) *big.Int {
+			return new(big.Int).Lsh(x, uint(args)+1)
+		})
+	})
+	registerOp("RSHIFT_CONST", "AB", 8, func(st *State, args uint32) error {
+		return arith1(st, false, func(x *big.Int) *big.Int {
+			return new(big.Int).Rsh(x, uint(args)+1)
+		})
+	})
+	registerOp("LSHIFT", "AC", 0, func(st *State, _ uint32) error {
+		y, err := st.stack.popSmall(1023)
+		if err != nil {
+			return err
+		}
+		return arith1(st, false, func(x *big.Int) *big.Int {
+			if x.Sign() == 0 {
+				return new(big.Int)
+			}
+			if y > 257 {
+				// overflow anyway, avoid huge numbers
+				return new(big.Int).Lsh(x, 258)
+			}
+			return new(big.Int).Lsh(x, uint(y))
+		})
+	})
+	registerOp("RSHIFT", "AD", 0, func(st *State, _ uint32) error {
+		y, err := st.stack.popSmall(1023)
+		if err != nil {
+			return err
+		}
+		return arith1(st, false, func(x *big.Int) *big.Int {
+			return new(big.Int).Rsh(x, uint(y))
+		})
+	})
+	registerOp("POW2", "AE", 0, func(st *State, _ uint32) error {
+		y, err := st.stack.popSmall(1023)
+		if err != nil {
+			return err
+		}
+		if y > 256 {
+			return errIntOverflow
+		}
+		return st.stack.pushInt(new(big.Int).Lsh(big.NewInt(1), uint(y)))
+	})
+	registerOp("FITS", "B4", 8, func(st *State, args uint32) error {
+		return fits(st, uint(args)+1, true)
+	})
+	registerOp("UFITS", "B5", 8, func(st *State, args uint32) error {
+		return fits(st, uint(args)+1, false)
+	})
+	registerOp("FITSX", "B600", 0, func(st *State, _ uint32) error {
+		c, err := st.stack.popSmall(1023)
+		if err != nil {
+			return err
+		}
+		return fits(st, uint(c), true)
+	})
+	registerOp("UFITSX", "B601", 0, func(st *State, _ uint32) error {
+		c, err := st.stack.popSmall(1023)
+		if err != nil {
+			return err
+		}
+		return fits(st, uint(c), false)
+	})
+	registerOp("BITSIZE", "B602", 0, func(st *State, _ uint32) error {
+		x, err := st.stack.popInt()
+		if err != nil {
+			return err
+		}
+		st.stack.pushSmall(int64(bitSize(x, true)))
+		return nil
+	})
+	registerOp("UBITSIZE", "B603", 0, func(st *State, _ uint32) error {
+		x, err := st.stack.popInt()
+		if err != nil {
+			return err
+		}
+		if x.Sign() < 0 {
+			return errRangeCheck
+		}
+		st.stack.pushSmall(int64(bitSize(x, false)))
+		return nil
+	})
+	registerOp("MIN", "B608", 0, func(st *State, _ uint32) error {
+		return arith2(st, false, func(x, y *big.Int) *big.Int {
+			if x.Cmp(y) <= 0 {
+				return x
+			}
+			return y
+		})
+	})
+	registerOp("MAX", "B609", 0, func(st *State, _ uint32) error {
+		return arith2(st, false, func(x, y *big.Int) *big.Int {
+			if x.Cmp(y) >= 0 {
+				return x
+			}
+			return y
+		})
+	})
+	registerOp("MINMAX", "B60A", 0, func(st *State, _ uint32) error {
+		y, err := st.stack.popInt()
+		if err != nil {
+			return err
+		}
+		x, err := st.stack.popInt()
+		if err != nil {
+			return err
+		}
+		if x.Cmp(y) > 0 {
+			x, y = y, x
+		}
+		st.stack.push(x)
+		st.stack.push(y)
+		return nil
+	})
+	registerOp("ABS", "B60B", 0, func(st *State, _ uint32) error {
+		return arith1(st, false, func(x *big.Int) *big.Int {
+			return new(big.Int).Abs(x)
+		})
+	})
+	registerOp("ISNAN", "C4", 0, func(st *State, _ uint32) error {
+		v, err := st.stack.pop()
+		if err != nil {
+			return err
+		}
+		switch v.(type) {
+		case tlb.StackNaN:
+			st.stack.pushBool(true)
+		case *big.Int:
+			st.stack.pushBool(false)
+		default:
+			return errTypeCheck
+		}
+		return nil
+	})
+	registerOp("CHKNAN", "C5", 0, func(st *State, _ uint32) error {
+		v, err := st.stack.at(0)
+		if err != nil {
+			return err
+		}
+		switch v.(type) {
+		case tlb.StackNaN:
+			return errIntOverflow
+		case *big.Int:
+			return nil
+		}
+		return errTypeCheck
+	})
+}
+
+// popIntQuiet - pops integer, in quiet mode NaN is returned as nil
+func popIntQuiet(st *State, quiet bool) (*big.Int, error) {
+	if !quiet {
+		return st.stack.popInt()
+	}
+
+	v, err := st.stack.pop()
+	if err != nil {
+		return nil, err
+	}
+	switch x := v.(type) {
+	case *big.Int:
+		return x, nil
+	case tlb.StackNaN:
+		return nil, nil
+	}
+	return nil, errTypeCheck
+}
+
+func pushIntQuiet(st *State, v *big.Int, quiet bool) error {
+	if v == nil || !fitsBits(v, 257, true) {
+		if quiet {
+			st.stack.push(tlb.StackNaN{})
+			return nil
+		}
+		return errIntOverflow
+	}
+	st.stack.push(v)
+	return nil
+}
+
+func arith1(st *State, quiet bool, f func(x *big.Int) *big.Int) error {
+	x, err := popIntQuiet(st, quiet)
+	if err != nil {
+		return err
+	}
+	if x == nil {
+		return pushIntQuiet(st, nil, quiet)
+	}
+	return pushIntQuiet(st, f(x), quiet)
+}
+
+func arith2(st *State, quiet bool, f func(x, y *big.Int) *big.Int) error {
+	y, err := popIntQuiet(st, quiet)
+	if err != nil {
+		return err
+	}
+	x, err := popIntQuiet(st, quiet)
+	if err != nil {
+		return err
+	}
+	if x == nil || y == nil {
+		return pushIntQuiet(st, nil, quiet)
+	}
+	return pushIntQuiet(st, f(x, y), quiet)
+}
+
+func compare(st *State, quiet bool, f func(c int) bool) error {
+	return arith2(st, quiet, func(x, y *big.Int) *big.Int {
+		if f(x.Cmp(y)) {
+			return big.NewInt(-1)
+		}
+		return big.NewInt(0)
+	})
+}
+
+func compareConst(st *State, quiet bool, y int64, f func(c int) bool) error {
+	return arith1(st, quiet, func(x *big.Int) *big.Int {
+		if f(x.Cmp(big.NewInt(y))) {
+			return big.NewInt(-1)
+		}
+		return big.NewInt(0)
+	})
+}
+
+func fits(st *State, bits uint, signed bool) error {
+	x, err := st.stack.popInt()
+	if err != nil {
+		return err
+	}
+	if !fitsBits(x, bits, signed) {
+		return errIntOverflow
+	}
+	st.stack.push(x)
+	return nil
+}
+
+// bitSize - minimal number of bits to store integer
+func bitSize(x *big.Int, signed bool) int {
+	if !signed {
+		return x.BitLen()
+	}
+	if x.Sign() >= 0 {
+		return x.BitLen() + 1
+	}
+	return new(big.Int).Not(x).BitLen() + 1
+}
+
+func checkDivArgs(args uint32) bool {
+	return args&3 != 3
+}
+
+func checkDivArgsConst(args uint32) bool {
+	return args>>8&3 != 3 && args>>10 != 0
+}
+
+// divMod - divides x by y using the given rounding mode, y must not be zero
+func divMod(x, y *big.Int, round int) (*big.Int, *big.Int) {
+	var q, r *big.Int
+	switch round {
+	case roundNearest:
+		// floor((2x + y) / 2y)
+		num := new(big.Int).Lsh(x, 1)
+		num.Add(num, y)
+		den := new(big.Int).Lsh(y, 1)
+		q, _ = divMod(num, den, roundFloor)
+		r = new(big.Int).Sub(x, new(big.Int).Mul(q, y))
+		return q, r
+	default:
+		q, r = new(big.Int).QuoRem(x, y, new(big.Int))
+		if r.Sign() != 0 {
+			sameSign := r.Sign() == y.Sign()
+			if round == roundFloor && !sameSign {
+				q.Sub(q, big.NewInt(1))
+				r.Add(r, y)
+			} else if round == roundCeil && sameSign {
+				q.Add(q, big.NewInt(1))
+				r.Sub(r, y)
+			}
+		}
+		return q, r
+	}
+}
+
+// divResult - pushes quotient, remainder or both depending on args, args format is 'dd rr'
+func divResult(st *State, x, y *big.Int, args uint32) error {
+	if y.Sign() == 0 {
+		return errIntOverflow
+	}
+
+	q, r := divMod(x, y, int(args&3))
+	switch args >> 2 {
+	case 1:
+		return st.stack.pushInt(q)
+	case 2:
+		return st.stack.pushInt(r)
+	default:
+		if err := st.stack.pushInt(q); err != nil {
+			return err
+		}
+		return st.stack.pushInt(r)
+	}
+}
+
+func shrMod(st *State, z int, args uint32) error {
+	var w *big.Int
+	var err error
+	if args>>2 == 0 {
+		if w, err = st.stack.popInt(); err != nil {
+			return err
+		}
+	}
+	x, err := st.stack.popInt()
+	if err != nil {
+		return err
+	}
+	if w != nil {
+		x = new(big.Int).Add(x, w)
+	}
+	return divResult(st, x, new(big.Int).Lsh(big.NewInt(1), uint(z)), args)
+}
+
+func mulShrMod(st *State, z int, args uint32) error {
+	var w *big.Int
+	var err error
+	if args>>2 == 0 {
+		if w, err = st.stack.popInt(); err != nil {
+			return err
+		}
+	}
+	y, err := st.stack.popInt()
+	if err != nil {
+		return err
+	}
+	x, err := st.stack.popInt()
+	if err != nil {
+		return err
+	}
+	x = new(big.Int).Mul(x, y)
+	if w != nil {
+		x.Add(x, w)
+	}
+	return divResult(st, x, new(big.Int).Lsh(big.NewInt(1), uint(z)), args)
+}
+
+func shlDivMod(st *State, z int, args uint32) error {
+	var w *big.Int
+	y, err := st.stack.popInt()
+	if err != nil {
+		return err
+	}
+	if args>>2 == 0 {
+		if w, err = st.stack.popInt(); err != nil {
+			return err
+		}
+	}
+	x, err := st.stack.popInt()
+	if err != nil {
+		return err
+	}
+	x = new(big.Int).Lsh(x, uint(z))
+	if w != nil {
+		x.Add(x, w)
+	}
+	return divResult(st, x, y, args)
+}
@@ -0,0 +1,679 @@
+package tvm
+
+import (
+	"math/big"
+
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+const (
+	storeStatusOK       = 0
+	storeStatusOverflow = -1
+	storeStatusRange    = 1
+)
+
+func init() {
+	registerOp("NEWC", "C8", 0, func(st *State, _ uint32) error {
+		st.stack.push(cell.BeginCell())
+		return nil
+	})
+	registerOp("ENDC", "C9", 0, func(st *State, _ uint32) error {
+		b, err := st.stack.popBuilder()
+		if err != nil {
+			return err
+		}
+		if err = st.registerCellCreate(); err != nil {
+			return err
+		}
+		st.stack.push(b.EndCell())
+		return nil
+	})
+	registerOp("STI", "CA", 8, func(st *State, args uint32) error {
+		return storeIntOp(st, uint(args)+1, true, false, false)
+	})
+	registerOp("STU", "CB", 8, func(st *State, args uint32) error {
+		return storeIntOp(st, uint(args)+1, false, false, false)
+	})
+	registerOp("STREF", "CC", 0, func(st *State, _ uint32) error {
+		return storeRefOp(st, false, false)
+	})
+	registerOp("ENDCST", "CD", 0, func(st *State, _ uint32) error {
+		return storeBuilderRefOp(st, true, false)
+	})
+	registerOp("STSLICE", "CE", 0, func(st *State, _ uint32) error {
+		return storeSliceOp(st, false, false)
+	})
+	registerOp("STIX", "CF0", 4, func(st *State, args uint32) error {
+		signed := args&1 == 0
+		rev, quiet := args&2 != 0, args&4 != 0
+		if args&8 != 0 {
+			// it is a const version, bits are stored in the next 8 bits of the code
+			n, err := st.code.LoadUInt(8)
+			if err != nil {
+				return errInvalidOpcode
+			}
+			if err = st.consumeGas(8); err != nil {
+				return err
+			}
+			return storeIntOp(st, uint(n)+1, signed, rev, quiet)
+		}
+
+		maxBits := int64(256)
+		if signed {
+			maxBits = 257
+		}
+		bits, err := st.stack.popSmall(maxBits)
+		if err != nil {
+			return err
+		}
+		return storeIntOp(st, uint(bits), signed, rev, quiet)
+	})
+	registerOp("STREF_ALT", "CF10", 0, func(st *State, _ uint32) error {
+		return storeRefOp(st, false, false)
+	})
+	registerOp("STBREF", "CF11", 0, func(st *State, _ uint32) error {
+		return storeBuilderRefOp(st, false, false)
+	})
+	registerOp("STSLICE_ALT", "CF12", 0, func(st *State, _ uint32) error {
+		return storeSliceOp(st, false, false)
+	})
+	registerOp("STB", "CF13", 0, func(st *State, _ uint32) error {
+		return storeBuilderOp(st, false, false)
+	})
+	registerOp("STREFR", "CF14", 0, func(st *State, _ uint32) error {
+		return storeRefOp(st, true, false)
+	})
+	registerOp("STBREFR", "CF15", 0, func(st *State, _ uint32) error {
+		return storeBuilderRefOp(st, true, false)
+	})
+	registerOp("STSLICER", "CF16", 0, func(st *State, _ uint32) error {
+		return storeSliceOp(st, true, false)
+	})
+	registerOp("STBR", "CF17", 0, func(st *State, _ uint32) error {
+		return storeBuilderOp(st, true, false)
+	})
+	registerOp("STREFQ", "CF18", 0, func(st *State, _ uint32) error {
+		return storeRefOp(st, false, true)
+	})
+	registerOp("STBREFQ", "CF19", 0, func(st *State, _ uint32) error {
+		return storeBuilderRefOp(st, false, true)
+	})
+	registerOp("STSLICEQ", "CF1A", 0, func(st *State, _ uint32) error {
+		return storeSliceOp(st, false, true)
+	})
+	registerOp("STBQ", "CF1B", 0, func(st *State, _ uint32) error {
+		return storeBuilderOp(st, false, true)
+	})
+	registerOp("STREFRQ", "CF1C", 0, func(st *State, _ uint32) error {
+		return storeRefOp(st, true, true)
+	})
+	registerOp("STBREFRQ", "CF1D", 0, func(st *State, _ uint32) error {
+		return storeBuilderRefOp(st, true, true)
+	})
+	registerOp("STSLICERQ", "CF1E", 0, func(st *State, _ uint32) error {
+		return storeSliceOp(st, true, true)
+	})
+	registerOp("STBRQ", "CF1F", 0, func(st *State, _ uint32) error {
+		return storeBuilderOp(st, true, true)
+	})
+	registerOp("STREFCONST", "CF20", 0, func(st *State, _ uint32) error {
+		return storeRefConst(st, 1)
+	})
+	registerOp("STREF2CONST", "CF21", 0, func(st *State, _ uint32) error {
+		return storeRefConst(st, 2)
+	})
+	registerOp("ENDXC", "CF23", 0, func(st *State, _ uint32) error {
+		special, err := st.stack.popBool()
+		if err != nil {
+			return err
+		}
+		b, err := st.stack.popBuilder()
+		if err != nil {
+			return err
+		}
+		if err = st.registerCellCreate(); err != nil {
+			return err
+		}
+
+		c := b.EndCell()
+		if special {
+			if c.BitsSize() < 8 {
+				return errCellOverflow
+			}
+			data := c.BeginParse().MustPreloadSlice(16 - 8*boolToUint(c.BitsSize() < 16))
+			var mask cell.LevelMask
+			if cell.Type(data[0]) == cell.PrunedCellType && len(data) > 1 {
+				mask = cell.LevelMask{Mask: data[1]}
+			}
+			c.UnsafeModify(mask, true)
+			if c.GetType() == cell.UnknownCellType {
+				return errCellOverflow
+			}
+		}
+		st.stack.push(c)
+		return nil
+	})
+	registerOpCheck("STLE", "CF2", 4, func(args uint32) bool {
+		return args>>2 == 2
+	}, func(st *State, args uint32) error {
+		bits := uint(32)
+		if args&2 != 0 {
+			bits = 64
+		}
+		b, err := st.stack.popBuilder()
+		if err != nil {
+			return err
+		}
+		x, err := st.stack.popInt()
+		if err != nil {
+			return err
+		}
+		if !fitsBits(x, bits, args&1 == 0) {
+			return errRangeCheck
+		}
+		if b.BitsLeft() < bits {
+			return errCellOverflow
+		}
+
+		v := x
+		if x.Sign() < 0 {
+			v = new(big.Int).Add(x, new(big.Int).Lsh(big.NewInt(1), bits))
+		}
+		be := v.FillBytes(make([]byte, bits/8))
+		for i, j := 0, len(be)-1; i < j; i, j = i+1, j-1 {
+			be[i], be[j] = be[j], be[i]
+		}
+		if err = b.StoreSlice(be, bits); err != nil {
+			return errCellOverflow
+		}
+		st.stack.push(b)
+		return nil
+	})
+	registerOp("BDEPTH", "CF30", 0, func(st *State, _ uint32) error {
+		b, err := st.stack.popBuilder()
+		if err != nil {
+			return err
+		}
+		st.stack.pushSmall(int64(builderDepth(b)))
+		return nil
+	})
+	registerOp("BBITS", "CF31", 0, func(st *State, _ uint32) error {
+		b, err := st.stack.popBuilder()
+		if err != nil {
+			return err
+		}
+		st.stack.pushSmall(int64(b.BitsUsed()))
+		return nil
+	})
+	registerOp("BREFS", "CF32", 0, func(st *State, _ uint32) error {
+		b, err := st.stack.popBuilder()
+		if err != nil {
+			return err
+		}
+		st.stack.pushSmall(int64(b.RefsUsed()))
+		return nil
+	})
+	registerOp("BBITREFS", "CF33", 0, func(st *State, _ uint32) error {
+		b, err := st.stack.popBuilder()
+		if err != nil {
+			return err
+		}
+		st.stack.pushSmall(int64(b.BitsUsed()))
+		st.stack.pushSmall(int64(b.RefsUsed()))
+		return nil
+	})
+	registerOp("BREMBITS", "CF35", 0, func(st *State, _ uint32) error {
+		b, err := st.stack.popBuilder()
+		if err != nil {
+			return err
+		}
+		st.stack.pushSmall(int64(b.BitsLeft()))
+		return nil
+	})
+	registerOp("BREMREFS", "CF36", 0, func(st *State, _ uint32) error {
+		b, err := st.stack.popBuilder()
+		if err != nil {
+			return err
+		}
+		st.stack.pushSmall(int64(b.RefsLeft()))
+		return nil
+	})
+	registerOp("BREMBITREFS", "CF37", 0, func(st *State, _ uint32) error {
+		b, err := st.stack.popBuilder()
+		if err != nil {
+			return err
+		}
+		st.stack.pushSmall(int64(b.BitsLeft()))
+		st.stack.pushSmall(int64(b.RefsLeft()))
+		return nil
+	})
+	registerOp("BCHKBITS_CONST", "CF38", 8, func(st *State, args uint32) error {
+		return builderCheck(st, int(args)+1, 0, false)
+	})
+	registerOp("BCHKBITS", "CF39", 0, func(st *State, _ uint32) error {
+		bits, err := st.stack.popSmall(1023)
+		if err != nil {
+			return err
+		}
+		return builderCheck(st, bits, 0, false)
+	})
+	registerOp("BCHKREFS", "CF3A", 0, func(st *State, _ uint32) error {
+		refs, err := st.stack.popSmall(7)
+		if err != nil {
+			return err
+		}
+		return builderCheck(st, 0, refs, false)
+	})
+	registerOp("BCHKBITREFS", "CF3B", 0, func(st *State, _ uint32) error {
+		refs, err := st.stack.popSmall(7)
+		if err != nil {
+			return err
+		}
+		bits, err := st.stack.popSmall(1023)
+		if err != nil {
+			return err
+		}
+		return builderCheck(st, bits, refs, false)
+	})
+	registerOp("BCHKBITSQ_CONST", "CF3C", 8, func(st *State, args uint32) error {
+		return builderCheck(st, int(args)+1, 0, true)
+	})
+	registerOp("BCHKBITSQ", "CF3D", 0, func(st *State, _ uint32) error {
+		bits, err := st.stack.popSmall(1023)
+		if err != nil {
+			return err
+		}
+		return builderCheck(st, bits, 0, true)
+	})
+	registerOp("BCHKREFSQ", "CF3E", 0, func(st *State, _ uint32) error {
+		refs, err := st.stack.popSmall(7)
+		if err != nil {
+			return err
+		}
+		return builderCheck(st, 0, refs, true)
+	})
+	registerOp("BCHKBITREFSQ", "CF3F", 0, func(st *State, _ uint32) error {
+		refs, err := st.stack.popSmall(7)
+		if err != nil {
+			return err
+		}
+		bits, err := st.stack.popSmall(1023)
+		if err != nil {
+			return err
+		}
+		return builderCheck(st, bits, refs, true)
+	})
+	registerOp("STZEROES", "CF40", 0, func(st *State, _ uint32) error {
+		return storeSame(st, 0)
+	})
+	registerOp("STONES", "CF41", 0, func(st *State, _ uint32) error {
+		return storeSame(st, 1)
+	})
+	registerOp("STSAME", "CF42", 0, func(st *State, _ uint32) error {
+		bit, err := st.stack.popSmall(1)
+		if err != nil {
+			return err
+		}
+		return storeSame(st, bit)
+	})
+	registerOp("BTOS", "CF50", 0, func(st *State, _ uint32) error {
+		b, err := st.stack.popBuilder()
+		if err != nil {
+			return err
+		}
+		if err = st.registerCellCreate(); err != nil {
+			return err
+		}
+		s, err := st.loadCell(b.EndCell())
+		if err != nil {
+			return err
+		}
+		st.stack.push(s)
+		return nil
+	})
+	registerOp("STSLICECONST", "CFC0_", 5, func(st *State, args uint32) error {
+		s, err := loadCodeSlice(st, 8*uint(args&7)+2, int(args>>3), true)
+		if err != nil {
+			return err
+		}
+		b, err := st.stack.popBuilder()
+		if err != nil {
+			return err
+		}
+		if err = storeSliceToBuilder(b, s); err != nil {
+			return err
+		}
+		st.stack.push(b)
+		return nil
+	})
+}
+
+// copyBuilder - creates a builder copy which doesn't share references list with the original one
+func copyBuilder(b *cell.Builder) *cell.Builder {
+	nb := cell.BeginCell()
+	_ = nb.StoreBuilder(b)
+	return nb
+}
+
+func boolToUint(v bool) uint {
+	if v {
+		return 1
+	}
+	return 0
+}
+
+// storeInt - stores integer to builder using two's complement for negative numbers
+func storeInt(b *cell.Builder, x *big.Int, bits uint, signed bool) error {
+	if !fitsBits(x, bits, signed) {
+		return errRangeCheck
+	}
+	if b.BitsLeft() < bits {
+		return errCellOverflow
+	}
+	if bits == 0 {
+		return nil
+	}
+
+	v := x
+	if x.Sign() < 0 {
+		v = new(big.Int).Add(x, new(big.Int).Lsh(big.NewInt(1), bits))
+	}
+
+	sz := (bits + 7) / 8
+	v = new(big.Int).Lsh(v, sz*8-bits)
+	if err := b.StoreSlice(v.FillBytes(make([]byte, sz)), bits); err != nil {
+		return errCellOverflow
+	}
+	return nil
+}
+
+// loadInt - reads integer of any size up to 257 bits
+func loadInt(s *cell.Slice, bits uint, signed bool, preload bool) (*big.Int, error) {
+	if s.BitsLeft() < bits {
+		return nil, errCellUnderflow
+	}
+	if bits == 0 {
+		return new(big.Int), nil
+	}
+
+	var data []byte
+	var err error
+	if preload {
+		data, err = s.PreloadSlice(bits)
+	} else {
+		data, err = s.LoadSlice(bits)
+	}
+	if err != nil {
+		return nil, errCellUnderflow
+	}
+
+	v := new(big.Int).SetBytes(data)
+	v.Rsh(v, uint(len(data))*8-bits)
+	if signed && v.Bit(int(bits-1)) == 1 {
+		v.Sub(v, new(big.Int).Lsh(big.NewInt(1), bits))
+	}
+	return v, nil
+}
+
+// storeSliceToBuilder - stores bits and refs of slice to builder
+func storeSliceToBuilder(b *cell.Builder, s *cell.Slice) error {
+	if b.BitsLeft() < s.BitsLeft() || int(b.RefsLeft()) < s.RefsNum() {
+		return errCellOverflow
+	}
+
+	s = s.Copy()
+	sz := s.BitsLeft()
+	data, err := s.LoadSlice(sz)
+	if err != nil {
+		return errCellUnderflow
+	}
+	if err = b.StoreSlice(data, sz); err != nil {
+		return errCellOverflow
+	}
+
+	for s.RefsNum() > 0 {
+		ref, err := s.LoadRefCell()
+		if err != nil {
+			return errCellUnderflow
+		}
+		if err = b.StoreRef(ref); err != nil {
+			return errCellOverflow
+		}
+	}
+	return nil
+}
+
+func storeIntOp(st *State, bits uint, signed, rev, quiet bool) error {
+	var b *cell.Builder
+	var x *big.Int
+	var err error
+	if rev {
+		if x, err = st.stack.popInt(); err != nil {
+			return err
+		}
+		if b, err = st.stack.popBuilder(); err != nil {
+			return err
+		}
+	} else {
+		if b, err = st.stack.popBuilder(); err != nil {
+			return err
+		}
+		if x, err = st.stack.popInt(); err != nil {
+			return err
+		}
+	}
+
+	if err = storeInt(b, x, bits, signed); err != nil {
+		if !quiet {
+			return err
+		}
+		code := storeStatusOverflow
+		if err == errRangeCheck {
+			code = storeStatusRange
+		}
+
+		if rev {
+			st.stack.push(b)
+			st.stack.push(x)
+		} else {
+			st.stack.push(x)
+			st.stack.push(b)
+		}
+		st.stack.pushSmall(int64(code))
+		return nil
+	}
+
+	st.stack.push(b)
+	if quiet {
+		st.stack.pushSmall(storeStatusOK)
+	}
+	return nil
+}
+
+// storeOp - common logic of storing value into builder, rev defines order of arguments (builder on top when false)
+func storeOp(st *State, rev, quiet bool, popVal func() (any, error), store func(b *cell.Builder, v any) error) error {
+	var b *cell.Builder
+	var v any
+	var err error
+	if rev {
+		if v, err = popVal(); err != nil {
+			return err
+		}
+		if b, err = st.stack.popBuilder(); err != nil {
+			return err
+		}
+	} else {
+		if b, err = st.stack.popBuilder(); err != nil {
+			return err
+		}
+		if v, err = popVal(); err != nil {
+			return err
+		}
+	}
+
+	nb := copyBuilder(b)
+	if err = store(nb, v); err != nil {
+		if !quiet {
+			return err
+		}
+		if rev {
+			st.stack.push(b)
+			st.stack.push(v)
+		} else {
+			st.stack.push(v)
+			st.stack.push(b)
+		}
+		st.stack.pushSmall(storeStatusOverflow)
+		return nil
+	}
+
+	st.stack.push(nb)
+	if quiet {
+		st.stack.pushSmall(storeStatusOK)
+	}
+	return nil
+}
+
+func storeRefOp(st *State, rev, quiet bool) error {
+	return storeOp(st, rev, quiet, func() (any, error) {
+		return st.stack.popCell()
+	}, func(b *cell.Builder, v any) error {
+		if b.RefsLeft() == 0 {
+			return errCellOverflow
+		}
+		return b.StoreRef(v.(*cell.Cell))
+	})
+}
+
+func storeBuilderRefOp(st *State, rev, quiet bool) error {
+	return storeOp(st, rev, quiet, func() (any, error) {
+		return st.stack.popBuilder()
+	}, func(b *cell.Builder, v any) error {
+		if b.RefsLeft() == 0 {
+			return errCellOverflow
+		}
+		if err := st.registerCellCreate(); err != nil {
+			return err
+		}
+		return b.StoreRef(v.(*cell.Builder).EndCell())
+	})
+}
+
+func storeSliceOp(st *State, rev, quiet bool) error {
+	return storeOp(st, rev, quiet, func() (any, error) {
+		return st.stack.popSlice()
+	}, func(b *cell.Builder, v any) error {
+		return storeSliceToBuilder(b, v.(*cell.Slice))
+	})
+}
+
+func storeBuilderOp(st *State, rev, quiet bool) error {
+	return storeOp(st, rev, quiet, func() (any, error) {
+		return st.stack.popBuilder()
+	}, func(b *cell.Builder, v any) error {
+		src := v.(*cell.Builder)
+		if b.BitsLeft() < src.BitsUsed() || b.RefsLeft() < uint(src.RefsUsed()) {
+			return errCellOverflow
+		}
+		if err := b.StoreBuilder(src); err != nil {
+			return errCellOverflow
+		}
+		return nil
+	})
+}
+
+func storeRefConst(st *State, n int) error {
+	b, err := st.stack.popBuilder()
+	if err != nil {
+		return err
+	}
+	if int(b.RefsLeft()) < n {
+		return errCellOverflow
+	}
+	for i := 0; i < n; i++ {
+		ref, err := st.loadCodeRef()
+		if err != nil {
+			return err
+		}
+		if err = b.StoreRef(ref); err != nil {
+			return errCellOverflow
+		}
+	}
+	st.stack.push(b)
+	return nil
+}
+
+func builderCheck(st *State, bits, refs int, quiet bool) error {
+	b, err := st.stack.popBuilder()
+	if err != nil {
+		return err
+	}
+	ok := int(b.BitsLeft()) >= bits && int(b.RefsLeft()) >= refs
+	if quiet {
+		st.stack.pushBool(ok)
+		return nil
+	}
+	if !ok {
+		return errCellOverflow
+	}
+	return nil
+}
+
+func storeSame(st *State, bit int) error {
+	n, err := st.stack.popSmall(1023)
+	if err != nil {
+		return err
+	}
+	b, err := st.stack.popBuilder()
+	if err != nil {
+		return err
+	}
+	if b.BitsLeft() < uint(n) {
+		return errCellOverflow
+	}
+
+	bits := make([]byte, n)
+	for i := range bits {
+		bits[i] = byte(bit)
+	}
+	if err = b.StoreSlice(packBits(bits), uint(n)); err != nil {
+		return errCellOverflow
+	}
+	st.stack.push(b)
+	return nil
+}
+
+func builderDepth(b *cell.Builder) int {
+	depth := 0
+	s := b.ToSlice()
+	for s.RefsNum() > 0 {
+		ref, err := s.LoadRefCell()
+		if err != nil {
+			break
+		}
+		if d := int(ref.Depth()) + 1; d > depth {
+			depth = d
+		}
+	}
+	return depth
+}
+
+// packBits - converts list of bits (one byte per bit) to a byte array
+func packBits(bits []byte) []byte {
+	data := make([]byte, (len(bits)+7)/8)
+	for i, b := range bits {
+		if b != 0 {
+			data[i/8] |= 0x80 >> (i % 8)
+		}
+	}
+	return data
+}
+
+// unpackBits - converts first n bits of data to a list with one byte per bit
+func unpackBits(data []byte, n uint) []byte {
+	bits := make([]byte, n)
+	for i := uint(0); i < n; i++ {
+		bits[i] = data[i/8] >> (7 - i%8) & 1
+	}
+	return bits
+}
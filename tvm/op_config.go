@@ -0,0 +1,309 @@
+package tvm
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"math/big"
+
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+// indexes of values in the first element of c7
+const (
+	c7TagIndex        = 0
+	c7ActionsIndex    = 1
+	c7MsgsSentIndex   = 2
+	c7NowIndex        = 3
+	c7BlockLTIndex    = 4
+	c7TxLTIndex       = 5
+	c7SeedIndex       = 6
+	c7BalanceIndex    = 7
+	c7AddressIndex    = 8
+	c7ConfigIndex     = 9
+	c7CodeIndex       = 10
+	c7InMsgValueIndex = 11
+	c7StorageFeeIndex = 12
+)
+
+const maxGlobals = 255
+
+func init() {
+	registerOp("ACCEPT", "F800", 0, func(st *State, _ uint32) error {
+		st.gas.changeLimit(st.gas.Max)
+		st.accepted = true
+		return st.checkGas()
+	})
+	registerOp("SETGASLIMIT", "F801", 0, func(st *State, _ uint32) error {
+		x, err := st.stack.popInt()
+		if err != nil {
+			return err
+		}
+
+		limit := int64(1<<63 - 1)
+		if x.IsInt64() {
+			limit = x.Int64()
+		} else if x.Sign() < 0 {
+			limit = 0
+		}
+		if limit < st.gas.Used() {
+			return vmError(ErrCodeOutOfGas, "too low gas limit")
+		}
+		st.gas.changeLimit(limit)
+		st.accepted = true
+		return st.checkGas()
+	})
+	registerOp("GASCONSUMED", "F807", 0, func(st *State, _ uint32) error {
+		st.stack.pushSmall(st.gas.Used())
+		return nil
+	})
+	registerOp("COMMIT", "F80F", 0, func(st *State, _ uint32) error {
+		if !st.tryCommit() {
+			return vmError(ErrCodeCellOverflow, "cannot commit too deep cells as new data/actions")
+		}
+		return nil
+	})
+
+	registerOp("RANDU256", "F810", 0, func(st *State, _ uint32) error {
+		x, err := nextRandom(st)
+		if err != nil {
+			return err
+		}
+		st.stack.push(x)
+		return nil
+	})
+	registerOp("RAND", "F811", 0, func(st *State, _ uint32) error {
+		y, err := st.stack.popInt()
+		if err != nil {
+			return err
+		}
+		x, err := nextRandom(st)
+		if err != nil {
+			return err
+		}
+		z := new(big.Int).Mul(x, y)
+		st.stack.push(z.Rsh(z, 256))
+		return nil
+	})
+	registerOp("SETRAND", "F814", 0, func(st *State, _ uint32) error {
+		x, err := st.stack.popInt()
+		if err != nil {
+			return err
+		}
+		if x.Sign() < 0 || x.BitLen() > 256 {
+			return errRangeCheck
+		}
+		return setParam(st, c7SeedIndex, x)
+	})
+	registerOp("ADDRAND", "F815", 0, func(st *State, _ uint32) error {
+		x, err := st.stack.popInt()
+		if err != nil {
+			return err
+		}
+		if x.Sign() < 0 || x.BitLen() > 256 {
+			return errRangeCheck
+		}
+		seed, err := randomSeed(st)
+		if err != nil {
+			return err
+		}
+
+		hash := sha256.Sum256(append(seed.FillBytes(make([]byte, 32)), x.FillBytes(make([]byte, 32))...))
+		return setParam(st, c7SeedIndex, new(big.Int).SetBytes(hash[:]))
+	})
+
+	registerOp("GETPARAM", "F82", 4, func(st *State, args uint32) error {
+		v, err := getParam(st, int(args))
+		if err != nil {
+			return err
+		}
+		st.stack.push(v)
+		return nil
+	})
+	registerOp("CONFIGDICT", "F830", 0, func(st *State, _ uint32) error {
+		v, err := getParam(st, c7ConfigIndex)
+		if err != nil {
+			return err
+		}
+		st.stack.push(v)
+		st.stack.pushSmall(32)
+		return nil
+	})
+	registerOp("CONFIGPARAM", "F832", 0, func(st *State, _ uint32) error {
+		c, err := configParam(st)
+		if err != nil {
+			return err
+		}
+		if c == nil {
+			st.stack.pushBool(false)
+			return nil
+		}
+		st.stack.push(c)
+		st.stack.pushBool(true)
+		return nil
+	})
+	registerOp("CONFIGOPTPARAM", "F833", 0, func(st *State, _ uint32) error {
+		c, err := configParam(st)
+		if err != nil {
+			return err
+		}
+		pushMaybeCell(st, c)
+		return nil
+	})
+
+	registerOp("GETGLOBVAR", "F840", 0, func(st *State, _ uint32) error {
+		k, err := st.stack.popSmall(maxGlobals - 1)
+		if err != nil {
+			return err
+		}
+		return getGlobal(st, k)
+	})
+	registerOpCheck("GETGLOB", "F85_", 5, func(args uint32) bool {
+		return args != 0
+	}, func(st *State, args uint32) error {
+		return getGlobal(st, int(args))
+	})
+	registerOp("SETGLOBVAR", "F860", 0, func(st *State, _ uint32) error {
+		k, err := st.stack.popSmall(maxGlobals - 1)
+		if err != nil {
+			return err
+		}
+		return setGlobal(st, k)
+	})
+	registerOpCheck("SETGLOB", "F87_", 5, func(args uint32) bool {
+		return args != 0
+	}, func(st *State, args uint32) error {
+		return setGlobal(st, int(args))
+	})
+}
+
+// checkGas - verifies that gas limit is not exceeded after limit change
+func (st *State) checkGas() error {
+	return st.consumeGas(0)
+}
+
+func contextParams(st *State) ([]any, error) {
+	if len(st.reg.c7) == 0 {
+		return nil, vmError(ErrCodeRangeCheck, "c7 is empty")
+	}
+	t, ok := st.reg.c7[0].([]any)
+	if !ok {
+		return nil, vmError(ErrCodeTypeCheck, "intermediate value is not a tuple")
+	}
+	return t, nil
+}
+
+func getParam(st *State, idx int) (any, error) {
+	t, err := contextParams(st)
+	if err != nil {
+		return nil, err
+	}
+	if idx >= len(t) {
+		return nil, vmError(ErrCodeRangeCheck, "tuple index out of range")
+	}
+	return t[idx], nil
+}
+
+// setParam - replaces value in the first element of c7, tuples are copied because they are immutable
+func setParam(st *State, idx int, v any) error {
+	t, err := contextParams(st)
+	if err != nil {
+		return err
+	}
+	if idx >= len(t) {
+		return vmError(ErrCodeRangeCheck, "tuple index out of range")
+	}
+
+	t = append([]any{}, t...)
+	t[idx] = v
+	c7 := append([]any{}, st.reg.c7...)
+	c7[0] = t
+	st.reg.c7 = c7
+
+	if err = st.consumeTupleGas(len(t)); err != nil {
+		return err
+	}
+	return st.consumeTupleGas(len(c7))
+}
+
+func randomSeed(st *State) (*big.Int, error) {
+	v, err := getParam(st, c7SeedIndex)
+	if err != nil {
+		return nil, err
+	}
+	seed, ok := v.(*big.Int)
+	if !ok {
+		return nil, vmError(ErrCodeTypeCheck, "random seed is not an integer")
+	}
+	if seed.Sign() < 0 || seed.BitLen() > 256 {
+		return nil, vmError(ErrCodeRangeCheck, "random seed out of range")
+	}
+	return seed, nil
+}
+
+// nextRandom - generates next pseudo-random number and updates seed,
+// sha512(seed) is split into the new seed and the result
+func nextRandom(st *State) (*big.Int, error) {
+	seed, err := randomSeed(st)
+	if err != nil {
+		return nil, err
+	}
+
+	hash := sha512.Sum512(seed.FillBytes(make([]byte, 32)))
+	if err = setParam(st, c7SeedIndex, new(big.Int).SetBytes(hash[:32])); err != nil {
+		return nil, err
+	}
+	return new(big.Int).SetBytes(hash[32:]), nil
+}
+
+func configParam(st *State) (*cell.Cell, error) {
+	idx, err := st.stack.popInt()
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := getParam(st, c7ConfigIndex)
+	if err != nil {
+		return nil, err
+	}
+	if v == nil || !fitsBits(idx, 32, true) {
+		return nil, nil
+	}
+	root, ok := v.(*cell.Cell)
+	if !ok {
+		return nil, errTypeCheck
+	}
+
+	val, err := dictLookup(st, root, intToBits(idx, 32))
+	if err != nil || val == nil {
+		return nil, err
+	}
+	return refValue(val)
+}
+
+func getGlobal(st *State, k int) error {
+	if k < len(st.reg.c7) {
+		st.stack.push(st.reg.c7[k])
+		return nil
+	}
+	st.stack.push(nil)
+	return nil
+}
+
+func setGlobal(st *State, k int) error {
+	v, err := st.stack.pop()
+	if err != nil {
+		return err
+	}
+
+	if k >= len(st.reg.c7) && v == nil {
+		return nil
+	}
+
+	c7 := append([]any{}, st.reg.c7...)
+	for len(c7) <= k {
+		c7 = append(c7, nil)
+	}
+	c7[k] = v
+	st.reg.c7 = c7
+	return st.consumeTupleGas(len(c7))
+}
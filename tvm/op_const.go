@@ -0,0 +1,178 @@
+package tvm
+
+import (
+	"math/big"
+
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+func init() {
+	registerOp("PUSHINT_4", "7", 4, func(st *State, args uint32) error {
+		v := int64(args)
+		if v > 10 {
+			v -= 16
+		}
+		st.stack.pushSmall(v)
+		return nil
+	})
+	registerOp("PUSHINT_8", "80", 8, func(st *State, args uint32) error {
+		st.stack.pushSmall(int64(int8(args)))
+		return nil
+	})
+	registerOp("PUSHINT_16", "81", 16, func(st *State, args uint32) error {
+		st.stack.pushSmall(int64(int16(args)))
+		return nil
+	})
+	registerOp("PUSHINT_LONG", "82", 5, func(st *State, args uint32) error {
+		sz := 8*uint(args) + 19
+		if err := st.consumeGas(int64(sz)); err != nil {
+			return err
+		}
+		v, err := st.code.LoadBigInt(sz)
+		if err != nil {
+			return errInvalidOpcode
+		}
+		return st.stack.pushInt(v)
+	})
+	registerOp("PUSHNAN", "83FF", 0, func(st *State, _ uint32) error {
+		st.stack.push(tlb.StackNaN{})
+		return nil
+	})
+	registerOp("PUSHPOW2", "83", 8, func(st *State, args uint32) error {
+		st.stack.push(new(big.Int).Lsh(big.NewInt(1), uint(args)+1))
+		return nil
+	})
+	registerOp("PUSHPOW2DEC", "84", 8, func(st *State, args uint32) error {
+		v := new(big.Int).Lsh(big.NewInt(1), uint(args)+1)
+		st.stack.push(v.Sub(v, big.NewInt(1)))
+		return nil
+	})
+	registerOp("PUSHNEGPOW2", "85", 8, func(st *State, args uint32) error {
+		v := new(big.Int).Lsh(big.NewInt(1), uint(args)+1)
+		st.stack.push(v.Neg(v))
+		return nil
+	})
+	registerOp("PUSHREF", "88", 0, func(st *State, _ uint32) error {
+		ref, err := st.loadCodeRef()
+		if err != nil {
+			return err
+		}
+		st.stack.push(ref)
+		return nil
+	})
+	registerOp("PUSHREFSLICE", "89", 0, func(st *State, _ uint32) error {
+		ref, err := st.loadCodeRef()
+		if err != nil {
+			return err
+		}
+		s, err := st.loadCell(ref)
+		if err != nil {
+			return err
+		}
+		st.stack.push(s)
+		return nil
+	})
+	registerOp("PUSHREFCONT", "8A", 0, func(st *State, _ uint32) error {
+		ref, err := st.loadCodeRef()
+		if err != nil {
+			return err
+		}
+		cont, err := st.refToCont(ref)
+		if err != nil {
+			return err
+		}
+		st.stack.push(cont)
+		return nil
+	})
+	registerOp("PUSHSLICE", "8B", 4, func(st *State, args uint32) error {
+		s, err := loadCodeSlice(st, 8*uint(args)+4, 0, true)
+		if err != nil {
+			return err
+		}
+		st.stack.push(s)
+		return nil
+	})
+	registerOp("PUSHSLICE_REFS", "8C", 7, func(st *State, args uint32) error {
+		s, err := loadCodeSlice(st, 8*uint(args&31)+1, int(args>>5)+1, true)
+		if err != nil {
+			return err
+		}
+		st.stack.push(s)
+		return nil
+	})
+	registerOpCheck("PUSHSLICE_LONG", "8D", 10, func(args uint32) bool {
+		return args>>7 <= 4
+	}, func(st *State, args uint32) error {
+		s, err := loadCodeSlice(st, 8*uint(args&127)+6, int(args>>7), true)
+		if err != nil {
+			return err
+		}
+		st.stack.push(s)
+		return nil
+	})
+	registerOp("PUSHCONT", "8F_", 9, func(st *State, args uint32) error {
+		s, err := loadCodeSlice(st, 8*uint(args&127), int(args>>7), false)
+		if err != nil {
+			return err
+		}
+		st.stack.push(newOrdinaryContinuation(s, st.cp))
+		return nil
+	})
+	registerOp("PUSHCONT_SHORT", "9", 4, func(st *State, args uint32) error {
+		s, err := loadCodeSlice(st, 8*uint(args), 0, false)
+		if err != nil {
+			return err
+		}
+		st.stack.push(newOrdinaryContinuation(s, st.cp))
+		return nil
+	})
+}
+
+// loadCodeSlice - takes inline data from the code, trailing completion tag is removed if needed
+func loadCodeSlice(st *State, bits uint, refs int, withTag bool) (*cell.Slice, error) {
+	if err := st.consumeGas(int64(bits)); err != nil {
+		return nil, err
+	}
+
+	if st.code.BitsLeft() < bits || st.code.RefsNum() < refs {
+		return nil, errInvalidOpcode
+	}
+
+	data, err := st.code.LoadSlice(bits)
+	if err != nil {
+		return nil, errInvalidOpcode
+	}
+
+	if withTag {
+		bits = removeCompletionTag(data, bits)
+	}
+
+	b := cell.BeginCell()
+	if err = b.StoreSlice(data, bits); err != nil {
+		return nil, errInvalidOpcode
+	}
+
+	for i := 0; i < refs; i++ {
+		ref, err := st.code.LoadRefCell()
+		if err != nil {
+			return nil, errInvalidOpcode
+		}
+		if err = b.StoreRef(ref); err != nil {
+			return nil, errInvalidOpcode
+		}
+	}
+	return b.ToSlice(), nil
+}
+
+// removeCompletionTag - returns data size without trailing zeroes and the last 1 bit
+func removeCompletionTag(data []byte, bits uint) uint {
+	for bits > 0 {
+		bit := data[(bits-1)/8] >> (7 - (bits-1)%8) & 1
+		bits--
+		if bit == 1 {
+			break
+		}
+	}
+	return bits
+}
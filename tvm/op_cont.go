@@ -0,0 +1,1124 @@
+package tvm
+
+import (
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+func init() {
+	registerOp("EXECUTE", "D8", 0, func(st *State, _ uint32) error {
+		c, err := st.stack.popCont()
+		if err != nil {
+			return err
+		}
+		return st.call(c)
+	})
+	registerOp("JMPX", "D9", 0, func(st *State, _ uint32) error {
+		c, err := st.stack.popCont()
+		if err != nil {
+			return err
+		}
+		return st.jump(c)
+	})
+	registerOp("CALLXARGS", "DA", 8, func(st *State, args uint32) error {
+		c, err := st.stack.popCont()
+		if err != nil {
+			return err
+		}
+		return st.callArgs(c, int(args>>4), int(args&15))
+	})
+	registerOp("CALLXARGS_VAR", "DB0", 4, func(st *State, args uint32) error {
+		c, err := st.stack.popCont()
+		if err != nil {
+			return err
+		}
+		return st.callArgs(c, int(args), -1)
+	})
+	registerOp("JMPXARGS", "DB1", 4, func(st *State, args uint32) error {
+		c, err := st.stack.popCont()
+		if err != nil {
+			return err
+		}
+		return st.jumpArgs(c, int(args))
+	})
+	registerOp("RETARGS", "DB2", 4, func(st *State, args uint32) error {
+		return st.retArgs(int(args))
+	})
+	registerOp("RET", "DB30", 0, func(st *State, _ uint32) error {
+		return st.ret()
+	})
+	registerOp("RETALT", "DB31", 0, func(st *State, _ uint32) error {
+		return st.retAlt()
+	})
+	registerOp("BRANCH", "DB32", 0, func(st *State, _ uint32) error {
+		f, err := st.stack.popBool()
+		if err != nil {
+			return err
+		}
+		if f {
+			return st.ret()
+		}
+		return st.retAlt()
+	})
+	registerOp("CALLCC", "DB34", 0, func(st *State, _ uint32) error {
+		c, err := st.stack.popCont()
+		if err != nil {
+			return err
+		}
+		cc, err := st.extractCC(3, -1, -1)
+		if err != nil {
+			return err
+		}
+		st.stack.push(cc)
+		return st.jump(c)
+	})
+	registerOp("JMPXDATA", "DB35", 0, func(st *State, _ uint32) error {
+		c, err := st.stack.popCont()
+		if err != nil {
+			return err
+		}
+		st.stack.push(st.code.Copy())
+		return st.jump(c)
+	})
+	registerOp("CALLCCARGS", "DB36", 8, func(st *State, args uint32) error {
+		ret := int(args & 15)
+		if ret == 15 {
+			ret = -1
+		}
+		return callCCArgs(st, int(args>>4), ret)
+	})
+	registerOp("CALLXVARARGS", "DB38", 0, func(st *State, _ uint32) error {
+		ret, err := st.stack.popIntRange(-1, 254)
+		if err != nil {
+			return err
+		}
+		pass, err := st.stack.popIntRange(-1, 254)
+		if err != nil {
+			return err
+		}
+		c, err := st.stack.popCont()
+		if err != nil {
+			return err
+		}
+		return st.callArgs(c, int(pass), int(ret))
+	})
+	registerOp("RETVARARGS", "DB39", 0, func(st *State, _ uint32) error {
+		ret, err := st.stack.popIntRange(-1, 254)
+		if err != nil {
+			return err
+		}
+		return st.retArgs(int(ret))
+	})
+	registerOp("JMPXVARARGS", "DB3A", 0, func(st *State, _ uint32) error {
+		pass, err := st.stack.popIntRange(-1, 254)
+		if err != nil {
+			return err
+		}
+		c, err := st.stack.popCont()
+		if err != nil {
+			return err
+		}
+		return st.jumpArgs(c, int(pass))
+	})
+	registerOp("CALLCCVARARGS", "DB3B", 0, func(st *State, _ uint32) error {
+		ret, err := st.stack.popIntRange(-1, 254)
+		if err != nil {
+			return err
+		}
+		pass, err := st.stack.popIntRange(-1, 254)
+		if err != nil {
+			return err
+		}
+		return callCCArgs(st, int(pass), int(ret))
+	})
+	registerOp("CALLREF", "DB3C", 0, func(st *State, _ uint32) error {
+		c, err := loadRefCont(st)
+		if err != nil {
+			return err
+		}
+		return st.call(c)
+	})
+	registerOp("JMPREF", "DB3D", 0, func(st *State, _ uint32) error {
+		c, err := loadRefCont(st)
+		if err != nil {
+			return err
+		}
+		return st.jump(c)
+	})
+	registerOp("JMPREFDATA", "DB3E", 0, func(st *State, _ uint32) error {
+		c, err := loadRefCont(st)
+		if err != nil {
+			return err
+		}
+		st.stack.push(st.code.Copy())
+		return st.jump(c)
+	})
+	registerOp("RETDATA", "DB3F", 0, func(st *State, _ uint32) error {
+		st.stack.push(st.code.Copy())
+		return st.ret()
+	})
+
+	registerOp("IFRET", "DC", 0, func(st *State, _ uint32) error {
+		f, err := st.stack.popBool()
+		if err != nil || !f {
+			return err
+		}
+		return st.ret()
+	})
+	registerOp("IFNOTRET", "DD", 0, func(st *State, _ uint32) error {
+		f, err := st.stack.popBool()
+		if err != nil || f {
+			return err
+		}
+		return st.ret()
+	})
+	registerOp("IF", "DE", 0, func(st *State, _ uint32) error {
+		return condCont(st, true, false)
+	})
+	registerOp("IFNOT", "DF", 0, func(st *State, _ uint32) error {
+		return condCont(st, false, false)
+	})
+	registerOp("IFJMP", "E0", 0, func(st *State, _ uint32) error {
+		return condCont(st, true, true)
+	})
+	registerOp("IFNOTJMP", "E1", 0, func(st *State, _ uint32) error {
+		return condCont(st, false, true)
+	})
+	registerOp("IFELSE", "E2", 0, func(st *State, _ uint32) error {
+		c2, err := st.stack.popCont()
+		if err != nil {
+			return err
+		}
+		c1, err := st.stack.popCont()
+		if err != nil {
+			return err
+		}
+		f, err := st.stack.popBool()
+		if err != nil {
+			return err
+		}
+		if f {
+			return st.call(c1)
+		}
+		return st.call(c2)
+	})
+	registerOp("IFREF", "E300", 0, func(st *State, _ uint32) error {
+		return condRef(st, true, false)
+	})
+	registerOp("IFNOTREF", "E301", 0, func(st *State, _ uint32) error {
+		return condRef(st, false, false)
+	})
+	registerOp("IFJMPREF", "E302", 0, func(st *State, _ uint32) error {
+		return condRef(st, true, true)
+	})
+	registerOp("IFNOTJMPREF", "E303", 0, func(st *State, _ uint32) error {
+		return condRef(st, false, true)
+	})
+	registerOp("CONDSEL", "E304", 0, func(st *State, _ uint32) error {
+		return condSelect(st, false)
+	})
+	registerOp("CONDSELCHK", "E305", 0, func(st *State, _ uint32) error {
+		return condSelect(st, true)
+	})
+	registerOp("IFRETALT", "E308", 0, func(st *State, _ uint32) error {
+		f, err := st.stack.popBool()
+		if err != nil || !f {
+			return err
+		}
+		return st.retAlt()
+	})
+	registerOp("IFNOTRETALT", "E309", 0, func(st *State, _ uint32) error {
+		f, err := st.stack.popBool()
+		if err != nil || f {
+			return err
+		}
+		return st.retAlt()
+	})
+	registerOp("IFREFELSE", "E30D", 0, func(st *State, _ uint32) error {
+		return ifElseRef(st, true)
+	})
+	registerOp("IFELSEREF", "E30E", 0, func(st *State, _ uint32) error {
+		return ifElseRef(st, false)
+	})
+	registerOp("IFREFELSEREF", "E30F", 0, func(st *State, _ uint32) error {
+		ref1, err := st.loadCodeRef()
+		if err != nil {
+			return err
+		}
+		ref2, err := st.loadCodeRef()
+		if err != nil {
+			return err
+		}
+		f, err := st.stack.popBool()
+		if err != nil {
+			return err
+		}
+		ref := ref2
+		if f {
+			ref = ref1
+		}
+		c, err := st.refToCont(ref)
+		if err != nil {
+			return err
+		}
+		return st.call(c)
+	})
+	registerOp("REPEATBRK", "E314", 0, func(st *State, _ uint32) error {
+		return repeatOp(st, true)
+	})
+	registerOp("REPEATENDBRK", "E315", 0, func(st *State, _ uint32) error {
+		return repeatEndOp(st, true)
+	})
+	registerOp("UNTILBRK", "E316", 0, func(st *State, _ uint32) error {
+		return untilOp(st, true)
+	})
+	registerOp("UNTILENDBRK", "E317", 0, func(st *State, _ uint32) error {
+		return untilEndOp(st, true)
+	})
+	registerOp("WHILEBRK", "E318", 0, func(st *State, _ uint32) error {
+		return whileOp(st, true)
+	})
+	registerOp("WHILEENDBRK", "E319", 0, func(st *State, _ uint32) error {
+		return whileEndOp(st, true)
+	})
+	registerOp("AGAINBRK", "E31A", 0, func(st *State, _ uint32) error {
+		return againOp(st, true)
+	})
+	registerOp("AGAINENDBRK", "E31B", 0, func(st *State, _ uint32) error {
+		return againEndOp(st, true)
+	})
+	registerOp("IFBITJMP", "E39_", 5, func(st *State, args uint32) error {
+		return ifBitJump(st, int(args), true)
+	})
+	registerOp("IFNBITJMP", "E3B_", 5, func(st *State, args uint32) error {
+		return ifBitJump(st, int(args), false)
+	})
+	registerOp("IFBITJMPREF", "E3D_", 5, func(st *State, args uint32) error {
+		return ifBitJumpRef(st, int(args), true)
+	})
+	registerOp("IFNBITJMPREF", "E3F_", 5, func(st *State, args uint32) error {
+		return ifBitJumpRef(st, int(args), false)
+	})
+
+	registerOp("REPEAT", "E4", 0, func(st *State, _ uint32) error {
+		return repeatOp(st, false)
+	})
+	registerOp("REPEATEND", "E5", 0, func(st *State, _ uint32) error {
+		return repeatEndOp(st, false)
+	})
+	registerOp("UNTIL", "E6", 0, func(st *State, _ uint32) error {
+		return untilOp(st, false)
+	})
+	registerOp("UNTILEND", "E7", 0, func(st *State, _ uint32) error {
+		return untilEndOp(st, false)
+	})
+	registerOp("WHILE", "E8", 0, func(st *State, _ uint32) error {
+		return whileOp(st, false)
+	})
+	registerOp("WHILEEND", "E9", 0, func(st *State, _ uint32) error {
+		return whileEndOp(st, false)
+	})
+	registerOp("AGAIN", "EA", 0, func(st *State, _ uint32) error {
+		return againOp(st, false)
+	})
+	registerOp("AGAINEND", "EB", 0, func(st *State, _ uint32) error {
+		return againEndOp(st, false)
+	})
+
+	registerOp("SETCONTARGS", "EC", 8, func(st *State, args uint32) error {
+		more := int(args & 15)
+		if more == 15 {
+			more = -1
+		}
+		return setContArgs(st, int(args>>4), more)
+	})
+	registerOp("RETURNARGS", "ED0", 4, func(st *State, args uint32) error {
+		return returnArgs(st, int(args))
+	})
+	registerOp("RETURNVARARGS", "ED10", 0, func(st *State, _ uint32) error {
+		n, err := st.stack.popSmall(255)
+		if err != nil {
+			return err
+		}
+		return returnArgs(st, n)
+	})
+	registerOp("SETCONTVARARGS", "ED11", 0, func(st *State, _ uint32) error {
+		more, err := st.stack.popIntRange(-1, 255)
+		if err != nil {
+			return err
+		}
+		copyN, err := st.stack.popSmall(255)
+		if err != nil {
+			return err
+		}
+		return setContArgs(st, copyN, int(more))
+	})
+	registerOp("SETNUMVARARGS", "ED12", 0, func(st *State, _ uint32) error {
+		more, err := st.stack.popIntRange(-1, 255)
+		if err != nil {
+			return err
+		}
+		return setContArgs(st, 0, int(more))
+	})
+	registerOp("BLESS", "ED1E", 0, func(st *State, _ uint32) error {
+		s, err := st.stack.popSlice()
+		if err != nil {
+			return err
+		}
+		st.stack.push(newOrdinaryContinuation(s, st.cp))
+		return nil
+	})
+	registerOp("BLESSVARARGS", "ED1F", 0, func(st *State, _ uint32) error {
+		more, err := st.stack.popIntRange(-1, 255)
+		if err != nil {
+			return err
+		}
+		copyN, err := st.stack.popSmall(255)
+		if err != nil {
+			return err
+		}
+		return blessArgs(st, copyN, int(more))
+	})
+	registerOpCheck("PUSHCTR", "ED4", 4, isValidCR, func(st *State, args uint32) error {
+		st.stack.push(st.reg.get(int(args)))
+		return nil
+	})
+	registerOpCheck("POPCTR", "ED5", 4, isValidCR, func(st *State, args uint32) error {
+		v, err := st.stack.pop()
+		if err != nil {
+			return err
+		}
+		return st.reg.set(int(args), v)
+	})
+	registerOpCheck("SETCONTCTR", "ED6", 4, isValidCR, func(st *State, args uint32) error {
+		c, err := st.stack.popCont()
+		if err != nil {
+			return err
+		}
+		v, err := st.stack.pop()
+		if err != nil {
+			return err
+		}
+		c, data := forceControlData(c)
+		if err = data.save.define(int(args), v); err != nil {
+			return err
+		}
+		st.stack.push(c)
+		return nil
+	})
+	registerOpCheck("SETRETCTR", "ED7", 4, isValidCR, func(st *State, args uint32) error {
+		return setSavedCtr(st, 0, int(args))
+	})
+	registerOpCheck("SETALTCTR", "ED8", 4, isValidCR, func(st *State, args uint32) error {
+		return setSavedCtr(st, 1, int(args))
+	})
+	registerOpCheck("POPSAVE", "ED9", 4, isValidCR, func(st *State, args uint32) error {
+		idx := int(args)
+		v, err := st.stack.pop()
+		if err != nil {
+			return err
+		}
+
+		c0, data := forceControlData(st.reg.c[0])
+		if idx == 0 {
+			// new c0 is taken from stack, old one is saved into it
+			nc, ok := v.(Continuation)
+			if !ok {
+				return errTypeCheck
+			}
+			nc, ndata := forceControlData(nc)
+			if ndata.save.c[0] == nil {
+				ndata.save.c[0] = st.reg.c[0]
+			}
+			st.reg.c[0] = nc
+			return nil
+		}
+
+		if !data.save.isSet(idx) {
+			_ = data.save.set(idx, st.reg.get(idx))
+		}
+		if err = st.reg.set(idx, v); err != nil {
+			return err
+		}
+		st.reg.c[0] = c0
+		return nil
+	})
+	registerOpCheck("SAVECTR", "EDA", 4, isValidCR, func(st *State, args uint32) error {
+		return saveCtr(st, int(args), true, false)
+	})
+	registerOpCheck("SAVEALTCTR", "EDB", 4, isValidCR, func(st *State, args uint32) error {
+		return saveCtr(st, int(args), false, true)
+	})
+	registerOpCheck("SAVEBOTHCTR", "EDC", 4, isValidCR, func(st *State, args uint32) error {
+		return saveCtr(st, int(args), true, true)
+	})
+	registerOp("PUSHCTRX", "EDE0", 0, func(st *State, _ uint32) error {
+		idx, err := st.stack.popSmall(16)
+		if err != nil {
+			return err
+		}
+		if !isValidCR(uint32(idx)) {
+			return errRangeCheck
+		}
+		st.stack.push(st.reg.get(idx))
+		return nil
+	})
+	registerOp("POPCTRX", "EDE1", 0, func(st *State, _ uint32) error {
+		idx, err := st.stack.popSmall(16)
+		if err != nil {
+			return err
+		}
+		if !isValidCR(uint32(idx)) {
+			return errRangeCheck
+		}
+		v, err := st.stack.pop()
+		if err != nil {
+			return err
+		}
+		return st.reg.set(idx, v)
+	})
+	registerOp("SETCONTCTRX", "EDE2", 0, func(st *State, _ uint32) error {
+		idx, err := st.stack.popSmall(16)
+		if err != nil {
+			return err
+		}
+		if !isValidCR(uint32(idx)) {
+			return errRangeCheck
+		}
+		c, err := st.stack.popCont()
+		if err != nil {
+			return err
+		}
+		v, err := st.stack.pop()
+		if err != nil {
+			return err
+		}
+		c, data := forceControlData(c)
+		if err = data.save.define(idx, v); err != nil {
+			return err
+		}
+		st.stack.push(c)
+		return nil
+	})
+	registerOp("COMPOS", "EDF0", 0, func(st *State, _ uint32) error {
+		return compos(st, true, false)
+	})
+	registerOp("COMPOSALT", "EDF1", 0, func(st *State, _ uint32) error {
+		return compos(st, false, true)
+	})
+	registerOp("COMPOSBOTH", "EDF2", 0, func(st *State, _ uint32) error {
+		return compos(st, true, true)
+	})
+	registerOp("ATEXIT", "EDF3", 0, func(st *State, _ uint32) error {
+		c, err := st.stack.popCont()
+		if err != nil {
+			return err
+		}
+		c, data := forceControlData(c)
+		defineCont(&data.save, 0, st.reg.c[0])
+		st.reg.c[0] = c
+		return nil
+	})
+	registerOp("ATEXITALT", "EDF4", 0, func(st *State, _ uint32) error {
+		c, err := st.stack.popCont()
+		if err != nil {
+			return err
+		}
+		c, data := forceControlData(c)
+		defineCont(&data.save, 1, st.reg.c[1])
+		st.reg.c[1] = c
+		return nil
+	})
+	registerOp("SETEXITALT", "EDF5", 0, func(st *State, _ uint32) error {
+		c, err := st.stack.popCont()
+		if err != nil {
+			return err
+		}
+		c, data := forceControlData(c)
+		defineCont(&data.save, 0, st.reg.c[0])
+		defineCont(&data.save, 1, st.reg.c[1])
+		st.reg.c[1] = c
+		return nil
+	})
+	registerOp("THENRET", "EDF6", 0, func(st *State, _ uint32) error {
+		c, err := st.stack.popCont()
+		if err != nil {
+			return err
+		}
+		c, data := forceControlData(c)
+		defineCont(&data.save, 0, st.reg.c[0])
+		st.stack.push(c)
+		return nil
+	})
+	registerOp("THENRETALT", "EDF7", 0, func(st *State, _ uint32) error {
+		c, err := st.stack.popCont()
+		if err != nil {
+			return err
+		}
+		c, data := forceControlData(c)
+		defineCont(&data.save, 0, st.reg.c[1])
+		st.stack.push(c)
+		return nil
+	})
+	registerOp("INVERT", "EDF8", 0, func(st *State, _ uint32) error {
+		st.reg.c[0], st.reg.c[1] = st.reg.c[1], st.reg.c[0]
+		return nil
+	})
+	registerOp("BOOLEVAL", "EDF9", 0, func(st *State, _ uint32) error {
+		c, err := st.stack.popCont()
+		if err != nil {
+			return err
+		}
+		cc, err := st.extractCC(3, -1, -1)
+		if err != nil {
+			return err
+		}
+		st.reg.c[0] = &pushIntContinuation{value: -1, next: cc}
+		st.reg.c[1] = &pushIntContinuation{value: 0, next: cc}
+		return st.jump(c)
+	})
+	registerOp("SAMEALT", "EDFA", 0, func(st *State, _ uint32) error {
+		st.reg.c[1] = st.reg.c[0]
+		return nil
+	})
+	registerOp("SAMEALTSAVE", "EDFB", 0, func(st *State, _ uint32) error {
+		c0, data := forceControlData(st.reg.c[0])
+		defineCont(&data.save, 1, st.reg.c[1])
+		st.reg.c[0] = c0
+		st.reg.c[1] = c0
+		return nil
+	})
+	registerOp("BLESSARGS", "EE", 8, func(st *State, args uint32) error {
+		more := int(args & 15)
+		if more == 15 {
+			more = -1
+		}
+		return blessArgs(st, int(args>>4), more)
+	})
+
+	registerOp("CALLDICT", "F0", 8, func(st *State, args uint32) error {
+		st.stack.pushSmall(int64(args))
+		return st.call(st.reg.c[3])
+	})
+	registerOp("CALLDICT_LONG", "F12_", 14, func(st *State, args uint32) error {
+		st.stack.pushSmall(int64(args))
+		return st.call(st.reg.c[3])
+	})
+	registerOp("JMPDICT", "F16_", 14, func(st *State, args uint32) error {
+		st.stack.pushSmall(int64(args))
+		return st.jump(st.reg.c[3])
+	})
+	registerOp("PREPAREDICT", "F1A_", 14, func(st *State, args uint32) error {
+		st.stack.pushSmall(int64(args))
+		st.stack.push(st.reg.c[3])
+		return nil
+	})
+
+	registerOp("SETCP", "FF", 8, func(st *State, args uint32) error {
+		return setCodepage(st, int(args))
+	})
+	registerOp("SETCPX", "FFF0", 0, func(st *State, _ uint32) error {
+		cp, err := st.stack.popIntRange(-(1 << 15), (1<<15)-1)
+		if err != nil {
+			return err
+		}
+		return setCodepage(st, int(cp))
+	})
+	registerOp("DEBUG", "FE", 8, func(st *State, _ uint32) error {
+		return nil
+	})
+	registerOp("DEBUGSTR", "FEF", 4, func(st *State, args uint32) error {
+		_, err := loadCodeSlice(st, 8*(uint(args)+1), 0, false)
+		return err
+	})
+}
+
+// isValidCR - c0-c5 and c7 are the only existing registers
+func isValidCR(idx uint32) bool {
+	return idx < 8 && (0xbf>>idx)&1 == 1
+}
+
+func setCodepage(st *State, cp int) error {
+	if cp != 0 {
+		return vmError(ErrCodeInvalidOpcode, "unsupported codepage")
+	}
+	st.cp = cp
+	return nil
+}
+
+// defineCont - sets continuation register in save list only if it is not set yet
+func defineCont(save *registers, idx int, c Continuation) {
+	if save.c[idx] == nil {
+		save.c[idx] = c
+	}
+}
+
+func loadRefCont(st *State) (Continuation, error) {
+	ref, err := st.loadCodeRef()
+	if err != nil {
+		return nil, err
+	}
+	return st.refToCont(ref)
+}
+
+func callCCArgs(st *State, pass, ret int) error {
+	c, err := st.stack.popCont()
+	if err != nil {
+		return err
+	}
+	if err = st.stack.checkUnderflow(pass); err != nil {
+		return err
+	}
+	cc, err := st.extractCC(3, pass, ret)
+	if err != nil {
+		return err
+	}
+	st.stack.push(cc)
+	return st.jump(c)
+}
+
+func condCont(st *State, expect, jump bool) error {
+	c, err := st.stack.popCont()
+	if err != nil {
+		return err
+	}
+	f, err := st.stack.popBool()
+	if err != nil {
+		return err
+	}
+	if f != expect {
+		return nil
+	}
+	if jump {
+		return st.jump(c)
+	}
+	return st.call(c)
+}
+
+func condRef(st *State, expect, jump bool) error {
+	ref, err := st.loadCodeRef()
+	if err != nil {
+		return err
+	}
+	f, err := st.stack.popBool()
+	if err != nil {
+		return err
+	}
+	if f != expect {
+		return nil
+	}
+
+	c, err := st.refToCont(ref)
+	if err != nil {
+		return err
+	}
+	if jump {
+		return st.jump(c)
+	}
+	return st.call(c)
+}
+
+func ifElseRef(st *State, refFirst bool) error {
+	ref, err := st.loadCodeRef()
+	if err != nil {
+		return err
+	}
+	c, err := st.stack.popCont()
+	if err != nil {
+		return err
+	}
+	f, err := st.stack.popBool()
+	if err != nil {
+		return err
+	}
+
+	if f == refFirst {
+		if c, err = st.refToCont(ref); err != nil {
+			return err
+		}
+	}
+	return st.call(c)
+}
+
+func condSelect(st *State, checkType bool) error {
+	y, err := st.stack.pop()
+	if err != nil {
+		return err
+	}
+	x, err := st.stack.pop()
+	if err != nil {
+		return err
+	}
+	f, err := st.stack.popBool()
+	if err != nil {
+		return err
+	}
+	if checkType && stackTypeOf(x) != stackTypeOf(y) {
+		return errTypeCheck
+	}
+	if f {
+		st.stack.push(x)
+	} else {
+		st.stack.push(y)
+	}
+	return nil
+}
+
+func stackTypeOf(v any) int {
+	switch v.(type) {
+	case nil:
+		return 0
+	case *cell.Cell:
+		return 2
+	case *cell.Slice:
+		return 3
+	case *cell.Builder:
+		return 4
+	case Continuation:
+		return 5
+	case []any:
+		return 6
+	}
+	// integers and NaN
+	return 1
+}
+
+func ifBitJump(st *State, bit int, expect bool) error {
+	c, err := st.stack.popCont()
+	if err != nil {
+		return err
+	}
+	x, err := st.stack.popInt()
+	if err != nil {
+		return err
+	}
+	st.stack.push(x)
+	if (x.Bit(bit) == 1) != expect {
+		return nil
+	}
+	return st.jump(c)
+}
+
+func ifBitJumpRef(st *State, bit int, expect bool) error {
+	ref, err := st.loadCodeRef()
+	if err != nil {
+		return err
+	}
+	x, err := st.stack.popInt()
+	if err != nil {
+		return err
+	}
+	st.stack.push(x)
+	if (x.Bit(bit) == 1) != expect {
+		return nil
+	}
+	c, err := st.refToCont(ref)
+	if err != nil {
+		return err
+	}
+	return st.jump(c)
+}
+
+// c1Envelope - makes continuation to be an alternative return point, used by loops with break
+func c1Envelope(st *State, c Continuation, save bool) Continuation {
+	if save {
+		var data *controlData
+		c, data = forceControlData(c)
+		defineCont(&data.save, 1, st.reg.c[1])
+		defineCont(&data.save, 0, st.reg.c[0])
+	}
+	st.reg.c[1] = c
+	return c
+}
+
+// c1SaveSet - saves c1 into c0 and makes c0 to be an alternative return point
+func c1SaveSet(st *State) {
+	c0, data := forceControlData(st.reg.c[0])
+	defineCont(&data.save, 1, st.reg.c[1])
+	st.reg.c[0] = c0
+	st.reg.c[1] = c0
+}
+
+func runRepeat(st *State, body, after Continuation, count int64) error {
+	if count <= 0 {
+		return st.jump(after)
+	}
+	return st.jump(&repeatContinuation{body: body, after: after, count: count})
+}
+
+func repeatOp(st *State, brk bool) error {
+	body, err := st.stack.popCont()
+	if err != nil {
+		return err
+	}
+	count, err := st.stack.popIntRange(-(1 << 31), (1<<31)-1)
+	if err != nil {
+		return err
+	}
+	if count <= 0 {
+		return nil
+	}
+
+	cc, err := st.extractCC(1, -1, -1)
+	if err != nil {
+		return err
+	}
+	return runRepeat(st, body, c1EnvelopeIf(st, brk, cc), count)
+}
+
+func repeatEndOp(st *State, brk bool) error {
+	count, err := st.stack.popIntRange(-(1 << 31), (1<<31)-1)
+	if err != nil {
+		return err
+	}
+	if count <= 0 {
+		return st.ret()
+	}
+
+	body, err := st.extractCC(0, -1, -1)
+	if err != nil {
+		return err
+	}
+	return runRepeat(st, body, c1EnvelopeIf(st, brk, st.reg.c[0]), count)
+}
+
+func c1EnvelopeIf(st *State, brk bool, c Continuation) Continuation {
+	if !brk {
+		return c
+	}
+	return c1Envelope(st, c, true)
+}
+
+func runUntil(st *State, body, after Continuation) error {
+	if !hasC0(body) {
+		st.reg.c[0] = &untilContinuation{body: body, after: after}
+	}
+	return st.jump(body)
+}
+
+func untilOp(st *State, brk bool) error {
+	body, err := st.stack.popCont()
+	if err != nil {
+		return err
+	}
+	cc, err := st.extractCC(1, -1, -1)
+	if err != nil {
+		return err
+	}
+	return runUntil(st, body, c1EnvelopeIf(st, brk, cc))
+}
+
+func untilEndOp(st *State, brk bool) error {
+	body, err := st.extractCC(0, -1, -1)
+	if err != nil {
+		return err
+	}
+	return runUntil(st, body, c1EnvelopeIf(st, brk, st.reg.c[0]))
+}
+
+func runWhile(st *State, cond, body, after Continuation) error {
+	if !hasC0(cond) {
+		st.reg.c[0] = &whileContinuation{cond: cond, body: body, after: after, checkCond: true}
+	}
+	return st.jump(cond)
+}
+
+func whileOp(st *State, brk bool) error {
+	body, err := st.stack.popCont()
+	if err != nil {
+		return err
+	}
+	cond, err := st.stack.popCont()
+	if err != nil {
+		return err
+	}
+	cc, err := st.extractCC(1, -1, -1)
+	if err != nil {
+		return err
+	}
+	return runWhile(st, cond, body, c1EnvelopeIf(st, brk, cc))
+}
+
+func whileEndOp(st *State, brk bool) error {
+	cond, err := st.stack.popCont()
+	if err != nil {
+		return err
+	}
+	body, err := st.extractCC(0, -1, -1)
+	if err != nil {
+		return err
+	}
+	return runWhile(st, cond, body, c1EnvelopeIf(st, brk, st.reg.c[0]))
+}
+
+func againOp(st *State, brk bool) error {
+	if brk {
+		c1SaveSet(st)
+	}
+	body, err := st.stack.popCont()
+	if err != nil {
+		return err
+	}
+	return st.jump(&againContinuation{body: body})
+}
+
+func againEndOp(st *State, brk bool) error {
+	if brk {
+		c1SaveSet(st)
+	}
+	body, err := st.extractCC(0, -1, -1)
+	if err != nil {
+		return err
+	}
+	return st.jump(&againContinuation{body: body})
+}
+
+func setContArgs(st *State, copyN, more int) error {
+	if err := st.stack.checkUnderflow(copyN + 1); err != nil {
+		return err
+	}
+	c, err := st.stack.popCont()
+	if err != nil {
+		return err
+	}
+
+	if copyN > 0 || more >= 0 {
+		var data *controlData
+		c, data = forceControlData(c)
+		if copyN > 0 {
+			if data.numArgs >= 0 && data.numArgs < copyN {
+				return vmError(ErrCodeStackOverflow, "too many arguments copied into a closure continuation")
+			}
+			if data.stack == nil {
+				data.stack = NewStack()
+			}
+			if err = data.stack.moveFrom(st.stack, copyN); err != nil {
+				return err
+			}
+			if err = st.consumeStackGas(data.stack); err != nil {
+				return err
+			}
+			if data.numArgs >= 0 {
+				data.numArgs -= copyN
+			}
+		}
+
+		if more >= 0 {
+			if data.numArgs > more {
+				// will throw an exception when executed
+				data.numArgs = 0x40000000
+			} else if data.numArgs < 0 {
+				data.numArgs = more
+			}
+		}
+	}
+	st.stack.push(c)
+	return nil
+}
+
+func blessArgs(st *State, copyN, more int) error {
+	if err := st.stack.checkUnderflow(copyN + 1); err != nil {
+		return err
+	}
+	s, err := st.stack.popSlice()
+	if err != nil {
+		return err
+	}
+
+	c := newOrdinaryContinuation(s, st.cp)
+	if copyN > 0 {
+		if c.data.stack, err = st.stack.splitTop(copyN); err != nil {
+			return err
+		}
+		if err = st.consumeStackGas(c.data.stack); err != nil {
+			return err
+		}
+	}
+	c.data.numArgs = more
+	st.stack.push(c)
+	return nil
+}
+
+// returnArgs - leaves only top n values on the current stack, the rest are moved to c0
+func returnArgs(st *State, n int) error {
+	if err := st.stack.checkUnderflow(n); err != nil {
+		return err
+	}
+	depth := st.stack.Len()
+	if depth == n {
+		return nil
+	}
+	cpy := depth - n
+
+	top, err := st.stack.splitTop(n)
+	if err != nil {
+		return err
+	}
+	rest := st.stack
+	st.stack = top
+
+	c0, data := forceControlData(st.reg.c[0])
+	if data.numArgs >= 0 && data.numArgs < cpy {
+		return vmError(ErrCodeStackOverflow, "too many arguments copied into a closure continuation")
+	}
+	if data.stack == nil {
+		data.stack = rest
+	} else if err = data.stack.moveFrom(rest, cpy); err != nil {
+		return err
+	}
+	if err = st.consumeStackGas(data.stack); err != nil {
+		return err
+	}
+	if data.numArgs >= 0 {
+		data.numArgs -= cpy
+	}
+	st.reg.c[0] = c0
+	return nil
+}
+
+func setSavedCtr(st *State, target, idx int) error {
+	v, err := st.stack.pop()
+	if err != nil {
+		return err
+	}
+	c, data := forceControlData(st.reg.c[target])
+	if err = data.save.define(idx, v); err != nil {
+		return err
+	}
+	st.reg.c[target] = c
+	return nil
+}
+
+func saveCtr(st *State, idx int, toC0, toC1 bool) error {
+	val := st.reg.get(idx)
+	for target, need := range []bool{toC0, toC1} {
+		if !need {
+			continue
+		}
+		c, data := forceControlData(st.reg.c[target])
+		if !data.save.isSet(idx) && val != nil {
+			_ = data.save.set(idx, val)
+		}
+		st.reg.c[target] = c
+	}
+	return nil
+}
+
+func compos(st *State, c0, c1 bool) error {
+	next, err := st.stack.popCont()
+	if err != nil {
+		return err
+	}
+	c, err := st.stack.popCont()
+	if err != nil {
+		return err
+	}
+	c, data := forceControlData(c)
+	if c0 {
+		defineCont(&data.save, 0, next)
+	}
+	if c1 {
+		defineCont(&data.save, 1, next)
+	}
+	st.stack.push(c)
+	return nil
+}
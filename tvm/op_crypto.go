@@ -0,0 +1,212 @@
+package tvm
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"math/big"
+
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+func init() {
+	registerOp("HASHCU", "F900", 0, func(st *State, _ uint32) error {
+		c, err := st.stack.popCell()
+		if err != nil {
+			return err
+		}
+		st.stack.push(new(big.Int).SetBytes(c.Hash()))
+		return nil
+	})
+	registerOp("HASHSU", "F901", 0, func(st *State, _ uint32) error {
+		s, err := st.stack.popSlice()
+		if err != nil {
+			return err
+		}
+		st.stack.push(new(big.Int).SetBytes(sliceToBuilder(s).EndCell().Hash()))
+		return nil
+	})
+	registerOp("SHA256U", "F902", 0, func(st *State, _ uint32) error {
+		s, err := st.stack.popSlice()
+		if err != nil {
+			return err
+		}
+		data, err := sliceBytes(s)
+		if err != nil {
+			return err
+		}
+		hash := sha256.Sum256(data)
+		st.stack.push(new(big.Int).SetBytes(hash[:]))
+		return nil
+	})
+
+	registerOp("CHKSIGNU", "F910", 0, func(st *State, _ uint32) error {
+		key, sig, err := popSignature(st)
+		if err != nil {
+			return err
+		}
+		hash, err := st.stack.popInt()
+		if err != nil {
+			return err
+		}
+		if hash.Sign() < 0 || hash.BitLen() > 256 {
+			return errRangeCheck
+		}
+		return checkSignature(st, hash.FillBytes(make([]byte, 32)), sig, key)
+	})
+	registerOp("CHKSIGNS", "F911", 0, func(st *State, _ uint32) error {
+		key, sig, err := popSignature(st)
+		if err != nil {
+			return err
+		}
+		s, err := st.stack.popSlice()
+		if err != nil {
+			return err
+		}
+		data, err := sliceBytes(s)
+		if err != nil {
+			return err
+		}
+		return checkSignature(st, data, sig, key)
+	})
+
+	registerOp("CDATASIZEQ", "F940", 0, func(st *State, _ uint32) error {
+		return dataSizeOp(st, false, true)
+	})
+	registerOp("CDATASIZE", "F941", 0, func(st *State, _ uint32) error {
+		return dataSizeOp(st, false, false)
+	})
+	registerOp("SDATASIZEQ", "F942", 0, func(st *State, _ uint32) error {
+		return dataSizeOp(st, true, true)
+	})
+	registerOp("SDATASIZE", "F943", 0, func(st *State, _ uint32) error {
+		return dataSizeOp(st, true, false)
+	})
+}
+
+// sliceBytes - returns slice data, its length must be divisible by 8
+func sliceBytes(s *cell.Slice) ([]byte, error) {
+	if s.BitsLeft()%8 != 0 {
+		return nil, vmError(ErrCodeCellUnderflow, "slice does not consist of an integer number of bytes")
+	}
+	data, err := s.Copy().LoadSlice(s.BitsLeft())
+	if err != nil {
+		return nil, errCellUnderflow
+	}
+	return data, nil
+}
+
+func popSignature(st *State) (ed25519.PublicKey, []byte, error) {
+	key, err := st.stack.popInt()
+	if err != nil {
+		return nil, nil, err
+	}
+	if key.Sign() < 0 || key.BitLen() > 256 {
+		return nil, nil, errRangeCheck
+	}
+
+	s, err := st.stack.popSlice()
+	if err != nil {
+		return nil, nil, err
+	}
+	sig, err := s.Copy().LoadSlice(ed25519.SignatureSize * 8)
+	if err != nil {
+		return nil, nil, vmError(ErrCodeCellUnderflow, "ed25519 signature must contain at least 512 data bits")
+	}
+	return key.FillBytes(make([]byte, ed25519.PublicKeySize)), sig, nil
+}
+
+func checkSignature(st *State, data, sig []byte, key ed25519.PublicKey) error {
+	st.chkSigns++
+	if st.chkSigns > gasChkSignFree {
+		if err := st.consumeGas(gasChkSignAfterFree); err != nil {
+			return err
+		}
+	}
+	st.stack.pushBool(ed25519.Verify(key, data, sig))
+	return nil
+}
+
+// dataSizeOp - counts unique cells, bits and refs of the tree, cells limit is taken from the stack
+func dataSizeOp(st *State, isSlice, quiet bool) error {
+	bound, err := st.stack.popInt()
+	if err != nil {
+		return err
+	}
+	if bound.Sign() < 0 {
+		return errRangeCheck
+	}
+	limit := uint64(1<<63 - 1)
+	if bound.IsUint64() && bound.Uint64() < limit {
+		limit = bound.Uint64()
+	}
+
+	var roots []*cell.Cell
+	var cells, bitsNum, refsNum uint64
+	if isSlice {
+		s, err := st.stack.popSlice()
+		if err != nil {
+			return err
+		}
+		bitsNum, refsNum = uint64(s.BitsLeft()), uint64(s.RefsNum())
+		roots = sliceRefs(s)
+	} else {
+		c, err := st.stack.popMaybeCell()
+		if err != nil {
+			return err
+		}
+		if c != nil {
+			roots = []*cell.Cell{c}
+		}
+	}
+
+	visited := map[string]bool{}
+	var visit func(c *cell.Cell) (bool, error)
+	visit = func(c *cell.Cell) (bool, error) {
+		key := string(c.Hash())
+		if visited[key] {
+			return true, nil
+		}
+		visited[key] = true
+
+		if cells >= limit {
+			return false, nil
+		}
+		cells++
+
+		if err := st.registerCellLoad(c); err != nil {
+			return false, err
+		}
+		bitsNum += uint64(c.BitsSize())
+		refsNum += uint64(c.RefsNum())
+
+		for i := 0; i < int(c.RefsNum()); i++ {
+			ok, err := visit(c.MustPeekRef(i))
+			if err != nil || !ok {
+				return ok, err
+			}
+		}
+		return true, nil
+	}
+
+	for _, r := range roots {
+		ok, err := visit(r)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			if !quiet {
+				return vmError(ErrCodeCellOverflow, "scanned too many cells")
+			}
+			st.stack.pushBool(false)
+			return nil
+		}
+	}
+
+	st.stack.push(new(big.Int).SetUint64(cells))
+	st.stack.push(new(big.Int).SetUint64(bitsNum))
+	st.stack.push(new(big.Int).SetUint64(refsNum))
+	if quiet {
+		st.stack.pushBool(true)
+	}
+	return nil
+}
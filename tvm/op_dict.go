@@ -0,0 +1,1156 @@
+package tvm
+
+import (
+	"bytes"
+	"math/big"
+	"math/bits"
+
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+type dictKeyType int
+
+const (
+	dictKeySlice dictKeyType = iota
+	dictKeySigned
+	dictKeyUnsigned
+)
+
+type dictSetMode int
+
+const (
+	dictModeSet dictSetMode = iota
+	dictModeReplace
+	dictModeAdd
+)
+
+func init() {
+	registerOp("STDICT", "F400", 0, func(st *State, _ uint32) error {
+		b, err := st.stack.popBuilder()
+		if err != nil {
+			return err
+		}
+		d, err := st.stack.popMaybeCell()
+		if err != nil {
+			return err
+		}
+		if d == nil {
+			if b.BitsLeft() < 1 {
+				return errCellOverflow
+			}
+			_ = b.StoreUInt(0, 1)
+		} else {
+			if b.BitsLeft() < 1 || b.RefsLeft() < 1 {
+				return errCellOverflow
+			}
+			_ = b.StoreUInt(1, 1)
+			_ = b.StoreRef(d)
+		}
+		st.stack.push(b)
+		return nil
+	})
+	registerOp("SKIPDICT", "F401", 0, func(st *State, _ uint32) error {
+		s, err := st.stack.popSlice()
+		if err != nil {
+			return err
+		}
+		if _, err = loadMaybeRef(s); err != nil {
+			return err
+		}
+		st.stack.push(s)
+		return nil
+	})
+	registerOp("LDDICTS", "F402", 0, func(st *State, _ uint32) error {
+		return loadDictSlice(st, false)
+	})
+	registerOp("PLDDICTS", "F403", 0, func(st *State, _ uint32) error {
+		return loadDictSlice(st, true)
+	})
+	registerOp("LDDICT", "F406_", 2, func(st *State, args uint32) error {
+		preload, quiet := args&1 != 0, args&2 != 0
+		s, err := st.stack.popSlice()
+		if err != nil {
+			return err
+		}
+		d, err := loadMaybeRef(s)
+		if err != nil {
+			if !quiet {
+				return err
+			}
+			if !preload {
+				st.stack.push(s)
+			}
+			st.stack.pushBool(false)
+			return nil
+		}
+
+		pushMaybeCell(st, d)
+		if !preload {
+			st.stack.push(s)
+		}
+		if quiet {
+			st.stack.pushBool(true)
+		}
+		return nil
+	})
+
+	for i := 0; i < 6; i++ {
+		kt, ref := dictKeyType(i/2), i%2 == 1
+		registerOp("DICTGET", hexOp(0xF40A+i), 0, func(st *State, _ uint32) error {
+			return dictGetOp(st, kt, ref)
+		})
+		registerOp("DICTSET", hexOp(0xF412+i), 0, func(st *State, _ uint32) error {
+			return dictSetOp(st, kt, dictValueKind(ref), dictModeSet, false)
+		})
+		registerOp("DICTSETGET", hexOp(0xF41A+i), 0, func(st *State, _ uint32) error {
+			return dictSetOp(st, kt, dictValueKind(ref), dictModeSet, true)
+		})
+		registerOp("DICTREPLACE", hexOp(0xF422+i), 0, func(st *State, _ uint32) error {
+			return dictSetOp(st, kt, dictValueKind(ref), dictModeReplace, false)
+		})
+		registerOp("DICTREPLACEGET", hexOp(0xF42A+i), 0, func(st *State, _ uint32) error {
+			return dictSetOp(st, kt, dictValueKind(ref), dictModeReplace, true)
+		})
+		registerOp("DICTADD", hexOp(0xF432+i), 0, func(st *State, _ uint32) error {
+			return dictSetOp(st, kt, dictValueKind(ref), dictModeAdd, false)
+		})
+		registerOp("DICTADDGET", hexOp(0xF43A+i), 0, func(st *State, _ uint32) error {
+			return dictSetOp(st, kt, dictValueKind(ref), dictModeAdd, true)
+		})
+		registerOp("DICTDELGET", hexOp(0xF462+i), 0, func(st *State, _ uint32) error {
+			return dictDeleteOp(st, kt, ref, true)
+		})
+		registerOp("DICTMIN", hexOp(0xF482+i), 0, func(st *State, _ uint32) error {
+			return dictMinMaxOp(st, kt, ref, false, false)
+		})
+		registerOp("DICTMAX", hexOp(0xF48A+i), 0, func(st *State, _ uint32) error {
+			return dictMinMaxOp(st, kt, ref, true, false)
+		})
+		registerOp("DICTREMMIN", hexOp(0xF492+i), 0, func(st *State, _ uint32) error {
+			return dictMinMaxOp(st, kt, ref, false, true)
+		})
+		registerOp("DICTREMMAX", hexOp(0xF49A+i), 0, func(st *State, _ uint32) error {
+			return dictMinMaxOp(st, kt, ref, true, true)
+		})
+	}
+
+	for i := 0; i < 3; i++ {
+		kt := dictKeyType(i)
+		registerOp("DICTSETB", hexOp(0xF441+i), 0, func(st *State, _ uint32) error {
+			return dictSetOp(st, kt, dictValueBuilder, dictModeSet, false)
+		})
+		registerOp("DICTSETGETB", hexOp(0xF445+i), 0, func(st *State, _ uint32) error {
+			return dictSetOp(st, kt, dictValueBuilder, dictModeSet, true)
+		})
+		registerOp("DICTREPLACEB", hexOp(0xF449+i), 0, func(st *State, _ uint32) error {
+			return dictSetOp(st, kt, dictValueBuilder, dictModeReplace, false)
+		})
+		registerOp("DICTREPLACEGETB", hexOp(0xF44D+i), 0, func(st *State, _ uint32) error {
+			return dictSetOp(st, kt, dictValueBuilder, dictModeReplace, true)
+		})
+		registerOp("DICTADDB", hexOp(0xF451+i), 0, func(st *State, _ uint32) error {
+			return dictSetOp(st, kt, dictValueBuilder, dictModeAdd, false)
+		})
+		registerOp("DICTADDGETB", hexOp(0xF455+i), 0, func(st *State, _ uint32) error {
+			return dictSetOp(st, kt, dictValueBuilder, dictModeAdd, true)
+		})
+		registerOp("DICTDEL", hexOp(0xF459+i), 0, func(st *State, _ uint32) error {
+			return dictDeleteOp(st, kt, false, false)
+		})
+		registerOp("DICTGETOPTREF", hexOp(0xF469+i), 0, func(st *State, _ uint32) error {
+			return dictGetOptRefOp(st, kt)
+		})
+		registerOp("DICTSETGETOPTREF", hexOp(0xF46D+i), 0, func(st *State, _ uint32) error {
+			return dictSetGetOptRefOp(st, kt)
+		})
+		for j := 0; j < 4; j++ {
+			prev, eq := j&2 != 0, j&1 != 0
+			registerOp("DICTGETNEAR", hexOp(0xF474+i*4+j), 0, func(st *State, _ uint32) error {
+				return dictNearOp(st, kt, prev, eq)
+			})
+		}
+	}
+
+	for i := 0; i < 4; i++ {
+		kt := dictKeySigned + dictKeyType(i%2)
+		exec := i >= 2
+		registerOp("DICTGETJMP", hexOp(0xF4A0+i), 0, func(st *State, _ uint32) error {
+			return dictGetJumpOp(st, kt, exec, false)
+		})
+		registerOp("DICTGETJMPZ", hexOp(0xF4BC+i), 0, func(st *State, _ uint32) error {
+			return dictGetJumpOp(st, kt, exec, true)
+		})
+	}
+
+	registerOp("DICTPUSHCONST", "F4A6_", 10, func(st *State, args uint32) error {
+		ref, err := st.loadCodeRef()
+		if err != nil {
+			return err
+		}
+		st.stack.push(ref)
+		st.stack.pushSmall(int64(args))
+		return nil
+	})
+}
+
+type dictValueType int
+
+const (
+	dictValueSlice dictValueType = iota
+	dictValueRef
+	dictValueBuilder
+)
+
+func dictValueKind(ref bool) dictValueType {
+	if ref {
+		return dictValueRef
+	}
+	return dictValueSlice
+}
+
+func hexOp(v int) string {
+	const digits = "0123456789ABCDEF"
+	return string([]byte{digits[v>>12&15], digits[v>>8&15], digits[v>>4&15], digits[v&15]})
+}
+
+func loadMaybeRef(s *cell.Slice) (*cell.Cell, error) {
+	bit, err := s.LoadUInt(1)
+	if err != nil {
+		return nil, errCellUnderflow
+	}
+	if bit == 0 {
+		return nil, nil
+	}
+	ref, err := s.LoadRefCell()
+	if err != nil {
+		return nil, errCellUnderflow
+	}
+	return ref, nil
+}
+
+func pushMaybeCell(st *State, c *cell.Cell) {
+	if c == nil {
+		st.stack.push(nil)
+		return
+	}
+	st.stack.push(c)
+}
+
+func loadDictSlice(st *State, preload bool) error {
+	s, err := st.stack.popSlice()
+	if err != nil {
+		return err
+	}
+
+	refs := 0
+	bit := sliceBits(s)
+	if len(bit) == 0 {
+		return errCellUnderflow
+	}
+	if bit[0] == 1 {
+		refs = 1
+	}
+
+	d, err := subSlice(s, 0, 0, 1, refs)
+	if err != nil {
+		return err
+	}
+	st.stack.push(d)
+	if !preload {
+		rest, err := subSlice(s, 1, refs, -1, -1)
+		if err != nil {
+			return err
+		}
+		st.stack.push(rest)
+	}
+	return nil
+}
+
+// popDictKey - pops key of the given type, fits is false when integer key cannot be represented with n bits
+func popDictKey(st *State, kt dictKeyType, n int) (key []byte, intKey *big.Int, fits bool, err error) {
+	if kt == dictKeySlice {
+		s, err := st.stack.popSlice()
+		if err != nil {
+			return nil, nil, false, err
+		}
+		if int(s.BitsLeft()) < n {
+			return nil, nil, false, errCellUnderflow
+		}
+		return sliceBits(s)[:n], nil, true, nil
+	}
+
+	x, err := st.stack.popInt()
+	if err != nil {
+		return nil, nil, false, err
+	}
+	if !fitsBits(x, uint(n), kt == dictKeySigned) {
+		return nil, x, false, nil
+	}
+	return intToBits(x, n), x, true, nil
+}
+
+func pushDictKey(st *State, kt dictKeyType, key []byte) error {
+	if kt == dictKeySlice {
+		s, err := newSlice(key, nil)
+		if err != nil {
+			return err
+		}
+		st.stack.push(s)
+		return nil
+	}
+	st.stack.push(bitsToInt(key, kt == dictKeySigned))
+	return nil
+}
+
+// intToBits - converts integer to n bits of two's complement representation
+func intToBits(x *big.Int, n int) []byte {
+	res := make([]byte, n)
+	for i := 0; i < n; i++ {
+		res[i] = byte(x.Bit(n - 1 - i))
+	}
+	return res
+}
+
+func bitsToInt(b []byte, signed bool) *big.Int {
+	x := new(big.Int)
+	for _, v := range b {
+		x.Lsh(x, 1)
+		if v != 0 {
+			x.SetBit(x, 0, 1)
+		}
+	}
+	if signed && len(b) > 0 && b[0] == 1 {
+		x.Sub(x, new(big.Int).Lsh(big.NewInt(1), uint(len(b))))
+	}
+	return x
+}
+
+// popDictValue - pops value which will be stored into dictionary and returns it as a builder
+func popDictValue(st *State, vt dictValueType) (*cell.Builder, any, error) {
+	switch vt {
+	case dictValueRef:
+		c, err := st.stack.popCell()
+		if err != nil {
+			return nil, nil, err
+		}
+		return cell.BeginCell().MustStoreRef(c), c, nil
+	case dictValueBuilder:
+		b, err := st.stack.popBuilder()
+		if err != nil {
+			return nil, nil, err
+		}
+		return b, b, nil
+	}
+
+	s, err := st.stack.popSlice()
+	if err != nil {
+		return nil, nil, err
+	}
+	b := cell.BeginCell()
+	if err = storeSliceToBuilder(b, s); err != nil {
+		return nil, nil, err
+	}
+	return b, s, nil
+}
+
+// pushDictValue - pushes value as slice or as a cell reference for ref variants
+func pushDictValue(st *State, v *cell.Slice, ref bool) error {
+	if !ref {
+		st.stack.push(v)
+		return nil
+	}
+	c, err := refValue(v)
+	if err != nil {
+		return err
+	}
+	st.stack.push(c)
+	return nil
+}
+
+func refValue(v *cell.Slice) (*cell.Cell, error) {
+	if v.BitsLeft() != 0 || v.RefsNum() != 1 {
+		return nil, vmError(ErrCodeDictionary, "dictionary value is not a single reference")
+	}
+	c, err := v.Copy().LoadRefCell()
+	if err != nil {
+		return nil, errDict
+	}
+	return c, nil
+}
+
+func dictGetOp(st *State, kt dictKeyType, ref bool) error {
+	n, err := st.stack.popSmall(1023)
+	if err != nil {
+		return err
+	}
+	root, err := st.stack.popMaybeCell()
+	if err != nil {
+		return err
+	}
+	key, _, fits, err := popDictKey(st, kt, n)
+	if err != nil {
+		return err
+	}
+	if !fits {
+		st.stack.pushBool(false)
+		return nil
+	}
+
+	v, err := dictLookup(st, root, key)
+	if err != nil {
+		return err
+	}
+	if v == nil {
+		st.stack.pushBool(false)
+		return nil
+	}
+	if err = pushDictValue(st, v, ref); err != nil {
+		return err
+	}
+	st.stack.pushBool(true)
+	return nil
+}
+
+func dictSetOp(st *State, kt dictKeyType, vt dictValueType, mode dictSetMode, getOld bool) error {
+	n, err := st.stack.popSmall(1023)
+	if err != nil {
+		return err
+	}
+	root, err := st.stack.popMaybeCell()
+	if err != nil {
+		return err
+	}
+	key, _, fits, err := popDictKey(st, kt, n)
+	if err != nil {
+		return err
+	}
+	value, _, err := popDictValue(st, vt)
+	if err != nil {
+		return err
+	}
+	if !fits {
+		return vmError(ErrCodeRangeCheck, "not enough bits for a dictionary key")
+	}
+
+	newRoot, old, err := dictSet(st, root, key, value, mode)
+	if err != nil {
+		return err
+	}
+	pushMaybeCell(st, newRoot)
+
+	ref := vt == dictValueRef
+	switch mode {
+	case dictModeSet:
+		if !getOld {
+			return nil
+		}
+		if old == nil {
+			st.stack.pushBool(false)
+			return nil
+		}
+		if err = pushDictValue(st, old, ref); err != nil {
+			return err
+		}
+		st.stack.pushBool(true)
+	case dictModeReplace:
+		if old != nil && getOld {
+			if err = pushDictValue(st, old, ref); err != nil {
+				return err
+			}
+		}
+		st.stack.pushBool(old != nil)
+	case dictModeAdd:
+		if old != nil && getOld {
+			if err = pushDictValue(st, old, ref); err != nil {
+				return err
+			}
+		}
+		st.stack.pushBool(old == nil)
+	}
+	return nil
+}
+
+func dictDeleteOp(st *State, kt dictKeyType, ref, getOld bool) error {
+	n, err := st.stack.popSmall(1023)
+	if err != nil {
+		return err
+	}
+	root, err := st.stack.popMaybeCell()
+	if err != nil {
+		return err
+	}
+	key, _, fits, err := popDictKey(st, kt, n)
+	if err != nil {
+		return err
+	}
+	if !fits {
+		pushMaybeCell(st, root)
+		st.stack.pushBool(false)
+		return nil
+	}
+
+	newRoot, old, err := dictDelete(st, root, key)
+	if err != nil {
+		return err
+	}
+	pushMaybeCell(st, newRoot)
+	if old != nil && getOld {
+		if err = pushDictValue(st, old, ref); err != nil {
+			return err
+		}
+	}
+	st.stack.pushBool(old != nil)
+	return nil
+}
+
+func dictGetOptRefOp(st *State, kt dictKeyType) error {
+	n, err := st.stack.popSmall(1023)
+	if err != nil {
+		return err
+	}
+	root, err := st.stack.popMaybeCell()
+	if err != nil {
+		return err
+	}
+	key, _, fits, err := popDictKey(st, kt, n)
+	if err != nil {
+		return err
+	}
+	if !fits {
+		st.stack.push(nil)
+		return nil
+	}
+
+	v, err := dictLookup(st, root, key)
+	if err != nil {
+		return err
+	}
+	if v == nil {
+		st.stack.push(nil)
+		return nil
+	}
+	return pushDictValue(st, v, true)
+}
+
+func dictSetGetOptRefOp(st *State, kt dictKeyType) error {
+	n, err := st.stack.popSmall(1023)
+	if err != nil {
+		return err
+	}
+	root, err := st.stack.popMaybeCell()
+	if err != nil {
+		return err
+	}
+	key, _, fits, err := popDictKey(st, kt, n)
+	if err != nil {
+		return err
+	}
+	value, err := st.stack.popMaybeCell()
+	if err != nil {
+		return err
+	}
+	if !fits {
+		return vmError(ErrCodeRangeCheck, "not enough bits for a dictionary key")
+	}
+
+	var newRoot *cell.Cell
+	var old *cell.Slice
+	if value == nil {
+		newRoot, old, err = dictDelete(st, root, key)
+	} else {
+		newRoot, old, err = dictSet(st, root, key, cell.BeginCell().MustStoreRef(value), dictModeSet)
+	}
+	if err != nil {
+		return err
+	}
+
+	pushMaybeCell(st, newRoot)
+	if old == nil {
+		st.stack.push(nil)
+		return nil
+	}
+	return pushDictValue(st, old, true)
+}
+
+func dictMinMaxOp(st *State, kt dictKeyType, ref, max, remove bool) error {
+	n, err := st.stack.popSmall(1023)
+	if err != nil {
+		return err
+	}
+	root, err := st.stack.popMaybeCell()
+	if err != nil {
+		return err
+	}
+
+	if root == nil {
+		if remove {
+			st.stack.push(nil)
+		}
+		st.stack.pushBool(false)
+		return nil
+	}
+
+	key, v, err := dictMinMax(st, root, n, 0, max, kt == dictKeySigned)
+	if err != nil {
+		return err
+	}
+
+	if remove {
+		newRoot, _, err := dictDelete(st, root, key)
+		if err != nil {
+			return err
+		}
+		pushMaybeCell(st, newRoot)
+	}
+	if err = pushDictValue(st, v, ref); err != nil {
+		return err
+	}
+	if err = pushDictKey(st, kt, key); err != nil {
+		return err
+	}
+	st.stack.pushBool(true)
+	return nil
+}
+
+func dictNearOp(st *State, kt dictKeyType, prev, eq bool) error {
+	n, err := st.stack.popSmall(1023)
+	if err != nil {
+		return err
+	}
+	root, err := st.stack.popMaybeCell()
+	if err != nil {
+		return err
+	}
+
+	var key []byte
+	if kt == dictKeySlice {
+		s, err := st.stack.popSlice()
+		if err != nil {
+			return err
+		}
+		if int(s.BitsLeft()) < n {
+			return errCellUnderflow
+		}
+		key = sliceBits(s)[:n]
+	} else {
+		x, err := st.stack.popInt()
+		if err != nil {
+			return err
+		}
+		signed := kt == dictKeySigned
+		if !fitsBits(x, uint(n), signed) {
+			// key is out of range, result is an edge element or nothing
+			if root == nil || (x.Sign() > 0) != prev {
+				st.stack.pushBool(false)
+				return nil
+			}
+			k, v, err := dictMinMax(st, root, n, 0, prev, signed)
+			if err != nil {
+				return err
+			}
+			st.stack.push(v)
+			if err = pushDictKey(st, kt, k); err != nil {
+				return err
+			}
+			st.stack.pushBool(true)
+			return nil
+		}
+		key = intToBits(x, n)
+	}
+
+	if root == nil {
+		st.stack.pushBool(false)
+		return nil
+	}
+
+	k, v, err := dictNearest(st, root, key, 0, prev, eq, kt == dictKeySigned)
+	if err != nil {
+		return err
+	}
+	if v == nil {
+		st.stack.pushBool(false)
+		return nil
+	}
+	st.stack.push(v)
+	if err = pushDictKey(st, kt, k); err != nil {
+		return err
+	}
+	st.stack.pushBool(true)
+	return nil
+}
+
+func dictGetJumpOp(st *State, kt dictKeyType, exec, pushBack bool) error {
+	n, err := st.stack.popSmall(1023)
+	if err != nil {
+		return err
+	}
+	root, err := st.stack.popMaybeCell()
+	if err != nil {
+		return err
+	}
+	x, err := st.stack.popInt()
+	if err != nil {
+		return err
+	}
+
+	var v *cell.Slice
+	if fitsBits(x, uint(n), kt == dictKeySigned) {
+		if v, err = dictLookup(st, root, intToBits(x, n)); err != nil {
+			return err
+		}
+	}
+
+	if v == nil {
+		if pushBack {
+			st.stack.push(x)
+		}
+		return nil
+	}
+
+	c := newOrdinaryContinuation(v, st.cp)
+	if exec {
+		return st.call(c)
+	}
+	return st.jump(c)
+}
+
+// parseDictNode - loads dictionary node with gas, returns its label and the rest of the node
+func parseDictNode(st *State, c *cell.Cell, m int) ([]byte, *cell.Slice, error) {
+	s, err := st.loadCell(c)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	label, err := loadDictLabel(s, m)
+	if err != nil {
+		return nil, nil, err
+	}
+	return label, s, nil
+}
+
+func loadDictLabel(s *cell.Slice, m int) ([]byte, error) {
+	first, err := s.LoadUInt(1)
+	if err != nil {
+		return nil, errDict
+	}
+
+	if first == 0 {
+		// hml_short$0
+		ln := 0
+		for {
+			bit, err := s.LoadUInt(1)
+			if err != nil {
+				return nil, errDict
+			}
+			if bit == 0 {
+				break
+			}
+			ln++
+		}
+		if ln > m {
+			return nil, errDict
+		}
+		data, err := s.LoadSlice(uint(ln))
+		if err != nil {
+			return nil, errDict
+		}
+		return unpackBits(data, uint(ln)), nil
+	}
+
+	second, err := s.LoadUInt(1)
+	if err != nil {
+		return nil, errDict
+	}
+	lenBits := uint(bits.Len(uint(m)))
+
+	if second == 0 {
+		// hml_long$10
+		ln, err := s.LoadUInt(lenBits)
+		if err != nil || int(ln) > m {
+			return nil, errDict
+		}
+		data, err := s.LoadSlice(uint(ln))
+		if err != nil {
+			return nil, errDict
+		}
+		return unpackBits(data, uint(ln)), nil
+	}
+
+	// hml_same$11
+	v, err := s.LoadUInt(1)
+	if err != nil {
+		return nil, errDict
+	}
+	ln, err := s.LoadUInt(lenBits)
+	if err != nil || int(ln) > m {
+		return nil, errDict
+	}
+	return bytes.Repeat([]byte{byte(v)}, int(ln)), nil
+}
+
+// storeDictLabel - stores label using the shortest form, the same way as node does
+func storeDictLabel(b *cell.Builder, label []byte, m int) error {
+	ln := len(label)
+	lenBits := bits.Len(uint(m))
+
+	var enc []byte
+	longLen := 2 + lenBits + ln
+	shortLen := 2 + 2*ln
+	sameLen := 3 + lenBits
+
+	isSame := ln > 0 && bytes.Count(label, label[:1]) == ln
+	switch {
+	case ln == 0:
+		enc = []byte{0, 0}
+	case isSame && sameLen < longLen && sameLen < shortLen:
+		enc = append([]byte{1, 1, label[0]}, intToBits(big.NewInt(int64(ln)), lenBits)...)
+	case shortLen <= longLen:
+		enc = append([]byte{0}, bytes.Repeat([]byte{1}, ln)...)
+		enc = append(enc, 0)
+		enc = append(enc, label...)
+	default:
+		enc = append([]byte{1, 0}, intToBits(big.NewInt(int64(ln)), lenBits)...)
+		enc = append(enc, label...)
+	}
+
+	if err := b.StoreSlice(packBits(enc), uint(len(enc))); err != nil {
+		return errCellOverflow
+	}
+	return nil
+}
+
+// makeDictNode - creates node cell with label and content, m is a remaining key length
+func makeDictNode(st *State, label []byte, m int, content *cell.Builder) (*cell.Cell, error) {
+	b := cell.BeginCell()
+	if err := storeDictLabel(b, label, m); err != nil {
+		return nil, err
+	}
+	if b.BitsLeft() < content.BitsUsed() || b.RefsLeft() < uint(content.RefsUsed()) {
+		return nil, errCellOverflow
+	}
+	if err := b.StoreBuilder(content); err != nil {
+		return nil, errCellOverflow
+	}
+	if err := st.registerCellCreate(); err != nil {
+		return nil, err
+	}
+	return b.EndCell(), nil
+}
+
+func sliceToBuilder(s *cell.Slice) *cell.Builder {
+	b := cell.BeginCell()
+	_ = storeSliceToBuilder(b, s)
+	return b
+}
+
+func forkContent(left, right *cell.Cell) *cell.Builder {
+	return cell.BeginCell().MustStoreRef(left).MustStoreRef(right)
+}
+
+func forkChildren(rest *cell.Slice) (*cell.Cell, *cell.Cell, error) {
+	refs := sliceRefs(rest)
+	if len(refs) != 2 {
+		return nil, nil, errDict
+	}
+	return refs[0], refs[1], nil
+}
+
+func dictLookup(st *State, root *cell.Cell, key []byte) (*cell.Slice, error) {
+	c := root
+	for c != nil {
+		label, rest, err := parseDictNode(st, c, len(key))
+		if err != nil {
+			return nil, err
+		}
+		if !bytes.HasPrefix(key, label) {
+			return nil, nil
+		}
+
+		key = key[len(label):]
+		if len(key) == 0 {
+			return rest, nil
+		}
+
+		left, right, err := forkChildren(rest)
+		if err != nil {
+			return nil, err
+		}
+		if key[0] == 0 {
+			c = left
+		} else {
+			c = right
+		}
+		key = key[1:]
+	}
+	return nil, nil
+}
+
+// dictSet - sets value by key depending on mode, returns new root and previous value
+func dictSet(st *State, root *cell.Cell, key []byte, value *cell.Builder, mode dictSetMode) (*cell.Cell, *cell.Slice, error) {
+	if root == nil {
+		if mode == dictModeReplace {
+			return nil, nil, nil
+		}
+		c, err := makeDictNode(st, key, len(key), value)
+		if err != nil {
+			return nil, nil, err
+		}
+		return c, nil, nil
+	}
+	return dictSetNode(st, root, key, value, mode)
+}
+
+func dictSetNode(st *State, c *cell.Cell, key []byte, value *cell.Builder, mode dictSetMode) (*cell.Cell, *cell.Slice, error) {
+	m := len(key)
+	label, rest, err := parseDictNode(st, c, m)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	p := 0
+	for p < len(label) && label[p] == key[p] {
+		p++
+	}
+
+	if p < len(label) {
+		// key is not in the dictionary, split node
+		if mode == dictModeReplace {
+			return c, nil, nil
+		}
+
+		existing, err := makeDictNode(st, label[p+1:], m-p-1, sliceToBuilder(rest))
+		if err != nil {
+			return nil, nil, err
+		}
+		leaf, err := makeDictNode(st, key[p+1:], m-p-1, value)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		left, right := existing, leaf
+		if key[p] == 0 {
+			left, right = leaf, existing
+		}
+		fork, err := makeDictNode(st, label[:p], m, forkContent(left, right))
+		if err != nil {
+			return nil, nil, err
+		}
+		return fork, nil, nil
+	}
+
+	if len(label) == m {
+		// leaf with the same key
+		if mode == dictModeAdd {
+			return c, rest, nil
+		}
+		leaf, err := makeDictNode(st, label, m, value)
+		if err != nil {
+			return nil, nil, err
+		}
+		return leaf, rest, nil
+	}
+
+	left, right, err := forkChildren(rest)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	bit := key[len(label)]
+	child := left
+	if bit == 1 {
+		child = right
+	}
+
+	newChild, old, err := dictSetNode(st, child, key[len(label)+1:], value, mode)
+	if err != nil {
+		return nil, nil, err
+	}
+	if newChild == child {
+		return c, old, nil
+	}
+
+	if bit == 0 {
+		left = newChild
+	} else {
+		right = newChild
+	}
+	fork, err := makeDictNode(st, label, m, forkContent(left, right))
+	if err != nil {
+		return nil, nil, err
+	}
+	return fork, old, nil
+}
+
+// dictDelete - removes key from dictionary, returns new root (nil when empty) and removed value
+func dictDelete(st *State, root *cell.Cell, key []byte) (*cell.Cell, *cell.Slice, error) {
+	if root == nil {
+		return nil, nil, nil
+	}
+
+	m := len(key)
+	label, rest, err := parseDictNode(st, root, m)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !bytes.HasPrefix(key, label) {
+		return root, nil, nil
+	}
+	if len(label) == m {
+		return nil, rest, nil
+	}
+
+	left, right, err := forkChildren(rest)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	bit := key[len(label)]
+	child, other := left, right
+	if bit == 1 {
+		child, other = right, left
+	}
+
+	newChild, old, err := dictDelete(st, child, key[len(label)+1:])
+	if err != nil {
+		return nil, nil, err
+	}
+	if old == nil {
+		return root, nil, nil
+	}
+
+	if newChild == nil {
+		// fork is not needed anymore, merge it with the other child
+		subM := m - len(label) - 1
+		otherLabel, otherRest, err := parseDictNode(st, other, subM)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		merged := append(append(append([]byte{}, label...), 1-bit), otherLabel...)
+		node, err := makeDictNode(st, merged, m, sliceToBuilder(otherRest))
+		if err != nil {
+			return nil, nil, err
+		}
+		return node, old, nil
+	}
+
+	if bit == 0 {
+		left = newChild
+	} else {
+		right = newChild
+	}
+	node, err := makeDictNode(st, label, m, forkContent(left, right))
+	if err != nil {
+		return nil, nil, err
+	}
+	return node, old, nil
+}
+
+// childOrder - returns the first child index for ascending order at the given key position,
+// for signed keys the highest bit is inverted
+func childOrder(pos int, signed bool) byte {
+	if signed && pos == 0 {
+		return 1
+	}
+	return 0
+}
+
+// dictMinMax - finds minimal or maximal key in the subtree, pos is an index of the first key bit of the node
+func dictMinMax(st *State, c *cell.Cell, m, pos int, max, signed bool) ([]byte, *cell.Slice, error) {
+	var key []byte
+	for {
+		label, rest, err := parseDictNode(st, c, m)
+		if err != nil {
+			return nil, nil, err
+		}
+		key = append(key, label...)
+		m -= len(label)
+		pos += len(label)
+		if m == 0 {
+			return key, rest, nil
+		}
+
+		left, right, err := forkChildren(rest)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		bit := childOrder(pos, signed)
+		if max {
+			bit = 1 - bit
+		}
+		c = left
+		if bit == 1 {
+			c = right
+		}
+		key = append(key, bit)
+		m--
+		pos++
+	}
+}
+
+// dictNearest - finds the closest key after (or before when prev) the given one
+func dictNearest(st *State, c *cell.Cell, key []byte, pos int, prev, eq, signed bool) ([]byte, *cell.Slice, error) {
+	m := len(key)
+	label, rest, err := parseDictNode(st, c, m)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for i := range label {
+		if label[i] == key[i] {
+			continue
+		}
+
+		// whole subtree is greater or less than the key
+		greater := label[i] == 1
+		if childOrder(pos+i, signed) == 1 {
+			greater = !greater
+		}
+		if greater == prev {
+			return nil, nil, nil
+		}
+
+		k, v, err := dictMinMax(st, c, m, pos, prev, signed)
+		if err != nil {
+			return nil, nil, err
+		}
+		return k, v, nil
+	}
+
+	if len(label) == m {
+		if eq {
+			return label, rest, nil
+		}
+		return nil, nil, nil
+	}
+
+	left, right, err := forkChildren(rest)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	bit := key[len(label)]
+	forkPos := pos + len(label)
+	children := [2]*cell.Cell{left, right}
+
+	k, v, err := dictNearest(st, children[bit], key[len(label)+1:], forkPos+1, prev, eq, signed)
+	if err != nil {
+		return nil, nil, err
+	}
+	if v != nil {
+		return append(append(append([]byte{}, label...), bit), k...), v, nil
+	}
+
+	// try sibling in the needed direction
+	first := childOrder(forkPos, signed)
+	if (bit == first) == prev {
+		return nil, nil, nil
+	}
+	sibling := 1 - bit
+	k, v, err = dictMinMax(st, children[sibling], m-len(label)-1, forkPos+1, prev, signed)
+	if err != nil {
+		return nil, nil, err
+	}
+	return append(append(append([]byte{}, label...), sibling), k...), v, nil
+}
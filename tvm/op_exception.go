@@ -0,0 +1,138 @@
+package tvm
+
+func init() {
+	registerOp("THROW_SHORT", "F22_", 6, func(st *State, args uint32) error {
+		return throwError(int32(args))
+	})
+	registerOp("THROWIF_SHORT", "F26_", 6, func(st *State, args uint32) error {
+		return throwIf(st, int32(args), true)
+	})
+	registerOp("THROWIFNOT_SHORT", "F2A_", 6, func(st *State, args uint32) error {
+		return throwIf(st, int32(args), false)
+	})
+	registerOp("THROW", "F2C4_", 11, func(st *State, args uint32) error {
+		return throwError(int32(args))
+	})
+	registerOp("THROWARG", "F2CC_", 11, func(st *State, args uint32) error {
+		arg, err := st.stack.pop()
+		if err != nil {
+			return err
+		}
+		return throwErrorArg(int32(args), arg)
+	})
+	registerOp("THROWIF", "F2D4_", 11, func(st *State, args uint32) error {
+		return throwIf(st, int32(args), true)
+	})
+	registerOp("THROWARGIF", "F2DC_", 11, func(st *State, args uint32) error {
+		return throwArgIf(st, int32(args), true)
+	})
+	registerOp("THROWIFNOT", "F2E4_", 11, func(st *State, args uint32) error {
+		return throwIf(st, int32(args), false)
+	})
+	registerOp("THROWARGIFNOT", "F2EC_", 11, func(st *State, args uint32) error {
+		return throwArgIf(st, int32(args), false)
+	})
+	registerOpCheck("THROWANY", "F2F", 4, func(args uint32) bool {
+		return args < 6
+	}, func(st *State, args uint32) error {
+		hasArg, hasCond, expect := args&1 != 0, args&6 != 0, args&4 == 0
+
+		f := true
+		if hasCond {
+			v, err := st.stack.popBool()
+			if err != nil {
+				return err
+			}
+			f = v == expect
+		}
+
+		code, err := st.stack.popSmall(0xffff)
+		if err != nil {
+			return err
+		}
+
+		var arg any
+		if hasArg {
+			if arg, err = st.stack.pop(); err != nil {
+				return err
+			}
+		}
+
+		if !f {
+			return nil
+		}
+		if hasArg {
+			return throwErrorArg(int32(code), arg)
+		}
+		return throwError(int32(code))
+	})
+	registerOp("TRY", "F2FF", 0, func(st *State, _ uint32) error {
+		return try(st, -1, -1)
+	})
+	registerOp("TRYARGS", "F3", 8, func(st *State, args uint32) error {
+		return try(st, int(args>>4), int(args&15))
+	})
+}
+
+func throwError(code int32) error {
+	return VMError{Code: code, Msg: "thrown by contract"}
+}
+
+func throwErrorArg(code int32, arg any) error {
+	return VMError{Code: code, Arg: arg, Msg: "thrown by contract", hasArg: true}
+}
+
+func throwIf(st *State, code int32, expect bool) error {
+	f, err := st.stack.popBool()
+	if err != nil {
+		return err
+	}
+	if f == expect {
+		return throwError(code)
+	}
+	return nil
+}
+
+func throwArgIf(st *State, code int32, expect bool) error {
+	f, err := st.stack.popBool()
+	if err != nil {
+		return err
+	}
+	arg, err := st.stack.pop()
+	if err != nil {
+		return err
+	}
+	if f == expect {
+		return throwErrorArg(code, arg)
+	}
+	return nil
+}
+
+// try - executes continuation with the exception handler, handler is called with the saved context
+func try(st *State, pass, ret int) error {
+	if err := st.stack.checkUnderflow(max(pass, 0) + 2); err != nil {
+		return err
+	}
+	handler, err := st.stack.popCont()
+	if err != nil {
+		return err
+	}
+	body, err := st.stack.popCont()
+	if err != nil {
+		return err
+	}
+
+	oldC2 := st.reg.c[2]
+	cc, err := st.extractCC(7, pass, ret)
+	if err != nil {
+		return err
+	}
+
+	handler, data := forceControlData(handler)
+	defineCont(&data.save, 2, oldC2)
+	defineCont(&data.save, 0, cc)
+
+	st.reg.c[0] = cc
+	st.reg.c[2] = handler
+	return st.jump(body)
+}
@@ -0,0 +1,425 @@
+package tvm
+
+import (
+	"math/big"
+
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+// Tags of OutAction, actions are stored to c5 by contract and processed in action phase of transaction
+const (
+	ActionTagSendMsg       = 0x0ec3c86d
+	ActionTagSetCode       = 0xad4de08e
+	ActionTagReserve       = 0x36e6b809
+	ActionTagChangeLibrary = 0x26fa1dd4
+)
+
+func init() {
+	registerOp("LDGRAMS", "FA00", 0, func(st *State, _ uint32) error {
+		return loadVarIntOp(st, 4, false)
+	})
+	registerOp("LDVARINT16", "FA01", 0, func(st *State, _ uint32) error {
+		return loadVarIntOp(st, 4, true)
+	})
+	registerOp("STGRAMS", "FA02", 0, func(st *State, _ uint32) error {
+		return storeVarIntOp(st, 4, false)
+	})
+	registerOp("STVARINT16", "FA03", 0, func(st *State, _ uint32) error {
+		return storeVarIntOp(st, 4, true)
+	})
+	registerOp("LDVARUINT32", "FA04", 0, func(st *State, _ uint32) error {
+		return loadVarIntOp(st, 5, false)
+	})
+	registerOp("LDVARINT32", "FA05", 0, func(st *State, _ uint32) error {
+		return loadVarIntOp(st, 5, true)
+	})
+	registerOp("STVARUINT32", "FA06", 0, func(st *State, _ uint32) error {
+		return storeVarIntOp(st, 5, false)
+	})
+	registerOp("STVARINT32", "FA07", 0, func(st *State, _ uint32) error {
+		return storeVarIntOp(st, 5, true)
+	})
+
+	registerOp("LDMSGADDR", "FA41_", 1, func(st *State, args uint32) error {
+		quiet := args&1 != 0
+		s, err := st.stack.popSlice()
+		if err != nil {
+			return err
+		}
+
+		addr, _, err := parseMsgAddr(s)
+		if err != nil {
+			if !quiet {
+				return err
+			}
+			st.stack.push(s)
+			st.stack.pushBool(false)
+			return nil
+		}
+
+		bits := len(sliceBits(addr))
+		rest, err := subSlice(s, bits, 0, -1, -1)
+		if err != nil {
+			return err
+		}
+		st.stack.push(addr)
+		st.stack.push(rest)
+		if quiet {
+			st.stack.pushBool(true)
+		}
+		return nil
+	})
+	registerOp("PARSEMSGADDR", "FA43_", 1, func(st *State, args uint32) error {
+		quiet := args&1 != 0
+		s, err := st.stack.popSlice()
+		if err != nil {
+			return err
+		}
+
+		addr, parsed, err := parseMsgAddr(s)
+		if err == nil && len(sliceBits(addr)) != int(s.BitsLeft()) {
+			err = vmError(ErrCodeCellUnderflow, "cannot parse a MsgAddress")
+		}
+		if err != nil {
+			if !quiet {
+				return err
+			}
+			st.stack.pushBool(false)
+			return nil
+		}
+
+		st.stack.push(parsed)
+		if quiet {
+			st.stack.pushBool(true)
+		}
+		return nil
+	})
+	registerOp("REWRITESTDADDR", "FA45_", 1, func(st *State, args uint32) error {
+		return rewriteAddrOp(st, false, args&1 != 0)
+	})
+	registerOp("REWRITEVARADDR", "FA47_", 1, func(st *State, args uint32) error {
+		return rewriteAddrOp(st, true, args&1 != 0)
+	})
+
+	registerOp("SENDRAWMSG", "FB00", 0, func(st *State, _ uint32) error {
+		mode, err := st.stack.popSmall(255)
+		if err != nil {
+			return err
+		}
+		msg, err := st.stack.popCell()
+		if err != nil {
+			return err
+		}
+		return installAction(st, cell.BeginCell().
+			MustStoreUInt(ActionTagSendMsg, 32).
+			MustStoreUInt(uint64(mode), 8).
+			MustStoreRef(msg))
+	})
+	registerOp("RAWRESERVE", "FB02", 0, func(st *State, _ uint32) error {
+		return reserveOp(st, false)
+	})
+	registerOp("RAWRESERVEX", "FB03", 0, func(st *State, _ uint32) error {
+		return reserveOp(st, true)
+	})
+	registerOp("SETCODE", "FB04", 0, func(st *State, _ uint32) error {
+		code, err := st.stack.popCell()
+		if err != nil {
+			return err
+		}
+		return installAction(st, cell.BeginCell().
+			MustStoreUInt(ActionTagSetCode, 32).
+			MustStoreRef(code))
+	})
+	registerOp("SETLIBCODE", "FB06", 0, func(st *State, _ uint32) error {
+		mode, err := st.stack.popSmall(2)
+		if err != nil {
+			return err
+		}
+		code, err := st.stack.popCell()
+		if err != nil {
+			return err
+		}
+		return installAction(st, cell.BeginCell().
+			MustStoreUInt(ActionTagChangeLibrary, 32).
+			MustStoreUInt(uint64(mode)<<1|1, 8).
+			MustStoreRef(code))
+	})
+	registerOp("CHANGELIB", "FB07", 0, func(st *State, _ uint32) error {
+		mode, err := st.stack.popSmall(2)
+		if err != nil {
+			return err
+		}
+		hash, err := st.stack.popInt()
+		if err != nil {
+			return err
+		}
+		if hash.Sign() < 0 || hash.BitLen() > 256 {
+			return errRangeCheck
+		}
+		return installAction(st, cell.BeginCell().
+			MustStoreUInt(ActionTagChangeLibrary, 32).
+			MustStoreUInt(uint64(mode)<<1, 8).
+			MustStoreBigUInt(hash, 256))
+	})
+}
+
+func loadVarIntOp(st *State, lenBits uint, signed bool) error {
+	s, err := st.stack.popSlice()
+	if err != nil {
+		return err
+	}
+	ln, err := s.LoadUInt(lenBits)
+	if err != nil {
+		return errCellUnderflow
+	}
+	x, err := loadInt(s, uint(ln)*8, signed, false)
+	if err != nil {
+		return err
+	}
+	st.stack.push(x)
+	st.stack.push(s)
+	return nil
+}
+
+func storeVarIntOp(st *State, lenBits uint, signed bool) error {
+	x, err := st.stack.popInt()
+	if err != nil {
+		return err
+	}
+	b, err := st.stack.popBuilder()
+	if err != nil {
+		return err
+	}
+	if err = storeVarInt(b, x, lenBits, signed); err != nil {
+		return err
+	}
+	st.stack.push(b)
+	return nil
+}
+
+// storeVarInt - stores integer as length in bytes and value, using minimal length
+func storeVarInt(b *cell.Builder, x *big.Int, lenBits uint, signed bool) error {
+	maxLen := uint(1)<<lenBits - 1
+
+	var ln uint
+	for ln <= maxLen && !fitsBits(x, ln*8, signed) {
+		ln++
+	}
+	if ln > maxLen {
+		return errRangeCheck
+	}
+	if b.BitsLeft() < lenBits+ln*8 {
+		return errCellOverflow
+	}
+	_ = b.StoreUInt(uint64(ln), lenBits)
+	return storeInt(b, x, ln*8, signed)
+}
+
+// parseMsgAddr - parses MsgAddress from the beginning of slice,
+// returns slice with the address only and its representation as a tuple
+func parseMsgAddr(s *cell.Slice) (*cell.Slice, []any, error) {
+	errParse := vmError(ErrCodeCellUnderflow, "cannot parse a MsgAddress")
+
+	bits := sliceBits(s)
+	pos := 0
+	take := func(n int) ([]byte, bool) {
+		if pos+n > len(bits) {
+			return nil, false
+		}
+		pos += n
+		return bits[pos-n : pos], true
+	}
+	takeInt := func(n int, signed bool) (*big.Int, bool) {
+		v, ok := take(n)
+		if !ok {
+			return nil, false
+		}
+		return bitsToInt(v, signed), true
+	}
+	maybeAnycast := func() (any, bool) {
+		has, ok := take(1)
+		if !ok {
+			return nil, false
+		}
+		if has[0] == 0 {
+			return nil, true
+		}
+		depth, ok := takeInt(5, false)
+		if !ok || depth.Sign() == 0 || depth.Int64() > 30 {
+			return nil, false
+		}
+		pfx, ok := take(int(depth.Int64()))
+		if !ok {
+			return nil, false
+		}
+		pfxSlice, err := newSlice(pfx, nil)
+		if err != nil {
+			return nil, false
+		}
+		return pfxSlice, true
+	}
+
+	tag, ok := takeInt(2, false)
+	if !ok {
+		return nil, nil, errParse
+	}
+
+	var parsed []any
+	switch tag.Int64() {
+	case 0:
+		parsed = []any{big.NewInt(0)}
+	case 1:
+		ln, ok := takeInt(9, false)
+		if !ok {
+			return nil, nil, errParse
+		}
+		data, ok := take(int(ln.Int64()))
+		if !ok {
+			return nil, nil, errParse
+		}
+		addr, err := newSlice(data, nil)
+		if err != nil {
+			return nil, nil, errParse
+		}
+		parsed = []any{big.NewInt(1), addr}
+	case 2, 3:
+		anycast, ok := maybeAnycast()
+		if !ok {
+			return nil, nil, errParse
+		}
+
+		addrLen, wcLen := 256, 8
+		if tag.Int64() == 3 {
+			ln, ok := takeInt(9, false)
+			if !ok {
+				return nil, nil, errParse
+			}
+			addrLen, wcLen = int(ln.Int64()), 32
+		}
+
+		wc, ok := takeInt(wcLen, true)
+		if !ok {
+			return nil, nil, errParse
+		}
+		data, ok := take(addrLen)
+		if !ok {
+			return nil, nil, errParse
+		}
+		addr, err := newSlice(data, nil)
+		if err != nil {
+			return nil, nil, errParse
+		}
+		parsed = []any{new(big.Int).Set(tag), anycast, wc, addr}
+	}
+
+	res, err := newSlice(bits[:pos], nil)
+	if err != nil {
+		return nil, nil, errParse
+	}
+	return res, parsed, nil
+}
+
+// rewriteAddrOp - parses internal address and applies anycast rewrite prefix
+func rewriteAddrOp(st *State, varAddr, quiet bool) error {
+	s, err := st.stack.popSlice()
+	if err != nil {
+		return err
+	}
+
+	wc, addr, err := rewriteAddr(s, varAddr)
+	if err != nil {
+		if !quiet {
+			return err
+		}
+		st.stack.pushBool(false)
+		return nil
+	}
+
+	st.stack.push(wc)
+	if varAddr {
+		a, err := newSlice(addr, nil)
+		if err != nil {
+			return err
+		}
+		st.stack.push(a)
+	} else {
+		st.stack.push(bitsToInt(addr, false))
+	}
+	if quiet {
+		st.stack.pushBool(true)
+	}
+	return nil
+}
+
+func rewriteAddr(s *cell.Slice, varAddr bool) (*big.Int, []byte, error) {
+	errParse := vmError(ErrCodeCellUnderflow, "cannot parse a MsgAddress")
+
+	addrSlice, parsed, err := parseMsgAddr(s)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(sliceBits(addrSlice)) != int(s.BitsLeft()) || len(parsed) != 4 {
+		return nil, nil, errParse
+	}
+
+	wc := parsed[2].(*big.Int)
+	addr := sliceBits(parsed[3].(*cell.Slice))
+	if !varAddr && (len(addr) != 256 || !fitsBits(wc, 32, true)) {
+		return nil, nil, errParse
+	}
+
+	if pfx, ok := parsed[1].(*cell.Slice); ok {
+		pfxBits := sliceBits(pfx)
+		if len(pfxBits) > len(addr) {
+			return nil, nil, errParse
+		}
+		copy(addr, pfxBits)
+	}
+	return wc, addr, nil
+}
+
+func reserveOp(st *State, withExtra bool) error {
+	mode, err := st.stack.popSmall(31)
+	if err != nil {
+		return err
+	}
+
+	var extra *cell.Cell
+	if withExtra {
+		if extra, err = st.stack.popMaybeCell(); err != nil {
+			return err
+		}
+	}
+
+	amount, err := st.stack.popInt()
+	if err != nil {
+		return err
+	}
+
+	b := cell.BeginCell().
+		MustStoreUInt(ActionTagReserve, 32).
+		MustStoreUInt(uint64(mode), 8)
+	if err = storeVarInt(b, amount, 4, false); err != nil {
+		return err
+	}
+	_ = b.StoreMaybeRef(extra)
+	return installAction(st, b)
+}
+
+// installAction - prepends action to the actions list in c5
+func installAction(st *State, action *cell.Builder) error {
+	prev := st.reg.d[1]
+	if prev == nil {
+		return errTypeCheck
+	}
+
+	b := cell.BeginCell().MustStoreRef(prev)
+	if err := b.StoreBuilder(action); err != nil {
+		return errCellOverflow
+	}
+	if err := st.registerCellCreate(); err != nil {
+		return err
+	}
+	st.reg.d[1] = b.EndCell()
+	return nil
+}
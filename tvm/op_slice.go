@@ -0,0 +1,869 @@
+package tvm
+
+import (
+	"bytes"
+	"math/big"
+
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+func init() {
+	registerOp("CTOS", "D0", 0, func(st *State, _ uint32) error {
+		c, err := st.stack.popCell()
+		if err != nil {
+			return err
+		}
+		s, err := st.loadCell(c)
+		if err != nil {
+			return err
+		}
+		st.stack.push(s)
+		return nil
+	})
+	registerOp("ENDS", "D1", 0, func(st *State, _ uint32) error {
+		s, err := st.stack.popSlice()
+		if err != nil {
+			return err
+		}
+		if s.BitsLeft() > 0 || s.RefsNum() > 0 {
+			return vmError(ErrCodeCellUnderflow, "extra data remaining in deserialized cell")
+		}
+		return nil
+	})
+	registerOp("LDI", "D2", 8, func(st *State, args uint32) error {
+		return loadIntOp(st, uint(args)+1, true, false, false)
+	})
+	registerOp("LDU", "D3", 8, func(st *State, args uint32) error {
+		return loadIntOp(st, uint(args)+1, false, false, false)
+	})
+	registerOp("LDREF", "D4", 0, func(st *State, _ uint32) error {
+		s, err := st.stack.popSlice()
+		if err != nil {
+			return err
+		}
+		ref, err := s.LoadRefCell()
+		if err != nil {
+			return errCellUnderflow
+		}
+		st.stack.push(ref)
+		st.stack.push(s)
+		return nil
+	})
+	registerOp("LDREFRTOS", "D5", 0, func(st *State, _ uint32) error {
+		s, err := st.stack.popSlice()
+		if err != nil {
+			return err
+		}
+		ref, err := s.LoadRefCell()
+		if err != nil {
+			return errCellUnderflow
+		}
+		rs, err := st.loadCell(ref)
+		if err != nil {
+			return err
+		}
+		st.stack.push(s)
+		st.stack.push(rs)
+		return nil
+	})
+	registerOp("LDSLICE", "D6", 8, func(st *State, args uint32) error {
+		return loadSliceOp(st, uint(args)+1, false, false)
+	})
+	registerOp("LDIX", "D70", 4, func(st *State, args uint32) error {
+		signed := args&1 == 0
+		preload, quiet := args&2 != 0, args&4 != 0
+		if args&8 != 0 {
+			n, err := st.code.LoadUInt(8)
+			if err != nil {
+				return errInvalidOpcode
+			}
+			if err = st.consumeGas(8); err != nil {
+				return err
+			}
+			return loadIntOp(st, uint(n)+1, signed, preload, quiet)
+		}
+
+		maxBits := int64(256)
+		if signed {
+			maxBits = 257
+		}
+		bits, err := st.stack.popSmall(maxBits)
+		if err != nil {
+			return err
+		}
+		return loadIntOp(st, uint(bits), signed, preload, quiet)
+	})
+	registerOp("PLDUZ", "D714_", 3, func(st *State, args uint32) error {
+		s, err := st.stack.popSlice()
+		if err != nil {
+			return err
+		}
+		bits := 32 * (uint(args) + 1)
+		have := s.BitsLeft()
+		if have > bits {
+			have = bits
+		}
+		x, err := loadInt(s, have, false, true)
+		if err != nil {
+			return err
+		}
+		st.stack.push(s)
+		st.stack.push(x.Lsh(x, bits-have))
+		return nil
+	})
+	registerOpCheck("LDSLICEX", "D71", 4, func(args uint32) bool {
+		return args >= 8
+	}, func(st *State, args uint32) error {
+		preload, quiet := args&1 != 0, args&2 != 0
+		if args&4 != 0 {
+			n, err := st.code.LoadUInt(8)
+			if err != nil {
+				return errInvalidOpcode
+			}
+			if err = st.consumeGas(8); err != nil {
+				return err
+			}
+			return loadSliceOp(st, uint(n)+1, preload, quiet)
+		}
+
+		bits, err := st.stack.popSmall(1023)
+		if err != nil {
+			return err
+		}
+		return loadSliceOp(st, uint(bits), preload, quiet)
+	})
+	registerOp("SDCUTFIRST", "D720", 0, func(st *State, _ uint32) error {
+		return cutSliceOp(st, false, func(bits, refs int) (int, int, int, int) {
+			return 0, 0, bits, 0
+		})
+	})
+	registerOp("SDSKIPFIRST", "D721", 0, func(st *State, _ uint32) error {
+		return cutSliceOp(st, false, func(bits, refs int) (int, int, int, int) {
+			return bits, 0, -1, -1
+		})
+	})
+	registerOp("SDCUTLAST", "D722", 0, func(st *State, _ uint32) error {
+		return cutSliceOp(st, false, func(bits, refs int) (int, int, int, int) {
+			return -bits, 0, bits, 0
+		})
+	})
+	registerOp("SDSKIPLAST", "D723", 0, func(st *State, _ uint32) error {
+		return cutSliceOp(st, false, func(bits, refs int) (int, int, int, int) {
+			return 0, 0, -bits - 1, -1
+		})
+	})
+	registerOp("SDSUBSTR", "D724", 0, func(st *State, _ uint32) error {
+		ln, err := st.stack.popSmall(1023)
+		if err != nil {
+			return err
+		}
+		offset, err := st.stack.popSmall(1023)
+		if err != nil {
+			return err
+		}
+		s, err := st.stack.popSlice()
+		if err != nil {
+			return err
+		}
+		res, err := subSlice(s, offset, 0, ln, 0)
+		if err != nil {
+			return err
+		}
+		st.stack.push(res)
+		return nil
+	})
+	registerOp("SDBEGINSX", "D726", 0, func(st *State, _ uint32) error {
+		pfx, err := st.stack.popSlice()
+		if err != nil {
+			return err
+		}
+		return sliceBegins(st, pfx, false)
+	})
+	registerOp("SDBEGINSXQ", "D727", 0, func(st *State, _ uint32) error {
+		pfx, err := st.stack.popSlice()
+		if err != nil {
+			return err
+		}
+		return sliceBegins(st, pfx, true)
+	})
+	registerOp("SDBEGINS", "D72A_", 7, func(st *State, args uint32) error {
+		pfx, err := loadCodeSlice(st, 8*uint(args)+3, 0, true)
+		if err != nil {
+			return err
+		}
+		return sliceBegins(st, pfx, false)
+	})
+	registerOp("SDBEGINSQ", "D72E_", 7, func(st *State, args uint32) error {
+		pfx, err := loadCodeSlice(st, 8*uint(args)+3, 0, true)
+		if err != nil {
+			return err
+		}
+		return sliceBegins(st, pfx, true)
+	})
+	registerOp("SCUTFIRST", "D730", 0, func(st *State, _ uint32) error {
+		return cutSliceOp(st, true, func(bits, refs int) (int, int, int, int) {
+			return 0, 0, bits, refs
+		})
+	})
+	registerOp("SSKIPFIRST", "D731", 0, func(st *State, _ uint32) error {
+		return cutSliceOp(st, true, func(bits, refs int) (int, int, int, int) {
+			return bits, refs, -1, -1
+		})
+	})
+	registerOp("SCUTLAST", "D732", 0, func(st *State, _ uint32) error {
+		return cutSliceOp(st, true, func(bits, refs int) (int, int, int, int) {
+			return -bits, -refs, bits, refs
+		})
+	})
+	registerOp("SSKIPLAST", "D733", 0, func(st *State, _ uint32) error {
+		return cutSliceOp(st, true, func(bits, refs int) (int, int, int, int) {
+			return 0, 0, -bits - 1, -refs - 1
+		})
+	})
+	registerOp("SUBSLICE", "D734", 0, func(st *State, _ uint32) error {
+		refs2, err := st.stack.popSmall(4)
+		if err != nil {
+			return err
+		}
+		bits2, err := st.stack.popSmall(1023)
+		if err != nil {
+			return err
+		}
+		refs1, err := st.stack.popSmall(4)
+		if err != nil {
+			return err
+		}
+		bits1, err := st.stack.popSmall(1023)
+		if err != nil {
+			return err
+		}
+		s, err := st.stack.popSlice()
+		if err != nil {
+			return err
+		}
+		res, err := subSlice(s, bits1, refs1, bits2, refs2)
+		if err != nil {
+			return err
+		}
+		st.stack.push(res)
+		return nil
+	})
+	registerOp("SPLIT", "D736", 0, func(st *State, _ uint32) error {
+		return splitSlice(st, false)
+	})
+	registerOp("SPLITQ", "D737", 0, func(st *State, _ uint32) error {
+		return splitSlice(st, true)
+	})
+	registerOp("XCTOS", "D739", 0, func(st *State, _ uint32) error {
+		c, err := st.stack.popCell()
+		if err != nil {
+			return err
+		}
+		if err = st.registerCellLoad(c); err != nil {
+			return err
+		}
+		st.stack.push(c.BeginParse())
+		st.stack.pushBool(c.GetType() != cell.OrdinaryCellType)
+		return nil
+	})
+	registerOp("XLOAD", "D73A", 0, func(st *State, _ uint32) error {
+		return xload(st, false)
+	})
+	registerOp("XLOADQ", "D73B", 0, func(st *State, _ uint32) error {
+		return xload(st, true)
+	})
+	registerOpCheck("SCHKBITREFS", "D74", 4, func(args uint32) bool {
+		return args&3 != 0 && args < 8
+	}, func(st *State, args uint32) error {
+		quiet := args&4 != 0
+		var bits, refs int
+		var err error
+		if args&2 != 0 {
+			if refs, err = st.stack.popSmall(4); err != nil {
+				return err
+			}
+		}
+		if args&1 != 0 {
+			if bits, err = st.stack.popSmall(1023); err != nil {
+				return err
+			}
+		}
+		s, err := st.stack.popSlice()
+		if err != nil {
+			return err
+		}
+
+		ok := int(s.BitsLeft()) >= bits && s.RefsNum() >= refs
+		if quiet {
+			st.stack.pushBool(ok)
+			return nil
+		}
+		if !ok {
+			return errCellUnderflow
+		}
+		return nil
+	})
+	registerOp("PLDREFVAR", "D748", 0, func(st *State, _ uint32) error {
+		idx, err := st.stack.popSmall(3)
+		if err != nil {
+			return err
+		}
+		return preloadRef(st, idx)
+	})
+	registerOp("SBITS", "D749", 0, func(st *State, _ uint32) error {
+		s, err := st.stack.popSlice()
+		if err != nil {
+			return err
+		}
+		st.stack.pushSmall(int64(s.BitsLeft()))
+		return nil
+	})
+	registerOp("SREFS", "D74A", 0, func(st *State, _ uint32) error {
+		s, err := st.stack.popSlice()
+		if err != nil {
+			return err
+		}
+		st.stack.pushSmall(int64(s.RefsNum()))
+		return nil
+	})
+	registerOp("SBITREFS", "D74B", 0, func(st *State, _ uint32) error {
+		s, err := st.stack.popSlice()
+		if err != nil {
+			return err
+		}
+		st.stack.pushSmall(int64(s.BitsLeft()))
+		st.stack.pushSmall(int64(s.RefsNum()))
+		return nil
+	})
+	registerOp("PLDREFIDX", "D74E_", 2, func(st *State, args uint32) error {
+		return preloadRef(st, int(args))
+	})
+	registerOp("LDLE", "D75", 4, func(st *State, args uint32) error {
+		signed := args&1 == 0
+		bits := uint(32)
+		if args&2 != 0 {
+			bits = 64
+		}
+		preload, quiet := args&4 != 0, args&8 != 0
+
+		s, err := st.stack.popSlice()
+		if err != nil {
+			return err
+		}
+		if s.BitsLeft() < bits {
+			if !quiet {
+				return errCellUnderflow
+			}
+			if !preload {
+				st.stack.push(s)
+			}
+			st.stack.pushBool(false)
+			return nil
+		}
+
+		data, err := s.LoadSlice(bits)
+		if err != nil {
+			return errCellUnderflow
+		}
+		for i, j := 0, len(data)-1; i < j; i, j = i+1, j-1 {
+			data[i], data[j] = data[j], data[i]
+		}
+		x := new(big.Int).SetBytes(data)
+		if signed && x.Bit(int(bits-1)) == 1 {
+			x.Sub(x, new(big.Int).Lsh(big.NewInt(1), bits))
+		}
+
+		st.stack.push(x)
+		if !preload {
+			st.stack.push(s)
+		}
+		if quiet {
+			st.stack.pushBool(true)
+		}
+		return nil
+	})
+	registerOp("LDZEROES", "D760", 0, func(st *State, _ uint32) error {
+		return loadSame(st, 0)
+	})
+	registerOp("LDONES", "D761", 0, func(st *State, _ uint32) error {
+		return loadSame(st, 1)
+	})
+	registerOp("LDSAME", "D762", 0, func(st *State, _ uint32) error {
+		bit, err := st.stack.popSmall(1)
+		if err != nil {
+			return err
+		}
+		return loadSame(st, byte(bit))
+	})
+	registerOp("SDEPTH", "D764", 0, func(st *State, _ uint32) error {
+		s, err := st.stack.popSlice()
+		if err != nil {
+			return err
+		}
+		depth := 0
+		for _, ref := range sliceRefs(s) {
+			if d := int(ref.Depth()) + 1; d > depth {
+				depth = d
+			}
+		}
+		st.stack.pushSmall(int64(depth))
+		return nil
+	})
+	registerOp("CDEPTH", "D765", 0, func(st *State, _ uint32) error {
+		c, err := st.stack.popMaybeCell()
+		if err != nil {
+			return err
+		}
+		if c == nil {
+			st.stack.pushSmall(0)
+			return nil
+		}
+		st.stack.pushSmall(int64(c.Depth()))
+		return nil
+	})
+
+	registerOp("SEMPTY", "C700", 0, func(st *State, _ uint32) error {
+		s, err := st.stack.popSlice()
+		if err != nil {
+			return err
+		}
+		st.stack.pushBool(s.BitsLeft() == 0 && s.RefsNum() == 0)
+		return nil
+	})
+	registerOp("SDEMPTY", "C701", 0, func(st *State, _ uint32) error {
+		s, err := st.stack.popSlice()
+		if err != nil {
+			return err
+		}
+		st.stack.pushBool(s.BitsLeft() == 0)
+		return nil
+	})
+	registerOp("SREMPTY", "C702", 0, func(st *State, _ uint32) error {
+		s, err := st.stack.popSlice()
+		if err != nil {
+			return err
+		}
+		st.stack.pushBool(s.RefsNum() == 0)
+		return nil
+	})
+	registerOp("SDFIRST", "C703", 0, func(st *State, _ uint32) error {
+		s, err := st.stack.popSlice()
+		if err != nil {
+			return err
+		}
+		bits := sliceBits(s)
+		st.stack.pushBool(len(bits) > 0 && bits[0] == 1)
+		return nil
+	})
+	registerOp("SDLEXCMP", "C704", 0, func(st *State, _ uint32) error {
+		return compareSlices(st, func(a, b []byte) int64 {
+			return int64(bytes.Compare(a, b))
+		})
+	})
+	registerOp("SDEQ", "C705", 0, func(st *State, _ uint32) error {
+		return compareSlices(st, func(a, b []byte) int64 {
+			return boolToInt(bytes.Equal(a, b))
+		})
+	})
+	registerOp("SDPFX", "C708", 0, func(st *State, _ uint32) error {
+		return compareSlices(st, func(a, b []byte) int64 {
+			return boolToInt(bytes.HasPrefix(b, a))
+		})
+	})
+	registerOp("SDPFXREV", "C709", 0, func(st *State, _ uint32) error {
+		return compareSlices(st, func(a, b []byte) int64 {
+			return boolToInt(bytes.HasPrefix(a, b))
+		})
+	})
+	registerOp("SDPPFX", "C70A", 0, func(st *State, _ uint32) error {
+		return compareSlices(st, func(a, b []byte) int64 {
+			return boolToInt(len(a) < len(b) && bytes.HasPrefix(b, a))
+		})
+	})
+	registerOp("SDPPFXREV", "C70B", 0, func(st *State, _ uint32) error {
+		return compareSlices(st, func(a, b []byte) int64 {
+			return boolToInt(len(b) < len(a) && bytes.HasPrefix(a, b))
+		})
+	})
+	registerOp("SDSFX", "C70C", 0, func(st *State, _ uint32) error {
+		return compareSlices(st, func(a, b []byte) int64 {
+			return boolToInt(bytes.HasSuffix(b, a))
+		})
+	})
+	registerOp("SDSFXREV", "C70D", 0, func(st *State, _ uint32) error {
+		return compareSlices(st, func(a, b []byte) int64 {
+			return boolToInt(bytes.HasSuffix(a, b))
+		})
+	})
+	registerOp("SDPSFX", "C70E", 0, func(st *State, _ uint32) error {
+		return compareSlices(st, func(a, b []byte) int64 {
+			return boolToInt(len(a) < len(b) && bytes.HasSuffix(b, a))
+		})
+	})
+	registerOp("SDPSFXREV", "C70F", 0, func(st *State, _ uint32) error {
+		return compareSlices(st, func(a, b []byte) int64 {
+			return boolToInt(len(b) < len(a) && bytes.HasSuffix(a, b))
+		})
+	})
+	registerOp("SDCNTLEAD0", "C710", 0, func(st *State, _ uint32) error {
+		return countBits(st, 0, true)
+	})
+	registerOp("SDCNTLEAD1", "C711", 0, func(st *State, _ uint32) error {
+		return countBits(st, 1, true)
+	})
+	registerOp("SDCNTTRAIL0", "C712", 0, func(st *State, _ uint32) error {
+		return countBits(st, 0, false)
+	})
+	registerOp("SDCNTTRAIL1", "C713", 0, func(st *State, _ uint32) error {
+		return countBits(st, 1, false)
+	})
+}
+
+func boolToInt(v bool) int64 {
+	if v {
+		return -1
+	}
+	return 0
+}
+
+// sliceBits - returns remaining bits of slice, one byte per bit
+func sliceBits(s *cell.Slice) []byte {
+	sz := s.BitsLeft()
+	data, err := s.Copy().PreloadSlice(sz)
+	if err != nil {
+		return nil
+	}
+	return unpackBits(data, sz)
+}
+
+// sliceRefs - returns remaining references of slice
+func sliceRefs(s *cell.Slice) []*cell.Cell {
+	s = s.Copy()
+	refs := make([]*cell.Cell, 0, s.RefsNum())
+	for s.RefsNum() > 0 {
+		ref, err := s.LoadRefCell()
+		if err != nil {
+			break
+		}
+		refs = append(refs, ref)
+	}
+	return refs
+}
+
+// newSlice - builds ordinary slice from bits (one byte per bit) and references
+func newSlice(bits []byte, refs []*cell.Cell) (*cell.Slice, error) {
+	b := cell.BeginCell()
+	if err := b.StoreSlice(packBits(bits), uint(len(bits))); err != nil {
+		return nil, errCellOverflow
+	}
+	for _, ref := range refs {
+		if err := b.StoreRef(ref); err != nil {
+			return nil, errCellOverflow
+		}
+	}
+	return b.ToSlice(), nil
+}
+
+// subSlice - skips skipBits and skipRefs, then takes bits and refs, negative take means all remaining
+func subSlice(s *cell.Slice, skipBits, skipRefs, bits, refs int) (*cell.Slice, error) {
+	allBits, allRefs := sliceBits(s), sliceRefs(s)
+	if skipBits > len(allBits) || skipRefs > len(allRefs) {
+		return nil, errCellUnderflow
+	}
+	allBits, allRefs = allBits[skipBits:], allRefs[skipRefs:]
+
+	if bits < 0 {
+		bits = len(allBits)
+	}
+	if refs < 0 {
+		refs = len(allRefs)
+	}
+	if bits > len(allBits) || refs > len(allRefs) {
+		return nil, errCellUnderflow
+	}
+	return newSlice(allBits[:bits], allRefs[:refs])
+}
+
+// cutSliceOp - pops params and slice, calc returns skip and take values for subSlice,
+// negative skip means offset from the end, for take -1 means all remaining and less than -1 means all except -(take+1)
+func cutSliceOp(st *State, withRefs bool, calc func(bits, refs int) (int, int, int, int)) error {
+	var refs int
+	var err error
+	if withRefs {
+		if refs, err = st.stack.popSmall(4); err != nil {
+			return err
+		}
+	}
+	bits, err := st.stack.popSmall(1023)
+	if err != nil {
+		return err
+	}
+	s, err := st.stack.popSlice()
+	if err != nil {
+		return err
+	}
+
+	haveBits, haveRefs := int(s.BitsLeft()), s.RefsNum()
+	if bits > haveBits || refs > haveRefs {
+		return errCellUnderflow
+	}
+
+	skipBits, skipRefs, takeBits, takeRefs := calc(bits, refs)
+	if skipBits < 0 {
+		skipBits += haveBits
+	}
+	if skipRefs < 0 {
+		skipRefs += haveRefs
+	}
+	if takeBits < -1 {
+		takeBits = haveBits + takeBits + 1
+	}
+	if takeRefs < -1 {
+		takeRefs = haveRefs + takeRefs + 1
+	}
+
+	res, err := subSlice(s, skipBits, skipRefs, takeBits, takeRefs)
+	if err != nil {
+		return err
+	}
+	st.stack.push(res)
+	return nil
+}
+
+func splitSlice(st *State, quiet bool) error {
+	refs, err := st.stack.popSmall(4)
+	if err != nil {
+		return err
+	}
+	bits, err := st.stack.popSmall(1023)
+	if err != nil {
+		return err
+	}
+	s, err := st.stack.popSlice()
+	if err != nil {
+		return err
+	}
+
+	if int(s.BitsLeft()) < bits || s.RefsNum() < refs {
+		if !quiet {
+			return errCellUnderflow
+		}
+		st.stack.push(s)
+		st.stack.pushBool(false)
+		return nil
+	}
+
+	first, err := subSlice(s, 0, 0, bits, refs)
+	if err != nil {
+		return err
+	}
+	rest, err := subSlice(s, bits, refs, -1, -1)
+	if err != nil {
+		return err
+	}
+	st.stack.push(first)
+	st.stack.push(rest)
+	if quiet {
+		st.stack.pushBool(true)
+	}
+	return nil
+}
+
+func loadIntOp(st *State, bits uint, signed, preload, quiet bool) error {
+	s, err := st.stack.popSlice()
+	if err != nil {
+		return err
+	}
+
+	if s.BitsLeft() < bits {
+		if !quiet {
+			return errCellUnderflow
+		}
+		if !preload {
+			st.stack.push(s)
+		}
+		st.stack.pushBool(false)
+		return nil
+	}
+
+	x, err := loadInt(s, bits, signed, false)
+	if err != nil {
+		return err
+	}
+	st.stack.push(x)
+	if !preload {
+		st.stack.push(s)
+	}
+	if quiet {
+		st.stack.pushBool(true)
+	}
+	return nil
+}
+
+func loadSliceOp(st *State, bits uint, preload, quiet bool) error {
+	s, err := st.stack.popSlice()
+	if err != nil {
+		return err
+	}
+
+	if s.BitsLeft() < bits {
+		if !quiet {
+			return errCellUnderflow
+		}
+		if !preload {
+			st.stack.push(s)
+		}
+		st.stack.pushBool(false)
+		return nil
+	}
+
+	data, err := s.LoadSlice(bits)
+	if err != nil {
+		return errCellUnderflow
+	}
+	res, err := newSlice(unpackBits(data, bits), nil)
+	if err != nil {
+		return err
+	}
+
+	st.stack.push(res)
+	if !preload {
+		st.stack.push(s)
+	}
+	if quiet {
+		st.stack.pushBool(true)
+	}
+	return nil
+}
+
+func sliceBegins(st *State, pfx *cell.Slice, quiet bool) error {
+	s, err := st.stack.popSlice()
+	if err != nil {
+		return err
+	}
+
+	if !bytes.HasPrefix(sliceBits(s), sliceBits(pfx)) {
+		if !quiet {
+			return errCellUnderflow
+		}
+		st.stack.push(s)
+		st.stack.pushBool(false)
+		return nil
+	}
+
+	if _, err = s.LoadSlice(pfx.BitsLeft()); err != nil {
+		return errCellUnderflow
+	}
+	st.stack.push(s)
+	if quiet {
+		st.stack.pushBool(true)
+	}
+	return nil
+}
+
+func preloadRef(st *State, idx int) error {
+	s, err := st.stack.popSlice()
+	if err != nil {
+		return err
+	}
+	refs := sliceRefs(s)
+	if idx >= len(refs) {
+		return errCellUnderflow
+	}
+	st.stack.push(refs[idx])
+	return nil
+}
+
+func xload(st *State, quiet bool) error {
+	c, err := st.stack.popCell()
+	if err != nil {
+		return err
+	}
+
+	switch c.GetType() {
+	case cell.OrdinaryCellType:
+	case cell.LibraryCellType:
+		if err = st.registerCellLoad(c); err != nil {
+			return err
+		}
+		lib, err := st.resolveLibrary(c)
+		if err != nil {
+			if !quiet {
+				return err
+			}
+			st.stack.push(c)
+			st.stack.pushBool(false)
+			return nil
+		}
+		c = lib
+	default:
+		if !quiet {
+			return vmError(ErrCodeCellUnderflow, "unexpected special cell")
+		}
+		st.stack.push(c)
+		st.stack.pushBool(false)
+		return nil
+	}
+
+	st.stack.push(c)
+	if quiet {
+		st.stack.pushBool(true)
+	}
+	return nil
+}
+
+func loadSame(st *State, bit byte) error {
+	s, err := st.stack.popSlice()
+	if err != nil {
+		return err
+	}
+
+	n := 0
+	for _, b := range sliceBits(s) {
+		if b != bit {
+			break
+		}
+		n++
+	}
+	if _, err = s.LoadSlice(uint(n)); err != nil {
+		return errCellUnderflow
+	}
+	st.stack.pushSmall(int64(n))
+	st.stack.push(s)
+	return nil
+}
+
+func compareSlices(st *State, cmp func(a, b []byte) int64) error {
+	s2, err := st.stack.popSlice()
+	if err != nil {
+		return err
+	}
+	s1, err := st.stack.popSlice()
+	if err != nil {
+		return err
+	}
+	st.stack.pushSmall(cmp(sliceBits(s1), sliceBits(s2)))
+	return nil
+}
+
+func countBits(st *State, bit byte, leading bool) error {
+	s, err := st.stack.popSlice()
+	if err != nil {
+		return err
+	}
+
+	bits := sliceBits(s)
+	n := 0
+	for i := range bits {
+		idx := i
+		if !leading {
+			idx = len(bits) - 1 - i
+		}
+		if bits[idx] != bit {
+			break
+		}
+		n++
+	}
+	st.stack.pushSmall(int64(n))
+	return nil
+}
@@ -0,0 +1,324 @@
+package tvm
+
+func init() {
+	registerOp("NOP", "00", 0, func(st *State, _ uint32) error {
+		return nil
+	})
+	registerOp("XCHG_0I", "0", 4, func(st *State, args uint32) error {
+		return st.stack.exchange(0, int(args))
+	})
+	registerOpCheck("XCHG_IJ", "10", 8, func(args uint32) bool {
+		return args>>4 > 0 && args>>4 < args&15
+	}, func(st *State, args uint32) error {
+		return st.stack.exchange(int(args>>4), int(args&15))
+	})
+	registerOp("XCHG_0I_LONG", "11", 8, func(st *State, args uint32) error {
+		return st.stack.exchange(0, int(args))
+	})
+	registerOpCheck("XCHG_1I", "1", 4, func(args uint32) bool {
+		return args >= 2
+	}, func(st *State, args uint32) error {
+		return st.stack.exchange(1, int(args))
+	})
+	registerOp("PUSH", "2", 4, func(st *State, args uint32) error {
+		return push(st, int(args))
+	})
+	registerOp("POP", "3", 4, func(st *State, args uint32) error {
+		return pop(st, int(args))
+	})
+	registerOp("XCHG3", "4", 12, func(st *State, args uint32) error {
+		return xchg3(st, int(args>>8), int(args>>4&15), int(args&15))
+	})
+	registerOp("XCHG2", "50", 8, func(st *State, args uint32) error {
+		return xchg2(st, int(args>>4), int(args&15))
+	})
+	registerOp("XCPU", "51", 8, func(st *State, args uint32) error {
+		return xcpu(st, int(args>>4), int(args&15))
+	})
+	registerOp("PUXC", "52", 8, func(st *State, args uint32) error {
+		return puxc(st, int(args>>4), int(args&15)-1)
+	})
+	registerOp("PUSH2", "53", 8, func(st *State, args uint32) error {
+		return push2(st, int(args>>4), int(args&15))
+	})
+	registerOp("XCHG3_LONG", "540", 12, func(st *State, args uint32) error {
+		return xchg3(st, int(args>>8), int(args>>4&15), int(args&15))
+	})
+	registerOp("XC2PU", "541", 12, func(st *State, args uint32) error {
+		if err := xchg2(st, int(args>>8), int(args>>4&15)); err != nil {
+			return err
+		}
+		return push(st, int(args&15))
+	})
+	registerOp("XCPUXC", "542", 12, func(st *State, args uint32) error {
+		if err := st.stack.exchange(1, int(args>>8)); err != nil {
+			return err
+		}
+		return puxc(st, int(args>>4&15), int(args&15)-1)
+	})
+	registerOp("XCPU2", "543", 12, func(st *State, args uint32) error {
+		if err := st.stack.exchange(0, int(args>>8)); err != nil {
+			return err
+		}
+		return push2(st, int(args>>4&15), int(args&15))
+	})
+	registerOp("PUXC2", "544", 12, func(st *State, args uint32) error {
+		if err := push(st, int(args>>8)); err != nil {
+			return err
+		}
+		if err := st.stack.exchange(0, 2); err != nil {
+			return err
+		}
+		return xchg2(st, int(args>>4&15), int(args&15))
+	})
+	registerOp("PUXCPU", "545", 12, func(st *State, args uint32) error {
+		if err := puxc(st, int(args>>8), int(args>>4&15)-1); err != nil {
+			return err
+		}
+		return push(st, int(args&15))
+	})
+	registerOp("PU2XC", "546", 12, func(st *State, args uint32) error {
+		if err := push(st, int(args>>8)); err != nil {
+			return err
+		}
+		if err := st.stack.exchange(0, 1); err != nil {
+			return err
+		}
+		return puxc(st, int(args>>4&15), int(args&15)-1)
+	})
+	registerOp("PUSH3", "547", 12, func(st *State, args uint32) error {
+		if err := push(st, int(args>>8)); err != nil {
+			return err
+		}
+		return push2(st, int(args>>4&15)+1, int(args&15)+1)
+	})
+	registerOp("BLKSWAP", "55", 8, func(st *State, args uint32) error {
+		return st.stack.blockSwap(int(args>>4)+1, int(args&15)+1)
+	})
+	registerOp("PUSH_LONG", "56", 8, func(st *State, args uint32) error {
+		return push(st, int(args))
+	})
+	registerOp("POP_LONG", "57", 8, func(st *State, args uint32) error {
+		return pop(st, int(args))
+	})
+	registerOp("ROT", "58", 0, func(st *State, _ uint32) error {
+		return st.stack.blockSwap(1, 2)
+	})
+	registerOp("ROTREV", "59", 0, func(st *State, _ uint32) error {
+		return st.stack.blockSwap(2, 1)
+	})
+	registerOp("SWAP2", "5A", 0, func(st *State, _ uint32) error {
+		return st.stack.blockSwap(2, 2)
+	})
+	registerOp("DROP2", "5B", 0, func(st *State, _ uint32) error {
+		return st.stack.drop(2)
+	})
+	registerOp("DUP2", "5C", 0, func(st *State, _ uint32) error {
+		return push2(st, 1, 0)
+	})
+	registerOp("OVER2", "5D", 0, func(st *State, _ uint32) error {
+		return push2(st, 3, 2)
+	})
+	registerOp("REVERSE", "5E", 8, func(st *State, args uint32) error {
+		return st.stack.reverse(int(args>>4)+2, int(args&15))
+	})
+	registerOp("BLKDROP", "5F0", 4, func(st *State, args uint32) error {
+		return st.stack.drop(int(args))
+	})
+	registerOp("BLKPUSH", "5F", 8, func(st *State, args uint32) error {
+		i, j := int(args>>4), int(args&15)
+		for x := 0; x < i; x++ {
+			if err := push(st, j); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	registerOp("PICK", "60", 0, func(st *State, _ uint32) error {
+		i, err := st.stack.popSmall(255)
+		if err != nil {
+			return err
+		}
+		return push(st, i)
+	})
+	registerOp("ROLL", "61", 0, func(st *State, _ uint32) error {
+		i, err := st.stack.popSmall(255)
+		if err != nil {
+			return err
+		}
+		return st.stack.blockSwap(1, i)
+	})
+	registerOp("ROLLREV", "62", 0, func(st *State, _ uint32) error {
+		i, err := st.stack.popSmall(255)
+		if err != nil {
+			return err
+		}
+		return st.stack.blockSwap(i, 1)
+	})
+	registerOp("BLKSWX", "63", 0, func(st *State, _ uint32) error {
+		j, err := st.stack.popSmall(255)
+		if err != nil {
+			return err
+		}
+		i, err := st.stack.popSmall(255)
+		if err != nil {
+			return err
+		}
+		if i == 0 || j == 0 {
+			return st.stack.checkUnderflow(i + j)
+		}
+		return st.stack.blockSwap(i, j)
+	})
+	registerOp("REVX", "64", 0, func(st *State, _ uint32) error {
+		j, err := st.stack.popSmall(255)
+		if err != nil {
+			return err
+		}
+		i, err := st.stack.popSmall(255)
+		if err != nil {
+			return err
+		}
+		return st.stack.reverse(i, j)
+	})
+	registerOp("DROPX", "65", 0, func(st *State, _ uint32) error {
+		i, err := st.stack.popSmall(255)
+		if err != nil {
+			return err
+		}
+		return st.stack.drop(i)
+	})
+	registerOp("TUCK", "66", 0, func(st *State, _ uint32) error {
+		if err := st.stack.exchange(0, 1); err != nil {
+			return err
+		}
+		return push(st, 1)
+	})
+	registerOp("XCHGX", "67", 0, func(st *State, _ uint32) error {
+		i, err := st.stack.popSmall(255)
+		if err != nil {
+			return err
+		}
+		return st.stack.exchange(0, i)
+	})
+	registerOp("DEPTH", "68", 0, func(st *State, _ uint32) error {
+		st.stack.pushSmall(int64(st.stack.Len()))
+		return nil
+	})
+	registerOp("CHKDEPTH", "69", 0, func(st *State, _ uint32) error {
+		i, err := st.stack.popSmall(255)
+		if err != nil {
+			return err
+		}
+		return st.stack.checkUnderflow(i)
+	})
+	registerOp("ONLYTOPX", "6A", 0, func(st *State, _ uint32) error {
+		i, err := st.stack.popSmall(255)
+		if err != nil {
+			return err
+		}
+		return st.stack.dropBottom(i)
+	})
+	registerOp("ONLYX", "6B", 0, func(st *State, _ uint32) error {
+		i, err := st.stack.popSmall(255)
+		if err != nil {
+			return err
+		}
+		if err = st.stack.checkUnderflow(i); err != nil {
+			return err
+		}
+		return st.stack.drop(st.stack.Len() - i)
+	})
+	registerOpCheck("BLKDROP2", "6C", 8, func(args uint32) bool {
+		return args>>4 > 0
+	}, func(st *State, args uint32) error {
+		i, j := int(args>>4), int(args&15)
+		if err := st.stack.checkUnderflow(i + j); err != nil {
+			return err
+		}
+		top, err := st.stack.splitTop(j)
+		if err != nil {
+			return err
+		}
+		if err = st.stack.drop(i); err != nil {
+			return err
+		}
+		return st.stack.moveFrom(top, j)
+	})
+}
+
+func push(st *State, i int) error {
+	v, err := st.stack.at(i)
+	if err != nil {
+		return err
+	}
+	st.stack.push(v)
+	return nil
+}
+
+func pop(st *State, i int) error {
+	if err := st.stack.checkUnderflow(i + 1); err != nil {
+		return err
+	}
+	if err := st.stack.exchange(0, i); err != nil {
+		return err
+	}
+	_, err := st.stack.pop()
+	return err
+}
+
+func xchg2(st *State, i, j int) error {
+	if err := st.stack.checkUnderflow(max(i, j, 1) + 1); err != nil {
+		return err
+	}
+	if err := st.stack.exchange(1, i); err != nil {
+		return err
+	}
+	return st.stack.exchange(0, j)
+}
+
+func xchg3(st *State, i, j, k int) error {
+	if err := st.stack.checkUnderflow(max(i, j, k, 2) + 1); err != nil {
+		return err
+	}
+	if err := st.stack.exchange(2, i); err != nil {
+		return err
+	}
+	if err := st.stack.exchange(1, j); err != nil {
+		return err
+	}
+	return st.stack.exchange(0, k)
+}
+
+func xcpu(st *State, i, j int) error {
+	if err := st.stack.checkUnderflow(max(i, j) + 1); err != nil {
+		return err
+	}
+	if err := st.stack.exchange(0, i); err != nil {
+		return err
+	}
+	return push(st, j)
+}
+
+// puxc - PUXC s(i),s(j): pushes s(i), then exchanges s0 and s1, then s0 and s(j+1)
+func puxc(st *State, i, j int) error {
+	if err := st.stack.checkUnderflow(max(i, j) + 1); err != nil {
+		return err
+	}
+	if err := push(st, i); err != nil {
+		return err
+	}
+	if err := st.stack.exchange(0, 1); err != nil {
+		return err
+	}
+	return st.stack.exchange(0, j+1)
+}
+
+func push2(st *State, i, j int) error {
+	if err := st.stack.checkUnderflow(max(i, j) + 1); err != nil {
+		return err
+	}
+	if err := push(st, i); err != nil {
+		return err
+	}
+	return push(st, j+1)
+}
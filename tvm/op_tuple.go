@@ -0,0 +1,386 @@
+package tvm
+
+import (
+	"math/big"
+)
+
+const maxTupleLen = 255
+
+func init() {
+	registerOp("PUSHNULL", "6D", 0, func(st *State, _ uint32) error {
+		st.stack.push(nil)
+		return nil
+	})
+	registerOp("ISNULL", "6E", 0, func(st *State, _ uint32) error {
+		v, err := st.stack.pop()
+		if err != nil {
+			return err
+		}
+		st.stack.pushBool(v == nil)
+		return nil
+	})
+	registerOp("TUPLE", "6F0", 4, func(st *State, args uint32) error {
+		return makeTuple(st, int(args))
+	})
+	registerOp("INDEX", "6F1", 4, func(st *State, args uint32) error {
+		return tupleIndex(st, int(args), false)
+	})
+	registerOp("UNTUPLE", "6F2", 4, func(st *State, args uint32) error {
+		return untuple(st, int(args))
+	})
+	registerOp("UNPACKFIRST", "6F3", 4, func(st *State, args uint32) error {
+		return unpackFirst(st, int(args))
+	})
+	registerOp("EXPLODE", "6F4", 4, func(st *State, args uint32) error {
+		return explode(st, int(args))
+	})
+	registerOp("SETINDEX", "6F5", 4, func(st *State, args uint32) error {
+		return tupleSetIndex(st, int(args), false)
+	})
+	registerOp("INDEXQ", "6F6", 4, func(st *State, args uint32) error {
+		return tupleIndex(st, int(args), true)
+	})
+	registerOp("SETINDEXQ", "6F7", 4, func(st *State, args uint32) error {
+		return tupleSetIndex(st, int(args), true)
+	})
+	registerOp("TUPLEVAR", "6F80", 0, func(st *State, _ uint32) error {
+		n, err := st.stack.popSmall(maxTupleLen)
+		if err != nil {
+			return err
+		}
+		return makeTuple(st, n)
+	})
+	registerOp("INDEXVAR", "6F81", 0, func(st *State, _ uint32) error {
+		n, err := st.stack.popSmall(maxTupleLen - 1)
+		if err != nil {
+			return err
+		}
+		return tupleIndex(st, n, false)
+	})
+	registerOp("UNTUPLEVAR", "6F82", 0, func(st *State, _ uint32) error {
+		n, err := st.stack.popSmall(maxTupleLen)
+		if err != nil {
+			return err
+		}
+		return untuple(st, n)
+	})
+	registerOp("UNPACKFIRSTVAR", "6F83", 0, func(st *State, _ uint32) error {
+		n, err := st.stack.popSmall(maxTupleLen)
+		if err != nil {
+			return err
+		}
+		return unpackFirst(st, n)
+	})
+	registerOp("EXPLODEVAR", "6F84", 0, func(st *State, _ uint32) error {
+		n, err := st.stack.popSmall(maxTupleLen)
+		if err != nil {
+			return err
+		}
+		return explode(st, n)
+	})
+	registerOp("SETINDEXVAR", "6F85", 0, func(st *State, _ uint32) error {
+		n, err := st.stack.popSmall(maxTupleLen - 1)
+		if err != nil {
+			return err
+		}
+		return tupleSetIndex(st, n, false)
+	})
+	registerOp("INDEXVARQ", "6F86", 0, func(st *State, _ uint32) error {
+		n, err := st.stack.popSmall(maxTupleLen - 1)
+		if err != nil {
+			return err
+		}
+		return tupleIndex(st, n, true)
+	})
+	registerOp("SETINDEXVARQ", "6F87", 0, func(st *State, _ uint32) error {
+		n, err := st.stack.popSmall(maxTupleLen - 1)
+		if err != nil {
+			return err
+		}
+		return tupleSetIndex(st, n, true)
+	})
+	registerOp("TLEN", "6F88", 0, func(st *State, _ uint32) error {
+		t, err := st.stack.popTuple()
+		if err != nil {
+			return err
+		}
+		st.stack.pushSmall(int64(len(t)))
+		return nil
+	})
+	registerOp("QTLEN", "6F89", 0, func(st *State, _ uint32) error {
+		v, err := st.stack.pop()
+		if err != nil {
+			return err
+		}
+		if t, ok := v.([]any); ok {
+			st.stack.pushSmall(int64(len(t)))
+			return nil
+		}
+		st.stack.pushSmall(-1)
+		return nil
+	})
+	registerOp("ISTUPLE", "6F8A", 0, func(st *State, _ uint32) error {
+		v, err := st.stack.pop()
+		if err != nil {
+			return err
+		}
+		_, ok := v.([]any)
+		st.stack.pushBool(ok)
+		return nil
+	})
+	registerOp("LAST", "6F8B", 0, func(st *State, _ uint32) error {
+		t, err := st.stack.popTuple()
+		if err != nil {
+			return err
+		}
+		if len(t) == 0 {
+			return errTypeCheck
+		}
+		st.stack.push(t[len(t)-1])
+		return nil
+	})
+	registerOp("TPUSH", "6F8C", 0, func(st *State, _ uint32) error {
+		v, err := st.stack.pop()
+		if err != nil {
+			return err
+		}
+		t, err := st.stack.popTupleRange(maxTupleLen - 1)
+		if err != nil {
+			return err
+		}
+		tup := make([]any, len(t)+1)
+		copy(tup, t)
+		tup[len(t)] = v
+		st.stack.push(tup)
+		return st.consumeTupleGas(len(tup))
+	})
+	registerOp("TPOP", "6F8D", 0, func(st *State, _ uint32) error {
+		t, err := st.stack.popTuple()
+		if err != nil {
+			return err
+		}
+		if len(t) == 0 {
+			return errTypeCheck
+		}
+		st.stack.push(append([]any{}, t[:len(t)-1]...))
+		st.stack.push(t[len(t)-1])
+		return st.consumeTupleGas(len(t) - 1)
+	})
+	registerOp("NULLSWAPIF", "6FA0", 0, func(st *State, _ uint32) error {
+		return nullSwap(st, true, 1, 0)
+	})
+	registerOp("NULLSWAPIFNOT", "6FA1", 0, func(st *State, _ uint32) error {
+		return nullSwap(st, false, 1, 0)
+	})
+	registerOp("NULLROTRIF", "6FA2", 0, func(st *State, _ uint32) error {
+		return nullSwap(st, true, 1, 1)
+	})
+	registerOp("NULLROTRIFNOT", "6FA3", 0, func(st *State, _ uint32) error {
+		return nullSwap(st, false, 1, 1)
+	})
+	registerOp("NULLSWAPIF2", "6FA4", 0, func(st *State, _ uint32) error {
+		return nullSwap(st, true, 2, 0)
+	})
+	registerOp("NULLSWAPIFNOT2", "6FA5", 0, func(st *State, _ uint32) error {
+		return nullSwap(st, false, 2, 0)
+	})
+	registerOp("NULLROTRIF2", "6FA6", 0, func(st *State, _ uint32) error {
+		return nullSwap(st, true, 2, 1)
+	})
+	registerOp("NULLROTRIFNOT2", "6FA7", 0, func(st *State, _ uint32) error {
+		return nullSwap(st, false, 2, 1)
+	})
+	registerOp("INDEX2", "6FB", 4, func(st *State, args uint32) error {
+		t, err := st.stack.popTuple()
+		if err != nil {
+			return err
+		}
+		v, err := tupleAt(t, int(args>>2))
+		if err != nil {
+			return err
+		}
+		t, ok := v.([]any)
+		if !ok {
+			return errTypeCheck
+		}
+		v, err = tupleAt(t, int(args&3))
+		if err != nil {
+			return err
+		}
+		st.stack.push(v)
+		return nil
+	})
+	registerOp("INDEX3", "6FE_", 6, func(st *State, args uint32) error {
+		t, err := st.stack.popTuple()
+		if err != nil {
+			return err
+		}
+		var v any = t
+		for _, idx := range []int{int(args >> 4), int(args >> 2 & 3), int(args & 3)} {
+			t, ok := v.([]any)
+			if !ok {
+				return errTypeCheck
+			}
+			if v, err = tupleAt(t, idx); err != nil {
+				return err
+			}
+		}
+		st.stack.push(v)
+		return nil
+	})
+}
+
+func tupleAt(t []any, i int) (any, error) {
+	if i >= len(t) {
+		return nil, errRangeCheck
+	}
+	return t[i], nil
+}
+
+func makeTuple(st *State, n int) error {
+	if err := st.stack.checkUnderflow(n); err != nil {
+		return err
+	}
+	top, err := st.stack.splitTop(n)
+	if err != nil {
+		return err
+	}
+	st.stack.push(top.elems)
+	return st.consumeTupleGas(n)
+}
+
+func tupleIndex(st *State, i int, quiet bool) error {
+	var t []any
+	var err error
+	if quiet {
+		t, err = st.stack.popMaybeTuple()
+	} else {
+		t, err = st.stack.popTuple()
+	}
+	if err != nil {
+		return err
+	}
+
+	if i >= len(t) {
+		if quiet {
+			st.stack.push(nil)
+			return nil
+		}
+		return errRangeCheck
+	}
+	st.stack.push(t[i])
+	return nil
+}
+
+func untuple(st *State, n int) error {
+	t, err := st.stack.popTuple()
+	if err != nil {
+		return err
+	}
+	if len(t) != n {
+		return errTypeCheck
+	}
+	for _, v := range t {
+		st.stack.push(v)
+	}
+	return st.consumeTupleGas(n)
+}
+
+func unpackFirst(st *State, n int) error {
+	t, err := st.stack.popTuple()
+	if err != nil {
+		return err
+	}
+	if len(t) < n {
+		return errTypeCheck
+	}
+	for _, v := range t[:n] {
+		st.stack.push(v)
+	}
+	return st.consumeTupleGas(n)
+}
+
+func explode(st *State, n int) error {
+	t, err := st.stack.popTuple()
+	if err != nil {
+		return err
+	}
+	if len(t) > n {
+		return errTypeCheck
+	}
+	for _, v := range t {
+		st.stack.push(v)
+	}
+	st.stack.pushSmall(int64(len(t)))
+	return st.consumeTupleGas(len(t))
+}
+
+func tupleSetIndex(st *State, i int, quiet bool) error {
+	v, err := st.stack.pop()
+	if err != nil {
+		return err
+	}
+
+	var t []any
+	if quiet {
+		t, err = st.stack.popMaybeTuple()
+	} else {
+		t, err = st.stack.popTuple()
+	}
+	if err != nil {
+		return err
+	}
+
+	if i >= len(t) {
+		if !quiet {
+			return errRangeCheck
+		}
+
+		if v == nil {
+			// nothing to set, keep tuple as is
+			if t == nil {
+				st.stack.push(nil)
+			} else {
+				st.stack.push(t)
+			}
+			return nil
+		}
+	}
+
+	ln := len(t)
+	if i >= ln {
+		ln = i + 1
+	}
+	tup := make([]any, ln)
+	copy(tup, t)
+	tup[i] = v
+	st.stack.push(tup)
+	return st.consumeTupleGas(ln)
+}
+
+// nullSwap - pushes cnt nulls under top integer (and depth more elements) if integer condition matches
+func nullSwap(st *State, ifNonZero bool, cnt, depth int) error {
+	if err := st.stack.checkUnderflow(depth + 1); err != nil {
+		return err
+	}
+
+	x, err := st.stack.popInt()
+	if err != nil {
+		return err
+	}
+
+	if (x.Sign() != 0) == ifNonZero {
+		top, err := st.stack.splitTop(depth)
+		if err != nil {
+			return err
+		}
+		for i := 0; i < cnt; i++ {
+			st.stack.push(nil)
+		}
+		if err = st.stack.moveFrom(top, depth); err != nil {
+			return err
+		}
+	}
+	st.stack.push(new(big.Int).Set(x))
+	return nil
+}
@@ -0,0 +1,139 @@
+package tvm
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type opExec func(st *State, args uint32) error
+
+type opcode struct {
+	name      string
+	prefix    uint32
+	prefixLen uint
+	argLen    uint
+	// check - optional filter of arguments, when it returns false, opcode is not matched
+	check func(args uint32) bool
+	exec  opExec
+}
+
+var (
+	opcodes     []*opcode
+	opTable     [256][]*opcode
+	opTableOnce sync.Once
+)
+
+// registerOp - registers instruction, prefix is a hex string, it can be ended with completion tag '_',
+// argLen is a size of fixed arguments part, which is passed to exec as number.
+func registerOp(name, prefix string, argLen uint, exec opExec) {
+	registerOpCheck(name, prefix, argLen, nil, exec)
+}
+
+func registerOpCheck(name, prefix string, argLen uint, check func(args uint32) bool, exec opExec) {
+	val, ln := parseHexPrefix(prefix)
+	if ln+argLen > 32 {
+		panic("too long opcode " + name)
+	}
+
+	opcodes = append(opcodes, &opcode{
+		name:      name,
+		prefix:    val,
+		prefixLen: ln,
+		argLen:    argLen,
+		check:     check,
+		exec:      exec,
+	})
+}
+
+func parseHexPrefix(prefix string) (uint32, uint) {
+	tag := strings.HasSuffix(prefix, "_")
+	prefix = strings.TrimSuffix(prefix, "_")
+
+	val, err := strconv.ParseUint(prefix, 16, 32)
+	if err != nil {
+		panic(fmt.Sprintf("invalid opcode prefix %s: %v", prefix, err))
+	}
+	ln := uint(len(prefix) * 4)
+
+	if tag {
+		// remove trailing zeroes and the last 1 bit
+		for val&1 == 0 && ln > 0 {
+			val >>= 1
+			ln--
+		}
+		val >>= 1
+		ln--
+	}
+	return uint32(val), ln
+}
+
+func buildOpTable() {
+	for _, op := range opcodes {
+		if op.prefixLen >= 8 {
+			b := op.prefix >> (op.prefixLen - 8)
+			opTable[b] = append(opTable[b], op)
+			continue
+		}
+
+		for b := uint32(0); b < 256; b++ {
+			if b>>(8-op.prefixLen) == op.prefix {
+				opTable[b] = append(opTable[b], op)
+			}
+		}
+	}
+
+	for i := range opTable {
+		sort.SliceStable(opTable[i], func(a, b int) bool {
+			return opTable[i][a].prefixLen > opTable[i][b].prefixLen
+		})
+	}
+}
+
+func dispatch(st *State) error {
+	opTableOnce.Do(buildOpTable)
+
+	n := st.code.BitsLeft()
+	if n > 32 {
+		n = 32
+	}
+
+	w, err := st.code.PreloadUInt(n)
+	if err != nil {
+		return errInvalidOpcode
+	}
+	word := uint32(w << (32 - n))
+
+	for _, op := range opTable[word>>24] {
+		ln := op.prefixLen + op.argLen
+		if ln > n {
+			continue
+		}
+
+		if word>>(32-op.prefixLen) != op.prefix {
+			continue
+		}
+
+		var args uint32
+		if op.argLen > 0 {
+			args = (word >> (32 - ln)) & (1<<op.argLen - 1)
+		}
+
+		if op.check != nil && !op.check(args) {
+			continue
+		}
+
+		if _, err = st.code.LoadSlice(ln); err != nil {
+			return errInvalidOpcode
+		}
+
+		if err = st.consumeGas(gasPerInstruction + int64(ln)*gasPerBit); err != nil {
+			return err
+		}
+		return op.exec(st, args)
+	}
+
+	return errInvalidOpcode
+}
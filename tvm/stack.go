@@ -0,0 +1,377 @@
+package tvm
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+// Stack - TVM stack, values are stored from the bottom to the top.
+//
+//	Possible values are: nil, *big.Int, tlb.StackNaN, *cell.Cell, *cell.Slice,
+//	*cell.Builder, Continuation and []any as tuple.
+type Stack struct {
+	elems []any
+}
+
+func NewStack() *Stack {
+	return &Stack{}
+}
+
+// Push - normalizes value and pushes it to the top of the stack,
+// all go integer types are converted to *big.Int.
+func (s *Stack) Push(val any) error {
+	v, err := normalizeValue(val)
+	if err != nil {
+		return err
+	}
+	s.push(v)
+	return nil
+}
+
+// Values - returns a copy of stack elements, from the bottom to the top
+func (s *Stack) Values() []any {
+	return append([]any{}, s.elems...)
+}
+
+func (s *Stack) Len() int {
+	return len(s.elems)
+}
+
+func (s *Stack) copy() *Stack {
+	return &Stack{elems: append([]any{}, s.elems...)}
+}
+
+func (s *Stack) push(v any) {
+	// typed nil cell is the null value for contract, not a cell
+	if c, ok := v.(*cell.Cell); ok && c == nil {
+		v = nil
+	}
+	s.elems = append(s.elems, v)
+}
+
+func (s *Stack) pushInt(v *big.Int) error {
+	if !fitsBits(v, 257, true) {
+		return errIntOverflow
+	}
+	s.push(v)
+	return nil
+}
+
+func (s *Stack) pushSmall(v int64) {
+	s.push(big.NewInt(v))
+}
+
+func (s *Stack) pushBool(v bool) {
+	if v {
+		s.push(big.NewInt(-1))
+		return
+	}
+	s.push(big.NewInt(0))
+}
+
+func (s *Stack) checkUnderflow(n int) error {
+	if n < 0 || len(s.elems) < n {
+		return errStackUnderflow
+	}
+	return nil
+}
+
+func (s *Stack) pop() (any, error) {
+	if len(s.elems) == 0 {
+		return nil, errStackUnderflow
+	}
+	v := s.elems[len(s.elems)-1]
+	s.elems[len(s.elems)-1] = nil
+	s.elems = s.elems[:len(s.elems)-1]
+	return v, nil
+}
+
+// at - returns s(i), where s(0) is the top of the stack
+func (s *Stack) at(i int) (any, error) {
+	if i < 0 || i >= len(s.elems) {
+		return nil, errStackUnderflow
+	}
+	return s.elems[len(s.elems)-1-i], nil
+}
+
+func (s *Stack) set(i int, v any) error {
+	if i < 0 || i >= len(s.elems) {
+		return errStackUnderflow
+	}
+	s.elems[len(s.elems)-1-i] = v
+	return nil
+}
+
+func (s *Stack) exchange(i, j int) error {
+	if i < 0 || j < 0 || i >= len(s.elems) || j >= len(s.elems) {
+		return errStackUnderflow
+	}
+	a, b := len(s.elems)-1-i, len(s.elems)-1-j
+	s.elems[a], s.elems[b] = s.elems[b], s.elems[a]
+	return nil
+}
+
+// reverse - reverses order of n elements starting from s(offset)
+func (s *Stack) reverse(n, offset int) error {
+	if err := s.checkUnderflow(n + offset); err != nil {
+		return err
+	}
+	to := len(s.elems) - offset
+	from := to - n
+	for i, j := from, to-1; i < j; i, j = i+1, j-1 {
+		s.elems[i], s.elems[j] = s.elems[j], s.elems[i]
+	}
+	return nil
+}
+
+// blockSwap - swaps blocks s(j+i-1)...s(j) and s(j-1)...s(0)
+func (s *Stack) blockSwap(i, j int) error {
+	if err := s.checkUnderflow(i + j); err != nil {
+		return err
+	}
+	if err := s.reverse(i+j, 0); err != nil {
+		return err
+	}
+	if err := s.reverse(i, 0); err != nil {
+		return err
+	}
+	return s.reverse(j, i)
+}
+
+func (s *Stack) drop(n int) error {
+	if err := s.checkUnderflow(n); err != nil {
+		return err
+	}
+	for i := len(s.elems) - n; i < len(s.elems); i++ {
+		s.elems[i] = nil
+	}
+	s.elems = s.elems[:len(s.elems)-n]
+	return nil
+}
+
+// dropBottom - removes all elements except top n
+func (s *Stack) dropBottom(n int) error {
+	if err := s.checkUnderflow(n); err != nil {
+		return err
+	}
+	s.elems = append([]any{}, s.elems[len(s.elems)-n:]...)
+	return nil
+}
+
+// splitTop - removes top n elements and returns them as a new stack
+func (s *Stack) splitTop(n int) (*Stack, error) {
+	if err := s.checkUnderflow(n); err != nil {
+		return nil, err
+	}
+	top := &Stack{elems: append([]any{}, s.elems[len(s.elems)-n:]...)}
+	s.elems = s.elems[:len(s.elems)-n]
+	return top, nil
+}
+
+// moveFrom - moves top n elements of other stack to the top of this stack, keeping order
+func (s *Stack) moveFrom(other *Stack, n int) error {
+	top, err := other.splitTop(n)
+	if err != nil {
+		return err
+	}
+	s.elems = append(s.elems, top.elems...)
+	return nil
+}
+
+func (s *Stack) popInt() (*big.Int, error) {
+	v, err := s.pop()
+	if err != nil {
+		return nil, err
+	}
+	switch x := v.(type) {
+	case *big.Int:
+		return x, nil
+	case tlb.StackNaN:
+		return nil, errIntOverflow
+	}
+	return nil, errTypeCheck
+}
+
+func (s *Stack) popIntRange(min, max int64) (int64, error) {
+	v, err := s.popInt()
+	if err != nil {
+		return 0, err
+	}
+	if !v.IsInt64() || v.Int64() < min || v.Int64() > max {
+		return 0, errRangeCheck
+	}
+	return v.Int64(), nil
+}
+
+func (s *Stack) popSmall(max int64) (int, error) {
+	v, err := s.popIntRange(0, max)
+	return int(v), err
+}
+
+func (s *Stack) popBool() (bool, error) {
+	v, err := s.popInt()
+	if err != nil {
+		return false, err
+	}
+	return v.Sign() != 0, nil
+}
+
+func (s *Stack) popCell() (*cell.Cell, error) {
+	v, err := s.pop()
+	if err != nil {
+		return nil, err
+	}
+	c, ok := v.(*cell.Cell)
+	if !ok {
+		return nil, errTypeCheck
+	}
+	return c, nil
+}
+
+func (s *Stack) popMaybeCell() (*cell.Cell, error) {
+	v, err := s.pop()
+	if err != nil {
+		return nil, err
+	}
+	if v == nil {
+		return nil, nil
+	}
+	c, ok := v.(*cell.Cell)
+	if !ok {
+		return nil, errTypeCheck
+	}
+	return c, nil
+}
+
+// popSlice - returns a copy of slice, so it can be safely modified
+func (s *Stack) popSlice() (*cell.Slice, error) {
+	v, err := s.pop()
+	if err != nil {
+		return nil, err
+	}
+	c, ok := v.(*cell.Slice)
+	if !ok {
+		return nil, errTypeCheck
+	}
+	return c.Copy(), nil
+}
+
+// popBuilder - returns a copy of builder, so it can be safely modified
+func (s *Stack) popBuilder() (*cell.Builder, error) {
+	v, err := s.pop()
+	if err != nil {
+		return nil, err
+	}
+	c, ok := v.(*cell.Builder)
+	if !ok {
+		return nil, errTypeCheck
+	}
+	return copyBuilder(c), nil
+}
+
+func (s *Stack) popCont() (Continuation, error) {
+	v, err := s.pop()
+	if err != nil {
+		return nil, err
+	}
+	c, ok := v.(Continuation)
+	if !ok {
+		return nil, errTypeCheck
+	}
+	return c, nil
+}
+
+func (s *Stack) popTuple() ([]any, error) {
+	v, err := s.pop()
+	if err != nil {
+		return nil, err
+	}
+	c, ok := v.([]any)
+	if !ok {
+		return nil, errTypeCheck
+	}
+	return c, nil
+}
+
+func (s *Stack) popTupleRange(max int) ([]any, error) {
+	t, err := s.popTuple()
+	if err != nil {
+		return nil, err
+	}
+	if len(t) > max {
+		return nil, errTypeCheck
+	}
+	return t, nil
+}
+
+func (s *Stack) popMaybeTuple() ([]any, error) {
+	v, err := s.pop()
+	if err != nil {
+		return nil, err
+	}
+	if v == nil {
+		return nil, nil
+	}
+	c, ok := v.([]any)
+	if !ok {
+		return nil, errTypeCheck
+	}
+	return c, nil
+}
+
+func fitsBits(v *big.Int, bits uint, signed bool) bool {
+	if signed {
+		if bits == 0 {
+			return v.Sign() == 0
+		}
+		if v.Sign() >= 0 {
+			return uint(v.BitLen()) < bits
+		}
+		// for negative -2^(bits-1) is allowed
+		return uint(new(big.Int).Not(v).BitLen()) < bits
+	}
+	return v.Sign() >= 0 && uint(v.BitLen()) <= bits
+}
+
+func normalizeValue(val any) (any, error) {
+	switch v := val.(type) {
+	case nil:
+		return nil, nil
+	case *big.Int:
+		if v == nil {
+			return nil, nil
+		}
+		if !fitsBits(v, 257, true) {
+			return nil, fmt.Errorf("integer is too big")
+		}
+		return new(big.Int).Set(v), nil
+	case int, int8, int16, int32, int64:
+		return big.NewInt(reflect.ValueOf(v).Int()), nil
+	case uint, uint8, uint16, uint32, uint64:
+		return new(big.Int).SetUint64(reflect.ValueOf(v).Uint()), nil
+	case bool:
+		if v {
+			return big.NewInt(-1), nil
+		}
+		return big.NewInt(0), nil
+	case tlb.StackNaN, *tlb.StackNaN:
+		return tlb.StackNaN{}, nil
+	case *cell.Cell, *cell.Slice, *cell.Builder, Continuation:
+		return v, nil
+	case []any:
+		tup := make([]any, len(v))
+		for i, e := range v {
+			n, err := normalizeValue(e)
+			if err != nil {
+				return nil, fmt.Errorf("failed to normalize tuple element %d: %w", i, err)
+			}
+			tup[i] = n
+		}
+		return tup, nil
+	}
+	return nil, fmt.Errorf("unsupported stack value type %s", reflect.TypeOf(val))
+}
@@ -0,0 +1,567 @@
+package tvm
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+type registers struct {
+	c  [4]Continuation
+	d  [2]*cell.Cell
+	c7 []any
+}
+
+// State - execution state of TVM
+type State struct {
+	code  *cell.Slice
+	cp    int
+	stack *Stack
+	reg   registers
+	gas   Gas
+	steps uint64
+
+	// accepted - ACCEPT or SETGASLIMIT was executed, so gas is bought by the contract
+	accepted bool
+
+	committed    bool
+	commitData   *cell.Cell
+	commitAction *cell.Cell
+
+	loaded    map[string]bool
+	libraries map[string]*cell.Cell
+	chkSigns  int
+
+	halted   bool
+	exitCode int32
+}
+
+func (r *registers) get(i int) any {
+	switch {
+	case i >= 0 && i < 4:
+		if r.c[i] == nil {
+			return nil
+		}
+		return r.c[i]
+	case i == 4 || i == 5:
+		if r.d[i-4] == nil {
+			return nil
+		}
+		return r.d[i-4]
+	case i == 7:
+		if r.c7 == nil {
+			return nil
+		}
+		return r.c7
+	}
+	return nil
+}
+
+func (r *registers) isSet(i int) bool {
+	switch {
+	case i >= 0 && i < 4:
+		return r.c[i] != nil
+	case i == 4 || i == 5:
+		return r.d[i-4] != nil
+	case i == 7:
+		return r.c7 != nil
+	}
+	return false
+}
+
+func (r *registers) set(i int, v any) error {
+	switch {
+	case i >= 0 && i < 4:
+		c, ok := v.(Continuation)
+		if !ok {
+			return errTypeCheck
+		}
+		r.c[i] = c
+	case i == 4 || i == 5:
+		c, ok := v.(*cell.Cell)
+		if !ok {
+			return errTypeCheck
+		}
+		r.d[i-4] = c
+	case i == 7:
+		t, ok := v.([]any)
+		if !ok {
+			return errTypeCheck
+		}
+		r.c7 = t
+	default:
+		return errRangeCheck
+	}
+	return nil
+}
+
+// define - sets register only if it is not set yet
+func (r *registers) define(i int, v any) error {
+	if r.isSet(i) {
+		return errTypeCheck
+	}
+	return r.set(i, v)
+}
+
+// adjust - overrides registers with values set in save
+func (r *registers) adjust(save *registers) {
+	for i := 0; i < 4; i++ {
+		if save.c[i] != nil {
+			r.c[i] = save.c[i]
+		}
+	}
+	for i := 0; i < 2; i++ {
+		if save.d[i] != nil {
+			r.d[i] = save.d[i]
+		}
+	}
+	if save.c7 != nil {
+		r.c7 = save.c7
+	}
+}
+
+func (st *State) halt(code int32) {
+	st.halted = true
+	st.exitCode = code
+}
+
+func (st *State) setCode(code *cell.Slice, cp int) {
+	st.code = code
+	st.cp = cp
+}
+
+func (st *State) consumeGas(amount int64) error {
+	return st.gas.consume(amount)
+}
+
+func (st *State) consumeTupleGas(n int) error {
+	return st.consumeGas(int64(n) * gasTupleEntry)
+}
+
+func (st *State) consumeStackGas(s *Stack) error {
+	if s == nil || s.Len() <= gasFreeStackDepth {
+		return nil
+	}
+	return st.consumeGas(int64(s.Len()-gasFreeStackDepth) * gasStackEntry)
+}
+
+func (st *State) registerCellLoad(c *cell.Cell) error {
+	key := string(c.Hash())
+	if st.loaded[key] {
+		return st.consumeGas(gasCellReload)
+	}
+	st.loaded[key] = true
+	return st.consumeGas(gasCellLoad)
+}
+
+func (st *State) registerCellCreate() error {
+	return st.consumeGas(gasCellCreate)
+}
+
+// loadCell - converts cell to slice, consuming gas, library cells are resolved
+func (st *State) loadCell(c *cell.Cell) (*cell.Slice, error) {
+	if err := st.registerCellLoad(c); err != nil {
+		return nil, err
+	}
+
+	if c.GetType() == cell.LibraryCellType {
+		lib, err := st.resolveLibrary(c)
+		if err != nil {
+			return nil, err
+		}
+		if err = st.registerCellLoad(lib); err != nil {
+			return nil, err
+		}
+		c = lib
+	}
+
+	if c.GetType() != cell.OrdinaryCellType {
+		return nil, vmError(ErrCodeCellUnderflow, "unexpected special cell")
+	}
+	return c.BeginParse(), nil
+}
+
+func (st *State) resolveLibrary(c *cell.Cell) (*cell.Cell, error) {
+	s := c.BeginParse()
+	if _, err := s.LoadSlice(8); err != nil {
+		return nil, errCellUnderflow
+	}
+
+	hash, err := s.LoadSlice(256)
+	if err != nil {
+		return nil, errCellUnderflow
+	}
+
+	lib := st.libraries[string(hash)]
+	if lib == nil {
+		return nil, vmError(ErrCodeCellUnderflow, "failed to load library cell")
+	}
+	return lib, nil
+}
+
+func (st *State) refToCont(c *cell.Cell) (Continuation, error) {
+	s, err := st.loadCell(c)
+	if err != nil {
+		return nil, err
+	}
+	return newOrdinaryContinuation(s, st.cp), nil
+}
+
+// loadCodeRef - takes next ref of current code, used by instructions with references
+func (st *State) loadCodeRef() (*cell.Cell, error) {
+	ref, err := st.code.LoadRefCell()
+	if err != nil {
+		return nil, errInvalidOpcode
+	}
+	return ref, nil
+}
+
+func (st *State) jump(c Continuation) error {
+	return st.jumpArgs(c, -1)
+}
+
+// jumpArgs - passes top passArgs stack values (all when -1) to continuation and jumps to it
+func (st *State) jumpArgs(c Continuation, passArgs int) error {
+	data := c.controlData()
+	if data != nil {
+		depth := st.stack.Len()
+		if passArgs > depth || data.numArgs > depth {
+			return vmError(ErrCodeStackUnderflow, "stack underflow while jumping to a continuation: not enough arguments")
+		}
+		if data.numArgs > passArgs && passArgs >= 0 {
+			return vmError(ErrCodeStackUnderflow, "stack underflow while jumping to closure continuation: not enough arguments passed")
+		}
+
+		if data.stack != nil || data.numArgs >= 0 {
+			cpy := data.numArgs
+			if passArgs >= 0 && cpy < 0 {
+				cpy = passArgs
+			}
+
+			if data.stack != nil && data.stack.Len() > 0 {
+				if cpy < 0 {
+					cpy = depth
+				}
+
+				newStack := data.stack.copy()
+				if err := newStack.moveFrom(st.stack, cpy); err != nil {
+					return err
+				}
+				if err := st.consumeStackGas(newStack); err != nil {
+					return err
+				}
+				st.stack = newStack
+			} else if cpy >= 0 && cpy < depth {
+				if err := st.stack.dropBottom(cpy); err != nil {
+					return err
+				}
+				if err := st.consumeStackGas(st.stack); err != nil {
+					return err
+				}
+			}
+		} else if passArgs >= 0 && passArgs < depth {
+			if err := st.stack.dropBottom(passArgs); err != nil {
+				return err
+			}
+			if err := st.consumeStackGas(st.stack); err != nil {
+				return err
+			}
+		}
+	} else if passArgs >= 0 {
+		depth := st.stack.Len()
+		if passArgs > depth {
+			return vmError(ErrCodeStackUnderflow, "stack underflow while jumping to a continuation: not enough arguments")
+		}
+		if passArgs < depth {
+			if err := st.stack.dropBottom(passArgs); err != nil {
+				return err
+			}
+			if err := st.consumeStackGas(st.stack); err != nil {
+				return err
+			}
+		}
+	}
+	return c.jump(st)
+}
+
+func (st *State) call(c Continuation) error {
+	return st.callArgs(c, -1, -1)
+}
+
+// callArgs - calls continuation passing top passArgs values (all if -1),
+// retArgs is a number of values that will be returned (all if -1)
+func (st *State) callArgs(c Continuation, passArgs, retArgs int) error {
+	data := c.controlData()
+	if data != nil {
+		if data.save.c[0] != nil {
+			// call reduces to a jump
+			return st.jumpArgs(c, passArgs)
+		}
+
+		depth := st.stack.Len()
+		if passArgs > depth || data.numArgs > depth {
+			return vmError(ErrCodeStackUnderflow, "stack underflow while calling a continuation: not enough arguments")
+		}
+		if data.numArgs > passArgs && passArgs >= 0 {
+			return vmError(ErrCodeStackUnderflow, "stack underflow while calling a closure continuation: not enough arguments passed")
+		}
+
+		oldC0 := st.reg.c[0]
+		cpy := data.numArgs
+		if passArgs >= 0 && cpy < 0 {
+			cpy = passArgs
+		}
+
+		var newStack *Stack
+		if data.stack != nil && data.stack.Len() > 0 {
+			if cpy < 0 {
+				cpy = depth
+			}
+			newStack = data.stack.copy()
+			if err := newStack.moveFrom(st.stack, cpy); err != nil {
+				return err
+			}
+		} else if cpy >= 0 {
+			var err error
+			newStack, err = st.stack.splitTop(cpy)
+			if err != nil {
+				return err
+			}
+		} else {
+			newStack = st.stack
+			st.stack = NewStack()
+		}
+		if err := st.consumeStackGas(newStack); err != nil {
+			return err
+		}
+
+		ret := newOrdinaryContinuation(st.code, st.cp)
+		ret.data.save.c[0] = oldC0
+		ret.data.numArgs = retArgs
+		if st.stack.Len() > 0 {
+			ret.data.stack = st.stack
+		}
+
+		st.stack = newStack
+		st.reg.c[0] = ret
+		return c.jump(st)
+	}
+
+	var ret *OrdinaryContinuation
+	if passArgs >= 0 {
+		depth := st.stack.Len()
+		if passArgs > depth {
+			return vmError(ErrCodeStackUnderflow, "stack underflow while calling a continuation: not enough arguments")
+		}
+
+		newStack, err := st.stack.splitTop(passArgs)
+		if err != nil {
+			return err
+		}
+		if err = st.consumeStackGas(newStack); err != nil {
+			return err
+		}
+
+		ret = newOrdinaryContinuation(st.code, st.cp)
+		if st.stack.Len() > 0 {
+			ret.data.stack = st.stack
+		}
+		st.stack = newStack
+	} else {
+		ret = newOrdinaryContinuation(st.code, st.cp)
+	}
+	ret.data.save.c[0] = st.reg.c[0]
+	ret.data.numArgs = retArgs
+
+	st.reg.c[0] = ret
+	return c.jump(st)
+}
+
+func (st *State) ret() error {
+	cont := st.reg.c[0]
+	st.reg.c[0] = &QuitContinuation{ExitCode: 0}
+	return st.jump(cont)
+}
+
+func (st *State) retArgs(n int) error {
+	cont := st.reg.c[0]
+	st.reg.c[0] = &QuitContinuation{ExitCode: 0}
+	return st.jumpArgs(cont, n)
+}
+
+func (st *State) retAlt() error {
+	cont := st.reg.c[1]
+	st.reg.c[1] = &QuitContinuation{ExitCode: 1}
+	return st.jump(cont)
+}
+
+func (st *State) retAltArgs(n int) error {
+	cont := st.reg.c[1]
+	st.reg.c[1] = &QuitContinuation{ExitCode: 1}
+	return st.jumpArgs(cont, n)
+}
+
+// extractCC - converts rest of the current code to continuation,
+// saveCR bits define which of c0, c1, c2 should be saved into it and reset.
+// stackCopy values are kept on stack (all when -1), the rest is saved into continuation.
+func (st *State) extractCC(saveCR int, stackCopy, ccArgs int) (*OrdinaryContinuation, error) {
+	var newStack *Stack
+	var oldStack *Stack
+	if stackCopy < 0 || stackCopy == st.stack.Len() {
+		newStack = st.stack
+	} else if stackCopy > 0 {
+		var err error
+		newStack, err = st.stack.splitTop(stackCopy)
+		if err != nil {
+			return nil, err
+		}
+		if err = st.consumeStackGas(newStack); err != nil {
+			return nil, err
+		}
+		oldStack = st.stack
+	} else {
+		newStack = NewStack()
+		oldStack = st.stack
+	}
+
+	cc := newOrdinaryContinuation(st.code, st.cp)
+	cc.data.stack = oldStack
+	cc.data.numArgs = ccArgs
+
+	if saveCR&1 != 0 {
+		cc.data.save.c[0] = st.reg.c[0]
+		st.reg.c[0] = &QuitContinuation{ExitCode: 0}
+	}
+	if saveCR&2 != 0 {
+		cc.data.save.c[1] = st.reg.c[1]
+		st.reg.c[1] = &QuitContinuation{ExitCode: 1}
+	}
+	if saveCR&4 != 0 {
+		cc.data.save.c[2] = st.reg.c[2]
+		st.reg.c[2] = &ExcQuitContinuation{}
+	}
+
+	st.stack = newStack
+	st.code = cell.BeginCell().ToSlice()
+	return cc, nil
+}
+
+func (st *State) throwException(code int32, arg any) error {
+	st.stack = NewStack()
+	st.stack.push(arg)
+	st.stack.pushSmall(int64(code))
+	st.code = cell.BeginCell().ToSlice()
+	if err := st.consumeGas(gasException); err != nil {
+		return err
+	}
+	return st.jump(st.reg.c[2])
+}
+
+func (st *State) tryCommit() bool {
+	data, actions := st.reg.d[0], st.reg.d[1]
+	if data == nil || actions == nil {
+		return false
+	}
+	if data.Depth() > 512 || actions.Depth() > 512 ||
+		data.GetType() != cell.OrdinaryCellType || actions.GetType() != cell.OrdinaryCellType {
+		return false
+	}
+	st.committed = true
+	st.commitData = data
+	st.commitAction = actions
+	return true
+}
+
+func (st *State) step() error {
+	st.steps++
+	if st.code.BitsLeft() > 0 {
+		return dispatch(st)
+	}
+
+	if st.code.RefsNum() > 0 {
+		// implicit JMPREF
+		if err := st.consumeGas(gasImplicitJumpRef); err != nil {
+			return err
+		}
+		ref, err := st.code.PreloadRefCell()
+		if err != nil {
+			return err
+		}
+		cont, err := st.refToCont(ref)
+		if err != nil {
+			return err
+		}
+		return st.jump(cont)
+	}
+
+	// implicit RET
+	if err := st.consumeGas(gasImplicitRet); err != nil {
+		return err
+	}
+	return st.ret()
+}
+
+func (st *State) run() {
+	for !st.halted {
+		err := st.step()
+		if err == nil {
+			continue
+		}
+
+		if st.handleError(err) {
+			continue
+		}
+	}
+
+	if st.exitCode == 0 || st.exitCode == 1 {
+		if !st.tryCommit() {
+			st.stack = NewStack()
+			st.stack.pushSmall(0)
+			st.exitCode = ErrCodeCellOverflow
+		}
+	}
+}
+
+// handleError - processes exception, returns true when execution can be continued
+func (st *State) handleError(err error) bool {
+	var noGas errOutOfGas
+	if errors.As(err, &noGas) {
+		st.outOfGas()
+		return false
+	}
+
+	var vmErr VMError
+	if !errors.As(err, &vmErr) {
+		vmErr = VMError{Code: ErrCodeFatal, Msg: err.Error()}
+	}
+
+	var arg any = big.NewInt(0)
+	if vmErr.hasArg {
+		arg = vmErr.Arg
+	}
+
+	if err = st.throwException(vmErr.Code, arg); err != nil {
+		if errors.As(err, &noGas) {
+			st.outOfGas()
+			return false
+		}
+
+		// exception during exception handling, cannot continue
+		if !errors.As(err, &vmErr) {
+			vmErr = VMError{Code: ErrCodeFatal}
+		}
+		st.stack = NewStack()
+		st.stack.pushSmall(0)
+		st.halt(vmErr.Code)
+		return false
+	}
+	return !st.halted
+}
+
+func (st *State) outOfGas() {
+	st.gas.Remaining = 0
+	st.stack = NewStack()
+	st.stack.pushSmall(st.gas.Used())
+	st.halt(^int32(ErrCodeOutOfGas))
+}
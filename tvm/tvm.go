@@ -0,0 +1,110 @@
+package tvm
+
+import (
+	"fmt"
+
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+// TVM - pure go implementation of TON virtual machine, it is safe for concurrent use,
+// every execution has its own state.
+type TVM struct {
+	libraries map[string]*cell.Cell
+}
+
+// Result - result of code execution
+type Result struct {
+	// ExitCode - 0 and 1 are successful codes, other codes are exceptions
+	ExitCode int32
+	// Stack - stack at the end of execution
+	Stack   *Stack
+	GasUsed int64
+	Steps   uint64
+	// Accepted - contract executed ACCEPT or SETGASLIMIT, false for get methods and messages which did not accept gas
+	Accepted bool
+	// Committed - is new data and actions were committed, they can be used only when it is true
+	Committed bool
+	// Data - c4 register at commit moment
+	Data *cell.Cell
+	// Actions - c5 register at commit moment
+	Actions *cell.Cell
+}
+
+func NewTVM() *TVM {
+	return &TVM{
+		libraries: map[string]*cell.Cell{},
+	}
+}
+
+// AddLibraries - registers library cells, they will be used to resolve library references by hash
+func (t *TVM) AddLibraries(libs ...*cell.Cell) {
+	for _, l := range libs {
+		t.libraries[string(l.Hash())] = l
+	}
+}
+
+// Execute - runs code with the given persistent data, c7 context and stack.
+//
+//	Stack is modified during execution, result contains its final state.
+//	Error is returned only when execution cannot be started,
+//	contract exceptions are returned as exit code in the result.
+func (t *TVM) Execute(code, data *cell.Cell, c7 []any, gas Gas, stack *Stack) (*Result, error) {
+	if code == nil {
+		return nil, ErrNoCode
+	}
+
+	if data == nil {
+		data = cell.BeginCell().EndCell()
+	}
+
+	if stack == nil {
+		stack = NewStack()
+	}
+
+	if c7 == nil {
+		c7 = []any{}
+	}
+
+	st := &State{
+		stack:     stack,
+		gas:       gas,
+		loaded:    map[string]bool{},
+		libraries: t.libraries,
+	}
+
+	quit0 := &QuitContinuation{ExitCode: 0}
+	st.reg.c[0] = quit0
+	st.reg.c[1] = &QuitContinuation{ExitCode: 1}
+	st.reg.c[2] = &ExcQuitContinuation{}
+	st.reg.d[0] = data
+	st.reg.d[1] = cell.BeginCell().EndCell()
+	st.reg.c7 = c7
+
+	if code.GetType() == cell.LibraryCellType {
+		lib, err := st.resolveLibrary(code)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve code library: %w", err)
+		}
+		code = lib
+	}
+	codeSlice := code.BeginParse()
+
+	st.reg.c[3] = newOrdinaryContinuation(codeSlice.Copy(), 0)
+	st.setCode(codeSlice, 0)
+
+	st.run()
+
+	res := &Result{
+		ExitCode:  st.exitCode,
+		Stack:     st.stack,
+		GasUsed:   st.gas.Used(),
+		Steps:     st.steps,
+		Accepted:  st.accepted,
+		Committed: st.committed,
+	}
+	if st.committed {
+		res.Data = st.commitData
+		res.Actions = st.commitAction
+	}
+	return res, nil
+}
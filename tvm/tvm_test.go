@@ -0,0 +1,365 @@
+package tvm
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	"github.com/xssnick/tonutils-go/address"
+	"github.com/xssnick/tonutils-go/tlb"
+	"github.com/xssnick/tonutils-go/tvm/cell"
+)
+
+const walletV4R2CodeHex = "B5EE9C72410214010002D4000114FF00F4A413F4BCF2C80B010201200203020148040504F8F28308D71820D31FD31FD31F02F823BBF264ED44D0D31FD31FD3FFF404D15143BAF2A15151BAF2A205F901541064F910F2A3F80024A4C8CB1F5240CB1F5230CBFF5210F400C9ED54F80F01D30721C0009F6C519320D74A96D307D402FB00E830E021C001E30021C002E30001C0039130E30D03A4C8CB1F12CB1FCBFF1011121302E6D001D0D3032171B0925F04E022D749C120925F04E002D31F218210706C7567BD22821064737472BDB0925F05E003FA403020FA4401C8CA07CBFFC9D0ED44D0810140D721F404305C810108F40A6FA131B3925F07E005D33FC8258210706C7567BA923830E30D03821064737472BA925F06E30D06070201200809007801FA00F40430F8276F2230500AA121BEF2E0508210706C7567831EB17080185004CB0526CF1658FA0219F400CB6917CB1F5260CB3F20C98040FB0006008A5004810108F45930ED44D0810140D720C801CF16F400C9ED540172B08E23821064737472831EB17080185005CB055003CF1623FA0213CB6ACB1FCB3FC98040FB00925F03E20201200A0B0059BD242B6F6A2684080A06B90FA0218470D4080847A4937D29910CE6903E9FF9837812801B7810148987159F31840201580C0D0011B8C97ED44D0D70B1F8003DB29DFB513420405035C87D010C00B23281F2FFF274006040423D029BE84C600201200E0F0019ADCE76A26840206B90EB85FFC00019AF1DF6A26840106B90EB858FC0006ED207FA00D4D422F90005C8CA0715CBFFC9D077748018C8CB05CB0222CF165005FA0214CB6B12CCCCC973FB00C84014810108F451F2A7020070810108D718FA00D33FC8542047810108F451F2A782106E6F746570748018C8CB05CB025006CF165004FA0214CB6A12CB1FCB3FC973FB0002006C810108D718FA00D33F305224810108F459F2A782106473747270748018C8CB05CB025005CF165003FA0213CB6ACB1F12CB3FC973FB00000AF400C9ED54696225E5"
+
+func codeFromHex(t *testing.T, h string) *cell.Cell {
+	data, err := hex.DecodeString(h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cell.BeginCell().MustStoreSlice(data, uint(len(data)*8)).EndCell()
+}
+
+func walletV4R2(t *testing.T, seqno uint64, key ed25519.PublicKey) (*cell.Cell, *cell.Cell) {
+	boc, _ := hex.DecodeString(walletV4R2CodeHex)
+	code, err := cell.FromBOC(boc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := cell.BeginCell().
+		MustStoreUInt(seqno, 32).
+		MustStoreUInt(698983191, 32).
+		MustStoreSlice(key, 256).
+		MustStoreDict(nil).
+		EndCell()
+	return code, data
+}
+
+func TestTVM_Arithmetic(t *testing.T) {
+	// PUSHINT 2; PUSHINT 3; ADD; PUSHINT 7; MUL; PUSHINT 5; DIVMOD
+	code := codeFromHex(t, "7273A077A875A90C")
+
+	res, err := NewTVM().Execute(code, nil, nil, GasWithLimit(10000), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.ExitCode != 0 {
+		t.Fatal("unexpected exit code", res.ExitCode)
+	}
+
+	vals := res.Stack.Values()
+	if len(vals) != 2 || vals[0].(*big.Int).Int64() != 7 || vals[1].(*big.Int).Int64() != 0 {
+		t.Fatal("unexpected stack", vals)
+	}
+}
+
+func TestTVM_Exceptions(t *testing.T) {
+	t.Run("throw", func(t *testing.T) {
+		// THROW 5
+		res, err := NewTVM().Execute(codeFromHex(t, "F205"), nil, nil, GasWithLimit(10000), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.ExitCode != 5 || res.Committed {
+			t.Fatal("unexpected result", res.ExitCode, res.Committed)
+		}
+	})
+
+	t.Run("underflow", func(t *testing.T) {
+		// ADD
+		res, err := NewTVM().Execute(codeFromHex(t, "A0"), nil, nil, GasWithLimit(10000), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.ExitCode != ErrCodeStackUnderflow {
+			t.Fatal("unexpected exit code", res.ExitCode)
+		}
+	})
+
+	t.Run("out of gas", func(t *testing.T) {
+		// AGAINEND
+		res, err := NewTVM().Execute(codeFromHex(t, "EB"), nil, nil, GasWithLimit(1000), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.ExitCode != ^int32(ErrCodeOutOfGas) {
+			t.Fatal("unexpected exit code", res.ExitCode)
+		}
+		if res.GasUsed != 1000 {
+			t.Fatal("unexpected gas used", res.GasUsed)
+		}
+	})
+}
+
+func TestTVM_RunGetMethod(t *testing.T) {
+	key := ed25519.NewKeyFromSeed(make([]byte, 32)).Public().(ed25519.PublicKey)
+	code, data := walletV4R2(t, 7, key)
+
+	c7, err := PrepareC7(C7Params{
+		Address: address.MustParseAddr("EQCD39VS5jcptHL8vMjEXrzGaRcCVYto7HUn4bpAOg8xqB2N"),
+		Balance: big.NewInt(1000),
+		Code:    code,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	vm := NewTVM()
+	run := func(method string, args ...any) []any {
+		res, err := vm.RunGetMethod(code, data, c7, GasWithLimit(DefaultGetMethodGas), tlb.MethodNameHash(method), args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.ExitCode != 0 {
+			t.Fatal(method, "unexpected exit code", res.ExitCode)
+		}
+		return res.Stack.Values()
+	}
+
+	if v := run("seqno"); len(v) != 1 || v[0].(*big.Int).Uint64() != 7 {
+		t.Fatal("bad seqno", v)
+	}
+	if v := run("get_subwallet_id"); len(v) != 1 || v[0].(*big.Int).Uint64() != 698983191 {
+		t.Fatal("bad subwallet", v)
+	}
+	if v := run("get_public_key"); len(v) != 1 || !bytes.Equal(v[0].(*big.Int).FillBytes(make([]byte, 32)), key) {
+		t.Fatal("bad public key", v)
+	}
+	if v := run("is_plugin_installed", 0, big.NewInt(123)); len(v) != 1 || v[0].(*big.Int).Sign() != 0 {
+		t.Fatal("bad plugin check", v)
+	}
+
+	res, err := vm.RunGetMethod(code, data, c7, GasWithLimit(DefaultGetMethodGas), 12345)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.ExitCode != 11 {
+		t.Fatal("unexpected exit code for unknown method", res.ExitCode)
+	}
+}
+
+func TestTVM_ExternalMessage(t *testing.T) {
+	priv := ed25519.NewKeyFromSeed(make([]byte, 32))
+	code, data := walletV4R2(t, 3, priv.Public().(ed25519.PublicKey))
+
+	c7, err := PrepareC7(C7Params{
+		Now:     1700000000,
+		Balance: big.NewInt(1000),
+		Code:    code,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	transfer := cell.BeginCell().MustStoreUInt(0x18, 6).EndCell()
+	payload := cell.BeginCell().
+		MustStoreUInt(698983191, 32).
+		MustStoreUInt(1700000060, 32).
+		MustStoreUInt(3, 32).
+		MustStoreUInt(0, 8).
+		MustStoreUInt(3, 8).
+		MustStoreRef(transfer).
+		EndCell()
+
+	body := cell.BeginCell().
+		MustStoreSlice(payload.Sign(priv), 512).
+		MustStoreBuilder(payload.ToBuilder()).
+		EndCell()
+
+	run := func(body *cell.Cell) *Result {
+		stack := NewStack()
+		for _, v := range []any{big.NewInt(1000), big.NewInt(0), cell.BeginCell().EndCell(), body.BeginParse(), -1} {
+			if err := stack.Push(v); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		res, err := NewTVM().Execute(code, data, c7, NewGas(0, 1_000_000, 10_000), stack)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+
+	res := run(body)
+	if res.ExitCode != 0 || !res.Committed || !res.Accepted {
+		t.Fatal("unexpected result", res.ExitCode, res.Committed, res.Accepted)
+	}
+	if seqno := res.Data.BeginParse().MustLoadUInt(32); seqno != 4 {
+		t.Fatal("seqno not incremented", seqno)
+	}
+
+	act := res.Actions.BeginParse()
+	if act.MustLoadRef().BitsLeft() != 0 {
+		t.Fatal("expected single action")
+	}
+	if act.MustLoadUInt(32) != ActionTagSendMsg || act.MustLoadUInt(8) != 3 {
+		t.Fatal("bad action")
+	}
+	if !bytes.Equal(act.MustLoadRef().MustToCell().Hash(), transfer.Hash()) {
+		t.Fatal("bad message")
+	}
+
+	// broken signature, message should not be accepted
+	bad := cell.BeginCell().
+		MustStoreSlice(make([]byte, 64), 512).
+		MustStoreBuilder(payload.ToBuilder()).
+		EndCell()
+
+	res = run(bad)
+	if res.ExitCode != 35 || res.Accepted || res.Committed {
+		t.Fatal("unexpected result for bad signature", res.ExitCode, res.Accepted, res.Committed)
+	}
+}
+
+func TestTVM_Accepted(t *testing.T) {
+	run := func(code []byte, gas Gas) *Result {
+		stack := NewStack()
+		for _, v := range []any{big.NewInt(1000), big.NewInt(0), cell.BeginCell().EndCell(), cell.BeginCell().EndCell().BeginParse(), -1} {
+			if err := stack.Push(v); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		res, err := NewTVM().Execute(cell.BeginCell().MustStoreSlice(code, uint(len(code)*8)).EndCell(), cell.BeginCell().EndCell(), []any{}, gas, stack)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.ExitCode != 0 {
+			t.Fatal("unexpected exit code", res.ExitCode)
+		}
+		return res
+	}
+
+	// external message without ACCEPT, with and without gas credit
+	if res := run(nil, NewGas(0, 1_000_000, 10_000)); res.Accepted {
+		t.Fatal("message without ACCEPT should not be accepted")
+	}
+	if res := run(nil, NewGas(1000, 1_000_000, 0)); res.Accepted {
+		t.Fatal("message without ACCEPT and credit should not be accepted")
+	}
+	if res := run(nil, GasWithLimit(1_000_000)); res.Accepted {
+		t.Fatal("get method should not be accepted")
+	}
+
+	// ACCEPT
+	if res := run([]byte{0xF8, 0x00}, NewGas(0, 1_000_000, 10_000)); !res.Accepted {
+		t.Fatal("message should be accepted")
+	}
+	// PUSHINT 1000 SETGASLIMIT
+	if res := run([]byte{0x81, 0x03, 0xE8, 0xF8, 0x01}, NewGas(0, 1_000_000, 10_000)); !res.Accepted || res.GasUsed > 1000 {
+		t.Fatal("message should be accepted by SETGASLIMIT", res.Accepted, res.GasUsed)
+	}
+}
+
+func TestTVM_DictOps(t *testing.T) {
+	st := &State{gas: GasWithLimit(1 << 40), loaded: map[string]bool{}}
+
+	dict := cell.NewDict(16)
+	var root *cell.Cell
+	keys := []int64{1, 7, 3, 0xFFFF, 255, 256, 0x8000, 12, 13, 14}
+	for i, k := range keys {
+		val := cell.BeginCell().MustStoreUInt(uint64(i), 10).EndCell()
+		if err := dict.SetIntKey(big.NewInt(k), val); err != nil {
+			t.Fatal(err)
+		}
+
+		var err error
+		root, _, err = dictSet(st, root, intToBits(big.NewInt(k), 16), val.ToBuilder(), dictModeSet)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(root.Hash(), dict.AsCell().Hash()) {
+			t.Fatal("dictionary hash mismatch after set", k)
+		}
+	}
+
+	key, v, err := dictMinMax(st, root, 16, 0, true, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bitsToInt(key, false).Int64() != 0xFFFF || v.MustLoadUInt(10) != 3 {
+		t.Fatal("bad max")
+	}
+
+	key, _, err = dictMinMax(st, root, 16, 0, false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bitsToInt(key, true).Int64() != -0x8000 {
+		t.Fatal("bad signed min", bitsToInt(key, true))
+	}
+
+	key, _, err = dictNearest(st, root, intToBits(big.NewInt(14), 16), 0, false, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bitsToInt(key, false).Int64() != 255 {
+		t.Fatal("bad next", bitsToInt(key, false))
+	}
+
+	key, _, err = dictNearest(st, root, intToBits(big.NewInt(12), 16), 0, true, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bitsToInt(key, false).Int64() != 7 {
+		t.Fatal("bad prev", bitsToInt(key, false))
+	}
+
+	for _, k := range keys[:5] {
+		if err = dict.DeleteIntKey(big.NewInt(k)); err != nil {
+			t.Fatal(err)
+		}
+
+		var old *cell.Slice
+		root, old, err = dictDelete(st, root, intToBits(big.NewInt(k), 16))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if old == nil {
+			t.Fatal("value not found", k)
+		}
+		if !bytes.Equal(root.Hash(), dict.AsCell().Hash()) {
+			t.Fatal("dictionary hash mismatch after delete", k)
+		}
+	}
+
+	v, err = dictLookup(st, root, intToBits(big.NewInt(256), 16))
+	if err != nil || v == nil || v.MustLoadUInt(10) != 5 {
+		t.Fatal("lookup failed", err)
+	}
+}
+
+func TestTVM_NullCell(t *testing.T) {
+	t.Run("empty dict", func(t *testing.T) {
+		// LDDICT; DROP; ISNULL
+		code := codeFromHex(t, "F404306E")
+
+		stack := NewStack()
+		if err := stack.Push(cell.BeginCell().MustStoreDict(nil).MustStoreUInt(7, 8).EndCell().BeginParse()); err != nil {
+			t.Fatal(err)
+		}
+
+		res, err := NewTVM().Execute(code, nil, nil, GasWithLimit(10000), stack)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		vals := res.Stack.Values()
+		if res.ExitCode != 0 || len(vals) != 1 || vals[0].(*big.Int).Int64() != -1 {
+			t.Fatal("empty dict should be loaded as null", res.ExitCode, vals)
+		}
+	})
+
+	t.Run("typed nil", func(t *testing.T) {
+		stack := NewStack()
+		stack.push((*cell.Cell)(nil))
+
+		if vals := stack.Values(); len(vals) != 1 || vals[0] != nil {
+			t.Fatal("nil cell should be pushed as null", vals)
+		}
+	})
+}